@@ -20,10 +20,12 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"text/template"
 	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
@@ -48,6 +50,7 @@ import (
 	"github.com/charmbracelet/crush/internal/version"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/charmtone"
+	"golang.org/x/text/language"
 )
 
 const (
@@ -61,8 +64,10 @@ const (
 
 var userAgent = fmt.Sprintf("Charm-Crush/%s (https://charm.land/crush)", version.Version)
 
-//go:embed templates/title.md
-var titlePrompt []byte
+//go:embed templates/title.md.tpl
+var titlePromptTmpl string
+
+var titlePromptTemplate = template.Must(template.New("title").Parse(titlePromptTmpl))
 
 //go:embed templates/summary.md
 var summaryPrompt []byte
@@ -94,6 +99,9 @@ type SessionAgentCall struct {
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
 	NonInteractive   bool
+	// ResponseStyle appends a verbosity directive to the system prompt
+	// for this call. The zero value leaves the prompt unchanged.
+	ResponseStyle config.ResponseStyle
 	// OnComplete, when non-nil, replaces the default RunComplete
 	// publish path: the inner Run hands the terminal payload to this
 	// callback instead of emitting it on the RunComplete broker. The
@@ -124,6 +132,10 @@ type SessionAgentCall struct {
 	// paths treat as covered by any present mark, preserving the
 	// pre-sequence behavior.
 	acceptSeq uint64
+	// afterContextLimit marks the continuation queued after a context
+	// limit error was summarized away, so a second one fails the run
+	// instead of summarizing again.
+	afterContextLimit bool
 	// OnAuthRefresh, when non-nil, is called by fantasy when a stream
 	// fails with an authentication error (HTTP 401). The callback should
 	// refresh credentials and return nil on success, in which case
@@ -136,8 +148,21 @@ type SessionAgent interface {
 	Run(context.Context, SessionAgentCall) (*fantasy.AgentResult, error)
 	BeginAccepted(sessionID string) *AcceptedRun
 	SetModels(large Model, small Model)
+	// SetSummaryModel sets a model of its own for summaries. A zero
+	// Model makes summaries use the large or small model again.
+	SetSummaryModel(model Model)
+	// SetContextFallbackModel sets the model a run switches to when the
+	// large model rejects a request as too long. A zero Model makes runs
+	// summarize the session instead.
+	SetContextFallbackModel(model Model)
 	SetTools(tools []fantasy.AgentTool)
 	SetSystemPrompt(systemPrompt string)
+	// SetSystemContext sets a block sent as its own system message right
+	// after the system prompt. An empty string sends nothing.
+	SetSystemContext(systemContext string)
+	// SetLanguage sets the language answers, titles and summaries are
+	// written in.
+	SetLanguage(lang language.Tag)
 	Cancel(sessionID string)
 	CancelAll()
 	IsSessionBusy(sessionID string) bool
@@ -155,6 +180,11 @@ type Model struct {
 	CatwalkCfg catwalk.Model
 	ModelCfg   config.SelectedModel
 	FlatRate   bool
+	// Quirks lists request parameters the model does not accept.
+	Quirks config.ModelQuirks
+	// RequestUser is sent as the user field by providers that take it
+	// as a per-call option (OpenRouter) rather than on the client.
+	RequestUser string
 }
 
 // activeCancel wraps a context.CancelFunc with a unique pointer identity.
@@ -167,19 +197,48 @@ type activeCancel struct {
 }
 
 type sessionAgent struct {
-	largeModel         *csync.Value[Model]
-	smallModel         *csync.Value[Model]
-	systemPromptPrefix *csync.Value[string]
-	systemPrompt       *csync.Value[string]
-	tools              *csync.Slice[fantasy.AgentTool]
-
-	isSubAgent           bool
-	sessions             session.Service
-	messages             message.Service
-	disableAutoSummarize bool
-	isYolo               bool
-	notify               pubsub.Publisher[notify.Notification]
-	runComplete          pubsub.Publisher[notify.RunComplete]
+	largeModel   *csync.Value[Model]
+	smallModel   *csync.Value[Model]
+	summaryModel *csync.Value[Model]
+	// contextFallbackModel takes over a run when the request no longer
+	// fits the context window of the large model.
+	contextFallbackModel *csync.Value[Model]
+	systemPromptPrefix   *csync.Value[string]
+	systemPrompt         *csync.Value[string]
+	// systemContext is sent as a system message of its own after the
+	// system prompt when options.context.placement is separate.
+	systemContext *csync.Value[string]
+	tools         *csync.Slice[fantasy.AgentTool]
+	// language is the language answers, titles and summaries are written
+	// in; see [languageDirective].
+	language *csync.Value[language.Tag]
+
+	isSubAgent            bool
+	sessions              session.Service
+	messages              message.Service
+	disableAutoSummarize  bool
+	auxiliaryRetries      int
+	contextTrimRatio      float64
+	ephemeralThinking     bool
+	maxIdenticalToolCalls int
+	titleTrigger          config.TitleTrigger
+	titleMinMessages      int
+	titlePrompt           string
+	titleMaxWords         int
+	titleMaxLength        int
+	smallModelLimiter     requestLimiter
+	summarizeModel        config.SelectedModelType
+	summarizeMaxTokens    int64
+	emptyResponse         config.EmptyResponsePolicy
+	todosInContext        bool
+	maxConcurrentRequests int
+	concurrencyLimitMode  config.ConcurrencyLimitMode
+	toolResultCache       bool
+	maxHistoryMessages    int
+	strictToolParams      bool
+	isYolo                bool
+	notify                pubsub.Publisher[notify.Notification]
+	runComplete           pubsub.Publisher[notify.RunComplete]
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
 	activeRequests *csync.Map[string, *activeCancel]
@@ -229,35 +288,115 @@ type SessionAgentOptions struct {
 	SystemPrompt         string
 	IsSubAgent           bool
 	DisableAutoSummarize bool
-	IsYolo               bool
-	Sessions             session.Service
-	Messages             message.Service
-	Tools                []fantasy.AgentTool
-	Notify               pubsub.Publisher[notify.Notification]
-	RunComplete          pubsub.Publisher[notify.RunComplete]
+	AuxiliaryRetries     int
+	ContextTrimRatio     float64
+	EphemeralThinking    bool
+	// MaxIdenticalToolCalls is how many identical tool calls in a row a
+	// run executes before answering repeats with a note; 0 means
+	// [config.DefaultMaxIdenticalToolCalls].
+	MaxIdenticalToolCalls int
+	// TitleTrigger and TitleMinMessages control when the session title
+	// is generated; see [config.TitleOptions].
+	TitleTrigger     config.TitleTrigger
+	TitleMinMessages int
+	// TitlePrompt, when set, replaces the built-in instructions of the
+	// title generator. TitleMaxWords limits the words of a title, 0
+	// meaning no limit, and TitleMaxLength its length in characters, 0
+	// meaning [config.DefaultTitleMaxLength].
+	TitlePrompt    string
+	TitleMaxWords  int
+	TitleMaxLength int
+	// SmallModelLimiter caps the small-model requests in flight at once.
+	// It is shared with the other agents of the coordinator.
+	SmallModelLimiter requestLimiter
+	// SummarizeModel picks the model that writes summaries; the zero
+	// value means the large model. SummaryModel, when set, is used
+	// instead. SummarizeMaxTokens caps the summary length, 0 meaning no
+	// limit.
+	SummarizeModel     config.SelectedModelType
+	SummaryModel       Model
+	SummarizeMaxTokens int64
+	// ContextFallbackModel, when set, retries a request the large model
+	// rejects as too long; otherwise the session is summarized and the
+	// run continues.
+	ContextFallbackModel Model
+	// EmptyResponse is what a run does when the model answers with no
+	// text and no tool calls; the zero value means
+	// [config.EmptyResponseFinish].
+	EmptyResponse config.EmptyResponsePolicy
+	// TodosInContext adds the open todo list of the session to every
+	// request as a system message.
+	TodosInContext bool
+	IsYolo         bool
+	Sessions       session.Service
+	Messages       message.Service
+	Tools          []fantasy.AgentTool
+	Notify         pubsub.Publisher[notify.Notification]
+	RunComplete    pubsub.Publisher[notify.RunComplete]
+	// MaxConcurrentRequests caps the turns generating at once across the
+	// process, 0 meaning no limit; ConcurrencyLimitMode says whether a
+	// turn over the cap waits or fails. Sub-agents are not counted.
+	MaxConcurrentRequests int
+	ConcurrencyLimitMode  config.ConcurrencyLimitMode
+	// ToolResultCache lets identical read-only tool calls of a run share
+	// the result of the first one; see [toolResultCache].
+	ToolResultCache bool
+	// Language is the language answers, titles and summaries are written
+	// in. English and the zero value leave the prompts unchanged.
+	Language language.Tag
+	// MaxHistoryMessages, when positive, caps how many messages of the
+	// session a request sends; see [sessionAgent.trimToMaxHistory].
+	MaxHistoryMessages int
+	// StrictToolParams answers built-in tool calls whose input does not
+	// match the tool's parameters with the problems instead of running
+	// them; see [wrapStrictParams].
+	StrictToolParams bool
 }
 
 func NewSessionAgent(
 	opts SessionAgentOptions,
 ) SessionAgent {
 	return &sessionAgent{
-		largeModel:           csync.NewValue(opts.LargeModel),
-		smallModel:           csync.NewValue(opts.SmallModel),
-		systemPromptPrefix:   csync.NewValue(opts.SystemPromptPrefix),
-		systemPrompt:         csync.NewValue(opts.SystemPrompt),
-		isSubAgent:           opts.IsSubAgent,
-		sessions:             opts.Sessions,
-		messages:             opts.Messages,
-		disableAutoSummarize: opts.DisableAutoSummarize,
-		tools:                csync.NewSliceFrom(opts.Tools),
-		isYolo:               opts.IsYolo,
-		notify:               opts.Notify,
-		runComplete:          opts.RunComplete,
-		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
-		activeRequests:       csync.NewMap[string, *activeCancel](),
-		dispatchMu:           csync.NewMap[string, *sync.Mutex](),
-		acceptedRuns:         csync.NewMap[string, int](),
-		cancelMark:           csync.NewMap[string, uint64](),
+		largeModel:            csync.NewValue(opts.LargeModel),
+		smallModel:            csync.NewValue(opts.SmallModel),
+		summaryModel:          csync.NewValue(opts.SummaryModel),
+		contextFallbackModel:  csync.NewValue(opts.ContextFallbackModel),
+		systemPromptPrefix:    csync.NewValue(opts.SystemPromptPrefix),
+		systemPrompt:          csync.NewValue(opts.SystemPrompt),
+		systemContext:         csync.NewValue(""),
+		language:              csync.NewValue(opts.Language),
+		isSubAgent:            opts.IsSubAgent,
+		sessions:              opts.Sessions,
+		messages:              opts.Messages,
+		disableAutoSummarize:  opts.DisableAutoSummarize,
+		auxiliaryRetries:      opts.AuxiliaryRetries,
+		contextTrimRatio:      opts.ContextTrimRatio,
+		ephemeralThinking:     opts.EphemeralThinking,
+		maxIdenticalToolCalls: opts.MaxIdenticalToolCalls,
+		titleTrigger:          opts.TitleTrigger,
+		titleMinMessages:      opts.TitleMinMessages,
+		titlePrompt:           opts.TitlePrompt,
+		titleMaxWords:         opts.TitleMaxWords,
+		titleMaxLength:        cmp.Or(opts.TitleMaxLength, config.DefaultTitleMaxLength),
+		smallModelLimiter:     opts.SmallModelLimiter,
+		summarizeModel:        opts.SummarizeModel,
+		summarizeMaxTokens:    opts.SummarizeMaxTokens,
+		emptyResponse:         opts.EmptyResponse,
+		todosInContext:        opts.TodosInContext,
+		maxConcurrentRequests: opts.MaxConcurrentRequests,
+		concurrencyLimitMode:  opts.ConcurrencyLimitMode,
+		toolResultCache:       opts.ToolResultCache,
+		maxHistoryMessages:    opts.MaxHistoryMessages,
+		strictToolParams:      opts.StrictToolParams,
+		tools:                 csync.NewSliceFrom(opts.Tools),
+		isYolo:                opts.IsYolo,
+		notify:                opts.Notify,
+		runComplete:           opts.RunComplete,
+		messageQueue:          csync.NewMap[string, []SessionAgentCall](),
+		activeRequests:        csync.NewMap[string, *activeCancel](),
+		dispatchMu:            csync.NewMap[string, *sync.Mutex](),
+		acceptedRuns:          csync.NewMap[string, int](),
+		cancelMark:            csync.NewMap[string, uint64](),
 	}
 }
 
@@ -656,10 +795,39 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	// the new run's cancel and breaking cancellation.
 	defer a.activeRequests.CompareAndDelete(call.SessionID, ac)
 
+	// Take a slot under options.max_concurrent_requests. The run is
+	// already active, so a Cancel while it waits ends the wait; the turn
+	// is then recorded as canceled like a cancel-on-entry.
+	releaseGeneration, err := a.acquireGeneration(genCtx, call.SessionID)
+	if errors.Is(err, ErrConcurrencyLimit) {
+		a.publishRunComplete(ctx, call, notify.RunComplete{
+			SessionID: call.SessionID,
+			RunID:     call.RunID,
+			Error:     err.Error(),
+		})
+		return nil, err
+	}
+	if err != nil {
+		complete := notify.RunComplete{SessionID: call.SessionID, RunID: call.RunID, Cancelled: true}
+		if err := a.persistCanceledTurn(ctx, call, false); err != nil {
+			complete.Error = err.Error()
+			a.publishRunComplete(ctx, call, complete)
+			return nil, err
+		}
+		a.publishRunComplete(ctx, call, complete)
+		return nil, nil
+	}
+	defer releaseGeneration()
+
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
-	agentTools := a.tools.Copy()
+	// Every copy of the tools is wrapped with the same guard and cache so
+	// repeats are counted, and results shared, across steps of this run.
+	guard := newRepeatGuard(a.maxIdenticalToolCalls)
+	cache := newToolResultCache(a.toolResultCache)
+	agentTools := guard.wrap(cache.wrap(wrapStrictParams(a.tools.Copy(), a.strictToolParams)))
 	largeModel := a.largeModel.Get()
 	systemPrompt := a.systemPrompt.Get()
+	systemContext := a.systemContext.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
 
@@ -676,6 +844,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	if s := instructions.String(); s != "" {
 		systemPrompt += "\n\n<mcp-instructions>\n" + s + "\n</mcp-instructions>"
 	}
+	if s := responseStyleDirective(call.ResponseStyle); s != "" {
+		systemPrompt += "\n\n" + s
+	}
+	if s := languageDirective(a.language.Get()); s != "" {
+		systemPrompt += "\n\n" + s
+	}
 
 	if len(agentTools) > 0 {
 		// Add Anthropic caching to the last tool.
@@ -701,14 +875,38 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	}
 
 	var wg sync.WaitGroup
-	// Generate title from the first real (non-shell) user prompt.
-	if !hasUserTextMessage(msgs) {
+	// firstChunk is closed when the model starts answering, and runDone
+	// when Run returns; a title with the first_chunk trigger waits for
+	// one of them.
+	var firstChunkOnce sync.Once
+	firstChunk, runDone := make(chan struct{}), make(chan struct{})
+	gotFirstChunk := func() {
+		firstChunkOnce.Do(func() { close(firstChunk) })
+	}
+	// Generate the title from the real (non-shell) user prompts once
+	// there are as many as options.title.min_messages asks for.
+	if prompts := userTextPrompts(msgs); len(prompts)+1 == max(a.titleMinMessages, 1) {
 		titleCtx := ctx // Copy to avoid race with ctx reassignment below.
+		titleInput := strings.Join(append(prompts, call.Prompt), "\n\n")
 		wg.Go(func() {
-			a.GenerateTitle(titleCtx, call.SessionID, call.Prompt)
+			if a.titleTrigger == config.TitleTriggerFirstChunk {
+				select {
+				case <-firstChunk:
+				case <-runDone:
+					// Both may be closed by now; only a run that
+					// never got an answer goes without a title.
+					select {
+					case <-firstChunk:
+					default:
+						return
+					}
+				}
+			}
+			a.GenerateTitle(titleCtx, call.SessionID, titleInput)
 		})
 	}
 	defer wg.Wait()
+	defer close(runDone)
 
 	// Add the user message to the session.
 	_, err = a.createUserMessage(ctx, call)
@@ -782,38 +980,65 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 	}()
 
 	history, files := a.preparePrompt(msgs, largeModel.CatwalkCfg.SupportsImages, call.Attachments...)
+	// The todo reminder and the summary of earlier turns head the history
+	// and must survive trimming.
+	pinned := 0
+	if !a.isSubAgent {
+		pinned++
+	}
+	if currentSession.SummaryMessageID != "" && len(msgs) > 0 && msgs[0].ID == currentSession.SummaryMessageID {
+		pinned++
+	}
+
+	dump := messageDumpFor(ctx, call.SessionID)
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
+	timings := timingsFor(ctx, call.SessionID)
+	timings.runStarted()
 
 	var stepMessages []fantasy.Message
 	var shouldSummarize bool
-	sanitizedToolCalls := make(map[string]bool)
+	// sanitizedToolCalls maps tool calls with invalid JSON arguments to
+	// the error reported in place of their result.
+	sanitizedToolCalls := make(map[string]string)
 	// Don't send MaxOutputTokens if 0 — some providers (e.g. LM Studio) reject it
 	var maxOutputTokens *int64
 	if call.MaxOutputTokens > 0 {
 		maxOutputTokens = &call.MaxOutputTokens
 	}
-	result, err = agent.Stream(genCtx, fantasy.AgentStreamCall{
+	providerOptions, temperature := call.ProviderOptions, call.Temperature
+	if largeModel.Quirks.NoTemperature {
+		temperature = nil
+	}
+	if largeModel.Quirks.UseMaxCompletionTokens && maxOutputTokens != nil {
+		if opts, ok := withMaxCompletionTokens(providerOptions, *maxOutputTokens); ok {
+			providerOptions = opts
+			maxOutputTokens = nil
+		}
+	}
+	streamCall := fantasy.AgentStreamCall{
 		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
 		Files:            files,
 		Messages:         history,
 		Headers:          sessionHeaders(call.SessionID),
-		ProviderOptions:  call.ProviderOptions,
+		ProviderOptions:  providerOptions,
 		MaxOutputTokens:  maxOutputTokens,
 		TopP:             call.TopP,
-		Temperature:      call.Temperature,
+		Temperature:      temperature,
 		PresencePenalty:  call.PresencePenalty,
 		TopK:             call.TopK,
 		FrequencyPenalty: call.FrequencyPenalty,
+		RepairToolCall:   repairToolCall,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+			timings.stepStarted()
 			prepared.Messages = options.Messages
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
 
 			// Use latest tools (updated by SetTools when MCP tools change).
-			prepared.Tools = a.tools.Copy()
+			prepared.Tools = guard.wrap(cache.wrap(wrapStrictParams(a.tools.Copy(), a.strictToolParams)))
 
 			// Drain queued follow-up prompts for this step. Calls covered
 			// by a cancel recorded while they sat in the queue are dropped:
@@ -837,6 +1062,22 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			}
 
 			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages, largeModel)
+			prepared.Messages = a.trimToMaxHistory(call.SessionID, prepared.Messages, pinned)
+			prepared.Messages = a.trimToContextWindow(call.SessionID, prepared.Messages, pinned, largeModel)
+			if systemContext != "" {
+				prepared.Messages = insertAfterSystemMessages(prepared.Messages, fantasy.NewSystemMessage(systemContext))
+			}
+			if a.todosInContext {
+				// Read the todos on every step so items completed by
+				// the previous step are shown as such.
+				sess, getErr := a.sessions.Get(callContext, call.SessionID)
+				if getErr != nil {
+					return callContext, prepared, getErr
+				}
+				if todos := todosContext(sess.Todos); todos != "" {
+					prepared.Messages = insertAfterSystemMessages(prepared.Messages, fantasy.NewSystemMessage(todos))
+				}
+			}
 
 			lastSystemRoleInx := 0
 			systemMessageUpdated := false
@@ -857,6 +1098,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			if promptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
 			}
+			if largeModel.Quirks.NoSystemRole {
+				prepared.Messages = foldSystemMessages(prepared.Messages)
+			}
+			if err = dump.write(largeModel, prepared.Messages, prepared.Tools); err != nil {
+				return callContext, prepared, fmt.Errorf("failed to dump messages: %w", err)
+			}
 
 			sessionLock.Lock()
 			stepMessages = cloneFantasyMessages(prepared.Messages)
@@ -864,10 +1111,11 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 
 			var assistantMsg message.Message
 			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
-				Role:     message.Assistant,
-				Parts:    []message.ContentPart{},
-				Model:    largeModel.ModelCfg.Model,
-				Provider: largeModel.ModelCfg.Provider,
+				Role:              message.Assistant,
+				Parts:             []message.ContentPart{},
+				Model:             largeModel.ModelCfg.Model,
+				Provider:          largeModel.ModelCfg.Provider,
+				EphemeralThinking: a.ephemeralThinking,
 			})
 			if err != nil {
 				return callContext, prepared, err
@@ -879,6 +1127,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			return callContext, prepared, err
 		},
 		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
+			timings.firstToken()
+			gotFirstChunk()
 			currentAssistant.AppendReasoningContent(reasoning.Text)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
@@ -907,6 +1157,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnTextDelta: func(id string, text string) error {
+			timings.firstToken()
+			gotFirstChunk()
 			// Strip leading newline from initial text content. This is is
 			// particularly important in non-interactive mode where leading
 			// newlines are very visible.
@@ -918,6 +1170,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
 		OnToolInputStart: func(id string, toolName string) error {
+			timings.firstToken()
+			gotFirstChunk()
 			toolCall := message.ToolCall{
 				ID:               id,
 				Name:             toolName,
@@ -931,6 +1185,15 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 		},
 		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
 			slog.Warn("Provider request failed, retrying", providerRetryLogFields(err, delay)...)
+			if a.notify != nil {
+				a.notify.Publish(pubsub.CreatedEvent, notify.Notification{
+					SessionID:  call.SessionID,
+					Type:       notify.TypeProviderRetry,
+					ProviderID: largeModel.ModelCfg.Provider,
+					RunID:      call.RunID,
+					Message:    providerRetryMessage(err, delay),
+				})
+			}
 			// Reset streamed content so the retried response doesn't
 			// concatenate with partial content from the failed attempt.
 			// On the final attempt (no more retries), any partial content
@@ -951,7 +1214,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			input, wasSanitized := sanitizeToolInput(tc.ToolName, tc.ToolCallID, tc.Input)
 			if wasSanitized {
-				sanitizedToolCalls[tc.ToolCallID] = true
+				sanitizedToolCalls[tc.ToolCallID] = toolInputErrorMessage(tc.Input)
 			}
 			toolCall := message.ToolCall{
 				ID:               tc.ToolCallID,
@@ -967,8 +1230,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 		},
 		OnToolResult: func(result fantasy.ToolResultContent) error {
 			toolResult := a.convertToToolResult(result)
-			if sanitizedToolCalls[result.ToolCallID] {
-				toolResult.Content = "Tool call failed: arguments were not valid JSON. Please check your tool call format and try again."
+			if msg, ok := sanitizedToolCalls[result.ToolCallID]; ok {
+				toolResult.Content = msg
 				toolResult.IsError = true
 			}
 			// Use parent ctx instead of genCtx to ensure the message is created
@@ -981,7 +1244,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			})
 			return createMsgErr
 		},
+		OnStreamFinish: func(fantasy.Usage, fantasy.FinishReason, fantasy.ProviderMetadata) error {
+			timings.streamFinished()
+			return nil
+		},
 		OnStepFinish: func(stepResult fantasy.StepResult) error {
+			timings.stepFinished()
 			for _, w := range stepResult.Warnings {
 				slog.Warn("Provider warning", "type", w.Type, "message", w.Message)
 			}
@@ -1006,6 +1274,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 					}
 				}
 			}
+			if err := a.finishIncompleteToolCalls(ctx, currentAssistant); err != nil {
+				return err
+			}
 			currentAssistant.AddFinish(finishReason, "", "")
 			sessionLock.Lock()
 			defer sessionLock.Unlock()
@@ -1050,9 +1321,53 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 				return hasRepeatedToolCalls(steps, loopDetectionWindowSize, loopDetectionMaxRepeats)
 			},
 		},
-	})
+	}
+	result, err = agent.Stream(genCtx, streamCall)
+	if err == nil && !shouldSummarize && isEmptyResponse(currentAssistant) {
+		if a.emptyResponse == config.EmptyResponseRetry {
+			slog.Warn("Model returned an empty response, retrying", "session_id", call.SessionID)
+			result, err = a.retryEmptyResponse(ctx, genCtx, agent, streamCall, currentSession, currentAssistant, largeModel)
+		}
+		if err == nil && isEmptyResponse(currentAssistant) {
+			err = ErrEmptyResponse
+		}
+	}
+	// continueAfterSummary queues the interrupted request again once the
+	// session has been summarized.
+	var continueAfterSummary bool
+	if contextErr := (*fantasy.ProviderError)(nil); errors.As(err, &contextErr) && contextErr.IsContextTooLarge() && isEmptyResponse(currentAssistant) {
+		fallback := a.contextFallbackModel.Get()
+		switch {
+		case fallback.Model != nil && fallback.ModelCfg.Model != largeModel.ModelCfg.Model:
+			if err := a.finishContextLimit(ctx, currentAssistant, fmt.Sprintf("The conversation is too long for %s. Retrying with %s.", largeModel.CatwalkCfg.Name, fallback.CatwalkCfg.Name)); err != nil {
+				return nil, err
+			}
+			slog.Warn("Context limit reached, retrying with the fallback model",
+				"session_id", call.SessionID,
+				"model", largeModel.ModelCfg.Model,
+				"fallback_model", fallback.ModelCfg.Model,
+			)
+			a.eventContextFallback(call.SessionID, largeModel, fallback, "fallback model")
+			largeModel = fallback
+			agent = fantasy.NewAgent(
+				largeModel.Model,
+				fantasy.WithSystemPrompt(systemPrompt),
+				fantasy.WithTools(agentTools...),
+				fantasy.WithUserAgent(userAgent),
+			)
+			result, err = a.retryContextLimit(ctx, genCtx, agent, streamCall, currentSession, largeModel)
+		case !a.disableAutoSummarize && !call.afterContextLimit:
+			if err := a.finishContextLimit(ctx, currentAssistant, fmt.Sprintf("The conversation is too long for %s. Summarizing it and continuing.", largeModel.CatwalkCfg.Name)); err != nil {
+				return nil, err
+			}
+			slog.Warn("Context limit reached, summarizing the session", "session_id", call.SessionID, "model", largeModel.ModelCfg.Model)
+			a.eventContextFallback(call.SessionID, largeModel, Model{}, "summarize")
+			shouldSummarize, continueAfterSummary, err = true, true, nil
+		}
+	}
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
+	timings.runFinished()
 
 	if err != nil {
 		isHyper := largeModel.ModelCfg.Provider == hyper.Name
@@ -1148,6 +1463,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 		linkStyle := lipgloss.NewStyle().Foreground(charmtone.Guac).Underline(true)
 		if isCancelErr {
 			currentAssistant.AddFinish(message.FinishReasonCanceled, "User canceled request", "")
+		} else if errors.Is(err, ErrEmptyResponse) {
+			currentAssistant.AddFinish(message.FinishReasonError, "Empty response", "The model returned no text and no tool calls. Try again, or switch models if it keeps happening.")
 		} else if isHyper && errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusUnauthorized {
 			currentAssistant.AddFinish(message.FinishReasonError, "Unauthorized", `Please re-authenticate with Hyper. You can also run "crush auth" to re-authenticate.`)
 		} else if isHyper && errors.As(err, &providerErr) && providerErr.StatusCode == http.StatusPaymentRequired {
@@ -1185,11 +1502,24 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
-		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
+		summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions)
+		if errors.Is(summarizeErr, context.Canceled) {
 			return nil, summarizeErr
 		}
-		// If the agent wasn't done...
-		if len(currentAssistant.ToolCalls()) > 0 {
+		if summarizeErr != nil {
+			slog.Error("Auto-summarize failed", "session_id", call.SessionID, "error", summarizeErr)
+			a.eventSummarizeFailed(call.SessionID, summarizeErr)
+		}
+		if continueAfterSummary {
+			// The request never got an answer; ask it again on top of
+			// the summary.
+			existing, _ := a.messageQueue.Get(call.SessionID)
+			call.afterContextLimit = true
+			a.messageQueue.Set(call.SessionID, append(existing, call))
+		} else if len(currentAssistant.ToolCalls()) > 0 {
+			// The agent wasn't done. Queue the continuation even when the
+			// summary failed, so the task isn't silently dropped; the
+			// failed summary message already shows the error.
 			existing, ok := a.messageQueue.Get(call.SessionID)
 			if !ok {
 				existing = []SessionAgentCall{}
@@ -1197,6 +1527,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 			call.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
 			existing = append(existing, call)
 			a.messageQueue.Set(call.SessionID, existing)
+		} else if summarizeErr != nil {
+			// Nothing left to run, so report the failure to the caller.
+			err = summarizeErr
 		}
 	}
 
@@ -1317,6 +1650,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (result *
 		}
 		a.publishRunComplete(ctx, call, complete)
 	}
+	// The queued turn takes a slot of its own; holding this one while it
+	// waits would deadlock at a limit of one.
+	releaseGeneration()
 	return a.Run(ctx, firstQueuedMessage)
 }
 
@@ -1329,6 +1665,17 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	largeModel := a.largeModel.Get()
 	systemPromptPrefix := a.systemPromptPrefix.Get()
 
+	// opts are built for the large model. Like title generation, the
+	// other models get none of them.
+	model := largeModel
+	if summaryModel := a.summaryModel.Get(); summaryModel.Model != nil {
+		model = summaryModel
+		opts = nil
+	} else if a.summarizeModel == config.SelectedModelTypeSmall {
+		model = a.smallModel.Get()
+		opts = nil
+	}
+
 	currentSession, err := a.sessions.Get(ctx, sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to get session: %w", err)
@@ -1342,7 +1689,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return nil
 	}
 
-	aiMsgs, _ := a.preparePrompt(msgs, largeModel.CatwalkCfg.SupportsImages)
+	aiMsgs, _ := a.preparePrompt(msgs, model.CatwalkCfg.SupportsImages)
 
 	genCtx, cancel := context.WithCancel(ctx)
 	ac := &activeCancel{cancel: cancel}
@@ -1355,22 +1702,36 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		}
 	}()
 
-	agent := fantasy.NewAgent(
-		largeModel.Model,
+	agentOpts := []fantasy.AgentOption{
 		fantasy.WithSystemPrompt(string(summaryPrompt)),
 		fantasy.WithUserAgent(userAgent),
-	)
+		fantasy.WithMaxRetries(a.auxiliaryRetries),
+	}
+	if a.summarizeMaxTokens > 0 {
+		var limited bool
+		if model.Quirks.UseMaxCompletionTokens {
+			opts, limited = withMaxCompletionTokens(opts, a.summarizeMaxTokens)
+		}
+		if !limited {
+			agentOpts = append(agentOpts, fantasy.WithMaxOutputTokens(a.summarizeMaxTokens))
+		}
+	}
+	agent := fantasy.NewAgent(model.Model, agentOpts...)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
-		Role:             message.Assistant,
-		Model:            largeModel.ModelCfg.Model,
-		Provider:         largeModel.ModelCfg.Provider,
-		IsSummaryMessage: true,
+		Role:              message.Assistant,
+		Model:             model.ModelCfg.Model,
+		Provider:          model.ModelCfg.Provider,
+		IsSummaryMessage:  true,
+		EphemeralThinking: a.ephemeralThinking,
 	})
 	if err != nil {
 		return err
 	}
 
-	summaryPromptText := buildSummaryPrompt(currentSession.Todos)
+	summaryPromptText := buildSummaryPrompt(currentSession.Todos, a.summarizeMaxTokens)
+	if name := languageName(a.language.Get()); name != "" {
+		summaryPromptText += "\n\nWrite the summary in " + name + ", keeping code, identifiers and file paths as they are."
+	}
 
 	resp, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:          summaryPromptText,
@@ -1382,6 +1743,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 			if systemPromptPrefix != "" {
 				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(systemPromptPrefix)}, prepared.Messages...)
 			}
+			if model.Quirks.NoSystemRole {
+				prepared.Messages = foldSystemMessages(prepared.Messages)
+			}
 			return callContext, prepared, nil
 		},
 		OnReasoningDelta: func(id string, text string) error {
@@ -1402,6 +1766,13 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 			summaryMessage.AppendContent(text)
 			return a.messages.Update(genCtx, summaryMessage)
 		},
+		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
+			slog.Warn("Summarize request failed, retrying", providerRetryLogFields(err, delay)...)
+			summaryMessage.ResetStreamedContent()
+			if updateErr := a.messages.Update(genCtx, summaryMessage); updateErr != nil {
+				slog.Error("Failed to reset summary message on retry", "error", updateErr)
+			}
+		},
 	})
 	if err != nil {
 		isCancelErr := errors.Is(err, context.Canceled)
@@ -1438,7 +1809,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		extractHyperCredits(step.ProviderMetadata)
 	}
 
-	a.updateSessionUsage(largeModel, &currentSession, resp.TotalUsage, openrouterCost, false)
+	a.updateSessionUsage(model, &currentSession, resp.TotalUsage, openrouterCost, false)
 
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
@@ -1467,6 +1838,107 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	return qErr
 }
 
+// isEmptyResponse reports whether msg, the last assistant message of a
+// run, has no text and no tool calls, leaving nothing to show or act on.
+func isEmptyResponse(msg *message.Message) bool {
+	return msg != nil && len(msg.ToolCalls()) == 0 && strings.TrimSpace(msg.Content().Text) == ""
+}
+
+// emptyResponseNudge is sent, without being stored, in place of the prompt
+// when a run retries after an empty response.
+const emptyResponseNudge = "Your previous response was empty. Continue with the task: answer the request above or call the tool you need."
+
+// retryEmptyResponse streams the run once more after an empty response. The
+// blank assistant message is dropped and the history is read back from the
+// session, so tool calls made before the empty step are not repeated.
+func (a *sessionAgent) retryEmptyResponse(
+	ctx, genCtx context.Context,
+	agent fantasy.Agent,
+	streamCall fantasy.AgentStreamCall,
+	currentSession session.Session,
+	emptyMsg *message.Message,
+	largeModel Model,
+) (*fantasy.AgentResult, error) {
+	if err := a.messages.Delete(ctx, emptyMsg.ID); err != nil {
+		return nil, err
+	}
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session messages: %w", err)
+	}
+	streamCall.Messages, streamCall.Files = a.preparePrompt(msgs, largeModel.CatwalkCfg.SupportsImages)
+	streamCall.Prompt = emptyResponseNudge
+	return agent.Stream(genCtx, streamCall)
+}
+
+// finishContextLimit closes the blank assistant message of a request that
+// hit the context limit with a note on what happens next.
+func (a *sessionAgent) finishContextLimit(ctx context.Context, msg *message.Message, note string) error {
+	msg.AddFinish(message.FinishReasonError, "Context limit reached", note)
+	return a.messages.Update(ctx, *msg)
+}
+
+// retryContextLimit streams the run again with model after the request
+// was rejected as too long. The history is read back from the session, so
+// tool calls made before the failed step are not repeated.
+func (a *sessionAgent) retryContextLimit(
+	ctx, genCtx context.Context,
+	agent fantasy.Agent,
+	streamCall fantasy.AgentStreamCall,
+	currentSession session.Session,
+	model Model,
+) (*fantasy.AgentResult, error) {
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session messages: %w", err)
+	}
+	streamCall.Messages, streamCall.Files = a.preparePrompt(msgs, model.CatwalkCfg.SupportsImages)
+	streamCall.Prompt = ""
+	// Retries of the step re-read the model; keep them on the fallback.
+	streamCall.ModelProvider = func() fantasy.LanguageModel { return model.Model }
+	if model.CatwalkCfg.DefaultMaxTokens > 0 {
+		streamCall.MaxOutputTokens = &model.CatwalkCfg.DefaultMaxTokens
+	}
+	return agent.Stream(genCtx, streamCall)
+}
+
+// trimToContextWindow drops the oldest turns from messages when they are
+// estimated to exceed the configured fraction of the model's context
+// window. See [trimToBudget] for what is kept.
+func (a *sessionAgent) trimToContextWindow(sessionID string, messages []fantasy.Message, pinned int, model Model) []fantasy.Message {
+	cw := model.CatwalkCfg.ContextWindow
+	if a.contextTrimRatio <= 0 || cw == 0 {
+		return messages
+	}
+	budget := int64(float64(cw) * min(a.contextTrimRatio, 1))
+	trimmed, dropped := trimToBudget(messages, pinned, budget)
+	if dropped > 0 {
+		slog.Info("Trimmed oldest messages to fit the context window",
+			"session_id", sessionID,
+			"dropped", dropped,
+			"estimated_tokens", estimateMessageTokens(trimmed),
+			"budget", budget,
+		)
+	}
+	return trimmed
+}
+
+// trimToMaxHistory drops the oldest turns from messages when more than
+// the configured number of messages follow the system and pinned ones.
+// The session itself keeps every message. See [trimToBudget] for what is
+// kept.
+func (a *sessionAgent) trimToMaxHistory(sessionID string, messages []fantasy.Message, pinned int) []fantasy.Message {
+	trimmed, dropped := trimToCount(messages, pinned, a.maxHistoryMessages)
+	if dropped > 0 {
+		slog.Info("Trimmed oldest messages to the history limit",
+			"session_id", sessionID,
+			"dropped", dropped,
+			"limit", a.maxHistoryMessages,
+		)
+	}
+	return trimmed
+}
+
 func (a *sessionAgent) getCacheControlOptions() fantasy.ProviderOptions {
 	if t, _ := strconv.ParseBool(os.Getenv("CRUSH_DISABLE_ANTHROPIC_CACHE")); t {
 		return fantasy.ProviderOptions{}
@@ -1698,20 +2170,23 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 	return msgs, nil
 }
 
-// hasUserTextMessage reports whether any user message in msgs contains
-// text content (as opposed to only shell commands or other non-text parts).
-func hasUserTextMessage(msgs []message.Message) bool {
+// userTextPrompts returns the text of the user messages in msgs that
+// contain text content (as opposed to only shell commands or other
+// non-text parts).
+func userTextPrompts(msgs []message.Message) []string {
+	var prompts []string
 	for _, msg := range msgs {
 		if msg.Role != message.User {
 			continue
 		}
 		for _, part := range msg.Parts {
 			if tc, ok := part.(message.TextContent); ok && tc.Text != "" {
-				return true
+				prompts = append(prompts, tc.Text)
+				break
 			}
 		}
 	}
-	return false
+	return prompts
 }
 
 // GenerateTitle generates a session title based on the initial prompt.
@@ -1733,16 +2208,31 @@ func (a *sessionAgent) GenerateTitle(ctx context.Context, sessionID string, user
 		}
 	}()
 
+	if err := a.smallModelLimiter.acquire(ctx); err != nil {
+		return
+	}
+	defer a.smallModelLimiter.release()
+
 	smallModel := a.smallModel.Get()
 	largeModel := a.largeModel.Get()
 	systemPromptPrefix := a.systemPromptPrefix.Get()
 
-	newAgent := func(m fantasy.LanguageModel, p []byte, tok int64) fantasy.Agent {
+	titleSystemPrompt, err := buildTitlePrompt(a.titlePrompt, a.titleMaxWords, a.titleMaxLength)
+	if err != nil {
+		slog.Error("Failed to build title prompt", "error", err)
+		return
+	}
+	if name := languageName(a.language.Get()); name != "" {
+		titleSystemPrompt += "\n\nWrite the title in " + name + "."
+	}
+
+	newAgent := func(m fantasy.LanguageModel, p string, tok int64) fantasy.Agent {
 		return fantasy.NewAgent(
 			m,
-			fantasy.WithSystemPrompt(string(p)+"\n /no_think"),
+			fantasy.WithSystemPrompt(p+"\n /no_think"),
 			fantasy.WithMaxOutputTokens(tok),
 			fantasy.WithUserAgent(userAgent),
+			fantasy.WithMaxRetries(a.auxiliaryRetries),
 		)
 	}
 
@@ -1770,7 +2260,6 @@ func (a *sessionAgent) GenerateTitle(ctx context.Context, sessionID string, user
 	}
 
 	var resp *fantasy.AgentResult
-	var err error
 	var model Model
 	var success bool
 	for _, attempt := range attempts {
@@ -1778,7 +2267,7 @@ func (a *sessionAgent) GenerateTitle(ctx context.Context, sessionID string, user
 		if attempt.model.CatwalkCfg.CanReason {
 			tok = attempt.model.CatwalkCfg.DefaultMaxTokens
 		}
-		agent := newAgent(attempt.model.Model, titlePrompt, tok)
+		agent := newAgent(attempt.model.Model, titleSystemPrompt, tok)
 		resp, err = agent.Stream(ctx, streamCall)
 		if err == nil && resp.Response.FinishReason != fantasy.FinishReasonLength {
 			model = attempt.model
@@ -1797,23 +2286,14 @@ func (a *sessionAgent) GenerateTitle(ctx context.Context, sessionID string, user
 		return
 	}
 
-	// Clean up title.
-	var title string
-	title = strings.ReplaceAll(resp.Response.Content.Text(), "\n", " ")
-
-	// Remove thinking tags if present.
-	title = thinkTagRegex.ReplaceAllString(title, "")
-	title = orphanThinkTagRegex.ReplaceAllString(title, "")
-
-	title = strings.TrimSpace(title)
+	title := cleanTitle(resp.Response.Content.Text(), a.titleMaxWords, a.titleMaxLength)
 	if title == "" {
 		// LLM returned empty content. Use the prompt itself as a
-		// fallback title, truncated to 50 chars, before resorting to
-		// the generic default.
-		fallback := strings.ReplaceAll(userPrompt, "\n", " ")
-		fallback = strings.TrimSpace(fallback)
-		if len(fallback) > 50 {
-			fallback = ansi.Truncate(fallback, 50, "…")
+		// fallback title, truncated to the maximum length, before
+		// resorting to the generic default.
+		fallback := strings.TrimSpace(strings.ReplaceAll(userPrompt, "\n", " "))
+		if ansi.StringWidth(fallback) > a.titleMaxLength {
+			fallback = ansi.Truncate(fallback, a.titleMaxLength, "…")
 		}
 		title = cmp.Or(fallback, DefaultSessionName)
 	}
@@ -2065,6 +2545,14 @@ func (a *sessionAgent) SetModels(large Model, small Model) {
 	a.smallModel.Set(small)
 }
 
+func (a *sessionAgent) SetSummaryModel(model Model) {
+	a.summaryModel.Set(model)
+}
+
+func (a *sessionAgent) SetContextFallbackModel(model Model) {
+	a.contextFallbackModel.Set(model)
+}
+
 func (a *sessionAgent) SetTools(tools []fantasy.AgentTool) {
 	a.tools.SetSlice(tools)
 }
@@ -2073,6 +2561,54 @@ func (a *sessionAgent) SetSystemPrompt(systemPrompt string) {
 	a.systemPrompt.Set(systemPrompt)
 }
 
+func (a *sessionAgent) SetSystemContext(systemContext string) {
+	a.systemContext.Set(systemContext)
+}
+
+func (a *sessionAgent) SetLanguage(lang language.Tag) {
+	a.language.Set(lang)
+}
+
+// maxContextTodos and maxContextTodoLength bound the todo list
+// [todosContext] adds to a request.
+const (
+	maxContextTodos      = 30
+	maxContextTodoLength = 200
+)
+
+// todosContext renders todos as a reminder for the model, or returns ""
+// when every item is completed.
+func todosContext(todos []session.Todo) string {
+	if !session.HasIncompleteTodos(todos) {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("<todos>\nYour current todo list. Keep it up to date with the todos tool as you work.\n")
+	for i, todo := range todos {
+		if i == maxContextTodos {
+			fmt.Fprintf(&sb, "... and %d more\n", len(todos)-i)
+			break
+		}
+		content := strings.Join(strings.Fields(todo.Content), " ")
+		if len(content) > maxContextTodoLength {
+			content = strings.ToValidUTF8(content[:maxContextTodoLength], "") + "…"
+		}
+		fmt.Fprintf(&sb, "- [%s] %s\n", todo.Status, content)
+	}
+	sb.WriteString("</todos>")
+	return sb.String()
+}
+
+// insertAfterSystemMessages inserts msg after the system messages at the
+// start of msgs.
+func insertAfterSystemMessages(msgs []fantasy.Message, msg fantasy.Message) []fantasy.Message {
+	i := 0
+	for i < len(msgs) && msgs[i].Role == fantasy.MessageRoleSystem {
+		i++
+	}
+	return slices.Insert(msgs, i, msg)
+}
+
 func (a *sessionAgent) Model() Model {
 	return a.largeModel.Get()
 }
@@ -2227,10 +2763,63 @@ func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Mes
 	return convertedMessages
 }
 
+// buildTitlePrompt returns the system prompt of the title generator:
+// custom when set, otherwise the built-in one with the word and length
+// limits filled in.
+func buildTitlePrompt(custom string, maxWords, maxLength int) (string, error) {
+	if custom != "" {
+		return custom, nil
+	}
+	var sb strings.Builder
+	err := titlePromptTemplate.Execute(&sb, struct{ MaxWords, MaxLength int }{maxWords, maxLength})
+	return sb.String(), err
+}
+
+// titleQuotes pairs the opening and closing quotes models wrap titles in.
+var titleQuotes = [][2]string{
+	{`"`, `"`}, {"'", "'"}, {"`", "`"}, {"*", "*"}, {"“", "”"}, {"‘", "’"}, {"«", "»"}, {"„", "“"}, {"「", "」"},
+}
+
+// cleanTitle normalizes a generated title: it drops thinking tags, a
+// "Title:" label, surrounding quotes and trailing punctuation, collapses
+// whitespace into single spaces, and then cuts the title to maxWords words,
+// when positive, and maxLength cells.
+func cleanTitle(title string, maxWords, maxLength int) string {
+	title = thinkTagRegex.ReplaceAllString(title, "")
+	title = orphanThinkTagRegex.ReplaceAllString(title, "")
+	words := strings.Fields(title)
+	if len(words) > 0 && strings.EqualFold(words[0], "title:") {
+		words = words[1:]
+	}
+	title = strings.Join(words, " ")
+
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, q := range titleQuotes {
+			if len(title) > len(q[0])+len(q[1]) && strings.HasPrefix(title, q[0]) && strings.HasSuffix(title, q[1]) {
+				title = strings.TrimSpace(title[len(q[0]) : len(title)-len(q[1])])
+				trimmed = true
+			}
+		}
+	}
+	title = strings.TrimRight(title, ".,;: ")
+
+	if words := strings.Fields(title); maxWords > 0 && len(words) > maxWords {
+		title = strings.TrimRight(strings.Join(words[:maxWords], " "), ".,;: ")
+	}
+	if maxLength > 0 && ansi.StringWidth(title) > maxLength {
+		title = ansi.Truncate(title, maxLength, "…")
+	}
+	return title
+}
+
 // buildSummaryPrompt constructs the prompt text for session summarization.
-func buildSummaryPrompt(todos []session.Todo) string {
+func buildSummaryPrompt(todos []session.Todo, maxTokens int64) string {
 	var sb strings.Builder
 	sb.WriteString("Provide a detailed summary of our conversation above.")
+	if maxTokens > 0 {
+		fmt.Fprintf(&sb, " Keep it under about %d tokens, leaving out detail before dropping any of the sections.", maxTokens)
+	}
 	if len(todos) > 0 {
 		sb.WriteString("\n\n## Current Todo List\n\n")
 		for _, t := range todos {
@@ -2242,6 +2831,28 @@ func buildSummaryPrompt(todos []session.Todo) string {
 	return sb.String()
 }
 
+// providerRetryMessage describes a retried provider failure for the user,
+// such as "429 Too Many Requests: rate limited; retrying in 10s".
+func providerRetryMessage(err *fantasy.ProviderError, delay time.Duration) string {
+	reason := "request failed"
+	if err != nil {
+		var parts []string
+		if err.StatusCode != 0 {
+			parts = append(parts, strconv.Itoa(err.StatusCode))
+		}
+		if err.Title != "" {
+			parts = append(parts, err.Title)
+		}
+		if len(parts) > 0 {
+			reason = strings.Join(parts, " ")
+		}
+		if err.Message != "" {
+			reason += ": " + err.Message
+		}
+	}
+	return fmt.Sprintf("%s; retrying in %s", reason, delay.Round(time.Second))
+}
+
 func providerRetryLogFields(err *fantasy.ProviderError, delay time.Duration) []any {
 	fields := []any{
 		"retry_delay", delay.String(),
@@ -653,7 +653,7 @@ func BenchmarkBuildSummaryPrompt(b *testing.B) {
 		b.Run(tc.name, func(b *testing.B) {
 			b.ReportAllocs()
 			for range b.N {
-				_ = buildSummaryPrompt(todos)
+				_ = buildSummaryPrompt(todos, 0)
 			}
 		})
 	}
@@ -1063,3 +1063,13 @@ func TestProviderRetryLogFields(t *testing.T) {
 		}, fields)
 	})
 }
+
+func TestProviderRetryMessage(t *testing.T) {
+	require.Equal(t, "request failed; retrying in 5s", providerRetryMessage(nil, 5*time.Second))
+	require.Equal(t, "429 rate limit: too many requests; retrying in 2s", providerRetryMessage(&fantasy.ProviderError{
+		StatusCode: 429,
+		Title:      "rate limit",
+		Message:    "too many requests",
+	}, 1500*time.Millisecond))
+	require.Equal(t, "503; retrying in 10s", providerRetryMessage(&fantasy.ProviderError{StatusCode: 503}, 10*time.Second))
+}
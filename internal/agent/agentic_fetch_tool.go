@@ -184,6 +184,9 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				SystemPromptPrefix:   smallProviderCfg.SystemPromptPrefix,
 				SystemPrompt:         systemPrompt,
 				DisableAutoSummarize: c.cfg.Config().Options.DisableAutoSummarize,
+				AuxiliaryRetries:     c.cfg.Config().Options.GetAuxiliaryRetries(),
+				ContextTrimRatio:     c.cfg.Config().Options.ContextTrimRatio,
+				EphemeralThinking:    !c.cfg.Config().Options.GetStoreThinking(),
 				IsYolo:               c.permissions.SkipRequests(),
 				Sessions:             c.sessions,
 				Messages:             c.messages,
@@ -0,0 +1,190 @@
+// Package breaker implements a per-provider circuit breaker for language
+// models. After a run of consecutive provider failures, requests fail
+// immediately for a cooldown instead of each one waiting on, and retrying
+// against, a provider that is down.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+)
+
+// ErrOpen is returned, wrapped, for requests made while a circuit is open.
+var ErrOpen = errors.New("circuit breaker open")
+
+// State is the state of a [Breaker].
+type State string
+
+const (
+	// StateClosed lets requests through.
+	StateClosed State = "closed"
+	// StateOpen fails requests immediately.
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probe request through.
+	StateHalfOpen State = "half-open"
+)
+
+// Breaker tracks the failures of one provider. It is safe for concurrent
+// use.
+type Breaker struct {
+	provider string
+	cfg      config.CircuitBreaker
+	now      func() time.Time
+
+	mu          sync.Mutex
+	state       State
+	failures    int
+	firstFailed time.Time
+	openedAt    time.Time
+	// probeStarted is when the half-open probe was let through, or zero
+	// when no probe is in flight.
+	probeStarted time.Time
+}
+
+// New returns a closed breaker for provider.
+func New(provider string, cfg config.CircuitBreaker) *Breaker {
+	return &Breaker{provider: provider, cfg: cfg, now: time.Now, state: StateClosed}
+}
+
+var breakers = csync.NewMap[string, *Breaker]()
+
+// For returns the breaker for provider, creating it on first use. Breakers
+// outlive the models they wrap so their state survives model switches and
+// config reloads; a breaker is replaced only when its settings change.
+func For(provider string, cfg config.CircuitBreaker) *Breaker {
+	if b, ok := breakers.Get(provider); ok && b.cfg == cfg {
+		return b
+	}
+	b := New(provider, cfg)
+	breakers.Set(provider, b)
+	return b
+}
+
+// GetStates returns the state of every provider breaker in use, keyed by
+// provider.
+func GetStates() map[string]State {
+	states := make(map[string]State)
+	for provider, b := range breakers.Seq2() {
+		states[provider] = b.State()
+	}
+	return states
+}
+
+// State returns the current state. An open breaker whose cooldown has
+// passed reports [StateHalfOpen].
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.GetCooldown() {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be sent now. probe is true when the
+// request is the half-open probe, whose outcome decides the next state.
+func (b *Breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	cooldown := b.cfg.GetCooldown()
+	switch b.state {
+	case StateClosed:
+		return false, nil
+	case StateOpen:
+		if wait := cooldown - now.Sub(b.openedAt); wait > 0 {
+			return false, fmt.Errorf("%w: %s failed %d times in a row, trying again in %s", ErrOpen, b.provider, b.failures, wait.Round(time.Second))
+		}
+		b.state = StateHalfOpen
+		slog.Info("Provider circuit breaker half-open, probing", "provider", b.provider)
+	}
+
+	// A probe whose outcome never arrived, such as a stream nobody read,
+	// must not hold the circuit half-open forever.
+	if !b.probeStarted.IsZero() && now.Sub(b.probeStarted) < cooldown {
+		return false, fmt.Errorf("%w: %s is being probed after %d failures", ErrOpen, b.provider, b.failures)
+	}
+	b.probeStarted = now
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a request allow let
+// through.
+func (b *Breaker) record(probe bool, err error) {
+	failed := isProviderFailure(err)
+	if err != nil && !failed && !probe {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if probe {
+		b.probeStarted = time.Time{}
+	}
+	if !failed {
+		if err != nil {
+			// A probe that failed for an unrelated reason, such as a
+			// cancellation, says nothing about the provider.
+			return
+		}
+		if b.state != StateClosed {
+			slog.Info("Provider circuit breaker closed", "provider", b.provider)
+		}
+		b.state = StateClosed
+		b.failures = 0
+		return
+	}
+
+	if b.state != StateClosed {
+		if !probe {
+			// A request let through before the circuit opened.
+			return
+		}
+		b.failures++
+		b.state = StateOpen
+		b.openedAt = now
+		slog.Warn("Provider circuit breaker reopened after failed probe", "provider", b.provider, "error", err, "cooldown", b.cfg.GetCooldown())
+		return
+	}
+
+	if b.failures == 0 || now.Sub(b.firstFailed) > b.cfg.GetWindow() {
+		b.failures = 0
+		b.firstFailed = now
+	}
+	b.failures++
+	if b.failures >= b.cfg.GetFailures() {
+		b.state = StateOpen
+		b.openedAt = now
+		slog.Warn("Provider circuit breaker opened", "provider", b.provider, "failures", b.failures, "error", err, "cooldown", b.cfg.GetCooldown())
+	}
+}
+
+// isProviderFailure reports whether err says the provider is unhealthy:
+// a retryable provider error (server errors, rate limits, timeouts) or a
+// network error. Invalid requests and cancellations do not count.
+func isProviderFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.IsRetryable()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return fantasy.IsTransportError(err)
+}
@@ -0,0 +1,193 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnavailable = &fantasy.ProviderError{Title: "unavailable", StatusCode: http.StatusServiceUnavailable}
+
+// testBreaker returns a breaker that opens after three failures, with a
+// clock advanced by the returned function.
+func testBreaker() (*Breaker, func(time.Duration)) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := New("fake", config.CircuitBreaker{Enabled: true, Failures: 3, WindowSeconds: 60, CooldownSeconds: 30})
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+// fail lets a request through and records a provider failure for it.
+func fail(t *testing.T, b *Breaker) {
+	t.Helper()
+	probe, err := b.allow()
+	require.NoError(t, err)
+	b.record(probe, errUnavailable)
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	t.Parallel()
+
+	b, advance := testBreaker()
+	fail(t, b)
+	fail(t, b)
+	require.Equal(t, StateClosed, b.State())
+	fail(t, b)
+	require.Equal(t, StateOpen, b.State())
+
+	_, err := b.allow()
+	require.ErrorIs(t, err, ErrOpen)
+	require.ErrorContains(t, err, "fake failed 3 times in a row, trying again in 30s")
+
+	advance(30 * time.Second)
+	require.Equal(t, StateHalfOpen, b.State())
+	probe, err := b.allow()
+	require.NoError(t, err)
+	require.True(t, probe)
+	_, err = b.allow()
+	require.ErrorIs(t, err, ErrOpen, "only one probe at a time")
+
+	b.record(probe, errUnavailable)
+	require.Equal(t, StateOpen, b.State())
+	_, err = b.allow()
+	require.ErrorIs(t, err, ErrOpen)
+
+	advance(30 * time.Second)
+	probe, err = b.allow()
+	require.NoError(t, err)
+	b.record(probe, nil)
+	require.Equal(t, StateClosed, b.State())
+	probe, err = b.allow()
+	require.NoError(t, err)
+	require.False(t, probe)
+}
+
+func TestBreakerWindow(t *testing.T) {
+	t.Parallel()
+
+	b, advance := testBreaker()
+	fail(t, b)
+	fail(t, b)
+	advance(61 * time.Second)
+	fail(t, b)
+	require.Equal(t, StateClosed, b.State(), "failures outside the window start a new count")
+	fail(t, b)
+	fail(t, b)
+	require.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerIgnoresNonProviderErrors(t *testing.T) {
+	t.Parallel()
+
+	b, advance := testBreaker()
+	for _, err := range []error{
+		context.Canceled,
+		errors.New("invalid tool schema"),
+		&fantasy.ProviderError{StatusCode: http.StatusBadRequest},
+	} {
+		for range 3 {
+			probe, allowErr := b.allow()
+			require.NoError(t, allowErr)
+			b.record(probe, err)
+		}
+	}
+	require.Equal(t, StateClosed, b.State())
+
+	fail(t, b)
+	fail(t, b)
+	fail(t, b)
+	advance(30 * time.Second)
+	probe, err := b.allow()
+	require.NoError(t, err)
+	b.record(probe, context.Canceled)
+	require.Equal(t, StateHalfOpen, b.State())
+	probe, err = b.allow()
+	require.NoError(t, err, "a canceled probe frees the slot for another")
+	require.True(t, probe)
+}
+
+func TestBreakerStalledProbe(t *testing.T) {
+	t.Parallel()
+
+	b, advance := testBreaker()
+	fail(t, b)
+	fail(t, b)
+	fail(t, b)
+	advance(30 * time.Second)
+	_, err := b.allow()
+	require.NoError(t, err)
+
+	advance(30 * time.Second)
+	probe, err := b.allow()
+	require.NoError(t, err, "a probe that never reported back does not block forever")
+	require.True(t, probe)
+}
+
+// flakyModel streams an error part while fail is set.
+type flakyModel struct {
+	fantasy.LanguageModel
+	fail  bool
+	calls int
+}
+
+func (m *flakyModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls++
+	fail := m.fail
+	return func(yield func(fantasy.StreamPart) bool) {
+		if fail {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: errUnavailable})
+			return
+		}
+		yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func TestModelStream(t *testing.T) {
+	t.Parallel()
+
+	b, advance := testBreaker()
+	inner := &flakyModel{fail: true}
+	model := Wrap(inner, b)
+	stream := func() error {
+		s, err := model.Stream(t.Context(), fantasy.Call{})
+		if err != nil {
+			return err
+		}
+		for part := range s {
+			if part.Type == fantasy.StreamPartTypeError {
+				return part.Error
+			}
+		}
+		return nil
+	}
+
+	for range 3 {
+		require.ErrorIs(t, stream(), errUnavailable)
+	}
+	require.ErrorIs(t, stream(), ErrOpen)
+	require.Equal(t, 3, inner.calls, "an open circuit does not reach the provider")
+
+	inner.fail = false
+	advance(30 * time.Second)
+	require.NoError(t, stream())
+	require.Equal(t, StateClosed, b.State())
+	require.Equal(t, 4, inner.calls)
+}
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.CircuitBreaker{Enabled: true, Failures: 2}
+	b := For("breaker-test-provider", cfg)
+	require.Same(t, b, For("breaker-test-provider", cfg))
+	require.Equal(t, StateClosed, GetStates()["breaker-test-provider"])
+
+	cfg.Failures = 4
+	require.NotSame(t, b, For("breaker-test-provider", cfg), "changed settings replace the breaker")
+}
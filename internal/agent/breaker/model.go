@@ -0,0 +1,97 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+
+	"charm.land/fantasy"
+)
+
+// Model wraps a [fantasy.LanguageModel] so its calls go through a
+// [Breaker].
+type Model struct {
+	fantasy.LanguageModel
+	breaker *Breaker
+}
+
+var _ fantasy.LanguageModel = (*Model)(nil)
+
+// Wrap returns model with its calls guarded by b.
+func Wrap(model fantasy.LanguageModel, b *Breaker) *Model {
+	return &Model{LanguageModel: model, breaker: b}
+}
+
+// Generate implements [fantasy.LanguageModel].
+func (m *Model) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	probe, err := m.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.LanguageModel.Generate(ctx, call)
+	m.breaker.record(probe, err)
+	return resp, err
+}
+
+// Stream implements [fantasy.LanguageModel]. The outcome is recorded at
+// the first error part, or once the stream ends without one.
+func (m *Model) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	probe, err := m.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		m.breaker.record(probe, err)
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		var once sync.Once
+		record := func(err error) { once.Do(func() { m.breaker.record(probe, err) }) }
+		defer record(nil)
+		for part := range stream {
+			if part.Type == fantasy.StreamPartTypeError {
+				record(part.Error)
+			}
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+// GenerateObject implements [fantasy.LanguageModel].
+func (m *Model) GenerateObject(ctx context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	probe, err := m.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.LanguageModel.GenerateObject(ctx, call)
+	m.breaker.record(probe, err)
+	return resp, err
+}
+
+// StreamObject implements [fantasy.LanguageModel].
+func (m *Model) StreamObject(ctx context.Context, call fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	probe, err := m.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := m.LanguageModel.StreamObject(ctx, call)
+	if err != nil {
+		m.breaker.record(probe, err)
+		return nil, err
+	}
+	return func(yield func(fantasy.ObjectStreamPart) bool) {
+		var once sync.Once
+		record := func(err error) { once.Do(func() { m.breaker.record(probe, err) }) }
+		defer record(nil)
+		for part := range stream {
+			if part.Type == fantasy.ObjectStreamPartTypeError {
+				record(part.Error)
+			}
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,187 @@
+// Package cache provides an on-disk cache of language model responses keyed
+// by a hash of the full request. It is meant for repeated, identical prompts
+// (CI runs, tests) and is unrelated to provider-side prompt caching.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"charm.land/fantasy"
+)
+
+// Model wraps a [fantasy.LanguageModel] and serves Generate and Stream calls
+// from disk when an identical request was answered within the TTL.
+type Model struct {
+	fantasy.LanguageModel
+	dir    string
+	ttl    time.Duration
+	params map[string]any
+}
+
+var _ fantasy.LanguageModel = (*Model)(nil)
+
+// Wrap returns model with response caching in dir. Entries older than ttl
+// are ignored and overwritten. params holds request parameters the provider
+// adds outside of the call, such as extra body fields, so that changing
+// them also changes the cache key.
+func Wrap(model fantasy.LanguageModel, dir string, ttl time.Duration, params map[string]any) *Model {
+	return &Model{LanguageModel: model, dir: dir, ttl: ttl, params: params}
+}
+
+// Generate implements [fantasy.LanguageModel].
+func (m *Model) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	key, err := m.key("generate", call)
+	if err != nil {
+		slog.Warn("Failed to compute response cache key", "error", err)
+		return m.LanguageModel.Generate(ctx, call)
+	}
+
+	var cached fantasy.Response
+	if m.load(key, &cached) {
+		return &cached, nil
+	}
+
+	resp, err := m.LanguageModel.Generate(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	m.store(key, resp)
+	return resp, nil
+}
+
+// Stream implements [fantasy.LanguageModel]. On a hit the recorded parts
+// are replayed in order. A stream is only recorded when it runs to a finish
+// part without errors and the caller consumes it fully.
+func (m *Model) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	key, err := m.key("stream", call)
+	if err != nil {
+		slog.Warn("Failed to compute response cache key", "error", err)
+		return m.LanguageModel.Stream(ctx, call)
+	}
+
+	var cached []fantasy.StreamPart
+	if m.load(key, &cached) {
+		return func(yield func(fantasy.StreamPart) bool) {
+			for _, part := range cached {
+				if !yield(part) {
+					return
+				}
+			}
+		}, nil
+	}
+
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(fantasy.StreamPart) bool) {
+		var (
+			parts    []fantasy.StreamPart
+			finished bool
+			failed   bool
+		)
+		for part := range stream {
+			switch part.Type {
+			case fantasy.StreamPartTypeError:
+				failed = true
+			case fantasy.StreamPartTypeFinish:
+				finished = true
+			}
+			parts = append(parts, part)
+			if !yield(part) {
+				return
+			}
+		}
+		if finished && !failed && ctx.Err() == nil {
+			m.store(key, parts)
+		}
+	}, nil
+}
+
+// key hashes everything that affects the response: the provider, the
+// model, the provider-level request parameters and the call itself
+// (messages, tools, sampling parameters and provider options).
+func (m *Model) key(kind string, call fantasy.Call) (string, error) {
+	data, err := json.Marshal(struct {
+		Kind     string         `json:"kind"`
+		Provider string         `json:"provider"`
+		Model    string         `json:"model"`
+		Params   map[string]any `json:"params,omitempty"`
+		Call     fantasy.Call   `json:"call"`
+	}{kind, m.Provider(), m.Model(), m.params, call})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (m *Model) path(key string) string {
+	return filepath.Join(m.dir, key+".json")
+}
+
+func (m *Model) load(key string, v any) bool {
+	path := m.path(key)
+	info, err := os.Stat(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("Failed to stat response cache entry", "path", path, "error", err)
+		}
+		return false
+	}
+	if m.ttl > 0 && time.Since(info.ModTime()) > m.ttl {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Warn("Failed to read response cache entry", "path", path, "error", err)
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		slog.Warn("Failed to decode response cache entry", "path", path, "error", err)
+		return false
+	}
+	slog.Debug("Serving response from cache", "provider", m.Provider(), "model", m.Model(), "key", key)
+	return true
+}
+
+func (m *Model) store(key string, v any) {
+	if err := m.write(key, v); err != nil {
+		slog.Warn("Failed to write response cache entry", "error", err)
+	}
+}
+
+func (m *Model) write(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding response: %w", err)
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	// Write to a temp file first so concurrent readers never see a
+	// partially written entry.
+	tmp, err := os.CreateTemp(m.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	return os.Rename(tmp.Name(), m.path(key))
+}
@@ -0,0 +1,167 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+// countingModel streams a fixed reply and counts how often it is called.
+type countingModel struct {
+	model string
+	calls int
+	fail  bool
+}
+
+func (m *countingModel) Provider() string { return "fake" }
+func (m *countingModel) Model() string    { return m.model }
+
+func (m *countingModel) Generate(context.Context, fantasy.Call) (*fantasy.Response, error) {
+	m.calls++
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "hello"}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *countingModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls++
+	return func(yield func(fantasy.StreamPart) bool) {
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: "hello"}) {
+			return
+		}
+		if m.fail {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: errors.New("boom")})
+			return
+		}
+		yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func (m *countingModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *countingModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func call(prompt string) fantasy.Call {
+	return fantasy.Call{Prompt: fantasy.Prompt{fantasy.NewUserMessage(prompt)}}
+}
+
+func drain(t *testing.T, stream fantasy.StreamResponse) string {
+	t.Helper()
+	var text string
+	for part := range stream {
+		text += part.Delta
+	}
+	return text
+}
+
+func TestStreamCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("replays identical requests", func(t *testing.T) {
+		t.Parallel()
+
+		inner := &countingModel{model: "m1"}
+		m := Wrap(inner, t.TempDir(), time.Hour, nil)
+
+		for range 2 {
+			stream, err := m.Stream(t.Context(), call("hi"))
+			require.NoError(t, err)
+			require.Equal(t, "hello", drain(t, stream))
+		}
+		require.Equal(t, 1, inner.calls)
+
+		stream, err := m.Stream(t.Context(), call("something else"))
+		require.NoError(t, err)
+		drain(t, stream)
+		require.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("keys on model", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		first := &countingModel{model: "m1"}
+		second := &countingModel{model: "m2"}
+
+		stream, err := Wrap(first, dir, time.Hour, nil).Stream(t.Context(), call("hi"))
+		require.NoError(t, err)
+		drain(t, stream)
+		stream, err = Wrap(second, dir, time.Hour, nil).Stream(t.Context(), call("hi"))
+		require.NoError(t, err)
+		drain(t, stream)
+
+		require.Equal(t, 1, first.calls)
+		require.Equal(t, 1, second.calls)
+	})
+
+	t.Run("misses when provider params change", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		inner := &countingModel{model: "m1"}
+
+		for _, params := range []map[string]any{
+			nil,
+			{"stop": []string{"END"}},
+			{"stop": []string{"END"}, "user": "jane"},
+			{"stop": []string{"END"}},
+		} {
+			stream, err := Wrap(inner, dir, time.Hour, params).Stream(t.Context(), call("hi"))
+			require.NoError(t, err)
+			drain(t, stream)
+		}
+		require.Equal(t, 3, inner.calls)
+	})
+
+	t.Run("does not record failed streams", func(t *testing.T) {
+		t.Parallel()
+
+		inner := &countingModel{model: "m1", fail: true}
+		m := Wrap(inner, t.TempDir(), time.Hour, nil)
+
+		for range 2 {
+			stream, err := m.Stream(t.Context(), call("hi"))
+			require.NoError(t, err)
+			drain(t, stream)
+		}
+		require.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("ignores expired entries", func(t *testing.T) {
+		t.Parallel()
+
+		inner := &countingModel{model: "m1"}
+		m := Wrap(inner, t.TempDir(), time.Nanosecond, nil)
+
+		for range 2 {
+			stream, err := m.Stream(t.Context(), call("hi"))
+			require.NoError(t, err)
+			drain(t, stream)
+			time.Sleep(time.Millisecond)
+		}
+		require.Equal(t, 2, inner.calls)
+	})
+}
+
+func TestGenerateCache(t *testing.T) {
+	t.Parallel()
+
+	inner := &countingModel{model: "m1"}
+	m := Wrap(inner, t.TempDir(), time.Hour, nil)
+
+	for range 2 {
+		resp, err := m.Generate(t.Context(), call("hi"))
+		require.NoError(t, err)
+		require.Equal(t, "hello", resp.Content.Text())
+	}
+	require.Equal(t, 1, inner.calls)
+}
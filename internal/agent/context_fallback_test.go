@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// contextTooLargeModel rejects every request as too long.
+type contextTooLargeModel struct {
+	finishStreamModel
+}
+
+func (m *contextTooLargeModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	return nil, &fantasy.ProviderError{Message: "prompt is too long", ContextTooLargeErr: true}
+}
+
+func TestRunContextFallback(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, fallback Model) ([]message.Message, error) {
+		env := testEnv(t)
+		agent := NewSessionAgent(SessionAgentOptions{
+			LargeModel: Model{
+				Model:      &contextTooLargeModel{},
+				CatwalkCfg: catwalk.Model{Name: "Small Window"},
+				ModelCfg:   config.SelectedModel{Provider: "fake", Model: "small-window"},
+			},
+			SmallModel:           Model{Model: &finishStreamModel{text: "title"}},
+			ContextFallbackModel: fallback,
+			IsSubAgent:           true,
+			DisableAutoSummarize: true,
+			IsYolo:               true,
+			Sessions:             env.sessions,
+			Messages:             env.messages,
+		})
+		sess, err := env.sessions.Create(t.Context(), "test")
+		require.NoError(t, err)
+		_, runErr := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello", NonInteractive: true})
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		return msgs, runErr
+	}
+
+	t.Run("fallback", func(t *testing.T) {
+		t.Parallel()
+		model := &callRecordingModel{finishStreamModel: finishStreamModel{text: "answer"}}
+		msgs, err := run(t, Model{
+			Model:      model,
+			CatwalkCfg: catwalk.Model{Name: "Large Window"},
+			ModelCfg:   config.SelectedModel{Provider: "fake", Model: "large-window"},
+		})
+		require.NoError(t, err)
+		require.Len(t, model.calls, 1)
+
+		// The failed request explains the switch and the fallback answers.
+		require.Len(t, msgs, 3)
+		finish := msgs[1].FinishPart()
+		require.NotNil(t, finish)
+		require.Equal(t, "Context limit reached", finish.Message)
+		require.Equal(t, "The conversation is too long for Small Window. Retrying with Large Window.", finish.Details)
+		require.Equal(t, "answer", msgs[2].Content().Text)
+		require.Equal(t, "large-window", msgs[2].Model)
+	})
+
+	t.Run("no fallback", func(t *testing.T) {
+		t.Parallel()
+		msgs, err := run(t, Model{})
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Len(t, msgs, 2)
+	})
+}
@@ -19,6 +19,8 @@ import (
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/breaker"
+	"github.com/charmbracelet/crush/internal/agent/cache"
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/notify"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
@@ -38,9 +40,11 @@ import (
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/question"
+	"github.com/charmbracelet/crush/internal/secrets"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/skills"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/text/language"
 
 	"charm.land/fantasy/providers/anthropic"
 	"charm.land/fantasy/providers/azure"
@@ -56,14 +60,18 @@ import (
 
 // Coordinator errors.
 var (
-	errCoderAgentNotConfigured         = errors.New("coder agent not configured")
-	errModelProviderNotConfigured      = errors.New("model provider not configured")
-	errLargeModelNotSelected           = errors.New("large model not selected")
-	errSmallModelNotSelected           = errors.New("small model not selected")
-	errLargeModelProviderNotConfigured = errors.New("large model provider not configured")
-	errSmallModelProviderNotConfigured = errors.New("small model provider not configured")
-	errLargeModelNotFound              = errors.New("large model not found in provider config")
-	errSmallModelNotFound              = errors.New("small model not found in provider config")
+	errCoderAgentNotConfigured            = errors.New("coder agent not configured")
+	errModelProviderNotConfigured         = errors.New("model provider not configured")
+	errLargeModelNotSelected              = errors.New("large model not selected")
+	errSmallModelNotSelected              = errors.New("small model not selected")
+	errLargeModelProviderNotConfigured    = errors.New("large model provider not configured")
+	errSmallModelProviderNotConfigured    = errors.New("small model provider not configured")
+	errLargeModelNotFound                 = errors.New("large model not found in provider config")
+	errSmallModelNotFound                 = errors.New("small model not found in provider config")
+	errSummaryModelProviderNotConfigured  = errors.New("summarize model provider not configured")
+	errSummaryModelNotFound               = errors.New("summarize model not found in provider config")
+	errFallbackModelProviderNotConfigured = errors.New("context fallback model provider not configured")
+	errFallbackModelNotFound              = errors.New("context fallback model not found in provider config")
 )
 
 // Copilot models that use the Responses API instead of Chat Completions.
@@ -129,6 +137,10 @@ type coordinator struct {
 	activeSkills []*skills.Skill // Post-filter: active skills only.
 	skillTracker *skills.Tracker
 
+	// smallModelLimiter is shared by all agents so title generation in
+	// one session does not pile onto the small model with another's.
+	smallModelLimiter requestLimiter
+
 	readyWg errgroup.Group
 }
 
@@ -180,6 +192,8 @@ func NewCoordinator(ctx context.Context, opts CoordinatorOptions) (Coordinator,
 		activeSkills: activeSkills,
 		skillTracker: skillTracker,
 		interactive:  opts.Interactive,
+
+		smallModelLimiter: newRequestLimiter(opts.Config.Config().Options.GetSmallModelConcurrency()),
 	}
 
 	agentCfg, ok := opts.Config.Config().Agents[config.AgentCoder]
@@ -230,6 +244,11 @@ func (c *coordinator) run(ctx context.Context, accept *AcceptedRun, sessionID st
 	if err := mcp.WaitForInit(ctx); err != nil {
 		return nil, fmt.Errorf("failed to wait for MCP initialization: %w", err)
 	}
+	// Give servers that failed at startup one more chance, in the
+	// background so a dead server does not hold up this prompt. Servers
+	// that come up contribute their tools from the next prompt on, when
+	// the tool list is rebuilt.
+	go mcp.RetryFailedStartups(context.WithoutCancel(ctx), c.cfg)
 
 	// refresh models before each run
 	if err := c.UpdateModels(ctx); err != nil {
@@ -292,6 +311,7 @@ func (c *coordinator) run(ctx context.Context, accept *AcceptedRun, sessionID st
 			TopK:             topK,
 			FrequencyPenalty: freqPenalty,
 			PresencePenalty:  presPenalty,
+			ResponseStyle:    c.responseStyle(),
 			OnComplete:       onComplete,
 			Accepted:         accept,
 			OnAuthRefresh:    c.makeAuthRefreshCallback(providerCfg),
@@ -459,6 +479,9 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 				"effort":  reasoningEffort,
 			}
 		}
+		if _, hasUser := mergedOptions["user"]; !hasUser && model.RequestUser != "" {
+			mergedOptions["user"] = model.RequestUser
+		}
 		parsed, err := openrouter.ParseOptions(mergedOptions)
 		if err == nil {
 			options[openrouter.Name] = parsed
@@ -610,21 +633,52 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	if err != nil {
 		return nil, err
 	}
+	summary, err := c.buildSummaryModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := c.buildContextFallbackModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summarizeModel, _ := c.cfg.Config().Options.GetSummarizeModel()
 
 	largeProviderCfg, _ := c.cfg.Config().Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
-		LargeModel:           large,
-		SmallModel:           small,
-		SystemPromptPrefix:   largeProviderCfg.SystemPromptPrefix,
-		SystemPrompt:         "",
-		IsSubAgent:           isSubAgent,
-		DisableAutoSummarize: c.cfg.Config().Options.DisableAutoSummarize,
-		IsYolo:               c.permissions.SkipRequests(),
-		Sessions:             c.sessions,
-		Messages:             c.messages,
-		Tools:                nil,
-		Notify:               c.notify,
-		RunComplete:          c.runComplete,
+		LargeModel:            large,
+		SmallModel:            small,
+		SystemPromptPrefix:    largeProviderCfg.SystemPromptPrefix,
+		SystemPrompt:          "",
+		IsSubAgent:            isSubAgent,
+		DisableAutoSummarize:  c.cfg.Config().Options.DisableAutoSummarize,
+		AuxiliaryRetries:      c.cfg.Config().Options.GetAuxiliaryRetries(),
+		ContextTrimRatio:      c.cfg.Config().Options.ContextTrimRatio,
+		EphemeralThinking:     !c.cfg.Config().Options.GetStoreThinking(),
+		MaxIdenticalToolCalls: c.cfg.Config().Options.GetMaxIdenticalToolCalls(),
+		TitleTrigger:          c.cfg.Config().Options.GetTitleTrigger(),
+		TitleMinMessages:      c.cfg.Config().Options.GetTitleMinMessages(),
+		TitlePrompt:           c.cfg.Config().Options.GetTitlePrompt(),
+		TitleMaxWords:         c.cfg.Config().Options.GetTitleMaxWords(),
+		TitleMaxLength:        c.cfg.Config().Options.GetTitleMaxLength(),
+		SmallModelLimiter:     c.smallModelLimiter,
+		SummarizeModel:        summarizeModel,
+		SummaryModel:          summary,
+		ContextFallbackModel:  fallback,
+		EmptyResponse:         c.cfg.Config().Options.GetEmptyResponse(),
+		TodosInContext:        c.cfg.Config().Options.TodosInContext,
+		SummarizeMaxTokens:    c.cfg.Config().Options.GetSummarizeMaxTokens(),
+		IsYolo:                c.permissions.SkipRequests(),
+		Sessions:              c.sessions,
+		Messages:              c.messages,
+		Tools:                 nil,
+		Notify:                c.notify,
+		RunComplete:           c.runComplete,
+		MaxConcurrentRequests: c.cfg.Config().Options.MaxConcurrentRequests,
+		ConcurrencyLimitMode:  c.cfg.Config().Options.GetConcurrencyLimitMode(),
+		ToolResultCache:       c.cfg.Config().Options.GetToolResultCache(),
+		Language:              c.language(),
+		MaxHistoryMessages:    c.cfg.Config().Options.MaxHistoryMessages,
+		StrictToolParams:      c.cfg.Config().Options.StrictToolParams,
 	})
 
 	// The readiness goroutines below perform one-time setup — building the
@@ -646,11 +700,14 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 	initCtx := context.WithoutCancel(ctx)
 
 	c.readyWg.Go(func() error {
-		systemPrompt, err := prompt.Build(initCtx, large.Model.Provider(), large.Model.Model(), c.cfg)
+		blocks, err := prompt.BuildBlocks(initCtx, large.Model.Provider(), large.Model.Model(), c.cfg)
 		if err != nil {
 			return err
 		}
-		result.SetSystemPrompt(systemPrompt)
+		result.SetSystemPrompt(blocks[0])
+		if len(blocks) > 1 {
+			result.SetSystemContext(blocks[1])
+		}
 		return nil
 	})
 
@@ -674,6 +731,15 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 }
 
 func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubAgent bool) ([]fantasy.AgentTool, error) {
+	// A per-run tool restriction narrows what the agent is configured to
+	// use; it never grants tools the agent wouldn't otherwise have.
+	if allowed := c.cfg.Overrides().AllowedTools; allowed != nil {
+		agent.AllowedTools = slices.DeleteFunc(slices.Clone(agent.AllowedTools), func(name string) bool {
+			return !slices.Contains(allowed, name)
+		})
+		agent.AllowedMCP = map[string][]string{}
+	}
+
 	var allTools []fantasy.AgentTool
 	if slices.Contains(agent.AllowedTools, AgentToolName) {
 		agentTool, err := c.agentTool(ctx)
@@ -700,6 +766,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 	}
 
 	logFile := filepath.Join(c.cfg.Config().Options.DataDirectory, "logs", "crush.log")
+	allowOutsideWorkdir := c.cfg.Config().Options.AllowOutsideWorkdir
 
 	// Build hook runner if PreToolUse hooks are configured.
 	var hookRunner *hooks.Runner
@@ -712,19 +779,26 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Options.Attribution, modelID),
 		tools.NewCrushInfoTool(c.cfg, c.lspManager, c.allSkills, c.activeSkills, c.skillTracker),
 		tools.NewCrushLogsTool(logFile),
+		tools.NewDocsTool(c.permissions, c.cfg.WorkingDir()),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
-		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
-		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
-		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), allowOutsideWorkdir, c.cfg.Config().Tools.Download, nil),
+		tools.NewEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir(), allowOutsideWorkdir),
+		tools.NewMultiEditTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir(), allowOutsideWorkdir),
+		tools.NewCodemodTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir(), allowOutsideWorkdir),
+		tools.NewEnvEditTool(c.permissions, c.cfg.WorkingDir(), allowOutsideWorkdir),
 		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
 		tools.NewGlobTool(c.cfg.WorkingDir(), c.cfg.Config().Tools.Glob),
+		tools.NewIssueTool(c.permissions, c.cfg.WorkingDir(), c.issueToolOptions(), nil),
+		tools.NewLintTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Tools.Lint),
 		tools.NewGrepTool(c.cfg.WorkingDir(), c.cfg.Config().Tools.Grep),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Config().Tools.Ls),
+		tools.NewRecentFilesTool(c.cfg.WorkingDir()),
+		tools.NewRepoMapTool(c.lspManager, c.cfg.WorkingDir()),
 		tools.NewSourcegraphTool(nil),
 		tools.NewTodosTool(c.sessions),
 		tools.NewViewTool(c.lspManager, c.permissions, c.filetracker, c.skillTracker, c.cfg.WorkingDir(), c.cfg.Config().Options.SkillsPaths...),
-		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir()),
+		tools.NewWriteTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir(), allowOutsideWorkdir),
 	)
 
 	// Question tool is interactive-only and not available to sub-agents.
@@ -735,14 +809,14 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 	// Add LSP tools if user has configured LSPs or auto_lsp is enabled (nil or true).
 	if len(c.cfg.Config().LSP) > 0 || c.cfg.Config().Options.AutoLSP == nil || *c.cfg.Config().Options.AutoLSP {
 		allTools = append(allTools,
-			tools.NewDiagnosticsTool(c.lspManager),
+			tools.NewDiagnosticsTool(c.lspManager, c.history, c.cfg.WorkingDir(), c.cfg.Config().Tools.Diagnostics),
 			tools.NewReferencesTool(c.lspManager),
 			tools.NewLSPRestartTool(c.lspManager),
 			tools.NewSymbolsTool(c.lspManager),
 			tools.NewDefinitionTool(c.lspManager),
 			tools.NewCallHierarchyTool(c.lspManager),
 			tools.NewRenameTool(c.lspManager, c.permissions, c.history, c.filetracker),
-			tools.NewReplaceSymbolTool(c.lspManager, c.permissions, c.history, c.filetracker),
+			tools.NewReplaceSymbolTool(c.lspManager, c.permissions, c.history, c.filetracker, c.cfg.WorkingDir(), allowOutsideWorkdir),
 		)
 	}
 
@@ -784,6 +858,7 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 			slog.Debug("MCP not allowed", "tool", tool.Name(), "agent", agent.Name)
 		}
 	}
+	filteredTools = dropDuplicateTools(filteredTools)
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
@@ -794,10 +869,82 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent, isSubA
 	// per delegated turn. The top-level invocation of the sub-agent tool
 	// itself is still wrapped from the coder's side.
 	filteredTools = wrapToolsWithHooks(filteredTools, hookRunner, isSubAgent)
+	// Mask secrets last so output added by hooks is covered too.
+	filteredTools = wrapToolsWithScrubber(filteredTools, c.secretScrubber())
 
 	return filteredTools, nil
 }
 
+// issueToolOptions returns the issue tool config with its tokens resolved,
+// so they can be given as $VAR or $(command) like provider API keys.
+func (c *coordinator) issueToolOptions() config.ToolIssue {
+	opts := c.cfg.Config().Tools.Issue
+	r := c.cfg.Resolver()
+	if r == nil {
+		return opts
+	}
+	for _, token := range []*string{&opts.GitHubToken, &opts.GitLabToken} {
+		if *token == "" {
+			continue
+		}
+		resolved, err := r.ResolveValue(*token)
+		if err != nil {
+			slog.Warn("Failed to resolve issue tool token", "error", err)
+		}
+		*token = resolved
+	}
+	tokens := make(map[string]string, len(opts.Tokens))
+	for host, token := range opts.Tokens {
+		resolved, err := r.ResolveValue(token)
+		if err != nil {
+			slog.Warn("Failed to resolve issue tool token", "host", host, "error", err)
+		}
+		tokens[strings.ToLower(host)] = resolved
+	}
+	opts.Tokens = tokens
+	return opts
+}
+
+// dropDuplicateTools removes every tool whose name is already used by an
+// earlier tool in list, since providers reject requests with duplicate tool
+// names. Built-in tools come before MCP tools, so they always win; between
+// MCP tools, whose names are namespaced as mcp_<server>_<tool> but can still
+// clash, the server that sorts first wins.
+func dropDuplicateTools(list []fantasy.AgentTool) []fantasy.AgentTool {
+	seen := make(map[string]bool, len(list))
+	result := list[:0]
+	for _, tool := range list {
+		name := tool.Info().Name
+		if seen[name] {
+			if mcpTool, ok := tool.(*tools.Tool); ok {
+				slog.Warn("Ignoring MCP tool with the same name as another tool", "tool", name, "mcp", mcpTool.MCP(), "mcp_tool", mcpTool.MCPToolName())
+			} else {
+				slog.Warn("Ignoring tool with the same name as another tool", "tool", name)
+			}
+			continue
+		}
+		seen[name] = true
+		result = append(result, tool)
+	}
+	return result
+}
+
+// secretScrubber returns the scrubber for tool output, or nil when
+// options.security.mask_secrets is off.
+func (c *coordinator) secretScrubber() *secrets.Scrubber {
+	security := c.cfg.Config().Options.Security
+	if security == nil || !security.MaskSecrets {
+		return nil
+	}
+	scrubber, err := secrets.New(security.SecretPatterns)
+	if err != nil {
+		// Patterns are validated when the config loads.
+		slog.Error("Invalid secret patterns; masking with the built-in ones only", "error", err)
+		scrubber, _ = secrets.New(nil)
+	}
+	return scrubber
+}
+
 // TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
 func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Model, Model, error) {
 	largeModelCfg, ok := c.cfg.Config().Models[config.SelectedModelTypeLarge]
@@ -814,6 +961,13 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, errLargeModelProviderNotConfigured
 	}
 
+	if stops := c.cfg.Overrides().StopSequences; len(stops) > 0 {
+		largeModelCfg.StopSequences = append(slices.Clone(largeModelCfg.StopSequences), stops...)
+	}
+	if maxTokens := c.cfg.Overrides().MaxTokens; maxTokens > 0 {
+		largeModelCfg.MaxTokens = maxTokens
+	}
+
 	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg, isSubAgent)
 	if err != nil {
 		return Model{}, Model{}, err
@@ -851,6 +1005,13 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, errSmallModelNotFound
 	}
 
+	if err := config.CapabilitiesOf(largeModelCfg.Provider, *largeCatwalkModel).CheckSelection(largeModelCfg); err != nil {
+		slog.Warn("Large model setting not supported", "error", err)
+	}
+	if err := config.CapabilitiesOf(smallModelCfg.Provider, *smallCatwalkModel).CheckSelection(smallModelCfg); err != nil {
+		slog.Warn("Small model setting not supported", "error", err)
+	}
+
 	largeModelID := largeModelCfg.Model
 	smallModelID := smallModelCfg.Model
 
@@ -870,20 +1031,116 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 	if err != nil {
 		return Model{}, Model{}, err
 	}
+	largeModel = guardModel(largeModel, largeModelCfg.Provider, largeProviderCfg)
+	smallModel = guardModel(smallModel, smallModelCfg.Provider, smallProviderCfg)
+
+	if rc := c.cfg.Config().Options.ResponseCache; rc != nil && rc.Enabled && !c.cfg.Overrides().DisableResponseCache {
+		dir := rc.GetDir(c.cfg.Config().Options.DataDirectory)
+		largeModel = cache.Wrap(largeModel, dir, rc.GetTTL(), c.cacheParams(largeProviderCfg, largeModelCfg))
+		smallModel = cache.Wrap(smallModel, dir, rc.GetTTL(), c.cacheParams(smallProviderCfg, smallModelCfg))
+	}
 
 	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-			FlatRate:   largeProviderCfg.FlatRate,
+			Model:       largeModel,
+			CatwalkCfg:  *largeCatwalkModel,
+			ModelCfg:    largeModelCfg,
+			FlatRate:    largeProviderCfg.FlatRate,
+			Quirks:      c.cfg.Config().Options.GetModelQuirks(largeModelCfg.Model),
+			RequestUser: c.requestUser(),
 		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-			FlatRate:   smallProviderCfg.FlatRate,
+			Model:       smallModel,
+			CatwalkCfg:  *smallCatwalkModel,
+			ModelCfg:    smallModelCfg,
+			FlatRate:    smallProviderCfg.FlatRate,
+			Quirks:      c.cfg.Config().Options.GetModelQuirks(smallModelCfg.Model),
+			RequestUser: c.requestUser(),
 		}, nil
 }
 
+// cacheParams returns the request parameters buildProvider adds outside of
+// each call (the configured extra body, stop sequences and request user),
+// so that changing them invalidates cached responses.
+func (c *coordinator) cacheParams(providerCfg config.ProviderConfig, model config.SelectedModel) map[string]any {
+	return withRequestUser(withStopSequences(providerCfg.ExtraBody, model.StopSequences), c.requestUser())
+}
+
+// guardModel wraps model with the circuit breaker of the provider with ID
+// providerID when its config enables one. Breakers are shared by every
+// model of a provider.
+func guardModel(model fantasy.LanguageModel, providerID string, providerCfg config.ProviderConfig) fantasy.LanguageModel {
+	if cb := providerCfg.CircuitBreaker; cb != nil && cb.Enabled {
+		return breaker.Wrap(model, breaker.For(providerID, *cb))
+	}
+	return model
+}
+
+// buildSummaryModel builds the model set as a provider/model pair in
+// options.summarize.model. It returns a zero Model when summaries use the
+// large or small model.
+func (c *coordinator) buildSummaryModel(ctx context.Context) (Model, error) {
+	_, modelCfg := c.cfg.Config().Options.GetSummarizeModel()
+	if modelCfg == nil {
+		return Model{}, nil
+	}
+	return c.buildExtraModel(ctx, modelCfg, errSummaryModelProviderNotConfigured, errSummaryModelNotFound)
+}
+
+// buildContextFallbackModel builds the model set in
+// options.context_fallback_model. It returns a zero Model when none is
+// set.
+func (c *coordinator) buildContextFallbackModel(ctx context.Context) (Model, error) {
+	modelCfg := c.cfg.Config().Options.GetContextFallbackModel()
+	if modelCfg == nil {
+		return Model{}, nil
+	}
+	return c.buildExtraModel(ctx, modelCfg, errFallbackModelProviderNotConfigured, errFallbackModelNotFound)
+}
+
+// buildExtraModel builds a model set by a provider/model pair outside of
+// the selected models. errProvider and errModel are returned, wrapped, when
+// the provider or the model is not configured.
+func (c *coordinator) buildExtraModel(ctx context.Context, modelCfg *config.SelectedModel, errProvider, errModel error) (Model, error) {
+	providerCfg, ok := c.cfg.Config().Providers.Get(modelCfg.Provider)
+	if !ok {
+		return Model{}, fmt.Errorf("%w: %s", errProvider, modelCfg.Provider)
+	}
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+		}
+	}
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("%w: %s", errModel, modelCfg.Model)
+	}
+
+	provider, err := c.buildProvider(providerCfg, *modelCfg, true)
+	if err != nil {
+		return Model{}, err
+	}
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
+	}
+	model, err := provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return Model{}, err
+	}
+	model = guardModel(model, modelCfg.Provider, providerCfg)
+	if rc := c.cfg.Config().Options.ResponseCache; rc != nil && rc.Enabled && !c.cfg.Overrides().DisableResponseCache {
+		model = cache.Wrap(model, rc.GetDir(c.cfg.Config().Options.DataDirectory), rc.GetTTL(), c.cacheParams(providerCfg, *modelCfg))
+	}
+
+	return Model{
+		Model:       model,
+		CatwalkCfg:  *catwalkModel,
+		ModelCfg:    *modelCfg,
+		FlatRate:    providerCfg.FlatRate,
+		Quirks:      c.cfg.Config().Options.GetModelQuirks(modelCfg.Model),
+		RequestUser: c.requestUser(),
+	}, nil
+}
+
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, providerID string) (fantasy.Provider, error) {
 	var opts []anthropic.Option
 
@@ -916,7 +1173,7 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 	return anthropic.New(opts...)
 }
 
-func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
+func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[string]string, user string, stops []string) (fantasy.Provider, error) {
 	opts := []openai.Option{
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
@@ -931,6 +1188,12 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 	if baseURL != "" {
 		opts = append(opts, openai.WithBaseURL(baseURL))
 	}
+	if user != "" {
+		opts = append(opts, openai.WithSDKOptions(openaisdk.WithJSONSet("user", user)))
+	}
+	if len(stops) > 0 {
+		opts = append(opts, openai.WithSDKOptions(openaisdk.WithJSONSet("stop", stops)))
+	}
 	return openai.New(opts...)
 }
 
@@ -1110,6 +1373,31 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 	apiKey, _ := c.cfg.Resolve(providerCfg.APIKey)
 	baseURL, _ := c.cfg.Resolve(providerCfg.BaseURL)
 
+	// Stop sequences are sent as the "stop" field of the request body. The
+	// OpenAI-compatible providers take it through the extra body and native
+	// OpenAI through the SDK, but only for models served by Chat
+	// Completions: the Responses API has no such field. Fantasy's Anthropic
+	// provider offers no way to add stop_sequences to its requests.
+	openaiCompatible := providerCfg.Type == openaicompat.Name || providerCfg.Type == hyper.Name || discover.IsKnownCustomProvider(string(providerCfg.Type))
+	openaiChat := providerCfg.Type == openai.Name && !openai.IsResponsesModel(model.Model)
+	stops := model.StopSequences
+	if len(stops) > 0 && !openaiCompatible && !openaiChat {
+		if providerCfg.Type == openai.Name {
+			slog.Warn("Stop sequences are not supported by the OpenAI Responses API, ignoring", "provider", providerCfg.ID, "model", model.Model)
+		} else {
+			slog.Warn("Stop sequences are not supported by this provider, ignoring", "provider", providerCfg.ID, "type", providerCfg.Type)
+		}
+		stops = nil
+	}
+
+	user := c.requestUser()
+	if err := config.ValidateRequestUser(user, providerCfg.Type); err != nil {
+		return nil, err
+	}
+	if user != "" && !openaiCompatible && providerCfg.Type != openai.Name && providerCfg.Type != openrouter.Name {
+		slog.Warn("Request user is not supported by this provider, ignoring", "provider", providerCfg.ID, "type", providerCfg.Type)
+	}
+
 	switch providerCfg.ID {
 	case string(catwalk.InferenceProviderOpenCodeGo), string(catwalk.InferenceProviderOpenCodeZen):
 		if opencodeMessagesModels[model.Model] {
@@ -1120,7 +1408,7 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 
 	switch providerCfg.Type {
 	case openai.Name:
-		return c.buildOpenaiProvider(baseURL, apiKey, headers)
+		return c.buildOpenaiProvider(baseURL, apiKey, headers, user, stops)
 	case anthropic.Name:
 		return c.buildAnthropicProvider(baseURL, apiKey, headers, providerCfg.ID)
 	case openrouter.Name:
@@ -1136,6 +1424,8 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 	case "google-vertex":
 		return c.buildGoogleVertexProvider(headers, providerCfg.ExtraParams)
 	case openaicompat.Name, hyper.Name:
+		providerCfg.ExtraBody = withStopSequences(providerCfg.ExtraBody, model.StopSequences)
+		providerCfg.ExtraBody = withRequestUser(providerCfg.ExtraBody, user)
 		switch providerCfg.ID {
 		case hyper.Name:
 			baseURL = hyper.BaseURL() + "/v1"
@@ -1151,12 +1441,69 @@ func (c *coordinator) buildProvider(providerCfg config.ProviderConfig, model con
 		// Known custom providers (litellm, ollama, omlx) are
 		// openai-compat under the hood.
 		if discover.IsKnownCustomProvider(string(providerCfg.Type)) {
+			providerCfg.ExtraBody = withStopSequences(providerCfg.ExtraBody, model.StopSequences)
+			providerCfg.ExtraBody = withRequestUser(providerCfg.ExtraBody, user)
 			return c.buildOpenaiCompatProvider(baseURL, apiKey, headers, providerCfg.ExtraBody, providerCfg.ID, isSubAgent)
 		}
 		return nil, fmt.Errorf("provider type not supported: %q", providerCfg.Type)
 	}
 }
 
+// withStopSequences returns extraBody with the OpenAI "stop" parameter set.
+// extraBody is copied rather than modified since it belongs to the config.
+func withStopSequences(extraBody map[string]any, stops []string) map[string]any {
+	if len(stops) == 0 {
+		return extraBody
+	}
+	extraBody = maps.Clone(extraBody)
+	if extraBody == nil {
+		extraBody = map[string]any{}
+	}
+	extraBody["stop"] = stops
+	return extraBody
+}
+
+// requestUser returns the end-user identifier to send with provider
+// requests: the --user override if set, otherwise options.request_user.
+func (c *coordinator) requestUser() string {
+	if user := c.cfg.Overrides().RequestUser; user != "" {
+		return user
+	}
+	return c.cfg.Config().Options.RequestUser
+}
+
+// responseStyle returns the verbosity of the coder agent's answers: the
+// --style override if set, otherwise options.response_style.
+func (c *coordinator) responseStyle() config.ResponseStyle {
+	if style := c.cfg.Overrides().ResponseStyle; style != "" {
+		return style
+	}
+	return c.cfg.Config().Options.GetResponseStyle()
+}
+
+// language returns the language answers, titles and summaries are written
+// in: the --language override if set, otherwise options.language.
+func (c *coordinator) language() language.Tag {
+	if lang := c.cfg.Overrides().Language; lang != language.Und {
+		return lang
+	}
+	return c.cfg.Config().Options.GetLanguage()
+}
+
+// withRequestUser returns extraBody with the OpenAI "user" parameter set.
+// Like [withStopSequences], it never modifies extraBody in place.
+func withRequestUser(extraBody map[string]any, user string) map[string]any {
+	if user == "" {
+		return extraBody
+	}
+	extraBody = maps.Clone(extraBody)
+	if extraBody == nil {
+		extraBody = map[string]any{}
+	}
+	extraBody["user"] = user
+	return extraBody
+}
+
 func isExactoSupported(modelID string) bool {
 	supportedModels := []string{
 		"moonshotai/kimi-k2-0905",
@@ -1208,6 +1555,17 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 		return err
 	}
 	c.currentAgent.SetModels(large, small)
+	summary, err := c.buildSummaryModel(ctx)
+	if err != nil {
+		return err
+	}
+	c.currentAgent.SetSummaryModel(summary)
+	fallback, err := c.buildContextFallbackModel(ctx)
+	if err != nil {
+		return err
+	}
+	c.currentAgent.SetContextFallbackModel(fallback)
+	c.currentAgent.SetLanguage(c.language())
 
 	agentCfg, ok := c.cfg.Config().Agents[config.AgentCoder]
 	if !ok {
@@ -1391,10 +1749,31 @@ type subAgentParams struct {
 	SessionSetup func(sessionID string)
 }
 
+// SubAgentResponseMetadata is attached to the responses of tools that run a
+// sub-agent. Depth is 1 for a sub-agent started by the top-level agent. Cost
+// includes any sub-agents the sub-agent started itself.
+type SubAgentResponseMetadata struct {
+	Depth        int     `json:"depth"`
+	Cost         float64 `json:"cost"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+}
+
 // runSubAgent runs a sub-agent and handles session management and cost accumulation.
 // It creates a sub-session, runs the agent with the given prompt, and propagates
 // the cost to the parent session.
 func (c *coordinator) runSubAgent(ctx context.Context, params subAgentParams) (fantasy.ToolResponse, error) {
+	// Refuse to nest sub-agents beyond the configured depth so a model that
+	// keeps delegating can't multiply cost without bound.
+	depth := tools.GetAgentDepthFromContext(ctx) + 1
+	if maxDepth := c.cfg.Config().Options.GetMaxAgentDepth(); depth > maxDepth {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf(
+			"Sub-agent depth limit reached (%d). Complete the task directly instead of delegating.",
+			maxDepth,
+		)), nil
+	}
+	ctx = context.WithValue(ctx, tools.AgentDepthContextKey, depth)
+
 	// Create sub-session
 	agentToolSessionID := c.sessions.CreateAgentToolSessionID(params.AgentMessageID, params.ToolCallID)
 	session, err := c.sessions.CreateTaskSession(ctx, agentToolSessionID, params.SessionID, params.SessionTitle)
@@ -1443,26 +1822,40 @@ func (c *coordinator) runSubAgent(ctx context.Context, params subAgentParams) (f
 			ProviderID: model.ModelCfg.Provider,
 		})
 	}
-	if err != nil {
-		return fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to generate response: %s", err)), nil
-	}
 
-	// Update parent session cost on a best-effort basis. A failure here must
-	// not discard the sub-agent output that was already produced.
-	if err := c.updateParentSessionCost(ctx, session.ID, params.SessionID); err != nil {
+	// Update parent session cost on a best-effort basis, even when the
+	// sub-agent failed, since whatever it spent was still billed. A failure
+	// here must not discard the sub-agent output that was already produced.
+	cost, costErr := c.updateParentSessionCost(ctx, session.ID, params.SessionID)
+	if costErr != nil {
 		slog.Warn(
 			"Failed to update parent session cost",
 			"child_session", session.ID,
 			"parent_session", params.SessionID,
-			"error", err,
+			"error", costErr,
 		)
 	}
+	metadata := SubAgentResponseMetadata{Depth: depth, Cost: cost}
+	if result != nil {
+		metadata.InputTokens = result.TotalUsage.InputTokens
+		metadata.OutputTokens = result.TotalUsage.OutputTokens
+	}
+
+	if err != nil {
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextErrorResponse(fmt.Sprintf("Failed to generate response: %s", err)),
+			metadata,
+		), nil
+	}
 
 	output := subAgentOutput(result)
 	if output == "" {
-		return fantasy.NewTextErrorResponse("Sub-agent completed but produced no text output."), nil
+		return fantasy.WithResponseMetadata(
+			fantasy.NewTextErrorResponse("Sub-agent completed but produced no text output."),
+			metadata,
+		), nil
 	}
-	return fantasy.NewTextResponse(output), nil
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
 }
 
 func subAgentOutput(result *fantasy.AgentResult) string {
@@ -1472,25 +1865,24 @@ func subAgentOutput(result *fantasy.AgentResult) string {
 	return result.Response.Content.Text()
 }
 
-// updateParentSessionCost accumulates the cost from a child session to its parent session.
-func (c *coordinator) updateParentSessionCost(ctx context.Context, childSessionID, parentSessionID string) error {
+// updateParentSessionCost accumulates the cost from a child session to its
+// parent session and returns the child's cost. The parent is updated with an
+// atomic increment because parallel sub-agents finish concurrently.
+func (c *coordinator) updateParentSessionCost(ctx context.Context, childSessionID, parentSessionID string) (float64, error) {
 	childSession, err := c.sessions.Get(ctx, childSessionID)
 	if err != nil {
-		return fmt.Errorf("get child session: %w", err)
+		return 0, fmt.Errorf("get child session: %w", err)
 	}
 
-	parentSession, err := c.sessions.Get(ctx, parentSessionID)
-	if err != nil {
-		return fmt.Errorf("get parent session: %w", err)
+	if _, err := c.sessions.Get(ctx, parentSessionID); err != nil {
+		return childSession.Cost, fmt.Errorf("get parent session: %w", err)
 	}
 
-	parentSession.Cost += childSession.Cost
-
-	if _, err := c.sessions.Save(ctx, parentSession); err != nil {
-		return fmt.Errorf("save parent session: %w", err)
+	if err := c.sessions.AddCost(ctx, parentSessionID, childSession.Cost); err != nil {
+		return childSession.Cost, fmt.Errorf("add parent session cost: %w", err)
 	}
 
-	return nil
+	return childSession.Cost, nil
 }
 
 // discoverSkills is a thin fallback wrapper used only when no
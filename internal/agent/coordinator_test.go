@@ -2,17 +2,28 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/anthropic"
 	"charm.land/fantasy/providers/bedrock"
+	"charm.land/fantasy/providers/openai"
 	"charm.land/fantasy/providers/openaicompat"
+	"charm.land/fantasy/providers/openrouter"
+	"github.com/charmbracelet/crush/internal/agent/breaker"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
 )
 
 // mockSessionAgent is a minimal mock for the SessionAgent interface.
@@ -30,10 +41,14 @@ func (m *mockSessionAgent) BeginAccepted(sessionID string) *AcceptedRun {
 	return &AcceptedRun{sessionID: sessionID}
 }
 
-func (m *mockSessionAgent) Model() Model                        { return m.model }
-func (m *mockSessionAgent) SetModels(large, small Model)        {}
-func (m *mockSessionAgent) SetTools(tools []fantasy.AgentTool)  {}
-func (m *mockSessionAgent) SetSystemPrompt(systemPrompt string) {}
+func (m *mockSessionAgent) Model() Model                          { return m.model }
+func (m *mockSessionAgent) SetModels(large, small Model)          {}
+func (m *mockSessionAgent) SetSummaryModel(model Model)           {}
+func (m *mockSessionAgent) SetContextFallbackModel(model Model)   {}
+func (m *mockSessionAgent) SetTools(tools []fantasy.AgentTool)    {}
+func (m *mockSessionAgent) SetSystemPrompt(systemPrompt string)   {}
+func (m *mockSessionAgent) SetSystemContext(systemContext string) {}
+func (m *mockSessionAgent) SetLanguage(lang language.Tag)         {}
 func (m *mockSessionAgent) Cancel(sessionID string) {
 	m.cancelled = append(m.cancelled, sessionID)
 }
@@ -116,6 +131,45 @@ func TestRunSubAgent(t *testing.T) {
 		assert.False(t, resp.IsError)
 	})
 
+	t.Run("tracks and limits nesting depth", func(t *testing.T) {
+		env := testEnv(t)
+		coord := newTestCoordinator(t, env, providerID, providerCfg)
+
+		parentSession, err := env.sessions.Create(t.Context(), "Parent")
+		require.NoError(t, err)
+
+		var runDepth int
+		agent := newMockAgent(providerID, 4096, func(ctx context.Context, _ SessionAgentCall) (*fantasy.AgentResult, error) {
+			runDepth = tools.GetAgentDepthFromContext(ctx)
+			return agentResultWithText("done"), nil
+		})
+		params := subAgentParams{
+			Agent:          agent,
+			SessionID:      parentSession.ID,
+			AgentMessageID: "msg-1",
+			ToolCallID:     "call-1",
+			Prompt:         "test",
+			SessionTitle:   "Test",
+		}
+
+		resp, err := coord.runSubAgent(t.Context(), params)
+		require.NoError(t, err)
+		assert.False(t, resp.IsError)
+		assert.Equal(t, 1, runDepth)
+		var meta SubAgentResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		assert.Equal(t, 1, meta.Depth)
+
+		runDepth = 0
+		ctx := context.WithValue(t.Context(), tools.AgentDepthContextKey, config.DefaultMaxAgentDepth)
+		params.ToolCallID = "call-2"
+		resp, err = coord.runSubAgent(ctx, params)
+		require.NoError(t, err)
+		assert.True(t, resp.IsError)
+		assert.Contains(t, resp.Content, "depth limit")
+		assert.Zero(t, runDepth, "sub-agent must not run past the depth limit")
+	})
+
 	t.Run("cost update failure preserves output", func(t *testing.T) {
 		env := testEnv(t)
 		coord := newTestCoordinator(t, env, providerID, providerCfg)
@@ -315,6 +369,71 @@ func TestRunSubAgent(t *testing.T) {
 		assert.Equal(t, "Failed to generate response: provider request failed", resp.Content)
 	})
 
+	t.Run("rolls up cost and usage even when the agent fails", func(t *testing.T) {
+		env := testEnv(t)
+		coord := newTestCoordinator(t, env, providerID, providerCfg)
+
+		parentSession, err := env.sessions.Create(t.Context(), "Parent")
+		require.NoError(t, err)
+
+		agent := newMockAgent(providerID, 4096, func(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+			child, err := env.sessions.Get(ctx, call.SessionID)
+			require.NoError(t, err)
+			child.Cost = 0.25
+			_, err = env.sessions.Save(ctx, child)
+			require.NoError(t, err)
+			return nil, errors.New("provider request failed")
+		})
+
+		resp, err := coord.runSubAgent(t.Context(), subAgentParams{
+			Agent:          agent,
+			SessionID:      parentSession.ID,
+			AgentMessageID: "msg-1",
+			ToolCallID:     "call-1",
+			Prompt:         "test",
+			SessionTitle:   "Test",
+		})
+		require.NoError(t, err)
+		assert.True(t, resp.IsError)
+
+		var meta SubAgentResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		assert.InDelta(t, 0.25, meta.Cost, 1e-9)
+
+		updated, err := env.sessions.Get(t.Context(), parentSession.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 0.25, updated.Cost, 1e-9)
+	})
+
+	t.Run("reports sub-agent token usage", func(t *testing.T) {
+		env := testEnv(t)
+		coord := newTestCoordinator(t, env, providerID, providerCfg)
+
+		parentSession, err := env.sessions.Create(t.Context(), "Parent")
+		require.NoError(t, err)
+
+		agent := newMockAgent(providerID, 4096, func(_ context.Context, _ SessionAgentCall) (*fantasy.AgentResult, error) {
+			result := agentResultWithText("done")
+			result.TotalUsage = fantasy.Usage{InputTokens: 1200, OutputTokens: 300}
+			return result, nil
+		})
+
+		resp, err := coord.runSubAgent(t.Context(), subAgentParams{
+			Agent:          agent,
+			SessionID:      parentSession.ID,
+			AgentMessageID: "msg-1",
+			ToolCallID:     "call-1",
+			Prompt:         "test",
+			SessionTitle:   "Test",
+		})
+		require.NoError(t, err)
+
+		var meta SubAgentResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		assert.Equal(t, int64(1200), meta.InputTokens)
+		assert.Equal(t, int64(300), meta.OutputTokens)
+	})
+
 	t.Run("session setup callback is invoked", func(t *testing.T) {
 		env := testEnv(t)
 		coord := newTestCoordinator(t, env, providerID, providerCfg)
@@ -397,7 +516,7 @@ func TestUpdateParentSessionCost(t *testing.T) {
 		_, err = env.sessions.Save(t.Context(), child)
 		require.NoError(t, err)
 
-		err = coord.updateParentSessionCost(t.Context(), child.ID, parent.ID)
+		_, err = coord.updateParentSessionCost(t.Context(), child.ID, parent.ID)
 		require.NoError(t, err)
 
 		updated, err := env.sessions.Get(t.Context(), parent.ID)
@@ -426,9 +545,9 @@ func TestUpdateParentSessionCost(t *testing.T) {
 		_, err = env.sessions.Save(t.Context(), child2)
 		require.NoError(t, err)
 
-		err = coord.updateParentSessionCost(t.Context(), child1.ID, parent.ID)
+		_, err = coord.updateParentSessionCost(t.Context(), child1.ID, parent.ID)
 		require.NoError(t, err)
-		err = coord.updateParentSessionCost(t.Context(), child2.ID, parent.ID)
+		_, err = coord.updateParentSessionCost(t.Context(), child2.ID, parent.ID)
 		require.NoError(t, err)
 
 		updated, err := env.sessions.Get(t.Context(), parent.ID)
@@ -445,7 +564,7 @@ func TestUpdateParentSessionCost(t *testing.T) {
 		parent, err := env.sessions.Create(t.Context(), "Parent")
 		require.NoError(t, err)
 
-		err = coord.updateParentSessionCost(t.Context(), "non-existent", parent.ID)
+		_, err = coord.updateParentSessionCost(t.Context(), "non-existent", parent.ID)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "get child session")
 	})
@@ -461,7 +580,7 @@ func TestUpdateParentSessionCost(t *testing.T) {
 		child, err := env.sessions.CreateTaskSession(t.Context(), "tool-1", parent.ID, "Child")
 		require.NoError(t, err)
 
-		err = coord.updateParentSessionCost(t.Context(), child.ID, "non-existent")
+		_, err = coord.updateParentSessionCost(t.Context(), child.ID, "non-existent")
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "get parent session")
 	})
@@ -477,7 +596,7 @@ func TestUpdateParentSessionCost(t *testing.T) {
 		child, err := env.sessions.CreateTaskSession(t.Context(), "tool-1", parent.ID, "Child")
 		require.NoError(t, err)
 
-		err = coord.updateParentSessionCost(t.Context(), child.ID, parent.ID)
+		_, err = coord.updateParentSessionCost(t.Context(), child.ID, parent.ID)
 		require.NoError(t, err)
 
 		updated, err := env.sessions.Get(t.Context(), parent.ID)
@@ -552,3 +671,189 @@ func TestGetProviderOptionsReasoningEffortFallback(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "enabled", thinking["type"])
 }
+
+func TestGetProviderOptionsOpenRouterUser(t *testing.T) {
+	t.Parallel()
+
+	providerCfg := config.ProviderConfig{ID: "openrouter", Type: openrouter.Name}
+
+	opts := getProviderOptions(Model{RequestUser: "jane"}, providerCfg)
+	parsed, ok := opts[openrouter.Name].(*openrouter.ProviderOptions)
+	require.True(t, ok)
+	require.NotNil(t, parsed.User)
+	require.Equal(t, "jane", *parsed.User)
+
+	// A user set in provider_options wins.
+	model := Model{
+		RequestUser: "jane",
+		ModelCfg:    config.SelectedModel{ProviderOptions: map[string]any{"user": "bob"}},
+	}
+	parsed, ok = getProviderOptions(model, providerCfg)[openrouter.Name].(*openrouter.ProviderOptions)
+	require.True(t, ok)
+	require.Equal(t, "bob", *parsed.User)
+}
+
+func TestWithStopSequences(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no stops leaves body untouched", func(t *testing.T) {
+		t.Parallel()
+		body := map[string]any{"foo": "bar"}
+		require.Equal(t, body, withStopSequences(body, nil))
+		require.Nil(t, withStopSequences(nil, nil))
+	})
+
+	t.Run("sets stop without modifying the original", func(t *testing.T) {
+		t.Parallel()
+		body := map[string]any{"foo": "bar"}
+		got := withStopSequences(body, []string{"END"})
+		require.Equal(t, map[string]any{"foo": "bar", "stop": []string{"END"}}, got)
+		require.NotContains(t, body, "stop")
+	})
+
+	t.Run("nil body", func(t *testing.T) {
+		t.Parallel()
+		got := withStopSequences(nil, []string{"END", "STOP"})
+		require.Equal(t, map[string]any{"stop": []string{"END", "STOP"}}, got)
+	})
+}
+
+func TestBuildProviderOpenAIStopSequences(t *testing.T) {
+	env := testEnv(t)
+	crushJSON := `{
+  "options": {"disable_default_providers": true, "disable_provider_auto_update": true},
+  "providers": {"mock": {"id": "mock", "name": "Mock", "type": "openai",
+    "base_url": "http://127.0.0.1:9/v1", "api_key": "test-key",
+    "models": [{"id": "mock-model", "name": "Mock", "context_window": 8192}]}}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(env.workingDir, "crush.json"), []byte(crushJSON), 0o644))
+	cfg, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	coord := &coordinator{cfg: cfg}
+
+	// requestBody builds an OpenAI provider for modelID and returns the body
+	// of the request it sends.
+	requestBody := func(t *testing.T, modelID string) map[string]any {
+		var body map[string]any
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &body)
+			http.Error(w, `{"error":{"message":"test"}}`, http.StatusBadRequest)
+		}))
+		t.Cleanup(srv.Close)
+
+		providerCfg := config.ProviderConfig{ID: "openai", Type: openai.Name, BaseURL: srv.URL, APIKey: "test-key"}
+		provider, err := coord.buildProvider(providerCfg, config.SelectedModel{Model: modelID, StopSequences: []string{"END"}}, false)
+		require.NoError(t, err)
+		model, err := provider.LanguageModel(t.Context(), modelID)
+		require.NoError(t, err)
+		_, err = model.Generate(t.Context(), fantasy.Call{Prompt: fantasy.Prompt{fantasy.NewUserMessage("hi")}})
+		require.Error(t, err)
+		require.NotNil(t, body, "no request was sent: %v", err)
+		return body
+	}
+
+	t.Run("chat completions", func(t *testing.T) {
+		require.Equal(t, []any{"END"}, requestBody(t, "custom-model")["stop"])
+	})
+
+	t.Run("responses API", func(t *testing.T) {
+		require.NotContains(t, requestBody(t, "gpt-4o"), "stop")
+	})
+}
+
+func TestWithRequestUser(t *testing.T) {
+	t.Parallel()
+
+	body := map[string]any{"stop": []string{"END"}}
+	require.Equal(t, body, withRequestUser(body, ""))
+
+	got := withRequestUser(body, "jane")
+	require.Equal(t, map[string]any{"stop": []string{"END"}, "user": "jane"}, got)
+	require.NotContains(t, body, "user")
+
+	require.Equal(t, map[string]any{"user": "jane"}, withRequestUser(nil, "jane"))
+}
+
+func TestCacheParams(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestStore(&config.Config{Options: &config.Options{}})
+	coord := &coordinator{cfg: cfg}
+	providerCfg := config.ProviderConfig{ExtraBody: map[string]any{"foo": "bar"}}
+
+	require.Equal(t, map[string]any{"foo": "bar"}, coord.cacheParams(providerCfg, config.SelectedModel{}))
+
+	// --stop is applied to the model config, --user through the overrides;
+	// both must change the response cache key.
+	cfg.Overrides().RequestUser = "jane"
+	got := coord.cacheParams(providerCfg, config.SelectedModel{StopSequences: []string{"END"}})
+	require.Equal(t, map[string]any{"foo": "bar", "stop": []string{"END"}, "user": "jane"}, got)
+	require.NotContains(t, providerCfg.ExtraBody, "stop")
+}
+
+func TestBuildAgentModelsAppliesMaxTokensOverride(t *testing.T) {
+	env := testEnv(t)
+
+	crushJSON := `{
+  "options": {"disable_default_providers": true, "disable_provider_auto_update": true},
+  "providers": {"mock": {"id": "mock", "name": "Mock", "type": "openai",
+    "base_url": "http://127.0.0.1:9/v1", "api_key": "test-key",
+    "models": [{"id": "mock-model", "name": "Mock", "context_window": 8192, "default_max_tokens": 128}]}},
+  "models": {"large": {"provider": "mock", "model": "mock-model", "max_tokens": 512},
+             "small": {"provider": "mock", "model": "mock-model"}}
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(env.workingDir, "crush.json"), []byte(crushJSON), 0o644))
+
+	cfg, err := config.Init(env.workingDir, "", false)
+	require.NoError(t, err)
+	coord := &coordinator{cfg: cfg}
+
+	large, small, err := coord.buildAgentModels(t.Context(), false)
+	require.NoError(t, err)
+	require.Equal(t, int64(512), large.ModelCfg.MaxTokens)
+
+	cfg.Overrides().MaxTokens = 64
+	large, small, err = coord.buildAgentModels(t.Context(), false)
+	require.NoError(t, err)
+	require.Equal(t, int64(64), large.ModelCfg.MaxTokens)
+	require.Equal(t, int64(128), small.ModelCfg.MaxTokens)
+}
+
+func TestGuardModel(t *testing.T) {
+	t.Parallel()
+
+	inner := &finishStreamModel{text: "ok"}
+	require.Same(t, inner, guardModel(inner, "guard-test", config.ProviderConfig{}))
+	require.Same(t, inner, guardModel(inner, "guard-test", config.ProviderConfig{CircuitBreaker: &config.CircuitBreaker{}}))
+
+	guarded := guardModel(inner, "guard-test", config.ProviderConfig{CircuitBreaker: &config.CircuitBreaker{Enabled: true}})
+	require.IsType(t, &breaker.Model{}, guarded)
+	require.Equal(t, inner.Model(), guarded.Model())
+	require.Contains(t, breaker.GetStates(), "guard-test")
+}
+
+func TestDropDuplicateTools(t *testing.T) {
+	t.Parallel()
+
+	newTool := func(name, description string) fantasy.AgentTool {
+		return fantasy.NewAgentTool(name, description, func(context.Context, echoParams, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.ToolResponse{}, nil
+		})
+	}
+	list := []fantasy.AgentTool{
+		newTool("edit", "built-in"),
+		newTool("mcp_a_b_c", "first"),
+		newTool("edit", "duplicate"),
+		newTool("mcp_a_b_c", "second"),
+		newTool("view", "built-in"),
+	}
+
+	got := dropDuplicateTools(list)
+	require.Len(t, got, 3)
+	for i, want := range []string{"edit", "mcp_a_b_c", "view"} {
+		require.Equal(t, want, got[i].Info().Name)
+	}
+	require.Equal(t, "built-in", got[0].Info().Description)
+	require.Equal(t, "first", got[1].Info().Description)
+}
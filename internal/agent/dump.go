@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"charm.land/fantasy"
+)
+
+// minSecretLength is the shortest secret [MessageDump] redacts. Shorter
+// values are too likely to match unrelated text.
+const minSecretLength = 8
+
+const redacted = "[REDACTED]"
+
+// MessageDump writes the messages and tool definitions of each step to a
+// file as JSON right before they are sent to the provider, so a provider
+// bug can be reproduced without raw HTTP logs. Attach it with [WithMessageDump].
+// Like [Timings], only the first session that runs with the context is
+// dumped; sub-agents started by tools are left out.
+type MessageDump struct {
+	mu        sync.Mutex
+	path      string
+	secrets   [][]byte
+	sessionID string
+}
+
+// NewMessageDump returns a dump that writes to path. Every occurrence of
+// one of secrets in the output is replaced with "[REDACTED]".
+func NewMessageDump(path string, secrets ...string) *MessageDump {
+	d := &MessageDump{path: path}
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength {
+			continue
+		}
+		// Match the secret as it appears inside a JSON string.
+		encoded, err := json.Marshal(secret)
+		if err != nil {
+			continue
+		}
+		d.secrets = append(d.secrets, encoded[1:len(encoded)-1])
+	}
+	return d
+}
+
+type messageDumpContextKey struct{}
+
+// WithMessageDump returns a copy of ctx that makes the agent dump the
+// messages it sends into d.
+func WithMessageDump(ctx context.Context, d *MessageDump) context.Context {
+	return context.WithValue(ctx, messageDumpContextKey{}, d)
+}
+
+// messageDumpFor returns the dump in ctx if it belongs to sessionID. The
+// result may be nil; write is a no-op on a nil receiver.
+func messageDumpFor(ctx context.Context, sessionID string) *MessageDump {
+	d, _ := ctx.Value(messageDumpContextKey{}).(*MessageDump)
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.sessionID == "" {
+		d.sessionID = sessionID
+	}
+	if d.sessionID != sessionID {
+		return nil
+	}
+	return d
+}
+
+type messageDumpPayload struct {
+	SessionID string             `json:"session_id"`
+	Provider  string             `json:"provider"`
+	Model     string             `json:"model"`
+	Messages  []fantasy.Message  `json:"messages"`
+	Tools     []fantasy.ToolInfo `json:"tools"`
+}
+
+// write replaces the dump file with the request of one step: the messages
+// as prepared for the provider, system prompt included, and the tools
+// offered. Every step overwrites it, so the file holds the last request
+// sent in the session.
+func (d *MessageDump) write(model Model, msgs []fantasy.Message, tools []fantasy.AgentTool) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	payload := messageDumpPayload{
+		SessionID: d.sessionID,
+		Provider:  model.Model.Provider(),
+		Model:     model.Model.Model(),
+		Messages:  msgs,
+		Tools:     make([]fantasy.ToolInfo, 0, len(tools)),
+	}
+	for _, tool := range tools {
+		payload.Tools = append(payload.Tools, tool.Info())
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding messages: %w", err)
+	}
+	for _, secret := range d.secrets {
+		data = bytes.ReplaceAll(data, secret, []byte(redacted))
+	}
+	if err := os.WriteFile(d.path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", d.path, err)
+	}
+	return nil
+}
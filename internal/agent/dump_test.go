@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageDump(t *testing.T) {
+	t.Parallel()
+
+	t.Run("writes the request of the last step", func(t *testing.T) {
+		t.Parallel()
+
+		const secret = "sk-test-0123456789"
+		echo := fantasy.NewAgentTool("echo", "Echo text", func(_ context.Context, params echoParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return fantasy.NewTextResponse(params.Text), nil
+		})
+
+		env := testEnv(t)
+		model := &truncatedToolCallModel{deltas: []string{`{"text":"hi"}`}, emitCall: true}
+		sa := testSessionAgent(env, model, &finishStreamModel{text: "title"}, "system", echo)
+		sess, err := env.sessions.Create(t.Context(), "session")
+		require.NoError(t, err)
+
+		path := filepath.Join(t.TempDir(), "dump.json")
+		ctx := WithMessageDump(t.Context(), NewMessageDump(path, secret, "short"))
+		_, err = sa.Run(ctx, SessionAgentCall{SessionID: sess.ID, Prompt: "my key is " + secret + ", keep it short"})
+		require.NoError(t, err)
+		require.EqualValues(t, 2, model.calls.Load())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), secret)
+
+		var dump struct {
+			SessionID string `json:"session_id"`
+			Model     string `json:"model"`
+			Messages  []struct {
+				Role    string `json:"role"`
+				Content []struct {
+					Type string `json:"type"`
+					Data struct {
+						Text string `json:"text"`
+					} `json:"data"`
+				} `json:"content"`
+			} `json:"messages"`
+			Tools []fantasy.ToolInfo `json:"tools"`
+		}
+		require.NoError(t, json.Unmarshal(data, &dump))
+		require.Equal(t, sess.ID, dump.SessionID)
+		require.Equal(t, "fake-model", dump.Model)
+		require.NotEmpty(t, dump.Messages)
+
+		// The dump is the prepared request: system prompt first, the tool
+		// result of the first step last.
+		require.Equal(t, "system", dump.Messages[0].Role)
+		last := dump.Messages[len(dump.Messages)-1]
+		require.Equal(t, "tool", last.Role)
+		require.Equal(t, "tool-result", last.Content[0].Type)
+
+		var prompts []string
+		for _, msg := range dump.Messages {
+			if msg.Role == "user" {
+				for _, part := range msg.Content {
+					prompts = append(prompts, part.Data.Text)
+				}
+			}
+		}
+		require.Contains(t, prompts, "my key is [REDACTED], keep it short")
+		require.Len(t, dump.Tools, 1)
+		require.Equal(t, "echo", dump.Tools[0].Name)
+	})
+
+	t.Run("records only the first session", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := WithMessageDump(t.Context(), NewMessageDump("unused"))
+		require.NotNil(t, messageDumpFor(ctx, "parent"))
+		require.Nil(t, messageDumpFor(ctx, "sub-agent"))
+	})
+
+	t.Run("is a no-op without a dump", func(t *testing.T) {
+		t.Parallel()
+
+		d := messageDumpFor(t.Context(), "session")
+		require.Nil(t, d)
+		require.NoError(t, d.write(Model{}, nil, nil))
+	})
+}
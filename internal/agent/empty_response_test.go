@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyThenTextModel answers its first call with nothing and later calls
+// with text, recording the prompt of each call.
+type emptyThenTextModel struct {
+	callRecordingModel
+
+	streams atomic.Int32
+}
+
+func (m *emptyThenTextModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	if m.streams.Add(1) > 1 {
+		return m.callRecordingModel.Stream(ctx, call)
+	}
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+	return func(yield func(fantasy.StreamPart) bool) {
+		yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop})
+	}, nil
+}
+
+func TestRunEmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, policy config.EmptyResponsePolicy) (*emptyThenTextModel, []message.Message, error) {
+		env := testEnv(t)
+		model := &emptyThenTextModel{callRecordingModel: callRecordingModel{finishStreamModel: finishStreamModel{text: "answer"}}}
+		agent := NewSessionAgent(SessionAgentOptions{
+			LargeModel:    Model{Model: model},
+			SmallModel:    Model{Model: &finishStreamModel{text: "title"}},
+			IsSubAgent:    true,
+			EmptyResponse: policy,
+			IsYolo:        true,
+			Sessions:      env.sessions,
+			Messages:      env.messages,
+		})
+		sess, err := env.sessions.Create(t.Context(), "test")
+		require.NoError(t, err)
+		_, runErr := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello", NonInteractive: true})
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		return model, msgs, runErr
+	}
+
+	t.Run("finish", func(t *testing.T) {
+		t.Parallel()
+		model, msgs, err := run(t, config.EmptyResponseFinish)
+		require.ErrorIs(t, err, ErrEmptyResponse)
+		require.Len(t, model.calls, 1)
+		require.Len(t, msgs, 2)
+		finish := msgs[1].FinishPart()
+		require.NotNil(t, finish)
+		require.Equal(t, message.FinishReasonError, finish.Reason)
+		require.Equal(t, "Empty response", finish.Message)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		t.Parallel()
+		model, msgs, err := run(t, config.EmptyResponseRetry)
+		require.NoError(t, err)
+		require.Len(t, model.calls, 2)
+		prompt := model.calls[1].Prompt
+		text, ok := fantasy.AsMessagePart[fantasy.TextPart](prompt[len(prompt)-1].Content[0])
+		require.True(t, ok)
+		require.Equal(t, emptyResponseNudge, text.Text)
+
+		// The blank answer is replaced and the nudge is not stored.
+		require.Len(t, msgs, 2)
+		require.Equal(t, message.User, msgs[0].Role)
+		require.Equal(t, "hello", msgs[0].Content().Text)
+		require.Equal(t, "answer", msgs[1].Content().Text)
+	})
+}
@@ -6,5 +6,7 @@ var (
 	ErrRequestCancelled = errors.New("request canceled by user")
 	ErrSessionBusy      = errors.New("session is currently processing another request")
 	ErrEmptyPrompt      = errors.New("prompt is empty")
+	ErrEmptyResponse    = errors.New("model returned an empty response")
 	ErrSessionMissing   = errors.New("session id is missing")
+	ErrConcurrencyLimit = errors.New("too many turns are generating at once")
 )
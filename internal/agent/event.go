@@ -37,6 +37,39 @@ func (a *sessionAgent) eventTokensUsed(sessionID string, model Model, usage fant
 	)
 }
 
+func (a *sessionAgent) eventSummarizeFailed(sessionID string, err error) {
+	event.SummarizeFailed(
+		append(
+			a.eventCommon(sessionID, a.largeModel.Get()),
+			"error", err.Error(),
+		)...,
+	)
+}
+
+func (a *sessionAgent) eventContextFallback(sessionID string, model, fallback Model, action string) {
+	event.ContextFallback(
+		append(
+			a.eventCommon(sessionID, model),
+			"action", action,
+			"fallback provider", fallback.ModelCfg.Provider,
+			"fallback model", fallback.ModelCfg.Model,
+		)...,
+	)
+}
+
+// eventRequestQueued reports a turn that found
+// options.max_concurrent_requests turns generating; outcome is started,
+// canceled or rejected.
+func (a *sessionAgent) eventRequestQueued(sessionID string, waited time.Duration, outcome string) {
+	event.RequestQueued(
+		append(
+			a.eventCommon(sessionID, a.largeModel.Get()),
+			"outcome", outcome,
+			"wait duration in seconds", waited.Seconds(),
+		)...,
+	)
+}
+
 func (a *sessionAgent) eventCommon(sessionID string, model Model) []any {
 	m := model.ModelCfg
 
@@ -39,6 +39,10 @@ func wrapToolsWithHooks(tools []fantasy.AgentTool, runner *hooks.Runner, isSubAg
 	return out
 }
 
+func (h *hookedTool) unwrap() fantasy.AgentTool {
+	return h.inner
+}
+
 func (h *hookedTool) Info() fantasy.ToolInfo {
 	return h.inner.Info()
 }
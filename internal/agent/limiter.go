@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// requestLimiter caps how many model requests are in flight at once. The
+// coordinator shares one between all of its agents for small-model
+// requests. A nil requestLimiter does not limit.
+type requestLimiter chan struct{}
+
+func newRequestLimiter(n int) requestLimiter {
+	if n <= 0 {
+		return nil
+	}
+	return make(requestLimiter, n)
+}
+
+// acquire waits until a request may start or ctx is done. Every successful
+// acquire must be followed by a release.
+func (l requestLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryAcquire is acquire without waiting. It reports whether a request may
+// start; if so it must be followed by a release.
+func (l requestLimiter) tryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l requestLimiter) release() {
+	if l != nil {
+		<-l
+	}
+}
+
+var (
+	generationsMu sync.Mutex
+	generations   requestLimiter
+)
+
+// generationLimiter returns the limiter for turns generating at once. It
+// is shared by every agent of the process, so options.max_concurrent_requests
+// holds across the workspaces of crush serve too. The limiter is replaced
+// when n changes; turns holding a slot release it to the limiter they got
+// it from.
+func generationLimiter(n int) requestLimiter {
+	generationsMu.Lock()
+	defer generationsMu.Unlock()
+	if cap(generations) != max(n, 0) {
+		generations = newRequestLimiter(n)
+	}
+	return generations
+}
+
+// acquireGeneration takes a slot under options.max_concurrent_requests for
+// a run of sessionID. Over the limit it waits until ctx is done, or fails
+// with [ErrConcurrencyLimit] when options.concurrency_limit_mode is reject.
+// Sub-agents run inside the turn of their parent and its slot. The
+// returned release may be called more than once.
+func (a *sessionAgent) acquireGeneration(ctx context.Context, sessionID string) (release func(), err error) {
+	if a.isSubAgent {
+		return func() {}, nil
+	}
+	slots := generationLimiter(a.maxConcurrentRequests)
+	if !slots.tryAcquire() {
+		if a.concurrencyLimitMode == config.ConcurrencyLimitReject {
+			slog.Warn("Turn rejected, too many turns generating", "session_id", sessionID, "limit", cap(slots))
+			a.eventRequestQueued(sessionID, 0, "rejected")
+			return nil, ErrConcurrencyLimit
+		}
+		slog.Info("Turn waiting, too many turns generating", "session_id", sessionID, "limit", cap(slots))
+		start := time.Now()
+		if err := slots.acquire(ctx); err != nil {
+			a.eventRequestQueued(sessionID, time.Since(start), "canceled")
+			return nil, err
+		}
+		waited := time.Since(start)
+		slog.Info("Turn started after waiting", "session_id", sessionID, "waited", waited)
+		a.eventRequestQueued(sessionID, waited, "started")
+	}
+	return sync.OnceFunc(slots.release), nil
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunConcurrencyLimit holds the only slot of the process-wide limiter,
+// so it must not run in parallel with other agent tests.
+func TestRunConcurrencyLimit(t *testing.T) {
+	run := func(t *testing.T, mode config.ConcurrencyLimitMode) (*sessionAgent, string, chan error) {
+		env := testEnv(t)
+		agent := NewSessionAgent(SessionAgentOptions{
+			LargeModel:            Model{Model: &finishStreamModel{text: "answer"}},
+			SmallModel:            Model{Model: &finishStreamModel{text: "title"}},
+			DisableAutoSummarize:  true,
+			IsYolo:                true,
+			Sessions:              env.sessions,
+			Messages:              env.messages,
+			MaxConcurrentRequests: 1,
+			ConcurrencyLimitMode:  mode,
+		}).(*sessionAgent)
+		sess, err := env.sessions.Create(t.Context(), "test")
+		require.NoError(t, err)
+		done := make(chan error, 1)
+		go func() {
+			_, err := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello", NonInteractive: true})
+			done <- err
+		}()
+		return agent, sess.ID, done
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		slots := generationLimiter(1)
+		require.True(t, slots.tryAcquire())
+		defer slots.release()
+
+		_, _, done := run(t, config.ConcurrencyLimitReject)
+		require.ErrorIs(t, <-done, ErrConcurrencyLimit)
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		slots := generationLimiter(1)
+		require.True(t, slots.tryAcquire())
+
+		agent, sessionID, done := run(t, config.ConcurrencyLimitQueue)
+		require.Eventually(t, func() bool { return agent.IsSessionBusy(sessionID) }, time.Second, 10*time.Millisecond)
+		select {
+		case err := <-done:
+			t.Fatalf("run finished while the limit was reached: %v", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		slots.release()
+		require.NoError(t, <-done)
+		msgs, err := agent.messages.List(t.Context(), sessionID)
+		require.NoError(t, err)
+		require.Len(t, msgs, 2)
+		require.Equal(t, "answer", msgs[1].Content().Text)
+		require.True(t, slots.tryAcquire(), "the run must release its slot")
+		slots.release()
+	})
+
+	t.Run("cancel while waiting", func(t *testing.T) {
+		slots := generationLimiter(1)
+		require.True(t, slots.tryAcquire())
+		defer slots.release()
+
+		agent, sessionID, done := run(t, config.ConcurrencyLimitQueue)
+		require.Eventually(t, func() bool { return agent.IsSessionBusy(sessionID) }, time.Second, 10*time.Millisecond)
+		agent.Cancel(sessionID)
+		require.NoError(t, <-done)
+		msgs, err := agent.messages.List(t.Context(), sessionID)
+		require.NoError(t, err)
+		require.Len(t, msgs, 2)
+		require.Equal(t, message.FinishReasonCanceled, msgs[1].FinishReason())
+	})
+}
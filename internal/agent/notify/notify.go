@@ -15,6 +15,10 @@ const (
 	// TypeAgentError indicates the agent's turn terminated with an
 	// error. The error text is carried in Notification.Message.
 	TypeAgentError Type = "error"
+	// TypeProviderRetry indicates a model request failed and is retried
+	// after a delay. The error and the delay are described in
+	// Notification.Message.
+	TypeProviderRetry Type = "provider_retry"
 )
 
 // Notification represents a domain event published by the agent.
@@ -29,8 +33,8 @@ type Notification struct {
 	// specific request rather than to any in-flight run on the
 	// session. Empty when no caller set one.
 	RunID string
-	// Message carries the error text for TypeAgentError. Other
-	// notification types ignore it.
+	// Message carries the error text for TypeAgentError and the retry
+	// status for TypeProviderRetry. Other notification types ignore it.
 	Message string
 }
 
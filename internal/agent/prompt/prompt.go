@@ -40,6 +40,11 @@ type PromptDat struct {
 	ContextFiles       []ContextFile
 	GlobalContextFiles []ContextFile
 	AvailSkillXML      string
+	// InlineContext reports whether the template renders its "context"
+	// template in place. It is false when options.context.placement
+	// puts the context before the instructions or in a block of its own;
+	// the prompt then renders that template separately.
+	InlineContext bool
 }
 
 type ContextFile struct {
@@ -80,20 +85,51 @@ func NewPrompt(name, promptTemplate string, opts ...Option) (*Prompt, error) {
 }
 
 func (p *Prompt) Build(ctx context.Context, provider, model string, store *config.ConfigStore) (string, error) {
+	blocks, err := p.BuildBlocks(ctx, provider, model, store)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
+
+// BuildBlocks renders the prompt as a list of system blocks. Templates
+// that define a "context" template have it placed according to
+// options.context.placement: after the instructions (the default), before
+// them, or as a second block to be sent as its own system message. Other
+// templates always yield a single block.
+func (p *Prompt) BuildBlocks(ctx context.Context, provider, model string, store *config.ConfigStore) ([]string, error) {
 	t, err := template.New(p.name).Parse(p.template)
 	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
+		return nil, fmt.Errorf("parsing template: %w", err)
 	}
-	var sb strings.Builder
 	d, err := p.promptData(ctx, provider, model, store)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	placement := store.Config().Options.GetContextPlacement()
+	d.InlineContext = placement == config.ContextPlacementSuffix || t.Lookup("context") == nil
+
+	var sb strings.Builder
 	if err := t.Execute(&sb, d); err != nil {
-		return "", fmt.Errorf("executing template: %w", err)
+		return nil, fmt.Errorf("executing template: %w", err)
+	}
+	if d.InlineContext {
+		return []string{sb.String()}, nil
 	}
 
-	return sb.String(), nil
+	var cb strings.Builder
+	if err := t.ExecuteTemplate(&cb, "context", d); err != nil {
+		return nil, fmt.Errorf("executing context template: %w", err)
+	}
+	contextBlock := strings.TrimSpace(cb.String())
+	switch {
+	case contextBlock == "":
+		return []string{sb.String()}, nil
+	case placement == config.ContextPlacementPrefix:
+		return []string{contextBlock + "\n\n" + sb.String()}, nil
+	default:
+		return []string{sb.String(), contextBlock}, nil
+	}
 }
 
 func processFile(filePath string) *ContextFile {
@@ -6,6 +6,8 @@ import (
 
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/config"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
 )
 
 //go:embed templates/coder.md.tpl
@@ -40,3 +42,40 @@ func InitializePrompt(cfg *config.ConfigStore) (string, error) {
 	}
 	return systemPrompt.Build(context.Background(), "", "", cfg)
 }
+
+// responseStyleDirective returns the instruction appended to the system
+// prompt for style, or "" for the normal style.
+func responseStyleDirective(style config.ResponseStyle) string {
+	switch style {
+	case config.ResponseStyleConcise:
+		return "<response-style>\nAnswer as briefly as possible. Give the answer or result directly, without preamble, summaries of what you did, or explanations the user did not ask for. Prefer a single sentence or a short list.\n</response-style>"
+	case config.ResponseStyleDetailed:
+		return "<response-style>\nExplain your answers thoroughly. Describe your reasoning, the trade-offs you considered, and the changes you made, and include examples where they help.\n</response-style>"
+	default:
+		return ""
+	}
+}
+
+// languageName names lang for the prompts, in English and in lang itself,
+// such as "German (Deutsch)". It returns "" for English and the zero value,
+// which need no directive.
+func languageName(lang language.Tag) string {
+	if lang == language.Und || lang == language.English {
+		return ""
+	}
+	name := display.English.Tags().Name(lang)
+	if self := display.Self.Name(lang); self != "" && self != name {
+		name += " (" + self + ")"
+	}
+	return name
+}
+
+// languageDirective returns the instruction appended to the system prompt
+// for lang, or "" when no directive is needed.
+func languageDirective(lang language.Tag) string {
+	name := languageName(lang)
+	if name == "" {
+		return ""
+	}
+	return "<language>\nWrite your answers to the user in " + name + ", unless they ask for another language. Keep code, identifiers, file paths, commands and tool output as they are.\n</language>"
+}
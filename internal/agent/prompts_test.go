@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/prompt"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestResponseStyleIsAppendedToSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	for style, want := range map[config.ResponseStyle]string{
+		"":                           "system",
+		config.ResponseStyleNormal:   "system",
+		config.ResponseStyleConcise:  "system\n\n" + responseStyleDirective(config.ResponseStyleConcise),
+		config.ResponseStyleDetailed: "system\n\n" + responseStyleDirective(config.ResponseStyleDetailed),
+	} {
+		t.Run(string(style), func(t *testing.T) {
+			t.Parallel()
+
+			env := testEnv(t)
+			sa := testSessionAgent(env, &finishStreamModel{text: "done"}, &finishStreamModel{text: "title"}, "system")
+			sess, err := env.sessions.Create(t.Context(), "session")
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "dump.json")
+			ctx := WithMessageDump(t.Context(), NewMessageDump(path))
+			_, err = sa.Run(ctx, SessionAgentCall{SessionID: sess.ID, Prompt: "hi", ResponseStyle: style})
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var dump struct {
+				Messages []struct {
+					Role    string `json:"role"`
+					Content []struct {
+						Data struct {
+							Text string `json:"text"`
+						} `json:"data"`
+					} `json:"content"`
+				} `json:"messages"`
+			}
+			require.NoError(t, json.Unmarshal(data, &dump))
+			require.NotEmpty(t, dump.Messages)
+			require.Equal(t, "system", dump.Messages[0].Role)
+			require.Equal(t, want, dump.Messages[0].Content[0].Data.Text)
+		})
+	}
+}
+
+func TestLanguageDirective(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, languageDirective(language.Und))
+	require.Empty(t, languageDirective(language.English))
+	require.Equal(t, "German (Deutsch)", languageName(language.German))
+	require.Equal(t, "Japanese (日本語)", languageName(language.Japanese))
+	require.Contains(t, languageDirective(language.German), "Write your answers to the user in German (Deutsch)")
+}
+
+func TestCoderPromptContextPlacement(t *testing.T) {
+	t.Parallel()
+
+	for placement, check := range map[config.ContextPlacement]func(t *testing.T, blocks []string){
+		config.ContextPlacementSuffix: func(t *testing.T, blocks []string) {
+			require.Len(t, blocks, 1)
+			require.Greater(t, strings.Index(blocks[0], "Always answer in haiku."), strings.Index(blocks[0], "<env>"))
+		},
+		config.ContextPlacementPrefix: func(t *testing.T, blocks []string) {
+			require.Len(t, blocks, 1)
+			require.True(t, strings.HasPrefix(blocks[0], "# Project-Specific Context"))
+		},
+		config.ContextPlacementSeparate: func(t *testing.T, blocks []string) {
+			require.Len(t, blocks, 2)
+			require.NotContains(t, blocks[0], "Always answer in haiku.")
+			require.True(t, strings.HasPrefix(blocks[1], "# Project-Specific Context"))
+			require.Contains(t, blocks[1], "Always answer in haiku.")
+		},
+	} {
+		t.Run(string(placement), func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			agentsMD := filepath.Join(dir, "AGENTS.md")
+			require.NoError(t, os.WriteFile(agentsMD, []byte("Always answer in haiku."), 0o644))
+			store, err := config.Init(dir, "", false)
+			require.NoError(t, err)
+			store.Config().Options.ContextPaths = []string{agentsMD}
+			store.Config().Options.GlobalContextPaths = nil
+			store.Config().Options.Context = &config.ContextOptions{Placement: placement}
+
+			p, err := coderPrompt(prompt.WithWorkingDir(dir))
+			require.NoError(t, err)
+			blocks, err := p.BuildBlocks(t.Context(), "fake", "fake-model", store)
+			require.NoError(t, err)
+			check(t, blocks)
+		})
+	}
+}
+
+func TestInsertAfterSystemMessages(t *testing.T) {
+	t.Parallel()
+
+	msgs := insertAfterSystemMessages([]fantasy.Message{
+		fantasy.NewSystemMessage("instructions"),
+		fantasy.NewUserMessage("hi"),
+	}, fantasy.NewSystemMessage("context"))
+	require.Len(t, msgs, 3)
+	require.Equal(t, fantasy.MessageRoleSystem, msgs[1].Role)
+	require.Equal(t, fantasy.MessageRoleUser, msgs[2].Role)
+}
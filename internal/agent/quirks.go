@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"maps"
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/openai"
+	"charm.land/fantasy/providers/openaicompat"
+)
+
+// withMaxCompletionTokens returns a copy of opts that sends n as
+// max_completion_tokens. It reports false, leaving opts untouched, when
+// opts has no OpenAI or OpenAI-compatible options to carry the limit.
+func withMaxCompletionTokens(opts fantasy.ProviderOptions, n int64) (fantasy.ProviderOptions, bool) {
+	switch o := opts[openai.Name].(type) {
+	case *openai.ProviderOptions:
+		clone := *o
+		clone.MaxCompletionTokens = &n
+		opts = maps.Clone(opts)
+		opts[openai.Name] = &clone
+		return opts, true
+	}
+	switch o := opts[openaicompat.Name].(type) {
+	case *openaicompat.ProviderOptions:
+		clone := *o
+		clone.ExtraBody = maps.Clone(o.ExtraBody)
+		if clone.ExtraBody == nil {
+			clone.ExtraBody = make(map[string]any)
+		}
+		clone.ExtraBody["max_completion_tokens"] = n
+		opts = maps.Clone(opts)
+		opts[openaicompat.Name] = &clone
+		return opts, true
+	}
+	return opts, false
+}
+
+// foldSystemMessages moves the text of the system messages in msgs to the
+// start of the first user message, for models that do not accept the
+// system role. Without a user message, the system text becomes one.
+func foldSystemMessages(msgs []fantasy.Message) []fantasy.Message {
+	var system []string
+	out := make([]fantasy.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.Role != fantasy.MessageRoleSystem {
+			out = append(out, msg)
+			continue
+		}
+		for _, part := range msg.Content {
+			if text, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok && text.Text != "" {
+				system = append(system, text.Text)
+			}
+		}
+	}
+	if len(system) == 0 {
+		return out
+	}
+
+	text := fantasy.TextPart{Text: strings.Join(system, "\n\n")}
+	for i, msg := range out {
+		if msg.Role != fantasy.MessageRoleUser {
+			continue
+		}
+		msg.Content = append([]fantasy.MessagePart{text}, msg.Content...)
+		out[i] = msg
+		return out
+	}
+	return append([]fantasy.Message{fantasy.NewUserMessage(text.Text)}, out...)
+}
@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/providers/anthropic"
+	"charm.land/fantasy/providers/openai"
+	"charm.land/fantasy/providers/openaicompat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMaxCompletionTokens(t *testing.T) {
+	t.Parallel()
+
+	t.Run("openai", func(t *testing.T) {
+		t.Parallel()
+		orig := &openai.ProviderOptions{}
+		opts, ok := withMaxCompletionTokens(fantasy.ProviderOptions{openai.Name: orig}, 1000)
+		require.True(t, ok)
+		require.Equal(t, int64(1000), *opts[openai.Name].(*openai.ProviderOptions).MaxCompletionTokens)
+		require.Nil(t, orig.MaxCompletionTokens)
+	})
+
+	t.Run("openai-compat", func(t *testing.T) {
+		t.Parallel()
+		orig := &openaicompat.ProviderOptions{ExtraBody: map[string]any{"foo": "bar"}}
+		opts, ok := withMaxCompletionTokens(fantasy.ProviderOptions{openaicompat.Name: orig}, 1000)
+		require.True(t, ok)
+		require.Equal(t, map[string]any{"foo": "bar", "max_completion_tokens": int64(1000)}, opts[openaicompat.Name].(*openaicompat.ProviderOptions).ExtraBody)
+		require.Equal(t, map[string]any{"foo": "bar"}, orig.ExtraBody)
+	})
+
+	t.Run("other providers", func(t *testing.T) {
+		t.Parallel()
+		_, ok := withMaxCompletionTokens(fantasy.ProviderOptions{anthropic.Name: &anthropic.ProviderOptions{}}, 1000)
+		require.False(t, ok)
+	})
+}
+
+func TestFoldSystemMessages(t *testing.T) {
+	t.Parallel()
+
+	msgs := foldSystemMessages([]fantasy.Message{
+		fantasy.NewSystemMessage("prefix"),
+		fantasy.NewSystemMessage("instructions"),
+		fantasy.NewUserMessage("hello"),
+		fantasy.NewUserMessage("again"),
+	})
+	require.Len(t, msgs, 2)
+	require.Equal(t, fantasy.MessageRoleUser, msgs[0].Role)
+	require.Equal(t, []fantasy.MessagePart{
+		fantasy.TextPart{Text: "prefix\n\ninstructions"},
+		fantasy.TextPart{Text: "hello"},
+	}, msgs[0].Content)
+	require.Equal(t, []fantasy.MessagePart{fantasy.TextPart{Text: "again"}}, msgs[1].Content)
+
+	msgs = foldSystemMessages([]fantasy.Message{fantasy.NewSystemMessage("only")})
+	require.Equal(t, []fantasy.Message{fantasy.NewUserMessage("only")}, msgs)
+}
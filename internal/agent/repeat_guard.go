@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// stateDependentTools are never treated as repeats: the same input can
+// give a different result each time, as when polling a background job
+// or re-running a command after a change.
+var stateDependentTools = map[string]bool{
+	tools.BashToolName:        true,
+	tools.JobOutputToolName:   true,
+	tools.DiagnosticsToolName: true,
+}
+
+// RepeatedToolCallMetadata is the metadata of the result returned in place
+// of a tool call that repeated the previous calls too many times. crush
+// stats counts results carrying it as loop interventions.
+type RepeatedToolCallMetadata struct {
+	RepeatedToolCall bool `json:"repeated_tool_call"`
+	// Count is how many identical calls were made in a row, including
+	// this one.
+	Count int `json:"count"`
+}
+
+// repeatGuard stops a run from executing the same tool call over and over.
+// It tracks the signature (tool name and input) of the latest call and how
+// many times in a row it was made; once that exceeds max, the call is
+// answered with a note instead of being run. Unlike hasRepeatedToolCalls,
+// which ends the turn, this gives the model a chance to change course.
+type repeatGuard struct {
+	max int
+
+	mu    sync.Mutex
+	last  string
+	count int
+}
+
+func newRepeatGuard(maxCalls int) *repeatGuard {
+	if maxCalls <= 0 {
+		maxCalls = config.DefaultMaxIdenticalToolCalls
+	}
+	return &repeatGuard{max: maxCalls}
+}
+
+// wrap returns tools with each entry wrapped so its calls go through g.
+func (g *repeatGuard) wrap(tools []fantasy.AgentTool) []fantasy.AgentTool {
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &guardedTool{inner: tool, guard: g}
+	}
+	return out
+}
+
+// observe records a call and returns how many identical calls were made in
+// a row, including this one, and whether it may run. Calls to
+// stateDependentTools always run and end the current streak.
+func (g *repeatGuard) observe(call fantasy.ToolCall) (int, bool) {
+	if stateDependentTools[call.Name] {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.last, g.count = "", 0
+		return 1, true
+	}
+
+	h := sha256.New()
+	io.WriteString(h, call.Name)
+	io.WriteString(h, "\x00")
+	io.WriteString(h, call.Input)
+	sig := hex.EncodeToString(h.Sum(nil))
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if sig == g.last {
+		g.count++
+	} else {
+		g.last = sig
+		g.count = 1
+	}
+	return g.count, g.count <= g.max
+}
+
+// guardedTool wraps a fantasy.AgentTool to consult a repeatGuard before
+// delegating to the inner tool.
+type guardedTool struct {
+	inner fantasy.AgentTool
+	guard *repeatGuard
+}
+
+func (t *guardedTool) Info() fantasy.ToolInfo {
+	return t.inner.Info()
+}
+
+func (t *guardedTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.inner.ProviderOptions()
+}
+
+func (t *guardedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.inner.SetProviderOptions(opts)
+}
+
+func (t *guardedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	count, ok := t.guard.observe(call)
+	if ok {
+		return t.inner.Run(ctx, call)
+	}
+	slog.Warn("Skipping repeated tool call", "tool", call.Name, "count", count)
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextErrorResponse(fmt.Sprintf(
+			"This exact %s call was already made %d times in a row, so it was not run again. Use the earlier result, or try a different tool or different arguments.",
+			call.Name, count-1,
+		)),
+		RepeatedToolCallMetadata{RepeatedToolCall: true, Count: count},
+	), nil
+}
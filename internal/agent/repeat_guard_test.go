@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepeatGuard(t *testing.T) {
+	t.Parallel()
+
+	inner := &fakeTool{name: "view", resp: fantasy.NewTextResponse("ok")}
+	tool := newRepeatGuard(2).wrap([]fantasy.AgentTool{inner})[0]
+	run := func(input string) fantasy.ToolResponse {
+		inner.called = false
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{Name: "view", Input: input})
+		require.NoError(t, err)
+		return resp
+	}
+
+	run(`{"file_path":"main.go"}`)
+	require.True(t, inner.called)
+	run(`{"file_path":"main.go"}`)
+	require.True(t, inner.called)
+
+	resp := run(`{"file_path":"main.go"}`)
+	require.False(t, inner.called, "third identical call should not run")
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "already made 2 times in a row")
+	var meta RepeatedToolCallMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, RepeatedToolCallMetadata{RepeatedToolCall: true, Count: 3}, meta)
+
+	// A different call resets the streak.
+	run(`{"file_path":"go.mod"}`)
+	require.True(t, inner.called)
+	run(`{"file_path":"main.go"}`)
+	require.True(t, inner.called)
+}
+
+func TestRepeatGuardDefault(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 3, newRepeatGuard(0).max)
+}
+
+func TestRepeatGuardStateDependentTools(t *testing.T) {
+	t.Parallel()
+
+	guard := newRepeatGuard(1)
+	poll := &fakeTool{name: "job_output", resp: fantasy.NewTextResponse("running")}
+	view := &fakeTool{name: "view", resp: fantasy.NewTextResponse("ok")}
+	wrapped := guard.wrap([]fantasy.AgentTool{poll, view})
+
+	// Polling a background job repeats the same input on purpose.
+	for range 3 {
+		poll.called = false
+		resp, err := wrapped[0].Run(t.Context(), fantasy.ToolCall{Name: "job_output", Input: `{"shell_id":"1"}`})
+		require.NoError(t, err)
+		require.True(t, poll.called)
+		require.False(t, resp.IsError)
+	}
+
+	// A state-dependent call also ends a streak of other calls.
+	_, err := wrapped[1].Run(t.Context(), fantasy.ToolCall{Name: "view", Input: `{"file_path":"main.go"}`})
+	require.NoError(t, err)
+	_, err = wrapped[0].Run(t.Context(), fantasy.ToolCall{Name: "job_output", Input: `{"shell_id":"1"}`})
+	require.NoError(t, err)
+	view.called = false
+	_, err = wrapped[1].Run(t.Context(), fantasy.ToolCall{Name: "view", Input: `{"file_path":"main.go"}`})
+	require.NoError(t, err)
+	require.True(t, view.called)
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/secrets"
+	"github.com/tidwall/sjson"
+)
+
+// SecretsMaskedMetadata is added to the metadata of a tool result, under
+// the "secrets_masked" key, when secrets were masked in it.
+type SecretsMaskedMetadata struct {
+	// Kinds lists the kinds of secrets that were found, such as
+	// aws_access_key or private_key.
+	Kinds []string `json:"kinds"`
+}
+
+// scrubbedTool wraps a fantasy.AgentTool to mask secrets in its responses
+// before they are stored or sent to the provider.
+type scrubbedTool struct {
+	inner    fantasy.AgentTool
+	scrubber *secrets.Scrubber
+}
+
+// wrapToolsWithScrubber returns a tool slice with each entry wrapped in a
+// scrubbedTool. Returns the original slice unchanged when scrubber is nil.
+func wrapToolsWithScrubber(tools []fantasy.AgentTool, scrubber *secrets.Scrubber) []fantasy.AgentTool {
+	if scrubber == nil {
+		return tools
+	}
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &scrubbedTool{inner: tool, scrubber: scrubber}
+	}
+	return out
+}
+
+func (s *scrubbedTool) unwrap() fantasy.AgentTool {
+	return s.inner
+}
+
+func (s *scrubbedTool) Info() fantasy.ToolInfo {
+	return s.inner.Info()
+}
+
+func (s *scrubbedTool) ProviderOptions() fantasy.ProviderOptions {
+	return s.inner.ProviderOptions()
+}
+
+func (s *scrubbedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	s.inner.SetProviderOptions(opts)
+}
+
+func (s *scrubbedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := s.inner.Run(ctx, call)
+	if err != nil {
+		return resp, err
+	}
+
+	content, contentKinds := s.scrubber.Scrub(resp.Content)
+	// Metadata often repeats the output, e.g. for bash.
+	metadata, metadataKinds := s.scrubber.Scrub(resp.Metadata)
+	if len(contentKinds) == 0 && len(metadataKinds) == 0 {
+		return resp, nil
+	}
+	resp.Content = content
+	resp.Metadata = metadata
+
+	kinds := slices.Compact(slices.Sorted(slices.Values(append(contentKinds, metadataKinds...))))
+	slog.Warn("Masked secrets in tool output", "tool", call.Name, "kinds", kinds)
+	data, err := json.Marshal(SecretsMaskedMetadata{Kinds: kinds})
+	if err != nil {
+		return resp, nil
+	}
+	existing := resp.Metadata
+	if existing == "" {
+		existing = "{}"
+	}
+	if merged, err := sjson.SetRaw(existing, "secrets_masked", string(data)); err == nil {
+		resp.Metadata = merged
+	}
+	return resp, nil
+}
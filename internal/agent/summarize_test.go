@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// callRecordingModel answers like finishStreamModel and records the calls
+// it receives.
+type callRecordingModel struct {
+	finishStreamModel
+
+	mu    sync.Mutex
+	calls []fantasy.Call
+}
+
+func (m *callRecordingModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+	return m.finishStreamModel.Stream(ctx, call)
+}
+
+func TestSummarizeModelAndMaxTokens(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	large := &callRecordingModel{finishStreamModel: finishStreamModel{text: "large summary"}}
+	small := &callRecordingModel{finishStreamModel: finishStreamModel{text: "small summary"}}
+	agent := NewSessionAgent(SessionAgentOptions{
+		LargeModel:         Model{Model: large},
+		SmallModel:         Model{Model: small},
+		SummarizeModel:     config.SelectedModelTypeSmall,
+		SummarizeMaxTokens: 500,
+		IsYolo:             true,
+		Sessions:           env.sessions,
+		Messages:           env.messages,
+	})
+
+	ctx := t.Context()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+	_, err = env.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, agent.Summarize(ctx, sess.ID, nil))
+
+	require.Empty(t, large.calls)
+	require.Len(t, small.calls, 1)
+	call := small.calls[0]
+	require.NotNil(t, call.MaxOutputTokens)
+	require.Equal(t, int64(500), *call.MaxOutputTokens)
+	last := call.Prompt[len(call.Prompt)-1]
+	text, ok := fantasy.AsMessagePart[fantasy.TextPart](last.Content[0])
+	require.True(t, ok)
+	require.Contains(t, text.Text, "about 500 tokens")
+
+	updated, err := env.sessions.Get(ctx, sess.ID)
+	require.NoError(t, err)
+	summary, err := env.messages.Get(ctx, updated.SummaryMessageID)
+	require.NoError(t, err)
+	require.Equal(t, "small summary", summary.Content().Text)
+}
+
+func TestSummaryModelOverridesSmall(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	small := &callRecordingModel{finishStreamModel: finishStreamModel{text: "small summary"}}
+	summary := &callRecordingModel{finishStreamModel: finishStreamModel{text: "own summary"}}
+	agent := NewSessionAgent(SessionAgentOptions{
+		LargeModel:     Model{Model: &finishStreamModel{text: "large summary"}},
+		SmallModel:     Model{Model: small},
+		SummarizeModel: config.SelectedModelTypeSmall,
+		SummaryModel:   Model{Model: summary},
+		IsYolo:         true,
+		Sessions:       env.sessions,
+		Messages:       env.messages,
+	})
+
+	ctx := t.Context()
+	sess, err := env.sessions.Create(ctx, "test")
+	require.NoError(t, err)
+	_, err = env.messages.Create(ctx, sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "hello"}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, agent.Summarize(ctx, sess.ID, nil))
+	require.Empty(t, small.calls)
+	require.Len(t, summary.calls, 1)
+
+	agent.SetSummaryModel(Model{})
+	require.NoError(t, agent.Summarize(ctx, sess.ID, nil))
+	require.Len(t, small.calls, 1)
+}
+
+func TestBuildSummaryModel(t *testing.T) {
+	t.Parallel()
+
+	const providerID = "test-provider"
+	providerCfg := config.ProviderConfig{
+		ID:      providerID,
+		Type:    catwalk.TypeOpenAICompat,
+		BaseURL: "http://localhost:1",
+		APIKey:  "test",
+		Models:  []catwalk.Model{{ID: "summarizer", ContextWindow: 1000}},
+	}
+	build := func(model string) (Model, error) {
+		env := testEnv(t)
+		coord := newTestCoordinator(t, env, providerID, providerCfg)
+		coord.cfg.Config().Options.Summarize = &config.SummarizeOptions{Model: model}
+		return coord.buildSummaryModel(t.Context())
+	}
+
+	model, err := build("large")
+	require.NoError(t, err)
+	require.Nil(t, model.Model)
+
+	model, err = build(providerID + "/summarizer")
+	require.NoError(t, err)
+	require.NotNil(t, model.Model)
+	require.Equal(t, "summarizer", model.ModelCfg.Model)
+	require.Equal(t, int64(1000), model.CatwalkCfg.ContextWindow)
+
+	_, err = build("missing/summarizer")
+	require.ErrorIs(t, err, errSummaryModelProviderNotConfigured)
+
+	_, err = build(providerID + "/missing")
+	require.ErrorIs(t, err, errSummaryModelNotFound)
+}
+
+func TestBuildSummaryPromptLength(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, buildSummaryPrompt(nil, 0), "tokens")
+	require.Contains(t, buildSummaryPrompt(nil, 2000), "about 2000 tokens")
+}
@@ -409,7 +409,8 @@ If a skill mentions scripts, references, or assets, they live in the same folder
 </skills_usage>
 {{end}}
 
-{{if .ContextFiles}}
+{{if .InlineContext}}{{template "context" .}}{{end}}
+{{define "context"}}{{if .ContextFiles}}
 # Project-Specific Context
 Make sure to follow the instructions in the context below.
 <project_context>
@@ -431,4 +432,4 @@ The following is personal content added by the user that they'd like you to foll
 </file>
 {{end}}
 </user_preferences>
-{{end}}
+{{end}}{{end}}
\ No newline at end of file
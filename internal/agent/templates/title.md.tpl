@@ -2,7 +2,10 @@ You will generate a short title based on the first message a user begins a conve
 
 <rules>
 - Keep the title in the same language that the user wrote their message in.
-- Ensure it is not more than 50 characters long.
+- Ensure it is not more than {{ .MaxLength }} characters long.
+{{- if .MaxWords }}
+- Use at most {{ .MaxWords }} words.
+{{- end }}
 - The title should be a summary of the user's message.
 - It should be one line long.
 - Do not use quotes or colons.
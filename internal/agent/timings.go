@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TimingReport attributes the wall-clock time of a run to its phases. The
+// per-phase durations are summed over all steps of the run.
+type TimingReport struct {
+	// TimeToFirstToken is the time from the start of the run until the
+	// model produced its first text, reasoning or tool call token.
+	TimeToFirstToken time.Duration `json:"time_to_first_token"`
+	// Waiting is the time spent waiting for the model to start responding.
+	Waiting time.Duration `json:"waiting"`
+	// Streaming is the time from the first token of a step until the
+	// model finished responding.
+	Streaming time.Duration `json:"streaming"`
+	// Tools is the time spent executing tool calls.
+	Tools time.Duration `json:"tools"`
+	// Total is the duration of the whole run.
+	Total time.Duration `json:"total"`
+	// Steps is the number of model requests made.
+	Steps int `json:"steps"`
+}
+
+// Timings records a [TimingReport] for the run whose context carries it.
+// Attach it with [WithTimings]. Only the first session that runs with the
+// context is recorded, so sub-agents started by tools don't count twice:
+// their time is already part of Tools.
+type Timings struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	sessionID string
+	report    TimingReport
+
+	runStart       time.Time
+	stepStart      time.Time
+	stepFirstToken time.Time
+	streamEnd      time.Time
+}
+
+type timingsContextKey struct{}
+
+// WithTimings returns a copy of ctx that makes the agent record phase
+// timings into t.
+func WithTimings(ctx context.Context, t *Timings) context.Context {
+	return context.WithValue(ctx, timingsContextKey{}, t)
+}
+
+// timingsFor returns the recorder in ctx if it belongs to sessionID. The
+// result may be nil; all recording methods are no-ops on a nil receiver.
+func timingsFor(ctx context.Context, sessionID string) *Timings {
+	t, _ := ctx.Value(timingsContextKey{}).(*Timings)
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID == "" {
+		t.sessionID = sessionID
+	}
+	if t.sessionID != sessionID {
+		return nil
+	}
+	return t
+}
+
+// Report returns the timings recorded so far.
+func (t *Timings) Report() TimingReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.report
+}
+
+func (t *Timings) record(fn func(now time.Time)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	fn(now())
+}
+
+func (t *Timings) runStarted() {
+	t.record(func(now time.Time) {
+		// Queued prompts run as recursive turns; keep the first start.
+		if t.runStart.IsZero() {
+			t.runStart = now
+		}
+	})
+}
+
+func (t *Timings) runFinished() {
+	t.record(func(now time.Time) {
+		t.report.Total = now.Sub(t.runStart)
+	})
+}
+
+func (t *Timings) stepStarted() {
+	t.record(func(now time.Time) {
+		t.stepStart = now
+		t.stepFirstToken = time.Time{}
+		t.streamEnd = time.Time{}
+	})
+}
+
+func (t *Timings) firstToken() {
+	t.record(func(now time.Time) {
+		if !t.stepFirstToken.IsZero() {
+			return
+		}
+		t.stepFirstToken = now
+		t.report.Waiting += now.Sub(t.stepStart)
+		if t.report.TimeToFirstToken == 0 {
+			t.report.TimeToFirstToken = now.Sub(t.runStart)
+		}
+	})
+}
+
+func (t *Timings) streamFinished() {
+	t.record(func(now time.Time) {
+		if !t.streamEnd.IsZero() {
+			return
+		}
+		t.streamEnd = now
+		if t.stepFirstToken.IsZero() {
+			t.report.Waiting += now.Sub(t.stepStart)
+		} else {
+			t.report.Streaming += now.Sub(t.stepFirstToken)
+		}
+	})
+}
+
+func (t *Timings) stepFinished() {
+	t.record(func(now time.Time) {
+		t.report.Steps++
+		if !t.streamEnd.IsZero() {
+			t.report.Tools += now.Sub(t.streamEnd)
+		}
+	})
+}
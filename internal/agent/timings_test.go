@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	t.Parallel()
+
+	t.Run("attributes time to phases", func(t *testing.T) {
+		t.Parallel()
+
+		clock := time.Unix(0, 0)
+		advance := func(d time.Duration) { clock = clock.Add(d) }
+		timings := &Timings{now: func() time.Time { return clock }}
+
+		rec := timingsFor(WithTimings(t.Context(), timings), "session")
+		require.NotNil(t, rec)
+
+		rec.runStarted()
+
+		// Step one: wait, stream, then run a tool.
+		advance(100 * time.Millisecond)
+		rec.stepStarted()
+		advance(300 * time.Millisecond)
+		rec.firstToken()
+		advance(time.Second)
+		rec.firstToken() // Later tokens don't move the markers.
+		advance(time.Second)
+		rec.streamFinished()
+		advance(500 * time.Millisecond)
+		rec.stepFinished()
+
+		// Step two: no tools.
+		rec.stepStarted()
+		advance(200 * time.Millisecond)
+		rec.firstToken()
+		advance(time.Second)
+		rec.streamFinished()
+		rec.stepFinished()
+		rec.runFinished()
+
+		require.Equal(t, TimingReport{
+			TimeToFirstToken: 400 * time.Millisecond,
+			Waiting:          500 * time.Millisecond,
+			Streaming:        3 * time.Second,
+			Tools:            500 * time.Millisecond,
+			Total:            4100 * time.Millisecond,
+			Steps:            2,
+		}, timings.Report())
+	})
+
+	t.Run("records only the first session", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := WithTimings(t.Context(), &Timings{})
+		require.NotNil(t, timingsFor(ctx, "parent"))
+		require.Nil(t, timingsFor(ctx, "sub-agent"))
+		require.NotNil(t, timingsFor(ctx, "parent"))
+	})
+
+	t.Run("is a no-op without a recorder", func(t *testing.T) {
+		t.Parallel()
+
+		rec := timingsFor(context.Background(), "session")
+		require.Nil(t, rec)
+		rec.runStarted()
+		rec.stepStarted()
+		rec.firstToken()
+		rec.streamFinished()
+		rec.stepFinished()
+		rec.runFinished()
+	})
+}
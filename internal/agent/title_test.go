@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTitleMinMessages(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	model := func(text string) Model {
+		return Model{
+			Model:      &finishStreamModel{text: text},
+			CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000},
+		}
+	}
+	sa := NewSessionAgent(SessionAgentOptions{
+		LargeModel:        model("answer"),
+		SmallModel:        model("Generated Title"),
+		SystemPrompt:      "system",
+		TitleTrigger:      config.TitleTriggerFirstChunk,
+		TitleMinMessages:  2,
+		SmallModelLimiter: newRequestLimiter(1),
+		IsYolo:            true,
+		Sessions:          env.sessions,
+		Messages:          env.messages,
+	})
+	sess, err := env.sessions.Create(t.Context(), "session")
+	require.NoError(t, err)
+
+	_, err = sa.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hi"})
+	require.NoError(t, err)
+	sess, err = env.sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "session", sess.Title, "one message is not enough for a title")
+
+	_, err = sa.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "fix the bug"})
+	require.NoError(t, err)
+	sess, err = env.sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Generated Title", sess.Title)
+}
+
+func TestRequestLimiter(t *testing.T) {
+	t.Parallel()
+
+	l := newRequestLimiter(1)
+	require.NoError(t, l.acquire(t.Context()))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.acquire(ctx), context.DeadlineExceeded)
+
+	l.release()
+	require.NoError(t, l.acquire(t.Context()))
+	l.release()
+
+	var unlimited requestLimiter
+	require.NoError(t, unlimited.acquire(t.Context()))
+	unlimited.release()
+}
+
+func TestBuildTitlePrompt(t *testing.T) {
+	t.Parallel()
+
+	prompt, err := buildTitlePrompt("", 0, config.DefaultTitleMaxLength)
+	require.NoError(t, err)
+	require.Equal(t, `You will generate a short title based on the first message a user begins a conversation with.
+
+<rules>
+- Keep the title in the same language that the user wrote their message in.
+- Ensure it is not more than 50 characters long.
+- The title should be a summary of the user's message.
+- It should be one line long.
+- Do not use quotes or colons.
+- The entire text you return will be used as the title.
+- Never return anything that is more than one sentence (one line) long.
+</rules>
+`, prompt)
+
+	prompt, err = buildTitlePrompt("", 5, 30)
+	require.NoError(t, err)
+	require.Contains(t, prompt, "- Ensure it is not more than 30 characters long.\n- Use at most 5 words.\n- The title")
+
+	prompt, err = buildTitlePrompt("Write the title in German.", 5, 30)
+	require.NoError(t, err)
+	require.Equal(t, "Write the title in German.", prompt)
+}
+
+func TestCleanTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		in       string
+		maxWords int
+		want     string
+	}{
+		{"plain", "Fix login bug", 0, "Fix login bug"},
+		{"quotes", `"Fix login bug"`, 0, "Fix login bug"},
+		{"nested quotes", "**\"Fix login bug\"**", 0, "Fix login bug"},
+		{"curly quotes", "“Corriger le bug de connexion”", 0, "Corriger le bug de connexion"},
+		{"label and period", "Title: Fix login bug.", 0, "Fix login bug"},
+		{"think tags", "<think>hmm</think>\n  Fix   login\nbug", 0, "Fix login bug"},
+		{"question kept", "Why does login fail?", 0, "Why does login fail?"},
+		{"word limit", "Fix the flaky login bug in the auth service", 4, "Fix the flaky login"},
+		{"lone quote", `"`, 0, `"`},
+		{"too long", "Investigate the intermittent login failures reported by enterprise users", 0, "Investigate the intermittent login failures repor…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, cleanTitle(tt.in, tt.maxWords, config.DefaultTitleMaxLength))
+		})
+	}
+}
+
+func TestGenerateTitleOptions(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	small := &callRecordingModel{finishStreamModel: finishStreamModel{text: `"Den Login-Fehler beheben und testen."`}}
+	sa := NewSessionAgent(SessionAgentOptions{
+		LargeModel:        Model{Model: &finishStreamModel{text: "answer"}},
+		SmallModel:        Model{Model: small},
+		SystemPrompt:      "system",
+		TitlePrompt:       "Schreibe den Titel auf Deutsch.",
+		TitleMaxWords:     4,
+		SmallModelLimiter: newRequestLimiter(1),
+		IsYolo:            true,
+		Sessions:          env.sessions,
+		Messages:          env.messages,
+	})
+	sess, err := env.sessions.Create(t.Context(), "session")
+	require.NoError(t, err)
+
+	sa.GenerateTitle(t.Context(), sess.ID, "fix the login bug")
+	sess, err = env.sessions.Get(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Den Login-Fehler beheben und", sess.Title)
+
+	require.Len(t, small.calls, 1)
+	system, ok := small.calls[0].Prompt[0].Content[0].(fantasy.TextPart)
+	require.True(t, ok)
+	require.Equal(t, "Schreibe den Titel auf Deutsch.\n /no_think", system.Text)
+}
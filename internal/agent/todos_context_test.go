@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodosContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty when everything is done", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, todosContext(nil))
+		require.Empty(t, todosContext([]session.Todo{{Content: "done", Status: session.TodoStatusCompleted}}))
+	})
+
+	t.Run("lists every item with its status", func(t *testing.T) {
+		t.Parallel()
+		got := todosContext([]session.Todo{
+			{Content: "read the code", Status: session.TodoStatusCompleted},
+			{Content: "write\nthe fix", Status: session.TodoStatusInProgress},
+			{Content: "add tests", Status: session.TodoStatusPending},
+		})
+		require.Contains(t, got, "- [completed] read the code\n")
+		require.Contains(t, got, "- [in_progress] write the fix\n")
+		require.Contains(t, got, "- [pending] add tests\n")
+		require.True(t, strings.HasPrefix(got, "<todos>"))
+		require.True(t, strings.HasSuffix(got, "</todos>"))
+	})
+
+	t.Run("is bounded", func(t *testing.T) {
+		t.Parallel()
+		var todos []session.Todo
+		for i := range maxContextTodos + 5 {
+			todos = append(todos, session.Todo{Content: fmt.Sprintf("item %d ", i) + strings.Repeat("x", 500), Status: session.TodoStatusPending})
+		}
+		got := todosContext(todos)
+		require.Equal(t, maxContextTodos, strings.Count(got, "- [pending]"))
+		require.Contains(t, got, "... and 5 more\n")
+		require.NotContains(t, got, strings.Repeat("x", maxContextTodoLength))
+	})
+}
+
+func TestRunTodosInContext(t *testing.T) {
+	t.Parallel()
+
+	env := testEnv(t)
+	model := &callRecordingModel{finishStreamModel: finishStreamModel{text: "answer"}}
+	agent := NewSessionAgent(SessionAgentOptions{
+		LargeModel:     Model{Model: model},
+		SmallModel:     Model{Model: &finishStreamModel{text: "title"}},
+		IsSubAgent:     true,
+		TodosInContext: true,
+		IsYolo:         true,
+		Sessions:       env.sessions,
+		Messages:       env.messages,
+	})
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	sess.Todos = []session.Todo{{Content: "write the fix", Status: session.TodoStatusInProgress}}
+	_, err = env.sessions.Save(t.Context(), sess)
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello", NonInteractive: true})
+	require.NoError(t, err)
+	require.Len(t, model.calls, 1)
+
+	var system []string
+	for _, msg := range model.calls[0].Prompt {
+		if msg.Role != fantasy.MessageRoleSystem {
+			continue
+		}
+		for _, part := range msg.Content {
+			if text, ok := fantasy.AsMessagePart[fantasy.TextPart](part); ok {
+				system = append(system, text.Text)
+			}
+		}
+	}
+	require.Contains(t, strings.Join(system, "\n"), "- [in_progress] write the fix")
+}
@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// cacheableTools only read the working tree, so within a run the same call
+// gives the same result until something else changes the files.
+var cacheableTools = map[string]bool{
+	tools.ViewToolName: true,
+	tools.LSToolName:   true,
+	tools.GrepToolName: true,
+	tools.GlobToolName: true,
+}
+
+// toolResultCache lets identical read-only tool calls of a run, such as
+// the same view in parallel tool calls, share the result of the first one.
+// Any other tool may change the files, so a call to one clears the cache.
+// A nil toolResultCache caches nothing.
+type toolResultCache struct {
+	mu      sync.Mutex
+	entries map[string]*toolCacheEntry
+}
+
+// toolCacheEntry is a cached result. done is closed once resp is set, so
+// a parallel identical call waits for the first instead of running too.
+type toolCacheEntry struct {
+	done chan struct{}
+	resp fantasy.ToolResponse
+	ok   bool
+}
+
+func newToolResultCache(enabled bool) *toolResultCache {
+	if !enabled {
+		return nil
+	}
+	return &toolResultCache{entries: map[string]*toolCacheEntry{}}
+}
+
+// wrap returns tools with each entry wrapped so its calls go through c.
+func (c *toolResultCache) wrap(tools []fantasy.AgentTool) []fantasy.AgentTool {
+	if c == nil {
+		return tools
+	}
+	out := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		out[i] = &cachedTool{inner: tool, cache: c}
+	}
+	return out
+}
+
+// toolCacheKey returns the key of call: the tool name and the input with
+// its keys sorted and whitespace dropped, so inputs that only differ in
+// formatting share an entry.
+func toolCacheKey(call fantasy.ToolCall) string {
+	input := []byte(call.Input)
+	var v any
+	if err := json.Unmarshal(input, &v); err == nil {
+		if normalized, err := json.Marshal(v); err == nil {
+			input = normalized
+		}
+	}
+	return call.Name + "\x00" + string(bytes.TrimSpace(input))
+}
+
+// entry returns the entry for key and whether the caller must fill it.
+func (c *toolResultCache) entry(key string) (*toolCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		return e, false
+	}
+	e := &toolCacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	return e, true
+}
+
+func (c *toolResultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// forget drops e unless the cache was cleared since it was added.
+func (c *toolResultCache) forget(key string, e *toolCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries[key] == e {
+		delete(c.entries, key)
+	}
+}
+
+// cachedTool wraps a fantasy.AgentTool to share results through a
+// toolResultCache.
+type cachedTool struct {
+	inner fantasy.AgentTool
+	cache *toolResultCache
+}
+
+func (t *cachedTool) Info() fantasy.ToolInfo {
+	return t.inner.Info()
+}
+
+func (t *cachedTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.inner.ProviderOptions()
+}
+
+func (t *cachedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.inner.SetProviderOptions(opts)
+}
+
+func (t *cachedTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if !cacheableTools[call.Name] {
+		t.cache.clear()
+		return t.inner.Run(ctx, call)
+	}
+
+	key := toolCacheKey(call)
+	for {
+		e, fill := t.cache.entry(key)
+		if fill {
+			resp, err := t.inner.Run(ctx, call)
+			// Errors may be transient, such as a timeout, so they are
+			// not shared; a waiting call then runs the tool itself.
+			if err != nil || resp.IsError {
+				t.cache.forget(key, e)
+			} else {
+				e.resp, e.ok = resp, true
+			}
+			close(e.done)
+			return resp, err
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return fantasy.ToolResponse{}, ctx.Err()
+		}
+		if e.ok {
+			slog.Debug("Reusing the result of an identical tool call", "tool", call.Name)
+			return e.resp, nil
+		}
+	}
+}
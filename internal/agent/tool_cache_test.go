@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolResultCache(t *testing.T) {
+	t.Parallel()
+
+	view := &fakeTool{name: "view", resp: fantasy.NewTextResponse("ok")}
+	edit := &fakeTool{name: "edit", resp: fantasy.NewTextResponse("edited")}
+	wrapped := newToolResultCache(true).wrap([]fantasy.AgentTool{view, edit})
+	run := func(tool fantasy.AgentTool, inner *fakeTool, input string) fantasy.ToolResponse {
+		inner.called = false
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{Name: inner.name, Input: input})
+		require.NoError(t, err)
+		return resp
+	}
+
+	run(wrapped[0], view, `{"file_path":"main.go","limit":10}`)
+	require.True(t, view.called)
+	resp := run(wrapped[0], view, `{"limit": 10, "file_path": "main.go"}`)
+	require.False(t, view.called, "identical call should reuse the result")
+	require.Equal(t, "ok", resp.Content)
+
+	run(wrapped[0], view, `{"file_path":"go.mod"}`)
+	require.True(t, view.called, "a different call should run")
+
+	// Any other tool may change the files.
+	run(wrapped[1], edit, `{"file_path":"main.go"}`)
+	require.True(t, edit.called)
+	run(wrapped[0], view, `{"file_path":"main.go","limit":10}`)
+	require.True(t, view.called, "call after an edit should run")
+
+	// Errors are not cached.
+	view.resp = fantasy.NewTextErrorResponse("timed out")
+	run(wrapped[0], view, `{"file_path":"README.md"}`)
+	require.True(t, view.called)
+	view.resp = fantasy.NewTextResponse("ok")
+	resp = run(wrapped[0], view, `{"file_path":"README.md"}`)
+	require.True(t, view.called, "call after an error should run")
+	require.False(t, resp.IsError)
+}
+
+func TestToolResultCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	tools := []fantasy.AgentTool{&fakeTool{name: "view"}}
+	require.Equal(t, tools, newToolResultCache(false).wrap(tools))
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"charm.land/fantasy"
+	"charm.land/fantasy/jsonrepair"
+	"github.com/charmbracelet/crush/internal/message"
+)
+
+const (
+	invalidToolInputMessage   = "Tool call failed: arguments were not valid JSON. Please check your tool call format and try again."
+	truncatedToolInputMessage = "Tool call failed: the arguments ended before the JSON was complete, so the tool was not run. Please send the complete tool call again."
+)
+
+// truncatedToolInputError reports tool call arguments that stop partway
+// through a JSON value, usually because the stream was cut off.
+type truncatedToolInputError struct {
+	ToolName string
+	Length   int
+}
+
+func (e *truncatedToolInputError) Error() string {
+	return fmt.Sprintf("arguments for %s end after %d bytes, before the JSON is complete", e.ToolName, e.Length)
+}
+
+// isTruncatedJSON reports whether input is the start of a JSON value that
+// ends too early.
+func isTruncatedJSON(input string) bool {
+	var v any
+	err := json.NewDecoder(strings.NewReader(input)).Decode(&v)
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// repairToolCall is the [fantasy.RepairToolCallFunction] for agent runs.
+// Truncated arguments are never repaired: closing the JSON would run the
+// tool with a partial command or path. Other malformed input gets the
+// jsonrepair pass fantasy applies when no repair function is set.
+func repairToolCall(_ context.Context, opts fantasy.ToolCallRepairOptions) (*fantasy.ToolCallContent, error) {
+	toolCall := opts.OriginalToolCall
+	if isTruncatedJSON(toolCall.Input) {
+		return nil, &truncatedToolInputError{ToolName: toolCall.ToolName, Length: len(toolCall.Input)}
+	}
+	repaired, err := jsonrepair.RepairJSON(toolCall.Input)
+	if err != nil {
+		return nil, err
+	}
+	toolCall.Input = repaired
+	return &toolCall, nil
+}
+
+// toolInputErrorMessage returns the tool result sent back to the model in
+// place of running a tool whose arguments are not valid JSON.
+func toolInputErrorMessage(input string) string {
+	if isTruncatedJSON(input) {
+		return truncatedToolInputMessage
+	}
+	return invalidToolInputMessage
+}
+
+// finishIncompleteToolCalls closes tool calls whose input started
+// streaming but never completed within the step. The model gets an error
+// result for each so the conversation stays valid.
+func (a *sessionAgent) finishIncompleteToolCalls(ctx context.Context, assistant *message.Message) error {
+	for _, tc := range assistant.ToolCalls() {
+		if tc.Finished {
+			continue
+		}
+		slog.Warn("Tool call input ended before it was complete", "tool", tc.Name, "id", tc.ID)
+		tc.Finished = true
+		tc.Input = "{}"
+		assistant.AddToolCall(tc)
+		if _, err := a.messages.Create(ctx, assistant.SessionID, message.CreateMessageParams{
+			Role: message.Tool,
+			Parts: []message.ContentPart{
+				message.ToolResult{
+					ToolCallID: tc.ID,
+					Name:       tc.Name,
+					Content:    truncatedToolInputMessage,
+					IsError:    true,
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
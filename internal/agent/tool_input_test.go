@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// truncatedToolCallModel streams a tool call whose input is cut off
+// mid-JSON on the first request and plain text on later ones. With
+// emitCall unset the stream ends without ever completing the tool call.
+type truncatedToolCallModel struct {
+	deltas   []string
+	emitCall bool
+	calls    atomic.Int32
+}
+
+func (m *truncatedToolCallModel) Provider() string { return "fake" }
+func (m *truncatedToolCallModel) Model() string    { return "fake-model" }
+
+func (m *truncatedToolCallModel) Generate(context.Context, fantasy.Call) (*fantasy.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *truncatedToolCallModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	first := m.calls.Add(1) == 1
+	return func(yield func(fantasy.StreamPart) bool) {
+		if !first {
+			for _, part := range []fantasy.StreamPart{
+				{Type: fantasy.StreamPartTypeTextStart, ID: "t"},
+				{Type: fantasy.StreamPartTypeTextDelta, ID: "t", Delta: "done"},
+				{Type: fantasy.StreamPartTypeTextEnd, ID: "t"},
+				{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+			} {
+				if !yield(part) {
+					return
+				}
+			}
+			return
+		}
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeToolInputStart, ID: "call-1", ToolCallName: "echo"}) {
+			return
+		}
+		var input string
+		for _, delta := range m.deltas {
+			input += delta
+			if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeToolInputDelta, ID: "call-1", Delta: delta}) {
+				return
+			}
+		}
+		finish := fantasy.FinishReasonStop
+		if m.emitCall {
+			finish = fantasy.FinishReasonToolCalls
+			if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeToolInputEnd, ID: "call-1"}) {
+				return
+			}
+			if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeToolCall, ID: "call-1", ToolCallName: "echo", ToolCallInput: input}) {
+				return
+			}
+		}
+		yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeFinish, FinishReason: finish})
+	}, nil
+}
+
+func (m *truncatedToolCallModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *truncatedToolCallModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+type echoParams struct {
+	Text string `json:"text"`
+}
+
+func TestTruncatedToolCallInput(t *testing.T) {
+	t.Parallel()
+
+	run := func(t *testing.T, model *truncatedToolCallModel) (int32, message.ToolResult) {
+		t.Helper()
+
+		var ran atomic.Int32
+		echo := fantasy.NewAgentTool("echo", "Echo text", func(_ context.Context, params echoParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			ran.Add(1)
+			return fantasy.NewTextResponse(params.Text), nil
+		})
+
+		env := testEnv(t)
+		// Title generation runs on the small model; keep it off the
+		// scripted one.
+		sa := testSessionAgent(env, model, &finishStreamModel{text: "title"}, "system", echo)
+		sess, err := env.sessions.Create(t.Context(), "session")
+		require.NoError(t, err)
+
+		_, err = sa.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "echo"})
+		require.NoError(t, err)
+
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		for _, msg := range msgs {
+			for _, tc := range msg.ToolCalls() {
+				require.True(t, tc.Finished)
+				require.Equal(t, "{}", tc.Input)
+			}
+			if msg.Role == message.Tool {
+				results := msg.ToolResults()
+				require.Len(t, results, 1)
+				return ran.Load(), results[0]
+			}
+		}
+		t.Fatal("no tool result recorded")
+		return 0, message.ToolResult{}
+	}
+
+	t.Run("does not run a tool with cut off arguments", func(t *testing.T) {
+		t.Parallel()
+
+		ran, result := run(t, &truncatedToolCallModel{
+			deltas:   []string{`{"te`, `xt": "rm -rf /tmp/fo`},
+			emitCall: true,
+		})
+		require.Zero(t, ran)
+		require.True(t, result.IsError)
+		require.Equal(t, truncatedToolInputMessage, result.Content)
+	})
+
+	t.Run("closes a tool call the stream never finished", func(t *testing.T) {
+		t.Parallel()
+
+		ran, result := run(t, &truncatedToolCallModel{
+			deltas: []string{`{"text": "hel`},
+		})
+		require.Zero(t, ran)
+		require.True(t, result.IsError)
+		require.Equal(t, truncatedToolInputMessage, result.Content)
+	})
+}
+
+func TestRepairToolCall(t *testing.T) {
+	t.Parallel()
+
+	repair := func(input string) (*fantasy.ToolCallContent, error) {
+		return repairToolCall(t.Context(), fantasy.ToolCallRepairOptions{
+			OriginalToolCall: fantasy.ToolCallContent{ToolName: "bash", Input: input},
+		})
+	}
+
+	_, err := repair(`{"command": "ls`)
+	var truncated *truncatedToolInputError
+	require.ErrorAs(t, err, &truncated)
+	require.Equal(t, "bash", truncated.ToolName)
+
+	repaired, err := repair(`{"command": "ls",}`)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"command": "ls"}`, repaired.Input)
+}
@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+)
+
+// wrapStrictParams returns tools with each built-in tool wrapped so calls
+// whose input does not match its parameter schema are answered with the
+// problems instead of running. MCP tools are left as they are since their
+// servers validate their own input. With enabled false tools is returned
+// unchanged.
+func wrapStrictParams(agentTools []fantasy.AgentTool, enabled bool) []fantasy.AgentTool {
+	if !enabled {
+		return agentTools
+	}
+	out := make([]fantasy.AgentTool, len(agentTools))
+	for i, tool := range agentTools {
+		if isMCPTool(tool) {
+			out[i] = tool
+			continue
+		}
+		out[i] = &strictParamsTool{inner: tool}
+	}
+	return out
+}
+
+// toolWrapper is implemented by the tool wrappers the coordinator applies,
+// such as hookedTool and scrubbedTool, to give access to the wrapped tool.
+type toolWrapper interface {
+	unwrap() fantasy.AgentTool
+}
+
+// isMCPTool reports whether tool is an MCP tool, looking through the
+// wrappers around it.
+func isMCPTool(tool fantasy.AgentTool) bool {
+	for {
+		switch t := tool.(type) {
+		case *tools.Tool:
+			return true
+		case toolWrapper:
+			tool = t.unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// strictParamsTool wraps a fantasy.AgentTool to validate call inputs
+// against its parameter schema.
+type strictParamsTool struct {
+	inner fantasy.AgentTool
+}
+
+func (t *strictParamsTool) Info() fantasy.ToolInfo {
+	return t.inner.Info()
+}
+
+func (t *strictParamsTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.inner.ProviderOptions()
+}
+
+func (t *strictParamsTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.inner.SetProviderOptions(opts)
+}
+
+func (t *strictParamsTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	if problems := validateToolParams(t.inner.Info(), call.Input); len(problems) > 0 {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid parameters for %s:\n- %s", call.Name, strings.Join(problems, "\n- "))), nil
+	}
+	return t.inner.Run(ctx, call)
+}
+
+// validateToolParams checks input against the parameters of info and
+// returns one line per problem, each naming the parameter: unknown
+// parameters, missing required ones and values of the wrong type.
+// Nested objects are not checked.
+func validateToolParams(info fantasy.ToolInfo, input string) []string {
+	var args map[string]json.RawMessage
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		return []string{"the input must be a JSON object with the parameters as keys"}
+	}
+
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		prop, ok := info.Parameters[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown parameter %q; valid parameters are %s", name, strings.Join(slices.Sorted(maps.Keys(info.Parameters)), ", ")))
+			continue
+		}
+		schema, _ := prop.(map[string]any)
+		if problem := checkParamType(schema, args[name]); problem != "" {
+			problems = append(problems, fmt.Sprintf("parameter %q %s", name, problem))
+		}
+	}
+	for _, name := range info.Required {
+		if _, ok := args[name]; !ok {
+			problems = append(problems, fmt.Sprintf("missing required parameter %q", name))
+		}
+	}
+	return problems
+}
+
+// checkParamType returns what is wrong with value for schema, or "" when
+// it matches. A schema without a type accepts any value. For arrays the
+// type of each item is checked too.
+func checkParamType(schema map[string]any, value json.RawMessage) string {
+	want, _ := schema["type"].(string)
+	if want == "" {
+		return ""
+	}
+	got := jsonType(value)
+	if got == "null" {
+		return ""
+	}
+	if got != want && (want != "number" || got != "integer") {
+		return fmt.Sprintf("must be %s %s, got %s", article(want), want, got)
+	}
+	if want != "array" {
+		return ""
+	}
+	items, _ := schema["items"].(map[string]any)
+	var elems []json.RawMessage
+	_ = json.Unmarshal(value, &elems)
+	for i, elem := range elems {
+		if problem := checkParamType(items, elem); problem != "" {
+			return fmt.Sprintf("item %d %s", i, problem)
+		}
+	}
+	return ""
+}
+
+// jsonType returns the JSON schema type of value. Numbers without a
+// fraction or exponent are integers.
+func jsonType(value json.RawMessage) string {
+	s := strings.TrimSpace(string(value))
+	switch {
+	case s == "null":
+		return "null"
+	case s == "true" || s == "false":
+		return "boolean"
+	case strings.HasPrefix(s, `"`):
+		return "string"
+	case strings.HasPrefix(s, "["):
+		return "array"
+	case strings.HasPrefix(s, "{"):
+		return "object"
+	case strings.ContainsAny(s, ".eE"):
+		return "number"
+	}
+	return "integer"
+}
+
+func article(typ string) string {
+	if typ == "integer" || typ == "array" || typ == "object" {
+		return "an"
+	}
+	return "a"
+}
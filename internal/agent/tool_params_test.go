@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+type strictTestParams struct {
+	Path    string   `json:"path" description:"The path"`
+	Limit   int      `json:"limit,omitempty" description:"The limit"`
+	Ratio   float64  `json:"ratio,omitempty" description:"The ratio"`
+	Recurse bool     `json:"recurse,omitempty" description:"Whether to recurse"`
+	Globs   []string `json:"globs,omitempty" description:"The globs"`
+}
+
+func TestValidateToolParams(t *testing.T) {
+	t.Parallel()
+
+	info := fantasy.NewAgentTool("list", "List files", func(context.Context, strictTestParams, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		return fantasy.NewTextResponse("ok"), nil
+	}).Info()
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "valid", input: `{"path":"a","limit":3,"ratio":0.5,"recurse":true,"globs":["*.go"]}`},
+		{name: "integer for number", input: `{"path":"a","ratio":1}`},
+		{name: "null", input: `{"path":"a","limit":null}`},
+		{
+			name:  "unknown",
+			input: `{"path":"a","pth":"b"}`,
+			want:  []string{`unknown parameter "pth"; valid parameters are globs, limit, path, ratio, recurse`},
+		},
+		{
+			name:  "missing",
+			input: `{"limit":3}`,
+			want:  []string{`missing required parameter "path"`},
+		},
+		{
+			name:  "empty input",
+			input: "",
+			want:  []string{`missing required parameter "path"`},
+		},
+		{
+			name:  "wrong type",
+			input: `{"path":"a","limit":"3","recurse":"yes"}`,
+			want: []string{
+				`parameter "limit" must be an integer, got string`,
+				`parameter "recurse" must be a boolean, got string`,
+			},
+		},
+		{
+			name:  "fraction for integer",
+			input: `{"path":"a","limit":1.5}`,
+			want:  []string{`parameter "limit" must be an integer, got number`},
+		},
+		{
+			name:  "array item",
+			input: `{"path":"a","globs":["*.go",3]}`,
+			want:  []string{`parameter "globs" item 1 must be a string, got integer`},
+		},
+		{
+			name:  "not an object",
+			input: `["a"]`,
+			want:  []string{"the input must be a JSON object with the parameters as keys"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, validateToolParams(info, tt.input))
+		})
+	}
+}
+
+func TestStrictParamsTool(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	tool := fantasy.NewAgentTool("list", "List files", func(context.Context, strictTestParams, fantasy.ToolCall) (fantasy.ToolResponse, error) {
+		called = true
+		return fantasy.NewTextResponse("ok"), nil
+	})
+	wrapped := wrapStrictParams([]fantasy.AgentTool{tool}, true)[0]
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{Name: "list", Input: `{"path":"a","depth":2}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "invalid parameters for list:\n- unknown parameter \"depth\"")
+	require.False(t, called, "tool should not run on invalid input")
+
+	resp, err = wrapped.Run(t.Context(), fantasy.ToolCall{Name: "list", Input: `{"path":"a"}`})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Equal(t, "ok", resp.Content)
+	require.True(t, called)
+}
+
+func TestStrictParamsDisabled(t *testing.T) {
+	t.Parallel()
+
+	tools := []fantasy.AgentTool{&fakeTool{name: "view"}}
+	require.Equal(t, tools, wrapStrictParams(tools, false))
+}
+
+func TestStrictParamsSkipsMCPTools(t *testing.T) {
+	t.Parallel()
+
+	scrubber, err := secrets.New(nil)
+	require.NoError(t, err)
+	mcpTool := &tools.Tool{}
+	hooked := newHookedTool(mcpTool, nil)
+	scrubbed := wrapToolsWithScrubber([]fantasy.AgentTool{hooked}, scrubber)[0]
+	builtin := &fakeTool{name: "view"}
+
+	in := []fantasy.AgentTool{mcpTool, hooked, scrubbed, newHookedTool(builtin, nil)}
+	out := wrapStrictParams(in, true)
+	require.Same(t, in[0], out[0])
+	require.Same(t, in[1], out[1], "hooked MCP tool should be left alone")
+	require.Same(t, in[2], out[2], "scrubbed MCP tool should be left alone")
+	require.IsType(t, &strictParamsTool{}, out[3])
+}
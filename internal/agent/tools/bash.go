@@ -22,19 +22,21 @@ import (
 )
 
 type BashParams struct {
-	Description         string `json:"description" description:"A brief description of what the command does, try to keep it under 30 characters or so"`
-	Command             string `json:"command" description:"The command to execute"`
-	WorkingDir          string `json:"working_dir,omitempty" description:"The working directory to execute the command in (defaults to current directory)"`
-	RunInBackground     bool   `json:"run_in_background,omitempty" description:"Set to true (boolean) to run this command in the background. Use job_output to read the output later."`
-	AutoBackgroundAfter int    `json:"auto_background_after,omitempty" description:"Seconds to wait before automatically moving the command to a background job (default: 60)"`
+	Description         string   `json:"description" description:"A brief description of what the command does, try to keep it under 30 characters or so"`
+	Command             string   `json:"command" description:"The command to execute"`
+	WorkingDir          string   `json:"working_dir,omitempty" description:"The working directory to execute the command in (defaults to current directory)"`
+	Env                 []string `json:"env,omitempty" description:"Environment variables to set for this command only, as KEY=value pairs"`
+	RunInBackground     bool     `json:"run_in_background,omitempty" description:"Set to true (boolean) to run this command in the background. Use job_output to read the output later."`
+	AutoBackgroundAfter int      `json:"auto_background_after,omitempty" description:"Seconds to wait before automatically moving the command to a background job (default: 60)"`
 }
 
 type BashPermissionsParams struct {
-	Description         string `json:"description"`
-	Command             string `json:"command"`
-	WorkingDir          string `json:"working_dir"`
-	RunInBackground     bool   `json:"run_in_background"`
-	AutoBackgroundAfter int    `json:"auto_background_after"`
+	Description         string   `json:"description"`
+	Command             string   `json:"command"`
+	WorkingDir          string   `json:"working_dir"`
+	Env                 []string `json:"env,omitempty"`
+	RunInBackground     bool     `json:"run_in_background"`
+	AutoBackgroundAfter int      `json:"auto_background_after"`
 }
 
 type BashResponseMetadata struct {
@@ -43,8 +45,27 @@ type BashResponseMetadata struct {
 	Output           string `json:"output"`
 	Description      string `json:"description"`
 	WorkingDirectory string `json:"working_directory"`
-	Background       bool   `json:"background,omitempty"`
-	ShellID          string `json:"shell_id,omitempty"`
+	// ExitCode is the exit status of a command that finished before the
+	// tool returned. It is nil for commands still running as background
+	// jobs, whose exit status job_output reports, and for commands that
+	// were interrupted.
+	ExitCode    *int   `json:"exit_code,omitempty"`
+	Interrupted bool   `json:"interrupted,omitempty"`
+	Background  bool   `json:"background,omitempty"`
+	ShellID     string `json:"shell_id,omitempty"`
+}
+
+// ExitStatus returns the exit code of the command, or 0 when it has none.
+func (m BashResponseMetadata) ExitStatus() int {
+	if m.ExitCode == nil {
+		return 0
+	}
+	return *m.ExitCode
+}
+
+// Duration returns how long the command ran before the tool returned.
+func (m BashResponseMetadata) Duration() time.Duration {
+	return time.Duration(m.EndTime-m.StartTime) * time.Millisecond
 }
 
 const (
@@ -205,11 +226,16 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 
 			// Determine working directory
 			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			if err := validateBashEnv(params.Env); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
 
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
 
-			if !containsCommandChaining(params.Command) {
+			// Variables such as LD_PRELOAD or PATH can change what even a
+			// read-only command does, so they always need permission.
+			if !containsCommandChaining(params.Command) && len(params.Env) == 0 {
 				for _, safe := range safeCommands {
 					if strings.HasPrefix(cmdLower, safe) {
 						if len(cmdLower) == len(safe) || cmdLower[len(safe)] == ' ' || cmdLower[len(safe)] == '-' {
@@ -251,7 +277,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+				bgShell, err := bgManager.StartWithEnv(context.Background(), execWorkingDir, params.Env, blockFuncs(), params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -277,9 +303,13 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 						EndTime:          time.Now().UnixMilli(),
 						Output:           stdout,
 						Description:      params.Description,
+						Interrupted:      interrupted,
 						Background:       params.RunInBackground,
 						WorkingDirectory: bgShell.WorkingDir,
 					}
+					if !interrupted {
+						metadata.ExitCode = new(exitCode)
+					}
 					if stdout == "" {
 						return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 					}
@@ -306,7 +336,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(), params.Command, params.Description)
+			bgShell, err := bgManager.StartWithEnv(context.Background(), execWorkingDir, params.Env, blockFuncs(), params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
@@ -335,10 +365,20 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					stdout, stderr, done, execErr = bgShell.GetOutput()
 					break waitLoop
 				case <-ctx.Done():
-					// Incoming context was cancelled before we moved to background
-					// Kill the shell and return error
+					// Incoming context was cancelled before we moved to
+					// background. Kill the shell and return the error, with
+					// the output so far recorded as an interrupted run.
+					stdout, stderr, _, _ = bgShell.GetOutput()
 					bgManager.Kill(bgShell.ID)
-					return fantasy.ToolResponse{}, ctx.Err()
+					metadata := BashResponseMetadata{
+						StartTime:        startTime.UnixMilli(),
+						EndTime:          time.Now().UnixMilli(),
+						Output:           formatOutput(stdout, stderr, ctx.Err()),
+						Description:      params.Description,
+						Interrupted:      true,
+						WorkingDirectory: bgShell.WorkingDir,
+					}
+					return fantasy.WithResponseMetadata(fantasy.NewTextErrorResponse("Command was aborted before completion"), metadata), ctx.Err()
 				}
 			}
 
@@ -361,9 +401,13 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 					EndTime:          time.Now().UnixMilli(),
 					Output:           stdout,
 					Description:      params.Description,
+					Interrupted:      interrupted,
 					Background:       params.RunInBackground,
 					WorkingDirectory: bgShell.WorkingDir,
 				}
+				if !interrupted {
+					metadata.ExitCode = new(exitCode)
+				}
 				if stdout == "" {
 					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(BashNoOutput), metadata), nil
 				}
@@ -386,6 +430,37 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 	)
 }
 
+// validateBashEnv checks the per-command "KEY=value" environment variables
+// of a bash call.
+func validateBashEnv(env []string) error {
+	for _, kv := range env {
+		key, _, ok := strings.Cut(kv, "=")
+		if !ok {
+			return fmt.Errorf("invalid environment variable %q: expected KEY=value", kv)
+		}
+		if !isEnvName(key) {
+			return fmt.Errorf("invalid environment variable name: %q", key)
+		}
+	}
+	return nil
+}
+
+// isEnvName reports whether name is a valid shell variable name.
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // formatOutput formats the output of a completed command with error handling
 func formatOutput(stdout, stderr string, execErr error) string {
 	interrupted := shell.IsInterrupt(execErr)
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"charm.land/fantasy"
@@ -31,6 +32,14 @@ func (m *mockBashPermissionService) GrantPersistent(req permission.PermissionReq
 	return true
 }
 
+func (m *mockBashPermissionService) GrantTool(req permission.PermissionRequest) bool {
+	return true
+}
+
+func (m *mockBashPermissionService) ResetSession(sessionID string) int {
+	return 0
+}
+
 func (m *mockBashPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockBashPermissionService) SetSkipRequests(skip bool) {}
@@ -102,6 +111,14 @@ func (m *recordingPermissionService) GrantPersistent(req permission.PermissionRe
 	return true
 }
 
+func (m *recordingPermissionService) GrantTool(req permission.PermissionRequest) bool {
+	return true
+}
+
+func (m *recordingPermissionService) ResetSession(sessionID string) int {
+	return 0
+}
+
 func (m *recordingPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *recordingPermissionService) SetSkipRequests(skip bool) {}
@@ -168,6 +185,100 @@ func TestBashTool_ChainedCommandsDenied(t *testing.T) {
 	require.Contains(t, resp.Content, "User denied permission")
 }
 
+func TestBashTool_EnvIsScopedToCommand(t *testing.T) {
+	workingDir := t.TempDir()
+	tool, perms := newBashToolWithRecordingPerms(workingDir, true)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+
+	resp := runBashTool(t, tool, ctx, BashParams{
+		Description: "echo with env",
+		Command:     "echo $CRUSH_TEST_GREETING",
+		Env:         []string{"CRUSH_TEST_GREETING=first", "CRUSH_TEST_GREETING=hello"},
+	})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "hello")
+	require.Equal(t, 1, perms.requestCount, "commands with env should always ask for permission")
+
+	resp = runBashTool(t, tool, ctx, BashParams{
+		Description: "echo without env",
+		Command:     "echo value:$CRUSH_TEST_GREETING",
+	})
+	require.False(t, resp.IsError)
+	require.NotContains(t, resp.Content, "hello")
+
+	for _, env := range []string{"NO_VALUE", "1BAD=x", "BAD-NAME=x", "=x"} {
+		resp = runBashTool(t, tool, ctx, BashParams{
+			Description: "invalid env",
+			Command:     "echo hi",
+			Env:         []string{env},
+		})
+		require.True(t, resp.IsError, env)
+	}
+}
+
+func TestBashTool_RecordsExitCode(t *testing.T) {
+	workingDir := t.TempDir()
+	tool := newBashToolForTest(workingDir)
+	ctx := context.WithValue(context.Background(), SessionIDContextKey, "test-session")
+
+	run := func(params BashParams) BashResponseMetadata {
+		t.Helper()
+		resp := runBashTool(t, tool, ctx, params)
+		var meta BashResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		return meta
+	}
+
+	t.Run("success", func(t *testing.T) {
+		meta := run(BashParams{Description: "succeed", Command: "echo ok"})
+		require.NotNil(t, meta.ExitCode)
+		require.Zero(t, *meta.ExitCode)
+		require.False(t, meta.Background)
+		require.GreaterOrEqual(t, meta.Duration(), time.Duration(0))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		meta := run(BashParams{Description: "fail", Command: "echo nope && exit 3"})
+		require.NotNil(t, meta.ExitCode)
+		require.Equal(t, 3, *meta.ExitCode)
+		require.False(t, meta.Interrupted)
+		require.Contains(t, meta.Output, "Exit code 3")
+	})
+
+	t.Run("interrupted by a timeout", func(t *testing.T) {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+		defer cancel()
+		input, err := json.Marshal(BashParams{Description: "hang", Command: "echo started && sleep 10"})
+		require.NoError(t, err)
+		resp, err := tool.Run(timeoutCtx, fantasy.ToolCall{ID: "test-call", Name: BashToolName, Input: string(input)})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		var meta BashResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		require.True(t, meta.Interrupted)
+		require.Nil(t, meta.ExitCode, "an interrupted command has no exit code")
+		require.False(t, meta.Background)
+		require.Contains(t, meta.Output, "aborted before completion")
+	})
+
+	t.Run("moved to background after the threshold", func(t *testing.T) {
+		meta := run(BashParams{Description: "slow", Command: "sleep 1.5 && exit 4", AutoBackgroundAfter: 1})
+		require.True(t, meta.Background)
+		require.Nil(t, meta.ExitCode, "a running job has no exit code yet")
+		require.GreaterOrEqual(t, meta.Duration(), time.Second)
+
+		input, err := json.Marshal(JobOutputParams{ShellID: meta.ShellID, Wait: true})
+		require.NoError(t, err)
+		resp, err := NewJobOutputTool().Run(ctx, fantasy.ToolCall{ID: "job", Name: JobOutputToolName, Input: string(input)})
+		require.NoError(t, err)
+		var jobMeta JobOutputResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &jobMeta))
+		require.True(t, jobMeta.Done)
+		require.Equal(t, 4, jobMeta.ExitCode)
+		require.NoError(t, shell.GetBackgroundShellManager().Remove(meta.ShellID))
+	})
+}
+
 func runBashTool(t *testing.T, tool fantasy.AgentTool, ctx context.Context, params BashParams) fantasy.ToolResponse {
 	t.Helper()
 
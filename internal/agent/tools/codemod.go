@@ -0,0 +1,368 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+const (
+	CodemodToolName = "codemod"
+
+	// codemodConfirmThreshold is the number of changed files above which
+	// a codemod is only applied with confirm set.
+	codemodConfirmThreshold = 10
+	// codemodMaxFiles bounds how many files one codemod scans.
+	codemodMaxFiles = 2000
+	// codemodMaxFileSize skips files too large to be hand-written source.
+	codemodMaxFileSize = 1 << 20
+	// codemodMaxDiffLength caps the diff returned to the model; the full
+	// diff is kept in the metadata.
+	codemodMaxDiffLength = 30000
+)
+
+//go:embed codemod.md.tpl
+var codemodDescriptionTmpl []byte
+
+var codemodDescriptionTpl = template.Must(
+	template.New("codemodDescription").
+		Parse(string(codemodDescriptionTmpl)),
+)
+
+type codemodDescriptionData struct {
+	ConfirmThreshold int
+	MaxFiles         int
+}
+
+func codemodDescription() string {
+	return renderTemplate(codemodDescriptionTpl, codemodDescriptionData{
+		ConfirmThreshold: codemodConfirmThreshold,
+		MaxFiles:         codemodMaxFiles,
+	})
+}
+
+type CodemodParams struct {
+	Pattern string `json:"pattern" description:"The structural pattern to find. :[name] is a hole matching any text within a line"`
+	Rewrite string `json:"rewrite" description:"The replacement. :[name] inserts the text matched by the hole of that name"`
+	Files   string `json:"files" description:"Glob of the files to change, relative to path, e.g. **/*.go"`
+	Path    string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
+	Confirm bool   `json:"confirm,omitempty" description:"Apply even though more files than the confirmation threshold would change"`
+}
+
+type CodemodPermissionsParams struct {
+	Pattern string   `json:"pattern"`
+	Rewrite string   `json:"rewrite"`
+	Files   []string `json:"files"`
+	Diff    string   `json:"diff"`
+}
+
+// CodemodFileChange summarizes the change to one file.
+type CodemodFileChange struct {
+	Path         string `json:"path"`
+	Replacements int    `json:"replacements"`
+	Additions    int    `json:"additions"`
+	Removals     int    `json:"removals"`
+}
+
+type CodemodResponseMetadata struct {
+	Files     []CodemodFileChange `json:"files"`
+	Additions int                 `json:"additions"`
+	Removals  int                 `json:"removals"`
+	Diff      string              `json:"diff,omitempty"`
+	// NeedsConfirm is set when the codemod was not applied because it
+	// would change more files than the threshold without confirm.
+	NeedsConfirm bool `json:"needs_confirm,omitempty"`
+}
+
+type codemodChange struct {
+	CodemodFileChange
+	oldContent, newContent string
+	isCrlf                 bool
+	diff                   string
+}
+
+func NewCodemodTool(
+	lspManager *lsp.Manager,
+	permissions permission.Service,
+	files history.Service,
+	filetracker filetracker.Service,
+	workingDir string,
+	allowOutsideWorkdir bool,
+) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodemodToolName,
+		codemodDescription(),
+		func(ctx context.Context, params CodemodParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Pattern == "" {
+				return fantasy.NewTextErrorResponse("pattern is required"), nil
+			}
+			if params.Files == "" {
+				return fantasy.NewTextErrorResponse("files is required"), nil
+			}
+			re, err := compileCodemodPattern(params.Pattern)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid pattern: %s", err)), nil
+			}
+			if err := checkCodemodRewrite(re, params.Rewrite); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			searchPath := filepathext.SmartJoin(workingDir, cmp.Or(params.Path, "."))
+			if resp, ok := checkWithinWorkingDir(workingDir, searchPath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
+			paths, truncated, err := fsext.GlobGitignoreAwareCtx(ctx, params.Files, searchPath, codemodMaxFiles)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error finding files: %v", err)), nil
+			}
+			if truncated {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("more than %d files match %s; narrow files or path", codemodMaxFiles, params.Files)), nil
+			}
+			// Symlinked files may point outside the working directory.
+			paths = slices.DeleteFunc(paths, func(path string) bool {
+				_, ok := checkWithinWorkingDir(workingDir, path, allowOutsideWorkdir)
+				return !ok
+			})
+			slices.Sort(paths)
+
+			changes, err := planCodemod(re, params.Rewrite, paths, workingDir)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if len(changes) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("No matches for the pattern in %d file(s)", len(paths))), nil
+			}
+			meta := codemodMetadata(changes)
+
+			if len(changes) > codemodConfirmThreshold && !params.Confirm {
+				meta.NeedsConfirm = true
+				var b strings.Builder
+				fmt.Fprintf(&b, "The codemod would change %d files, more than %d, so it was not applied. Review the files below and call codemod again with confirm: true to apply it, or narrow files or path.\n\n", len(changes), codemodConfirmThreshold)
+				writeCodemodSummary(&b, changes)
+				return fantasy.WithResponseMetadata(fantasy.NewTextErrorResponse(b.String()), meta), nil
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for applying a codemod")
+			}
+			changedPaths := make([]string, len(changes))
+			for i, c := range changes {
+				changedPaths[i] = c.Path
+			}
+			p, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        fsext.PathOrPrefix(searchPath, workingDir),
+				ToolCallID:  call.ID,
+				ToolName:    CodemodToolName,
+				Action:      "write",
+				Description: fmt.Sprintf("Apply codemod to %d file(s)", len(changes)),
+				Params: CodemodPermissionsParams{
+					Pattern: params.Pattern,
+					Rewrite: params.Rewrite,
+					Files:   changedPaths,
+					Diff:    meta.Diff,
+				},
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.WithResponseMetadata(NewPermissionDeniedResponse(), meta), nil
+			}
+
+			edit := editContext{ctx, permissions, files, filetracker, workingDir}
+			for _, c := range changes {
+				writeContent := c.newContent
+				if c.isCrlf {
+					writeContent, _ = fsext.ToWindowsLineEndings(writeContent)
+				}
+				if err := commitFileChange(edit, sessionID, c.Path, c.oldContent, writeContent); err != nil {
+					return fantasy.ToolResponse{}, err
+				}
+				notifyLSPs(ctx, lspManager, c.Path)
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "Applied codemod to %d file(s):\n\n", len(changes))
+			writeCodemodSummary(&b, changes)
+			diffText := meta.Diff
+			if len(diffText) > codemodMaxDiffLength {
+				diffText = diffText[:codemodMaxDiffLength] + "\n... (diff truncated)"
+			}
+			fmt.Fprintf(&b, "\n<diff>\n%s\n</diff>\n", strings.TrimRight(diffText, "\n"))
+			b.WriteString(getDiagnostics(changes[0].Path, lspManager))
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(b.String()), meta), nil
+		},
+	)
+}
+
+var (
+	// codemodHole matches a :[name] hole in a pattern or rewrite.
+	codemodHole  = regexp.MustCompile(`:\[(\w+)\]`)
+	codemodSpace = regexp.MustCompile(`\s+`)
+)
+
+// compileCodemodPattern turns a structural pattern into a regular
+// expression with a named group per hole. Holes match lazily within a line,
+// except a trailing hole, which takes the rest of the line; runs of
+// whitespace match any run of whitespace; everything else is literal.
+func compileCodemodPattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("pattern is empty")
+	}
+	var expr strings.Builder
+	last := 0
+	locs := codemodHole.FindAllStringSubmatchIndex(pattern, -1)
+	for i, loc := range locs {
+		writeCodemodLiteral(&expr, pattern[last:loc[0]])
+		hole := `[^\n]*?`
+		if i == len(locs)-1 && loc[1] == len(pattern) {
+			hole = `[^\n]*`
+		}
+		fmt.Fprintf(&expr, `(?P<%s>%s)`, pattern[loc[2]:loc[3]], hole)
+		last = loc[1]
+	}
+	writeCodemodLiteral(&expr, pattern[last:])
+	return regexp.Compile(expr.String())
+}
+
+// writeCodemodLiteral writes s to expr as literal text in which each run of
+// whitespace matches any run of whitespace.
+func writeCodemodLiteral(expr *strings.Builder, s string) {
+	for i, part := range codemodSpace.Split(s, -1) {
+		if i > 0 {
+			expr.WriteString(`\s+`)
+		}
+		expr.WriteString(regexp.QuoteMeta(part))
+	}
+}
+
+// checkCodemodRewrite reports an error if rewrite uses a hole that is not
+// in the pattern.
+func checkCodemodRewrite(re *regexp.Regexp, rewrite string) error {
+	for _, m := range codemodHole.FindAllStringSubmatch(rewrite, -1) {
+		if re.SubexpIndex(m[1]) < 0 {
+			return fmt.Errorf("rewrite uses :[%s], which is not a hole in the pattern", m[1])
+		}
+	}
+	return nil
+}
+
+// applyCodemod replaces each match of re in content with rewrite and
+// returns the result and the number of replacements. Matches whose holes
+// of the same name captured different text are left alone.
+func applyCodemod(re *regexp.Regexp, rewrite, content string) (string, int) {
+	names := re.SubexpNames()
+	var (
+		out   strings.Builder
+		last  int
+		count int
+	)
+	for _, loc := range re.FindAllStringSubmatchIndex(content, -1) {
+		holes := make(map[string]string)
+		consistent := true
+		for i, name := range names {
+			if name == "" || loc[2*i] < 0 {
+				continue
+			}
+			text := content[loc[2*i]:loc[2*i+1]]
+			if prev, ok := holes[name]; ok && prev != text {
+				consistent = false
+				break
+			}
+			holes[name] = text
+		}
+		if !consistent {
+			continue
+		}
+		out.WriteString(content[last:loc[0]])
+		out.WriteString(codemodHole.ReplaceAllStringFunc(rewrite, func(hole string) string {
+			return holes[hole[2:len(hole)-1]]
+		}))
+		last = loc[1]
+		count++
+	}
+	if count == 0 {
+		return content, 0
+	}
+	out.WriteString(content[last:])
+	return out.String(), count
+}
+
+// planCodemod applies the codemod to the contents of paths in memory and
+// returns the files that would change. Binary and very large files are
+// skipped.
+func planCodemod(re *regexp.Regexp, rewrite string, paths []string, workingDir string) ([]codemodChange, error) {
+	var changes []codemodChange
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() > codemodMaxFileSize {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			continue
+		}
+		oldContent, isCrlf := fsext.ToUnixLineEndings(string(data))
+		newContent, count := applyCodemod(re, rewrite, oldContent)
+		if count == 0 || newContent == oldContent {
+			continue
+		}
+		d, additions, removals := diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(path, workingDir))
+		changes = append(changes, codemodChange{
+			CodemodFileChange: CodemodFileChange{
+				Path:         path,
+				Replacements: count,
+				Additions:    additions,
+				Removals:     removals,
+			},
+			oldContent: oldContent,
+			newContent: newContent,
+			isCrlf:     isCrlf,
+			diff:       d,
+		})
+	}
+	return changes, nil
+}
+
+func codemodMetadata(changes []codemodChange) CodemodResponseMetadata {
+	var (
+		meta     CodemodResponseMetadata
+		diffText strings.Builder
+	)
+	for _, c := range changes {
+		meta.Files = append(meta.Files, c.CodemodFileChange)
+		meta.Additions += c.Additions
+		meta.Removals += c.Removals
+		diffText.WriteString(c.diff)
+	}
+	meta.Diff = diffText.String()
+	return meta
+}
+
+func writeCodemodSummary(b *strings.Builder, changes []codemodChange) {
+	for _, c := range changes {
+		fmt.Fprintf(b, "  %s (%d replacement(s), +%d -%d)\n", c.Path, c.Replacements, c.Additions, c.Removals)
+	}
+}
@@ -0,0 +1 @@
+Apply a structural find-and-replace across many files at once, for mechanical refactors where per-file edit would be slow. In pattern, :[name] is a hole matching any text within a line and whitespace matches any run of whitespace; rewrite uses :[name] to insert what the hole matched, e.g. pattern `errors.Wrap(:[err], :[msg])` with rewrite `fmt.Errorf(:[msg]+": %w", :[err])`. Holes with the same name must match the same text. Returns the changed files and a combined diff. Changing more than {{ .ConfirmThreshold }} files requires confirm: true; run without it first to review the list. Scans at most {{ .MaxFiles }} files; narrow files or path for larger trees.
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyCodemod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pattern string
+		rewrite string
+		content string
+		want    string
+		count   int
+	}{
+		{
+			name:    "holes",
+			pattern: "errors.Wrap(:[err], :[msg])",
+			rewrite: "fmt.Errorf(:[msg]+\": %w\", :[err])",
+			content: "return errors.Wrap(err, \"open\")\n",
+			want:    "return fmt.Errorf(\"open\"+\": %w\", err)\n",
+			count:   1,
+		},
+		{
+			name:    "whitespace",
+			pattern: "if :[x] != nil {",
+			rewrite: "if :[x] == nil {",
+			content: "if  err   !=  nil {\nif v != nil {\n",
+			want:    "if err == nil {\nif v == nil {\n",
+			count:   2,
+		},
+		{
+			name:    "trailing hole",
+			pattern: "// TODO: :[rest]",
+			rewrite: "// FIXME: :[rest]",
+			content: "a := 1 // TODO: clean up\n",
+			want:    "a := 1 // FIXME: clean up\n",
+			count:   1,
+		},
+		{
+			name:    "same name must match",
+			pattern: ":[a] = :[a]",
+			rewrite: ":[a]++",
+			content: "x = x\ny = z\n",
+			want:    "x++\ny = z\n",
+			count:   1,
+		},
+		{
+			name:    "no match",
+			pattern: "foo(:[x])",
+			rewrite: "bar(:[x])",
+			content: "baz(1)\n",
+			want:    "baz(1)\n",
+			count:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			re, err := compileCodemodPattern(tt.pattern)
+			require.NoError(t, err)
+			require.NoError(t, checkCodemodRewrite(re, tt.rewrite))
+			got, count := applyCodemod(re, tt.rewrite, tt.content)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.count, count)
+		})
+	}
+}
+
+func TestCodemodPatternErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := compileCodemodPattern("  ")
+	require.Error(t, err)
+
+	re, err := compileCodemodPattern("foo(:[x])")
+	require.NoError(t, err)
+	err = checkCodemodRewrite(re, "bar(:[y])")
+	require.ErrorContains(t, err, ":[y]")
+}
+
+func TestPlanCodemod(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	changed := write("a.go", "log.Printf(\"a\")\n")
+	crlf := write("b.go", "log.Printf(\"b\")\r\nother()\r\n")
+	untouched := write("c.go", "fmt.Println(\"c\")\n")
+	binary := write("d.bin", "log.Printf(\"d\")\x00")
+
+	re, err := compileCodemodPattern("log.Printf(:[args])")
+	require.NoError(t, err)
+	changes, err := planCodemod(re, "slog.Info(:[args])", []string{changed, crlf, untouched, binary}, dir)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	require.Equal(t, changed, changes[0].Path)
+	require.Equal(t, 1, changes[0].Replacements)
+	require.Equal(t, "slog.Info(\"a\")\n", changes[0].newContent)
+	require.Contains(t, changes[0].diff, "+slog.Info(\"a\")")
+
+	require.Equal(t, crlf, changes[1].Path)
+	require.True(t, changes[1].isCrlf)
+	require.Equal(t, "slog.Info(\"b\")\nother()\n", changes[1].newContent)
+
+	meta := codemodMetadata(changes)
+	require.Len(t, meta.Files, 2)
+	require.Equal(t, 2, meta.Additions)
+}
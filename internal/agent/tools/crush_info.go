@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/breaker"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -43,9 +44,12 @@ func buildCrushInfo(cfg *config.ConfigStore, lspManager *lsp.Manager, allSkills
 	writeConfigFiles(&b, cfg)
 	writeConfigStaleness(&b, cfg)
 	writeModels(&b, cfg)
-	writeProviders(&b, cfg)
+	writeProviders(&b, cfg, breaker.GetStates())
 	writeLSP(&b, lspManager, cfg)
 	writeMCP(&b, mcp.GetStates(), cfg)
+	if resolver := cfg.Resolver(); resolver != nil {
+		writeMissingCommands(&b, cfg.Config().MissingCommands(resolver))
+	}
 	writeSkills(&b, allSkills, activeSkills, skillTracker, cfg)
 	writeHooks(&b, cfg)
 	writePermissions(&b, cfg)
@@ -111,7 +115,9 @@ func writeModels(b *strings.Builder, cfg *config.ConfigStore) {
 	b.WriteString("\n")
 }
 
-func writeProviders(b *strings.Builder, cfg *config.ConfigStore) {
+// writeProviders lists the enabled providers, with the state of their
+// circuit breakers from breakers where one is in use.
+func writeProviders(b *strings.Builder, cfg *config.ConfigStore, breakers map[string]breaker.State) {
 	c := cfg.Config()
 	type pv struct {
 		name  string
@@ -130,7 +136,11 @@ func writeProviders(b *strings.Builder, cfg *config.ConfigStore) {
 	slices.SortFunc(providers, func(a, b pv) int { return strings.Compare(a.name, b.name) })
 	b.WriteString("[providers]\n")
 	for _, p := range providers {
-		fmt.Fprintf(b, "%s = enabled (%d models)\n", p.name, p.count)
+		fmt.Fprintf(b, "%s = enabled (%d models)", p.name, p.count)
+		if state, ok := breakers[p.name]; ok {
+			fmt.Fprintf(b, ", circuit %s", state)
+		}
+		b.WriteString("\n")
 	}
 	b.WriteString("\n")
 }
@@ -292,6 +302,23 @@ func writeMCP(b *strings.Builder, states map[string]mcp.ClientInfo, cfg *config.
 	}
 }
 
+// writeMissingCommands lists configured LSP and MCP servers whose
+// command is not installed, with a suggested install step when known.
+func writeMissingCommands(b *strings.Builder, missing []config.MissingCommand) {
+	if len(missing) == 0 {
+		return
+	}
+	b.WriteString("[missing_commands]\n")
+	for _, m := range missing {
+		fmt.Fprintf(b, "%s.%s = %s not found", m.Kind, m.Name, m.Command)
+		if m.Hint != "" {
+			fmt.Fprintf(b, " (install: %s)", m.Hint)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
 func writeSkills(b *strings.Builder, allSkills []*skills.Skill, activeSkills []*skills.Skill, tracker *skills.Tracker, cfg *config.ConfigStore) {
 	var disabled []string
 	if cfg.Config().Options != nil {
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/breaker"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
@@ -503,3 +504,36 @@ func TestCrushInfo_Hooks_NoHooks(t *testing.T) {
 	output := buildCrushInfo(cfg, nil, nil, nil, nil)
 	require.NotContains(t, output, "[hooks]")
 }
+
+func TestCrushInfo_ProviderCircuitBreakers(t *testing.T) {
+	t.Parallel()
+
+	providers := csync.NewMap[string, config.ProviderConfig]()
+	providers.Set("openai", config.ProviderConfig{Models: make([]catwalk.Model, 8)})
+	providers.Set("anthropic", config.ProviderConfig{Models: make([]catwalk.Model, 12)})
+	cfg := config.NewTestStore(&config.Config{Providers: providers})
+
+	var b strings.Builder
+	writeProviders(&b, cfg, map[string]breaker.State{"openai": breaker.StateOpen})
+	output := b.String()
+	require.Contains(t, output, "openai = enabled (8 models), circuit open\n")
+	require.Contains(t, output, "anthropic = enabled (12 models)\n")
+}
+
+func TestCrushInfo_MissingCommands(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	writeMissingCommands(&b, []config.MissingCommand{
+		{Kind: "lsp", Name: "go", Command: "gopls", Hint: "go install golang.org/x/tools/gopls@latest"},
+		{Kind: "mcp", Name: "custom", Command: "my-mcp"},
+	})
+	output := b.String()
+	require.Contains(t, output, "[missing_commands]\n")
+	require.Contains(t, output, "lsp.go = gopls not found (install: go install golang.org/x/tools/gopls@latest)\n")
+	require.Contains(t, output, "mcp.custom = my-mcp not found\n")
+
+	b.Reset()
+	writeMissingCommands(&b, nil)
+	require.Empty(t, b.String())
+}
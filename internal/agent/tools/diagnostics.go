@@ -1,41 +1,218 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 )
 
 type DiagnosticsParams struct {
 	FilePath string `json:"file_path,omitempty" description:"The path to the file to get diagnostics for (leave empty for project diagnostics)"`
+	Path     string `json:"path,omitempty" description:"Only report project diagnostics for this file or the files under this directory"`
+	Scope    string `json:"scope,omitempty" description:"Which files to report project diagnostics for: all (default) or changed, the files edited in this session"`
 }
 
-const DiagnosticsToolName = "lsp_diagnostics"
+const (
+	DiagnosticsScopeAll     = "all"
+	DiagnosticsScopeChanged = "changed"
+)
+
+// DiagnosticsResponseMetadata lists the diagnostics a call reported so the
+// UI can group them by severity and file.
+type DiagnosticsResponseMetadata struct {
+	Diagnostics []DiagnosticEntry `json:"diagnostics,omitempty"`
+	// Total is the number of diagnostics found; Diagnostics holds at most
+	// maxDiagnosticEntries of them.
+	Total int `json:"total"`
+}
+
+// DiagnosticEntry is a single diagnostic in [DiagnosticsResponseMetadata].
+type DiagnosticEntry struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+const (
+	DiagnosticsToolName = "lsp_diagnostics"
+
+	maxDiagnosticEntries = 200
+)
 
 //go:embed diagnostics.md
 var diagnosticsDescription string
 
-func NewDiagnosticsTool(lspManager *lsp.Manager) fantasy.AgentTool {
+func NewDiagnosticsTool(lspManager *lsp.Manager, files history.Service, workingDir string, cfg config.ToolDiagnostics) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		DiagnosticsToolName,
 		diagnosticsDescription,
 		func(ctx context.Context, params DiagnosticsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
-			notifyLSPs(ctx, lspManager, params.FilePath)
-			output := getDiagnostics(params.FilePath, lspManager)
-			return fantasy.NewTextResponse(output), nil
+			var filter diagnosticsFilter
+			if params.Path != "" {
+				filter.root = params.Path
+				if !filepath.IsAbs(filter.root) {
+					filter.root = filepath.Join(workingDir, filter.root)
+				}
+				filter.root = filepath.Clean(filter.root)
+			}
+
+			switch params.Scope {
+			case "", DiagnosticsScopeAll:
+			case DiagnosticsScopeChanged:
+				sessionID := GetSessionFromContext(ctx)
+				if sessionID == "" {
+					return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for the changed scope")
+				}
+				changed, err := files.ListLatestSessionFiles(ctx, sessionID)
+				if err != nil {
+					return fantasy.ToolResponse{}, fmt.Errorf("error listing changed files: %w", err)
+				}
+				filter.changed = make(map[string]struct{}, len(changed))
+				for _, f := range changed {
+					filter.changed[filepath.Clean(f.Path)] = struct{}{}
+				}
+				if len(filter.changed) == 0 && params.FilePath == "" {
+					return fantasy.NewTextResponse("No files have been changed in this session."), nil
+				}
+			default:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid scope %q: use %q or %q", params.Scope, DiagnosticsScopeAll, DiagnosticsScopeChanged)), nil
+			}
+
+			if params.FilePath != "" {
+				notifyLSPs(ctx, lspManager, params.FilePath)
+			} else {
+				refreshLSPs(ctx, lspManager, filter, cfg)
+			}
+			output, metadata := collectDiagnostics(params.FilePath, lspManager, filter)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(output), metadata), nil
 		},
 	)
 }
 
+// diagnosticsFilter selects the files whose project diagnostics are
+// reported. The zero value matches every file.
+type diagnosticsFilter struct {
+	// root limits the filter to a file or the files under a directory.
+	root string
+	// changed, when not nil, limits the filter to these files.
+	changed map[string]struct{}
+}
+
+// match reports whether diagnostics for path are reported.
+func (f diagnosticsFilter) match(path string) bool {
+	if f.root != "" && !withinDir(f.root, path) {
+		return false
+	}
+	if f.changed != nil {
+		_, ok := f.changed[filepath.Clean(path)]
+		return ok
+	}
+	return true
+}
+
+// files returns the files the filter names explicitly, sorted: the changed
+// files it matches, or root when it is a file. Diagnostics for them may not
+// have been published yet, so they are opened before waiting.
+func (f diagnosticsFilter) files() []string {
+	var out []string
+	if f.changed != nil {
+		for path := range f.changed {
+			if f.match(path) {
+				out = append(out, path)
+			}
+		}
+		slices.Sort(out)
+		return out
+	}
+	if f.root != "" {
+		if info, err := os.Stat(f.root); err == nil && !info.IsDir() {
+			out = append(out, f.root)
+		}
+	}
+	return out
+}
+
+// fileHandler is the part of [lsp.Client] that decides which files a server
+// is relevant for.
+type fileHandler interface {
+	HandlesFile(path string) bool
+}
+
+// relevant reports whether client can publish diagnostics the filter
+// matches. With no explicit files every client is relevant.
+func (f diagnosticsFilter) relevant(client fileHandler, files []string) bool {
+	if f.changed == nil && len(files) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(files, client.HandlesFile)
+}
+
+// refreshLSPs refreshes the open files of every client relevant to filter
+// and waits for their diagnostics. Clients are refreshed concurrently, at
+// most cfg.GetConcurrency() at a time, and each one is waited on for at most
+// cfg.GetTimeout().
+func refreshLSPs(
+	ctx context.Context,
+	manager *lsp.Manager,
+	filter diagnosticsFilter,
+	cfg config.ToolDiagnostics,
+) {
+	if manager == nil {
+		return
+	}
+
+	files := filter.files()
+	for _, path := range files {
+		manager.Start(ctx, path)
+	}
+
+	sem := make(chan struct{}, cfg.GetConcurrency())
+	var wg sync.WaitGroup
+	for client := range manager.Clients().Seq() {
+		if !filter.relevant(client, files) {
+			continue
+		}
+		wg.Go(func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			for _, path := range files {
+				if client.HandlesFile(path) {
+					_ = client.OpenFileOnDemand(ctx, path)
+				}
+			}
+			client.RefreshOpenFiles(ctx)
+			if err := client.NotifyWorkspaceChange(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to notify workspace change", "error", err)
+			}
+			client.WaitForDiagnostics(ctx, cfg.GetTimeout())
+		})
+	}
+	wg.Wait()
+}
+
 // openInLSPs ensures LSP servers are running and aware of the file, but does
 // not notify changes or wait for fresh diagnostics. Use this for read-only
 // operations like view where the file content hasn't changed.
@@ -97,17 +274,7 @@ func notifyLSPs(
 	}
 	if filepath == "" {
 		// No specific file — refresh all open files for all clients.
-		var wg sync.WaitGroup
-		for client := range manager.Clients().Seq() {
-			wg.Go(func() {
-				client.RefreshOpenFiles(ctx)
-				if err := client.NotifyWorkspaceChange(ctx); err != nil {
-					slog.WarnContext(ctx, "Failed to notify workspace change", "error", err)
-				}
-				client.WaitForDiagnostics(ctx, 5*time.Second)
-			})
-		}
-		wg.Wait()
+		refreshLSPs(ctx, manager, diagnosticsFilter{}, config.ToolDiagnostics{})
 		return
 	}
 
@@ -128,8 +295,17 @@ func notifyLSPs(
 }
 
 func getDiagnostics(filePath string, manager *lsp.Manager) string {
+	out, _ := collectDiagnostics(filePath, manager, diagnosticsFilter{})
+	return out
+}
+
+// collectDiagnostics merges the diagnostics of all clients. Those for
+// filePath are always reported; those for other files only when filter
+// matches them.
+func collectDiagnostics(filePath string, manager *lsp.Manager, filter diagnosticsFilter) (string, DiagnosticsResponseMetadata) {
+	var metadata DiagnosticsResponseMetadata
 	if manager == nil {
-		return ""
+		return "", metadata
 	}
 
 	var fileDiagnostics []string
@@ -143,6 +319,9 @@ func getDiagnostics(filePath string, manager *lsp.Manager) string {
 				continue
 			}
 			isCurrentFile := path == filePath
+			if !isCurrentFile && !filter.match(path) {
+				continue
+			}
 			for _, diag := range diags {
 				formattedDiag := formatDiagnostic(path, diag, lspName)
 				if isCurrentFile {
@@ -150,9 +329,15 @@ func getDiagnostics(filePath string, manager *lsp.Manager) string {
 				} else {
 					projectDiagnostics = append(projectDiagnostics, formattedDiag)
 				}
+				metadata.Diagnostics = append(metadata.Diagnostics, diagnosticEntry(path, diag, lspName))
 			}
 		}
 	}
+	metadata.Total = len(metadata.Diagnostics)
+	sortDiagnosticEntries(metadata.Diagnostics)
+	if len(metadata.Diagnostics) > maxDiagnosticEntries {
+		metadata.Diagnostics = metadata.Diagnostics[:maxDiagnosticEntries]
+	}
 
 	sortDiagnostics(fileDiagnostics)
 	sortDiagnostics(projectDiagnostics)
@@ -174,7 +359,7 @@ func getDiagnostics(filePath string, manager *lsp.Manager) string {
 
 	out := output.String()
 	slog.Debug("Diagnostics", "output", out)
-	return out
+	return out, metadata
 }
 
 func writeDiagnostics(output *strings.Builder, tag string, in []string) {
@@ -203,16 +388,52 @@ func sortDiagnostics(in []string) []string {
 	return in
 }
 
-func formatDiagnostic(pth string, diagnostic protocol.Diagnostic, source string) string {
-	severity := "Info"
-	switch diagnostic.Severity {
+// diagnosticEntry converts an LSP diagnostic to a [DiagnosticEntry].
+func diagnosticEntry(pth string, diagnostic protocol.Diagnostic, source string) DiagnosticEntry {
+	if diagnostic.Source != "" {
+		source += " " + diagnostic.Source
+	}
+	return DiagnosticEntry{
+		Path:     pth,
+		Line:     int(diagnostic.Range.Start.Line) + 1,
+		Column:   int(diagnostic.Range.Start.Character) + 1,
+		Severity: severityName(diagnostic.Severity),
+		Source:   source,
+		Message:  diagnostic.Message,
+	}
+}
+
+// severityRank orders severity names from most to least severe.
+var severityRank = map[string]int{"Error": 0, "Warn": 1, "Info": 2, "Hint": 3}
+
+// sortDiagnosticEntries sorts entries by severity, then file and position,
+// so truncation keeps the most severe ones.
+func sortDiagnosticEntries(entries []DiagnosticEntry) {
+	slices.SortStableFunc(entries, func(a, b DiagnosticEntry) int {
+		return cmp.Or(
+			cmp.Compare(severityRank[a.Severity], severityRank[b.Severity]),
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+}
+
+func severityName(severity protocol.DiagnosticSeverity) string {
+	switch severity {
 	case protocol.SeverityError:
-		severity = "Error"
+		return "Error"
 	case protocol.SeverityWarning:
-		severity = "Warn"
+		return "Warn"
 	case protocol.SeverityHint:
-		severity = "Hint"
+		return "Hint"
+	default:
+		return "Info"
 	}
+}
+
+func formatDiagnostic(pth string, diagnostic protocol.Diagnostic, source string) string {
+	severity := severityName(diagnostic.Severity)
 
 	location := fmt.Sprintf("%s:%d:%d", pth, diagnostic.Range.Start.Line+1, diagnostic.Range.Start.Character+1)
 
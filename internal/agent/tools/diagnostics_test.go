@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type extHandler string
+
+func (h extHandler) HandlesFile(path string) bool {
+	return strings.HasSuffix(path, string(h))
+}
+
+func TestDiagnosticsFilter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "pkg", "a.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	var all diagnosticsFilter
+	require.True(t, all.match(filepath.Join(dir, "anything.ts")))
+	require.Empty(t, all.files())
+	require.True(t, all.relevant(extHandler(".ts"), all.files()))
+
+	byDir := diagnosticsFilter{root: filepath.Join(dir, "pkg")}
+	require.True(t, byDir.match(file))
+	require.False(t, byDir.match(filepath.Join(dir, "other", "b.go")))
+	require.False(t, byDir.match(filepath.Join(dir, "pkg2", "c.go")))
+	require.Empty(t, byDir.files())
+
+	byFile := diagnosticsFilter{root: file}
+	require.True(t, byFile.match(file))
+	require.Equal(t, []string{file}, byFile.files())
+	require.True(t, byFile.relevant(extHandler(".go"), byFile.files()))
+	require.False(t, byFile.relevant(extHandler(".ts"), byFile.files()))
+
+	changedTS := filepath.Join(dir, "web", "b.ts")
+	changed := diagnosticsFilter{changed: map[string]struct{}{file: {}, changedTS: {}}}
+	require.True(t, changed.match(file))
+	require.False(t, changed.match(filepath.Join(dir, "pkg", "other.go")))
+	require.Equal(t, []string{file, changedTS}, changed.files())
+	require.True(t, changed.relevant(extHandler(".ts"), changed.files()))
+	require.False(t, changed.relevant(extHandler(".py"), changed.files()))
+
+	changed.root = filepath.Join(dir, "pkg")
+	require.Equal(t, []string{file}, changed.files())
+	require.False(t, changed.match(changedTS))
+	require.False(t, changed.relevant(extHandler(".ts"), changed.files()))
+
+	none := diagnosticsFilter{changed: map[string]struct{}{}}
+	require.False(t, none.relevant(extHandler(".go"), none.files()))
+}
+
+func TestSortDiagnosticEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := []DiagnosticEntry{
+		{Path: "b.go", Line: 1, Severity: "Hint"},
+		{Path: "b.go", Line: 9, Severity: "Error"},
+		{Path: "a.go", Line: 3, Severity: "Warn"},
+		{Path: "a.go", Line: 2, Severity: "Error"},
+		{Path: "a.go", Line: 1, Severity: "Info"},
+	}
+	sortDiagnosticEntries(entries)
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Severity+" "+e.Path)
+	}
+	require.Equal(t, []string{"Error a.go", "Error b.go", "Warn a.go", "Info a.go", "Hint b.go"}, got)
+}
+
+func TestDiagnosticsToolScope(t *testing.T) {
+	t.Parallel()
+
+	tool := NewDiagnosticsTool(nil, &mockHistoryService{}, t.TempDir(), config.ToolDiagnostics{})
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(params DiagnosticsParams) fantasy.ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: DiagnosticsToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(DiagnosticsParams{Scope: "recent"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, `invalid scope "recent"`)
+
+	resp = run(DiagnosticsParams{Scope: DiagnosticsScopeChanged})
+	require.False(t, resp.IsError)
+	require.Equal(t, "No files have been changed in this session.", resp.Content)
+
+	resp = run(DiagnosticsParams{Path: "internal"})
+	require.False(t, resp.IsError)
+	var meta DiagnosticsResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Zero(t, meta.Total)
+}
@@ -0,0 +1,268 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type DocsParams struct {
+	Command string `json:"command" description:"The command to look up, optionally with subcommands, e.g. \"rg\" or \"git rebase\""`
+	Query   string `json:"query,omitempty" description:"Optional flag or keyword, e.g. \"--onto\"; only the parts of the documentation mentioning it are returned"`
+}
+
+type DocsPermissionsParams struct {
+	Command string `json:"command"`
+}
+
+type DocsResponseMetadata struct {
+	// Source is where the documentation came from: "man" or "--help".
+	Source    string `json:"source"`
+	Truncated bool   `json:"truncated"`
+}
+
+const (
+	DocsToolName = "docs"
+
+	// docsMaxOutput caps the documentation returned to the model.
+	docsMaxOutput = 16 * 1024
+	// docsMaxRead caps what is read from man or --help before trimming,
+	// so a command that ignores --help can't fill memory.
+	docsMaxRead = 1024 * 1024
+	docsTimeout = 10 * time.Second
+	// docsContextLines is how many lines are kept after a query match.
+	docsContextLines = 8
+	// docsMaxWords is the longest command, subcommands included, that
+	// can be looked up.
+	docsMaxWords = 3
+)
+
+//go:embed docs.md.tpl
+var docsDescriptionTmpl []byte
+
+var docsDescriptionTpl = template.Must(
+	template.New("docsDescription").
+		Parse(string(docsDescriptionTmpl)),
+)
+
+type docsDescriptionData struct {
+	MaxOutputKB int
+}
+
+func docsDescription() string {
+	return renderTemplate(docsDescriptionTpl, docsDescriptionData{
+		MaxOutputKB: docsMaxOutput / 1024,
+	})
+}
+
+// docsWord matches a command or subcommand name. Flags, paths and shell
+// syntax are rejected so the lookup can't be turned into a command line.
+var docsWord = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// overstrike matches the backspace sequences man uses for bold and
+// underlined text.
+var overstrike = regexp.MustCompile(".\b")
+
+func NewDocsTool(permissions permission.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DocsToolName,
+		docsDescription(),
+		func(ctx context.Context, params DocsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			words, err := docsCommandWords(params.Command)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if _, err := exec.LookPath(words[0]); err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("command %q not found", words[0])), nil
+			}
+
+			source := "man"
+			doc := readManPage(ctx, workingDir, words)
+			if doc == "" {
+				// Unlike man, --help runs the command itself, so it
+				// needs the same permission as running it with bash.
+				sessionID := GetSessionFromContext(ctx)
+				if sessionID == "" {
+					return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for reading command help")
+				}
+				helpCommand := strings.Join(words, " ") + " --help"
+				p, err := permissions.Request(ctx,
+					permission.CreatePermissionRequest{
+						SessionID:   sessionID,
+						Path:        workingDir,
+						ToolCallID:  call.ID,
+						ToolName:    DocsToolName,
+						Action:      "execute",
+						Description: fmt.Sprintf("Run %s to read its documentation", helpCommand),
+						Params:      DocsPermissionsParams{Command: helpCommand},
+					},
+				)
+				if err != nil {
+					return fantasy.ToolResponse{}, err
+				}
+				if !p {
+					return NewPermissionDeniedResponse(), nil
+				}
+				source = "--help"
+				// Many commands print their help to stderr or exit
+				// non-zero, so any output is taken as the help text.
+				doc, _ = runDocsCommand(ctx, workingDir, words[0], append(words[1:], "--help")...)
+			}
+			if doc == "" {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no documentation found for %q", strings.Join(words, " "))), nil
+			}
+
+			if params.Query != "" {
+				matched, ok := filterDocs(doc, params.Query)
+				if !ok {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%q is not mentioned in the documentation of %q", params.Query, strings.Join(words, " "))), nil
+				}
+				doc = matched
+			}
+
+			doc, truncated := truncateDocs(doc)
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(doc),
+				DocsResponseMetadata{Source: source, Truncated: truncated},
+			), nil
+		},
+	)
+}
+
+// docsCommandWords splits command into its words and checks that it names
+// a command the bash tool would be allowed to run.
+func docsCommandWords(command string) ([]string, error) {
+	words := strings.Fields(command)
+	if len(words) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	if len(words) > docsMaxWords {
+		return nil, fmt.Errorf("command can have at most %d words, such as \"git remote add\"", docsMaxWords)
+	}
+	for _, word := range words {
+		if !docsWord.MatchString(word) {
+			return nil, fmt.Errorf("invalid command %q: use only the command and subcommand names, without flags or arguments", command)
+		}
+	}
+	for _, block := range blockFuncs() {
+		if block(words) {
+			return nil, fmt.Errorf("command is not allowed for security reasons: %q", words[0])
+		}
+	}
+	return words, nil
+}
+
+// readManPage returns the man page for the subcommand, such as
+// git-rebase, falling back to the page of the command itself. It returns
+// an empty string when man or the page is not available.
+func readManPage(ctx context.Context, workingDir string, words []string) string {
+	if _, err := exec.LookPath("man"); err != nil {
+		return ""
+	}
+	pages := []string{strings.Join(words, "-")}
+	if len(words) > 1 {
+		pages = append(pages, words[0])
+	}
+	for _, page := range pages {
+		if doc, err := runDocsCommand(ctx, workingDir, "man", page); err == nil && doc != "" {
+			return doc
+		}
+	}
+	return ""
+}
+
+// runDocsCommand runs a documentation command with a timeout and no input
+// and returns its stdout and stderr as plain text. Output of a command
+// that timed out is dropped.
+func runDocsCommand(ctx context.Context, workingDir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, docsTimeout)
+	defer cancel()
+
+	var out docsBuffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), "MANPAGER=cat", "PAGER=cat", "MANWIDTH=100", "NO_COLOR=1")
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	cmd.WaitDelay = time.Second
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return cleanDocs(out.String()), err
+	}
+	return cleanDocs(out.String()), nil
+}
+
+// cleanDocs strips the formatting of man pages and colored help output.
+func cleanDocs(doc string) string {
+	doc = overstrike.ReplaceAllString(doc, "")
+	return strings.TrimSpace(ansi.Strip(doc))
+}
+
+// filterDocs returns the lines of doc mentioning query, case-insensitively,
+// each with the line before it and the lines after it. Overlapping
+// excerpts are merged and separated by "...".
+func filterDocs(doc, query string) (string, bool) {
+	lines := strings.Split(doc, "\n")
+	query = strings.ToLower(query)
+
+	var excerpts []string
+	end := -1
+	start := -1
+	for i, line := range lines {
+		if !strings.Contains(strings.ToLower(line), query) {
+			continue
+		}
+		from, to := max(i-1, 0), min(i+docsContextLines+1, len(lines))
+		if start >= 0 && from > end {
+			excerpts = append(excerpts, strings.Join(lines[start:end], "\n"))
+			start = -1
+		}
+		if start < 0 {
+			start = from
+		}
+		end = to
+	}
+	if start < 0 {
+		return "", false
+	}
+	excerpts = append(excerpts, strings.Join(lines[start:end], "\n"))
+	return strings.Join(excerpts, "\n...\n"), true
+}
+
+// truncateDocs cuts doc at a line boundary once it exceeds docsMaxOutput.
+func truncateDocs(doc string) (string, bool) {
+	if len(doc) <= docsMaxOutput {
+		return doc, false
+	}
+	doc = doc[:docsMaxOutput]
+	if i := strings.LastIndexByte(doc, '\n'); i > 0 {
+		doc = doc[:i]
+	}
+	return doc + "\n\n[truncated; set query to find a specific flag]", true
+}
+
+// docsBuffer keeps the first docsMaxRead bytes written to it and discards
+// the rest.
+type docsBuffer struct {
+	bytes.Buffer
+}
+
+func (b *docsBuffer) Write(p []byte) (int, error) {
+	if room := docsMaxRead - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
@@ -0,0 +1 @@
+Look up the man page or --help output of a command line tool (max {{ .MaxOutputKB }}KB). Use it to check the exact flags of a command before running it with bash instead of guessing. Set query to a flag or keyword to get only the matching parts.
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocsCommandWords(t *testing.T) {
+	t.Parallel()
+
+	words, err := docsCommandWords("  git   rebase ")
+	require.NoError(t, err)
+	require.Equal(t, []string{"git", "rebase"}, words)
+
+	for _, command := range []string{
+		"",
+		"git rebase --onto",
+		"ls; rm -rf /",
+		"../bin/tool",
+		"git remote add origin",
+		"curl",
+		"sudo",
+		"apt-get install",
+	} {
+		_, err := docsCommandWords(command)
+		require.Error(t, err, command)
+	}
+}
+
+func TestFilterDocs(t *testing.T) {
+	t.Parallel()
+
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = "line"
+	}
+	lines[5] = "  --onto <newbase>"
+	lines[7] = "  see --ONTO above"
+	lines[30] = "  --onto again"
+	doc := strings.Join(lines, "\n")
+
+	matched, ok := filterDocs(doc, "--onto")
+	require.True(t, ok)
+	excerpts := strings.Split(matched, "\n...\n")
+	require.Len(t, excerpts, 2)
+	require.True(t, strings.HasPrefix(excerpts[0], "line\n  --onto <newbase>"))
+	require.Contains(t, excerpts[0], "see --ONTO above")
+	require.Equal(t, 1+1+docsContextLines, strings.Count(excerpts[1], "\n")+1)
+
+	_, ok = filterDocs(doc, "--autosquash")
+	require.False(t, ok)
+}
+
+func TestCleanDocs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "NAME\n  git-rebase", cleanDocs("N\bNA\bAM\bME\bE\n  \x1b[1mgit-rebase\x1b[0m\n"))
+}
+
+func TestTruncateDocs(t *testing.T) {
+	t.Parallel()
+
+	doc, truncated := truncateDocs("short")
+	require.False(t, truncated)
+	require.Equal(t, "short", doc)
+
+	doc, truncated = truncateDocs(strings.Repeat("0123456789\n", docsMaxOutput/5))
+	require.True(t, truncated)
+	require.LessOrEqual(t, len(doc), docsMaxOutput+100)
+	require.True(t, strings.HasSuffix(doc, "set query to find a specific flag]"))
+}
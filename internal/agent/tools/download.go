@@ -14,7 +14,9 @@ import (
 	"time"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/permission"
 )
 
@@ -28,6 +30,15 @@ type DownloadPermissionsParams struct {
 	URL      string `json:"url"`
 	FilePath string `json:"file_path"`
 	Timeout  int    `json:"timeout,omitempty"`
+	// Overwrite is set when the download replaces an existing file.
+	Overwrite bool `json:"overwrite,omitempty"`
+}
+
+type DownloadResponseMetadata struct {
+	Overwritten bool `json:"overwritten"`
+	// Ignored is set when the file was saved to a path ignored by
+	// .gitignore or .crushignore.
+	Ignored bool `json:"ignored,omitempty"`
 }
 
 const DownloadToolName = "download"
@@ -50,7 +61,7 @@ func downloadDescription() string {
 	})
 }
 
-func NewDownloadTool(permissions permission.Service, workingDir string, client *http.Client) fantasy.AgentTool {
+func NewDownloadTool(permissions permission.Service, workingDir string, allowOutsideWorkdir bool, opts config.ToolDownload, client *http.Client) fantasy.AgentTool {
 	if client == nil {
 		transport := http.DefaultTransport.(*http.Transport).Clone()
 		transport.MaxIdleConns = 100
@@ -79,23 +90,53 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 			}
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, ok := checkWithinWorkingDir(workingDir, filePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
 			relPath, _ := filepath.Rel(workingDir, filePath)
 			relPath = filepath.ToSlash(cmp.Or(relPath, filePath))
 
+			ignored := false
+			if policy := opts.GetIgnoredPaths(); policy != config.DownloadIgnoredAllow {
+				ignored = isIgnoredPath(workingDir, filePath)
+				if ignored && policy == config.DownloadIgnoredRefuse {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is ignored by .gitignore or .crushignore; downloads into ignored paths are refused", relPath)), nil
+				}
+			}
+
+			info, err := os.Stat(filePath)
+			overwrite := err == nil
+			if overwrite && info.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is a directory", relPath)), nil
+			}
+
 			sessionID := GetSessionFromContext(ctx)
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for downloading files")
 			}
 
+			// Replacing a file needs its own grant, so that allowing
+			// downloads for the session does not allow overwrites.
+			action := "download"
+			description := fmt.Sprintf("Download file from URL: %s to %s", params.URL, filePath)
+			if overwrite {
+				action = "overwrite"
+				description = fmt.Sprintf("Overwrite %s with file downloaded from URL: %s", filePath, params.URL)
+			}
 			p, err := permissions.Request(
 				ctx,
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        filePath,
 					ToolName:    DownloadToolName,
-					Action:      "download",
-					Description: fmt.Sprintf("Download file from URL: %s to %s", params.URL, filePath),
-					Params:      DownloadPermissionsParams(params),
+					Action:      action,
+					Description: description,
+					Params: DownloadPermissionsParams{
+						URL:       params.URL,
+						FilePath:  params.FilePath,
+						Timeout:   params.Timeout,
+						Overwrite: overwrite,
+					},
 				},
 			)
 			if err != nil {
@@ -156,11 +197,37 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 
 			contentType := resp.Header.Get("Content-Type")
 			responseMsg := fmt.Sprintf("Successfully downloaded %d bytes to %s", bytesWritten, relPath)
+			if overwrite {
+				responseMsg = fmt.Sprintf("Successfully downloaded %d bytes to %s, replacing the existing file", bytesWritten, relPath)
+			}
 			if contentType != "" {
 				responseMsg += fmt.Sprintf(" (Content-Type: %s)", contentType)
 			}
+			if ignored {
+				responseMsg += fmt.Sprintf("\nNote: %s is ignored by .gitignore or .crushignore, so it is not tracked.", relPath)
+			}
 
-			return fantasy.NewTextResponse(responseMsg), nil
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(responseMsg), DownloadResponseMetadata{
+				Overwritten: overwrite,
+				Ignored:     ignored,
+			}), nil
 		},
 	)
 }
+
+// isIgnoredPath reports whether filePath, or a directory between it and
+// workingDir, is ignored by .gitignore, .crushignore or the common ignore
+// patterns. Paths outside workingDir are never ignored.
+func isIgnoredPath(workingDir, filePath string) bool {
+	rel, err := filepath.Rel(workingDir, filePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	walker := fsext.NewFastGlobWalker(workingDir)
+	for dir := filepath.Dir(filePath); dir != workingDir && len(dir) > len(workingDir); dir = filepath.Dir(dir) {
+		if walker.ShouldSkipDir(dir) {
+			return true
+		}
+	}
+	return walker.ShouldSkip(filePath)
+}
@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+// actionRecordingPermissionService grants every request and records its
+// action.
+type actionRecordingPermissionService struct {
+	mockBashPermissionService
+	actions []string
+}
+
+func (m *actionRecordingPermissionService) Request(ctx context.Context, req permission.CreatePermissionRequest) (bool, error) {
+	m.actions = append(m.actions, req.Action)
+	return true, nil
+}
+
+func TestDownloadTool(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("new content"))
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("generated/\n"), 0o644))
+
+	run := func(opts config.ToolDownload, filePath string) (fantasy.ToolResponse, *actionRecordingPermissionService) {
+		perms := &actionRecordingPermissionService{}
+		tool := NewDownloadTool(perms, dir, false, opts, srv.Client())
+		input, err := json.Marshal(DownloadParams{URL: srv.URL, FilePath: filePath})
+		require.NoError(t, err)
+		ctx := context.WithValue(t.Context(), SessionIDContextKey, "test-session")
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: DownloadToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp, perms
+	}
+	metadata := func(resp fantasy.ToolResponse) DownloadResponseMetadata {
+		var meta DownloadResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		return meta
+	}
+
+	t.Run("new file", func(t *testing.T) {
+		resp, perms := run(config.ToolDownload{}, "data.txt")
+		require.False(t, resp.IsError, resp.Content)
+		require.Equal(t, []string{"download"}, perms.actions)
+		require.Equal(t, DownloadResponseMetadata{}, metadata(resp))
+	})
+
+	t.Run("overwrite needs its own permission", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("old"), 0o644))
+		resp, perms := run(config.ToolDownload{}, "existing.txt")
+		require.False(t, resp.IsError, resp.Content)
+		require.Equal(t, []string{"overwrite"}, perms.actions)
+		require.Contains(t, resp.Content, "replacing the existing file")
+		require.True(t, metadata(resp).Overwritten)
+		got, err := os.ReadFile(filepath.Join(dir, "existing.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "new content", string(got))
+	})
+
+	t.Run("ignored path warns", func(t *testing.T) {
+		resp, _ := run(config.ToolDownload{}, "generated/out.txt")
+		require.False(t, resp.IsError, resp.Content)
+		require.Contains(t, resp.Content, "is ignored by .gitignore")
+		require.True(t, metadata(resp).Ignored)
+	})
+
+	t.Run("ignored path refused", func(t *testing.T) {
+		resp, perms := run(config.ToolDownload{IgnoredPaths: config.DownloadIgnoredRefuse}, "node_modules/pkg/index.js")
+		require.True(t, resp.IsError)
+		require.Empty(t, perms.actions)
+		require.NoFileExists(t, filepath.Join(dir, "node_modules", "pkg", "index.js"))
+	})
+
+	t.Run("ignored path allowed", func(t *testing.T) {
+		resp, _ := run(config.ToolDownload{IgnoredPaths: config.DownloadIgnoredAllow}, "generated/other.txt")
+		require.False(t, resp.IsError, resp.Content)
+		require.NotContains(t, resp.Content, "ignored")
+	})
+}
@@ -60,6 +60,7 @@ func NewEditTool(
 	files history.Service,
 	filetracker filetracker.Service,
 	workingDir string,
+	allowOutsideWorkdir bool,
 ) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		EditToolName,
@@ -70,6 +71,9 @@ func NewEditTool(
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, ok := checkWithinWorkingDir(workingDir, params.FilePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
 
 			var response fantasy.ToolResponse
 			var err error
@@ -194,7 +198,18 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 // findAndReplace performs a find-and-replace on content. When replaceAll is
 // false it requires exactly one match. Returns the new content or an error
 // describing why the replacement could not be made.
+//
+// If old is not in content but consists of lines copied from view output,
+// the line number prefixes are stripped from old (and from new, if it has
+// them too) before matching.
 func findAndReplace(content, old, new string, replaceAll bool) (string, error) {
+	if !strings.Contains(content, old) {
+		if stripped, ok := stripLineNumbers(old); ok && strings.Contains(content, stripped) {
+			old = stripped
+			new, _ = stripLineNumbers(new)
+		}
+	}
+
 	if replaceAll {
 		if !strings.Contains(content, old) {
 			return "", fmt.Errorf("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks")
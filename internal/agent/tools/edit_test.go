@@ -85,3 +85,38 @@ func TestDeleteContentRejectsMultipleMatchesWithoutReplaceAll(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "alpha\nbeta\nalpha\n", string(content))
 }
+
+func TestFindAndReplaceStripsViewLineNumbers(t *testing.T) {
+	t.Parallel()
+
+	content := "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+	numbered := addLineNumbers("func main() {\n\tprintln(\"hi\")", 3)
+
+	t.Run("numbered old and new", func(t *testing.T) {
+		t.Parallel()
+		got, err := findAndReplace(content, numbered, addLineNumbers("func main() {\n\tprintln(\"bye\")", 3), false)
+		require.NoError(t, err)
+		require.Equal(t, "package main\n\nfunc main() {\n\tprintln(\"bye\")\n}\n", got)
+	})
+
+	t.Run("numbered old only", func(t *testing.T) {
+		t.Parallel()
+		got, err := findAndReplace(content, numbered, "func main() {}", false)
+		require.NoError(t, err)
+		require.Equal(t, "package main\n\nfunc main() {}\n}\n", got)
+	})
+
+	t.Run("exact matches win", func(t *testing.T) {
+		t.Parallel()
+		literal := "     1|x\n"
+		got, err := findAndReplace(literal, "     1|x", "     2|y", false)
+		require.NoError(t, err)
+		require.Equal(t, "     2|y\n", got)
+	})
+
+	t.Run("partially numbered old is not stripped", func(t *testing.T) {
+		t.Parallel()
+		_, err := findAndReplace(content, "     3|func main() {\n\tprintln(\"hi\")", "", false)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,316 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+const EnvEditToolName = "env_edit"
+
+// Operations of the env_edit tool.
+const (
+	EnvEditGet   = "get"
+	EnvEditSet   = "set"
+	EnvEditUnset = "unset"
+)
+
+//go:embed env_edit.md
+var envEditDescription []byte
+
+type EnvEditParams struct {
+	Operation string `json:"operation" description:"get, set or unset"`
+	Key       string `json:"key,omitempty" description:"The variable name. Required for set and unset; omit it with get to list every key in the file"`
+	Value     string `json:"value,omitempty" description:"The value for set. It is quoted as needed when written"`
+	FilePath  string `json:"file_path,omitempty" description:"The dotenv file. Defaults to .env in the working directory"`
+}
+
+// EnvEditPermissionsParams describes a change to a dotenv file. It carries
+// the length of a new value, never the value itself.
+type EnvEditPermissionsParams struct {
+	FilePath    string `json:"file_path"`
+	Operation   string `json:"operation"`
+	Key         string `json:"key"`
+	ValueLength int    `json:"value_length,omitempty"`
+}
+
+type EnvEditResponseMetadata struct {
+	FilePath  string `json:"file_path"`
+	Operation string `json:"operation"`
+	// Keys lists the keys of the file for a get without key.
+	Keys []string `json:"keys,omitempty"`
+	// Created is set when set created the file.
+	Created bool `json:"created,omitempty"`
+}
+
+// NewEnvEditTool returns a tool that reads and changes dotenv files by key.
+// Unlike edit, it keeps no file history, so the values it writes are not
+// stored in the session.
+func NewEnvEditTool(permissions permission.Service, workingDir string, allowOutsideWorkdir bool) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		EnvEditToolName,
+		string(envEditDescription),
+		func(ctx context.Context, params EnvEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			switch params.Operation {
+			case EnvEditGet:
+			case EnvEditSet, EnvEditUnset:
+				if params.Key == "" {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("key is required for %s", params.Operation)), nil
+				}
+			default:
+				return fantasy.NewTextErrorResponse("operation must be get, set or unset"), nil
+			}
+			if params.Key != "" && !envKeyPattern.MatchString(params.Key) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid key %q: use letters, digits, underscores and dots, not starting with a digit", params.Key)), nil
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, cmp.Or(params.FilePath, ".env"))
+			if resp, ok := checkWithinWorkingDir(workingDir, filePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
+			meta := EnvEditResponseMetadata{FilePath: filePath, Operation: params.Operation}
+
+			data, err := os.ReadFile(filePath)
+			exists := err == nil
+			switch {
+			case errors.Is(err, fs.ErrNotExist) && params.Operation == EnvEditSet:
+			case errors.Is(err, fs.ErrNotExist):
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("file not found: %s", filePath)), nil
+			case err != nil:
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read file: %v", err)), nil
+			}
+			content, isCrlf := fsext.ToUnixLineEndings(string(data))
+			env := parseEnvFile(content)
+
+			if params.Operation == EnvEditGet {
+				if params.Key == "" {
+					meta.Keys = env.keys()
+					if len(meta.Keys) == 0 {
+						return fantasy.WithResponseMetadata(fantasy.NewTextResponse(fmt.Sprintf("%s defines no keys", filePath)), meta), nil
+					}
+					text := fmt.Sprintf("%s defines %d key(s):\n%s", filePath, len(meta.Keys), strings.Join(meta.Keys, "\n"))
+					return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+				}
+				var text string
+				switch entry, ok := env.lookup(params.Key); {
+				case !ok:
+					text = fmt.Sprintf("%s is not set in %s", params.Key, filePath)
+				case entry.empty:
+					text = fmt.Sprintf("%s is set to an empty value in %s", params.Key, filePath)
+				default:
+					text = fmt.Sprintf("%s is set in %s", params.Key, filePath)
+				}
+				return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+			}
+
+			var newContent, description, text string
+			if params.Operation == EnvEditSet {
+				newContent = env.set(params.Key, params.Value)
+				description = fmt.Sprintf("Set %s in %s", params.Key, filePath)
+				text = description
+				if !exists {
+					meta.Created = true
+					text = fmt.Sprintf("Created %s and set %s", filePath, params.Key)
+				}
+			} else {
+				if _, ok := env.lookup(params.Key); !ok {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is not set in %s", params.Key, filePath)), nil
+				}
+				newContent = env.unset(params.Key)
+				description = fmt.Sprintf("Remove %s from %s", params.Key, filePath)
+				text = fmt.Sprintf("Removed %s from %s", params.Key, filePath)
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for editing a dotenv file")
+			}
+			p, err := permissions.Request(ctx, permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        fsext.PathOrPrefix(filePath, workingDir),
+				ToolCallID:  call.ID,
+				ToolName:    EnvEditToolName,
+				Action:      "write",
+				Description: description,
+				Params: EnvEditPermissionsParams{
+					FilePath:    filePath,
+					Operation:   params.Operation,
+					Key:         params.Key,
+					ValueLength: len(params.Value),
+				},
+			})
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			if isCrlf {
+				newContent, _ = fsext.ToWindowsLineEndings(newContent)
+			}
+			mode := fs.FileMode(0o600)
+			if info, err := os.Stat(filePath); err == nil {
+				mode = info.Mode().Perm()
+			}
+			if err := os.WriteFile(filePath, []byte(newContent), mode); err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+		},
+	)
+}
+
+var (
+	envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	// envAssignment matches the start of an assignment: an optional
+	// export, the key and the equals sign.
+	envAssignment = regexp.MustCompile(`^\s*(export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=\s*`)
+)
+
+// envFile is a dotenv file split into lines, with the assignments found in
+// it. Lines that are not assignments, such as comments, are kept as is.
+type envFile struct {
+	lines   []string
+	entries []envEntry
+}
+
+// envEntry is one assignment, spanning lines first to last when its value
+// is quoted across lines.
+type envEntry struct {
+	key         string
+	export      bool
+	empty       bool
+	first, last int
+}
+
+func parseEnvFile(content string) *envFile {
+	f := &envFile{}
+	if content != "" {
+		f.lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	for i := 0; i < len(f.lines); i++ {
+		m := envAssignment.FindStringSubmatchIndex(f.lines[i])
+		if m == nil {
+			continue
+		}
+		line := f.lines[i]
+		entry := envEntry{key: line[m[4]:m[5]], export: m[2] >= 0, first: i, last: i}
+		value := strings.TrimSpace(line[m[1]:])
+		switch {
+		case value == "", value == `""`, value == "''", strings.HasPrefix(value, "#"):
+			entry.empty = true
+		case value[0] == '"' || value[0] == '\'':
+			// A quoted value runs until its closing quote, possibly on a
+			// later line.
+			rest := value[1:]
+			for !envQuoteClosed(rest, value[0]) && entry.last+1 < len(f.lines) {
+				entry.last++
+				rest += "\n" + f.lines[entry.last]
+			}
+		}
+		f.entries = append(f.entries, entry)
+		i = entry.last
+	}
+	return f
+}
+
+// envQuoteClosed reports whether s, the text after an opening quote,
+// contains the closing quote. Double quotes can be escaped with a
+// backslash.
+func envQuoteClosed(s string, quote byte) bool {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return true
+		}
+	}
+	return false
+}
+
+// keys returns the keys assigned in the file in order of first
+// appearance.
+func (f *envFile) keys() []string {
+	var keys []string
+	for _, e := range f.entries {
+		if !slices.Contains(keys, e.key) {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// lookup returns the last assignment of key, the one that takes effect.
+func (f *envFile) lookup(key string) (envEntry, bool) {
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		if f.entries[i].key == key {
+			return f.entries[i], true
+		}
+	}
+	return envEntry{}, false
+}
+
+// set returns the file content with every assignment of key replaced by
+// one setting value, or with such an assignment appended.
+func (f *envFile) set(key, value string) string {
+	lines := slices.Clone(f.lines)
+	found := false
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		e := f.entries[i]
+		if e.key != key {
+			continue
+		}
+		line := key + "=" + formatEnvValue(value)
+		if e.export {
+			line = "export " + line
+		}
+		lines = slices.Replace(lines, e.first, e.last+1, line)
+		found = true
+	}
+	if !found {
+		lines = append(lines, key+"="+formatEnvValue(value))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// unset returns the file content without the assignments of key.
+func (f *envFile) unset(key string) string {
+	lines := slices.Clone(f.lines)
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		if e := f.entries[i]; e.key == key {
+			lines = slices.Delete(lines, e.first, e.last+1)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatEnvValue quotes value when it would not survive unquoted: single
+// quotes when that is enough, double quotes with escapes otherwise.
+func formatEnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t\r\n#\"'\\$`") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\r\n") {
+		return "'" + value + "'"
+	}
+	return `"` + envValueEscaper.Replace(value) + `"`
+}
+
+var envValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
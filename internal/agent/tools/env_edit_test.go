@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvFileSetUnset(t *testing.T) {
+	t.Parallel()
+
+	content := "# database\nDB_HOST=localhost\nexport DB_PASS=\"multi\nline\"\n\nEMPTY=\nDB_HOST=override # later wins\n"
+	env := parseEnvFile(content)
+	require.Equal(t, []string{"DB_HOST", "DB_PASS", "EMPTY"}, env.keys())
+
+	entry, ok := env.lookup("DB_HOST")
+	require.True(t, ok)
+	require.Equal(t, 6, entry.first)
+	entry, ok = env.lookup("EMPTY")
+	require.True(t, ok)
+	require.True(t, entry.empty)
+	_, ok = env.lookup("MISSING")
+	require.False(t, ok)
+
+	require.Equal(t,
+		"# database\nDB_HOST=localhost\nexport DB_PASS='new secret'\n\nEMPTY=\nDB_HOST=override # later wins\n",
+		env.set("DB_PASS", "new secret"))
+	require.Equal(t,
+		"# database\nDB_HOST=db\nexport DB_PASS=\"multi\nline\"\n\nEMPTY=\nDB_HOST=db\n",
+		env.set("DB_HOST", "db"))
+	require.Equal(t, content+"API_KEY=abc123\n", env.set("API_KEY", "abc123"))
+	require.Equal(t,
+		"# database\nDB_HOST=localhost\n\nEMPTY=\nDB_HOST=override # later wins\n",
+		env.unset("DB_PASS"))
+	require.Equal(t, "FOO=bar\n", parseEnvFile("").set("FOO", "bar"))
+}
+
+func TestFormatEnvValue(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "plain", formatEnvValue("plain"))
+	require.Equal(t, "", formatEnvValue(""))
+	require.Equal(t, "'has space'", formatEnvValue("has space"))
+	require.Equal(t, `'$HOME'`, formatEnvValue("$HOME"))
+	require.Equal(t, `"it's \$HOME\nnext"`, formatEnvValue("it's $HOME\nnext"))
+}
+
+func TestEnvEditTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(envPath, []byte("# keys\r\nTOKEN=s3cr3t-value\r\n"), 0o600))
+
+	tool := NewEnvEditTool(&mockPermissionService{}, dir, false)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	run := func(params EnvEditParams) fantasy.ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: EnvEditToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(EnvEditParams{Operation: EnvEditGet})
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "TOKEN")
+	require.NotContains(t, resp.Content, "s3cr3t")
+
+	resp = run(EnvEditParams{Operation: EnvEditGet, Key: "TOKEN"})
+	require.Contains(t, resp.Content, "TOKEN is set")
+	require.NotContains(t, resp.Content, "s3cr3t")
+
+	resp = run(EnvEditParams{Operation: EnvEditSet, Key: "NEW_KEY", Value: "another secret"})
+	require.False(t, resp.IsError, resp.Content)
+	require.NotContains(t, resp.Content, "another secret")
+
+	resp = run(EnvEditParams{Operation: EnvEditUnset, Key: "TOKEN"})
+	require.False(t, resp.IsError, resp.Content)
+
+	data, err := os.ReadFile(envPath)
+	require.NoError(t, err)
+	require.Equal(t, "# keys\r\nNEW_KEY='another secret'\r\n", string(data))
+	info, err := os.Stat(envPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	resp = run(EnvEditParams{Operation: EnvEditUnset, Key: "TOKEN"})
+	require.True(t, resp.IsError)
+
+	resp = run(EnvEditParams{Operation: EnvEditSet, Key: "1BAD", Value: "x"})
+	require.True(t, resp.IsError)
+
+	resp = run(EnvEditParams{Operation: EnvEditSet, Key: "KEY", Value: "x", FilePath: filepath.Join(t.TempDir(), ".env")})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "outside the working directory")
+
+	resp = run(EnvEditParams{Operation: EnvEditSet, Key: "KEY", Value: "x", FilePath: ".env.local"})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "Created")
+	data, err = os.ReadFile(filepath.Join(dir, ".env.local"))
+	require.NoError(t, err)
+	require.Equal(t, "KEY=x\n", string(data))
+}
@@ -0,0 +1,622 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type IssueParams struct {
+	URL         string `json:"url" description:"The URL of a GitHub issue or pull request, or a GitLab issue or merge request"`
+	MaxComments int    `json:"max_comments,omitempty" description:"Optional number of comments to include, oldest first (default: 30, max: 100)"`
+}
+
+type IssuePermissionsParams struct {
+	URL string `json:"url"`
+}
+
+type IssueResponseMetadata struct {
+	Host string `json:"host"`
+	Repo string `json:"repo"`
+	// Kind is "issue", "pull request" or "merge request".
+	Kind      string   `json:"kind"`
+	Number    int      `json:"number"`
+	Title     string   `json:"title"`
+	State     string   `json:"state"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels,omitempty"`
+	Comments  int      `json:"comments"`
+	Truncated bool     `json:"truncated"`
+}
+
+const (
+	IssueToolName = "issue"
+
+	issueDefaultComments = 30
+	issueMaxComments     = 100
+	// issueMaxDiff caps the diff of a pull or merge request; the rest of
+	// the output is capped at MaxFetchSize like fetch.
+	issueMaxDiff = 48 * 1024
+	// issueMaxRead caps every API response, so a huge diff can't fill
+	// memory before it is trimmed.
+	issueMaxRead = 4 * 1024 * 1024
+)
+
+const (
+	issueKindIssue        = "issue"
+	issueKindPullRequest  = "pull request"
+	issueKindMergeRequest = "merge request"
+)
+
+//go:embed issue.md.tpl
+var issueDescriptionTmpl []byte
+
+var issueDescriptionTpl = template.Must(
+	template.New("issueDescription").
+		Parse(string(issueDescriptionTmpl)),
+)
+
+type issueDescriptionData struct {
+	MaxComments int
+	MaxDiffKB   int
+}
+
+func issueDescription() string {
+	return renderTemplate(issueDescriptionTpl, issueDescriptionData{
+		MaxComments: issueMaxComments,
+		MaxDiffKB:   issueMaxDiff / 1024,
+	})
+}
+
+// issueRef identifies an issue, pull request or merge request.
+type issueRef struct {
+	host   string
+	gitlab bool
+	// repo is owner/name on GitHub, or the full project path on GitLab.
+	repo   string
+	kind   string
+	number int
+}
+
+// parseIssueURL parses a GitHub issue or pull request URL, or a GitLab
+// issue or merge request URL. GitLab is told apart by the "/-/" in its
+// paths, so self-hosted instances work without extra config.
+func parseIssueURL(raw string) (issueRef, error) {
+	errInvalid := fmt.Errorf("%q is not a link to an issue, pull request or merge request", raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return issueRef{}, errInvalid
+	}
+	ref := issueRef{host: strings.ToLower(u.Hostname())}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var kind, number string
+	if i := slices.Index(parts, "-"); i >= 2 && len(parts) >= i+3 {
+		ref.gitlab = true
+		ref.repo = strings.Join(parts[:i], "/")
+		kind, number = parts[i+1], parts[i+2]
+	} else if len(parts) >= 4 {
+		ref.repo = parts[0] + "/" + parts[1]
+		kind, number = parts[2], parts[3]
+	} else {
+		return issueRef{}, errInvalid
+	}
+	switch {
+	case kind == "issues":
+		ref.kind = issueKindIssue
+	case kind == "pull" && !ref.gitlab:
+		ref.kind = issueKindPullRequest
+	case kind == "merge_requests" && ref.gitlab:
+		ref.kind = issueKindMergeRequest
+	default:
+		return issueRef{}, errInvalid
+	}
+	if ref.number, err = strconv.Atoi(number); err != nil || ref.number <= 0 {
+		return issueRef{}, errInvalid
+	}
+	return ref, nil
+}
+
+// apiBase returns the REST API root for the host of ref.
+func (r issueRef) apiBase() string {
+	switch {
+	case r.gitlab:
+		return "https://" + r.host + "/api/v4"
+	case r.host == "github.com":
+		return "https://api.github.com"
+	default:
+		// GitHub Enterprise Server.
+		return "https://" + r.host + "/api/v3"
+	}
+}
+
+// String returns the short reference, e.g. "owner/repo#12" or
+// "group/project!34" for a merge request.
+func (r issueRef) String() string {
+	sep := "#"
+	if r.kind == issueKindMergeRequest {
+		sep = "!"
+	}
+	return r.repo + sep + strconv.Itoa(r.number)
+}
+
+type issueComment struct {
+	author  string
+	created time.Time
+	body    string
+}
+
+type issueData struct {
+	kind          string
+	title         string
+	state         string
+	author        string
+	created       time.Time
+	body          string
+	labels        []string
+	head, base    string
+	comments      []issueComment
+	totalComments int
+	diff          string
+}
+
+// issueRateLimitError is returned when a host rate limits the tool.
+type issueRateLimitError struct {
+	host  string
+	reset time.Time
+}
+
+func (e *issueRateLimitError) Error() string {
+	return fmt.Sprintf("%s is rate limiting requests until %s; try again later or set a token in tools.issue", e.host, e.reset.Format(time.Kitchen))
+}
+
+// issueLimits remembers hosts that rate limited the tool, so further calls
+// fail right away instead of spending more of the limit.
+type issueLimits struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func (l *issueLimits) check(host string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if reset, ok := l.until[host]; ok && time.Now().Before(reset) {
+		return &issueRateLimitError{host: host, reset: reset}
+	}
+	return nil
+}
+
+func (l *issueLimits) set(err *issueRateLimitError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.until == nil {
+		l.until = map[string]time.Time{}
+	}
+	l.until[err.host] = err.reset
+}
+
+func NewIssueTool(permissions permission.Service, workingDir string, opts config.ToolIssue, client *http.Client) fantasy.AgentTool {
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConns = 100
+		transport.MaxIdleConnsPerHost = 10
+		transport.IdleConnTimeout = 90 * time.Second
+
+		client = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: transport,
+		}
+	}
+	hosts := opts.GetHosts()
+	limits := &issueLimits{}
+
+	return fantasy.NewParallelAgentTool(
+		IssueToolName,
+		issueDescription(),
+		func(ctx context.Context, params IssueParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.URL == "" {
+				return fantasy.NewTextErrorResponse("URL parameter is required"), nil
+			}
+			ref, err := parseIssueURL(params.URL)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if !slices.Contains(hosts, ref.host) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("%s is not in the hosts the issue tool may fetch from (%s); add it to tools.issue.hosts", ref.host, strings.Join(hosts, ", "))), nil
+			}
+			if err := limits.check(ref.host); err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			maxComments := params.MaxComments
+			if maxComments <= 0 {
+				maxComments = issueDefaultComments
+			} else if maxComments > issueMaxComments {
+				maxComments = issueMaxComments
+			}
+
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for fetching an issue")
+			}
+			p, err := permissions.Request(
+				ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    IssueToolName,
+					Action:      "fetch",
+					Description: fmt.Sprintf("Fetch %s %s from %s", ref.kind, ref, ref.host),
+					Params:      IssuePermissionsParams{URL: params.URL},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			f := &issueFetcher{client: client, ref: ref, token: issueToken(opts, ref)}
+			var data issueData
+			if ref.gitlab {
+				data, err = f.fetchGitLab(ctx, maxComments)
+			} else {
+				data, err = f.fetchGitHub(ctx, maxComments)
+			}
+			if rateErr, ok := errors.AsType[*issueRateLimitError](err); ok {
+				limits.set(rateErr)
+				return fantasy.NewTextErrorResponse(rateErr.Error()), nil
+			}
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			content, truncated := formatIssue(ref, data)
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(content),
+				IssueResponseMetadata{
+					Host:      ref.host,
+					Repo:      ref.repo,
+					Kind:      data.kind,
+					Number:    ref.number,
+					Title:     data.title,
+					State:     data.state,
+					Author:    data.author,
+					Labels:    data.labels,
+					Comments:  data.totalComments,
+					Truncated: truncated,
+				},
+			), nil
+		},
+	)
+}
+
+// issueToken returns the token to send to the host of ref. github.com and
+// gitlab.com use the configured token, falling back to the environment
+// variables their CLIs use. Any other host, such as GitHub Enterprise or a
+// self-hosted GitLab, only gets a token set for it in tools.issue.tokens,
+// so a github.com or gitlab.com credential never leaves for another
+// server. gh keeps GH_ENTERPRISE_TOKEN apart for the same reason.
+func issueToken(opts config.ToolIssue, ref issueRef) string {
+	switch ref.host {
+	case "github.com":
+		return cmp.Or(opts.GitHubToken, os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"))
+	case "gitlab.com":
+		return cmp.Or(opts.GitLabToken, os.Getenv("GITLAB_TOKEN"))
+	default:
+		return opts.Tokens[ref.host]
+	}
+}
+
+type issueFetcher struct {
+	client *http.Client
+	ref    issueRef
+	token  string
+}
+
+// get requests path from the API and returns the body.
+func (f *issueFetcher) get(ctx context.Context, path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.ref.apiBase()+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "crush/1.0")
+	req.Header.Set("Accept", accept)
+	if f.token != "" {
+		if f.ref.gitlab {
+			req.Header.Set("PRIVATE-TOKEN", f.token)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+f.token)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", f.ref, err)
+	}
+	defer resp.Body.Close()
+
+	if reset, ok := issueRateLimitReset(resp); ok {
+		return nil, &issueRateLimitError{host: f.ref.host, reset: reset}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, issueMaxRead))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return body, nil
+	case resp.StatusCode == http.StatusNotFound && f.token == "":
+		return nil, fmt.Errorf("%s was not found; if it is private, set a token in tools.issue", f.ref)
+	default:
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		return nil, fmt.Errorf("request for %s failed with status code %d: %s", f.ref, resp.StatusCode, cmp.Or(apiErr.Message, http.StatusText(resp.StatusCode)))
+	}
+}
+
+func (f *issueFetcher) getJSON(ctx context.Context, path string, v any) error {
+	body, err := f.get(ctx, path, "application/json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response for %s: %w", f.ref, err)
+	}
+	return nil
+}
+
+// issueRateLimitReset reports whether resp is a rate limit response and
+// when the limit resets. GitHub uses X-RateLimit-* headers, GitLab uses
+// RateLimit-*, and both may send Retry-After.
+func issueRateLimitReset(resp *http.Response) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if resp.Header.Get(prefix+"Remaining") != "0" {
+			continue
+		}
+		if reset, err := strconv.ParseInt(resp.Header.Get(prefix+"Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+		return time.Now().Add(time.Minute), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return time.Now().Add(time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+func (f *issueFetcher) fetchGitHub(ctx context.Context, maxComments int) (issueData, error) {
+	var issue struct {
+		Title     string    `json:"title"`
+		State     string    `json:"state"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		User      struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		Comments    int `json:"comments"`
+		PullRequest *struct {
+			MergedAt *time.Time `json:"merged_at"`
+		} `json:"pull_request"`
+	}
+	// The issues endpoint serves pull requests too, so an issue link that
+	// redirects to a pull request still works.
+	issuePath := fmt.Sprintf("/repos/%s/issues/%d", f.ref.repo, f.ref.number)
+	if err := f.getJSON(ctx, issuePath, &issue); err != nil {
+		return issueData{}, err
+	}
+	data := issueData{
+		kind:          issueKindIssue,
+		title:         issue.Title,
+		state:         issue.State,
+		author:        issue.User.Login,
+		created:       issue.CreatedAt,
+		body:          issue.Body,
+		totalComments: issue.Comments,
+	}
+	for _, label := range issue.Labels {
+		data.labels = append(data.labels, label.Name)
+	}
+
+	var comments []struct {
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		User      struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/comments?per_page=%d", issuePath, maxComments), &comments); err != nil {
+		return issueData{}, err
+	}
+	for _, c := range comments {
+		data.comments = append(data.comments, issueComment{author: c.User.Login, created: c.CreatedAt, body: c.Body})
+	}
+
+	if issue.PullRequest == nil {
+		return data, nil
+	}
+	data.kind = issueKindPullRequest
+	if issue.PullRequest.MergedAt != nil {
+		data.state = "merged"
+	}
+	pullPath := fmt.Sprintf("/repos/%s/pulls/%d", f.ref.repo, f.ref.number)
+	var pull struct {
+		Head struct {
+			Label string `json:"label"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}
+	if err := f.getJSON(ctx, pullPath, &pull); err != nil {
+		return issueData{}, err
+	}
+	data.head, data.base = pull.Head.Label, pull.Base.Ref
+	diff, err := f.get(ctx, pullPath, "application/vnd.github.diff")
+	if err != nil {
+		return issueData{}, err
+	}
+	data.diff = string(diff)
+	return data, nil
+}
+
+func (f *issueFetcher) fetchGitLab(ctx context.Context, maxComments int) (issueData, error) {
+	resource := "issues"
+	if f.ref.kind == issueKindMergeRequest {
+		resource = "merge_requests"
+	}
+	// GitLab takes the project path URL-encoded in place of its ID.
+	itemPath := fmt.Sprintf("/projects/%s/%s/%d", url.PathEscape(f.ref.repo), resource, f.ref.number)
+	var item struct {
+		Title       string    `json:"title"`
+		State       string    `json:"state"`
+		Description string    `json:"description"`
+		CreatedAt   time.Time `json:"created_at"`
+		Author      struct {
+			Username string `json:"username"`
+		} `json:"author"`
+		Labels         []string `json:"labels"`
+		UserNotesCount int      `json:"user_notes_count"`
+		SourceBranch   string   `json:"source_branch"`
+		TargetBranch   string   `json:"target_branch"`
+	}
+	if err := f.getJSON(ctx, itemPath, &item); err != nil {
+		return issueData{}, err
+	}
+	data := issueData{
+		kind:          f.ref.kind,
+		title:         item.Title,
+		state:         strings.Replace(item.State, "opened", "open", 1),
+		author:        item.Author.Username,
+		created:       item.CreatedAt,
+		body:          item.Description,
+		labels:        item.Labels,
+		head:          item.SourceBranch,
+		base:          item.TargetBranch,
+		totalComments: item.UserNotesCount,
+	}
+
+	var notes []struct {
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		System    bool      `json:"system"`
+		Author    struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if err := f.getJSON(ctx, fmt.Sprintf("%s/notes?sort=asc&order_by=created_at&per_page=%d", itemPath, maxComments), &notes); err != nil {
+		return issueData{}, err
+	}
+	for _, n := range notes {
+		// System notes record events such as label changes.
+		if n.System {
+			continue
+		}
+		data.comments = append(data.comments, issueComment{author: n.Author.Username, created: n.CreatedAt, body: n.Body})
+	}
+
+	if f.ref.kind != issueKindMergeRequest {
+		return data, nil
+	}
+	var diffs []struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+		Diff    string `json:"diff"`
+	}
+	if err := f.getJSON(ctx, itemPath+"/diffs?per_page=100", &diffs); err != nil {
+		return issueData{}, err
+	}
+	var sb strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintf(&sb, "diff --git a/%s b/%s\n--- a/%[1]s\n+++ b/%[2]s\n%s", d.OldPath, d.NewPath, d.Diff)
+		if !strings.HasSuffix(d.Diff, "\n") {
+			sb.WriteString("\n")
+		}
+	}
+	data.diff = sb.String()
+	return data, nil
+}
+
+// formatIssue renders data as markdown for the model. It reports whether
+// the diff or the whole output had to be truncated.
+func formatIssue(ref issueRef, data issueData) (string, bool) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s %s: %s\n\n", strings.ToUpper(data.kind[:1])+data.kind[1:], ref, data.title)
+	fmt.Fprintf(&sb, "- State: %s\n", data.state)
+	fmt.Fprintf(&sb, "- Author: @%s\n", data.author)
+	if !data.created.IsZero() {
+		fmt.Fprintf(&sb, "- Created: %s\n", data.created.Format(time.DateOnly))
+	}
+	if len(data.labels) > 0 {
+		fmt.Fprintf(&sb, "- Labels: %s\n", strings.Join(data.labels, ", "))
+	}
+	if data.head != "" {
+		fmt.Fprintf(&sb, "- Branch: %s into %s\n", data.head, data.base)
+	}
+
+	sb.WriteString("\n## Description\n\n")
+	if body := strings.TrimSpace(data.body); body != "" {
+		sb.WriteString(body)
+	} else {
+		sb.WriteString("_No description._")
+	}
+	sb.WriteString("\n")
+
+	if len(data.comments) > 0 {
+		fmt.Fprintf(&sb, "\n## Comments (%d of %d)\n", len(data.comments), max(data.totalComments, len(data.comments)))
+		for _, c := range data.comments {
+			fmt.Fprintf(&sb, "\n### @%s on %s\n\n%s\n", c.author, c.created.Format(time.DateOnly), strings.TrimSpace(c.body))
+		}
+	}
+
+	truncated := false
+	if data.diff != "" {
+		diff := data.diff
+		if len(diff) > issueMaxDiff {
+			diff = diff[:issueMaxDiff]
+			truncated = true
+		}
+		sb.WriteString("\n## Diff\n\n```diff\n")
+		sb.WriteString(strings.TrimSuffix(diff, "\n"))
+		sb.WriteString("\n```\n")
+		if truncated {
+			fmt.Fprintf(&sb, "\n[Diff truncated to %d bytes]\n", issueMaxDiff)
+		}
+	}
+
+	content := sb.String()
+	if len(content) > MaxFetchSize {
+		content = content[:MaxFetchSize] + fmt.Sprintf("\n\n[Content truncated to %d bytes]", MaxFetchSize)
+		truncated = true
+	}
+	return content, truncated
+}
@@ -0,0 +1 @@
+Fetch a GitHub issue or pull request, or a GitLab issue or merge request, by URL and return it as structured markdown: state, author, labels, description, up to {{ .MaxComments }} comments and, for pull and merge requests, the diff (max {{ .MaxDiffKB }}KB). Use it when asked to work on an issue or PR link instead of fetching its HTML page.
@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectTransport sends every request to target, keeping the path, so
+// the tool can be pointed at a test server.
+type redirectTransport struct {
+	target *url.URL
+	hosts  []string
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestParseIssueURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url  string
+		want issueRef
+	}{
+		{"https://github.com/charmbracelet/crush/issues/12", issueRef{host: "github.com", repo: "charmbracelet/crush", kind: issueKindIssue, number: 12}},
+		{"https://github.com/charmbracelet/crush/pull/34/files", issueRef{host: "github.com", repo: "charmbracelet/crush", kind: issueKindPullRequest, number: 34}},
+		{"https://gitlab.com/group/sub/project/-/issues/5", issueRef{host: "gitlab.com", gitlab: true, repo: "group/sub/project", kind: issueKindIssue, number: 5}},
+		{"https://git.example.com/group/project/-/merge_requests/6#note_1", issueRef{host: "git.example.com", gitlab: true, repo: "group/project", kind: issueKindMergeRequest, number: 6}},
+	}
+	for _, tt := range tests {
+		got, err := parseIssueURL(tt.url)
+		require.NoError(t, err, tt.url)
+		require.Equal(t, tt.want, got, tt.url)
+	}
+
+	for _, raw := range []string{
+		"github.com/charmbracelet/crush/issues/12",
+		"https://github.com/charmbracelet/crush",
+		"https://github.com/charmbracelet/crush/issues/new",
+		"https://github.com/charmbracelet/crush/merge_requests/1",
+		"https://gitlab.com/group/project/-/pull/1",
+	} {
+		_, err := parseIssueURL(raw)
+		require.Error(t, err, raw)
+	}
+}
+
+func TestIssueTool(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/owner/repo/issues/7", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{
+			"title": "Fix the parser",
+			"state": "closed",
+			"body": "Fixes #3.",
+			"created_at": "2026-01-02T10:00:00Z",
+			"user": {"login": "alice"},
+			"labels": [{"name": "bug"}],
+			"comments": 2,
+			"pull_request": {"merged_at": "2026-01-03T10:00:00Z"}
+		}`))
+	})
+	mux.HandleFunc("GET /repos/owner/repo/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "1", r.URL.Query().Get("per_page"))
+		_, _ = w.Write([]byte(`[{"body": "LGTM", "created_at": "2026-01-02T12:00:00Z", "user": {"login": "bob"}}]`))
+	})
+	mux.HandleFunc("GET /repos/owner/repo/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") == "application/vnd.github.diff" {
+			_, _ = w.Write([]byte("diff --git a/parser.go b/parser.go\n+fixed\n"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"head": {"label": "alice:fix"}, "base": {"ref": "main"}}`))
+	})
+	mux.HandleFunc("GET /repos/owner/limited/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "4102444800")
+		w.WriteHeader(http.StatusForbidden)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	transport := &redirectTransport{target: target}
+	tool := NewIssueTool(&mockBashPermissionService{}, t.TempDir(), config.ToolIssue{GitHubToken: "secret"}, &http.Client{Transport: transport})
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "test-session")
+
+	run := func(params IssueParams) fantasy.ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: IssueToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(IssueParams{URL: "https://github.com/owner/repo/issues/7", MaxComments: 1})
+	require.False(t, resp.IsError, resp.Content)
+	require.Contains(t, resp.Content, "# Pull request owner/repo#7: Fix the parser")
+	require.Contains(t, resp.Content, "- State: merged")
+	require.Contains(t, resp.Content, "- Branch: alice:fix into main")
+	require.Contains(t, resp.Content, "## Comments (1 of 2)\n\n### @bob on 2026-01-02\n\nLGTM")
+	require.Contains(t, resp.Content, "```diff\ndiff --git a/parser.go b/parser.go\n+fixed\n```")
+	require.Equal(t, "api.github.com", transport.hosts[0])
+
+	var meta IssueResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, IssueResponseMetadata{
+		Host:     "github.com",
+		Repo:     "owner/repo",
+		Kind:     issueKindPullRequest,
+		Number:   7,
+		Title:    "Fix the parser",
+		State:    "merged",
+		Author:   "alice",
+		Labels:   []string{"bug"},
+		Comments: 2,
+	}, meta)
+
+	resp = run(IssueParams{URL: "https://example.com/owner/repo/issues/7"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "add it to tools.issue.hosts")
+
+	// Once rate limited, the host isn't asked again until the reset.
+	requests := len(transport.hosts)
+	resp = run(IssueParams{URL: "https://github.com/owner/limited/issues/1"})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "github.com is rate limiting requests")
+	resp = run(IssueParams{URL: "https://github.com/owner/repo/issues/7"})
+	require.True(t, resp.IsError)
+	require.Len(t, transport.hosts, requests+1)
+}
+
+func TestIssueToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "gh-env")
+	t.Setenv("GITLAB_TOKEN", "gl-env")
+
+	ref := func(raw string) issueRef {
+		ref, err := parseIssueURL(raw)
+		require.NoError(t, err)
+		return ref
+	}
+	github := ref("https://github.com/owner/repo/issues/1")
+	gitlab := ref("https://gitlab.com/group/project/-/issues/1")
+	enterprise := ref("https://github.example.com/owner/repo/issues/1")
+	selfHosted := ref("https://gitlab.example.com/group/project/-/issues/1")
+
+	var opts config.ToolIssue
+	require.Equal(t, "gh-env", issueToken(opts, github))
+	require.Equal(t, "gl-env", issueToken(opts, gitlab))
+	require.Empty(t, issueToken(opts, enterprise), "environment tokens must not be sent to other hosts")
+	require.Empty(t, issueToken(opts, selfHosted), "environment tokens must not be sent to other hosts")
+
+	opts = config.ToolIssue{
+		GitHubToken: "gh-cfg",
+		GitLabToken: "gl-cfg",
+		Tokens:      map[string]string{"github.example.com": "ghe-cfg"},
+	}
+	require.Equal(t, "gh-cfg", issueToken(opts, github))
+	require.Equal(t, "gl-cfg", issueToken(opts, gitlab))
+	require.Equal(t, "ghe-cfg", issueToken(opts, enterprise))
+	require.Empty(t, issueToken(opts, selfHosted), "configured tokens for one host must not be sent to another")
+}
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/shell"
@@ -12,6 +13,10 @@ import (
 
 const (
 	JobOutputToolName = "job_output"
+
+	// jobOutputWindow is the most output, in bytes, a single job_output
+	// call returns.
+	jobOutputWindow = MaxOutputLength
 )
 
 //go:embed job_output.md
@@ -20,6 +25,8 @@ var jobOutputDescription string
 type JobOutputParams struct {
 	ShellID string `json:"shell_id" description:"The ID of the background shell to retrieve output from"`
 	Wait    bool   `json:"wait" description:"If true, block until the background shell completes before returning output"`
+	Offset  int    `json:"offset,omitempty" description:"Byte offset into the output to read from, such as the next_offset of an earlier call. Returns at most 30000 bytes"`
+	Tail    int    `json:"tail,omitempty" description:"Return only the last this many bytes of the output, at most 30000. Ignored when offset is set"`
 }
 
 type JobOutputResponseMetadata struct {
@@ -28,6 +35,15 @@ type JobOutputResponseMetadata struct {
 	Description      string `json:"description"`
 	Done             bool   `json:"done"`
 	WorkingDirectory string `json:"working_directory"`
+	// ExitCode is the exit status of the job once it is done.
+	ExitCode int `json:"exit_code"`
+	// TotalBytes is the size of the job's output so far and ReturnedBytes
+	// the part of it included in the response.
+	TotalBytes    int `json:"total_bytes"`
+	ReturnedBytes int `json:"returned_bytes"`
+	// NextOffset is the offset to pass to read the output that follows
+	// the response, or 0 when there is none.
+	NextOffset int `json:"next_offset,omitempty"`
 }
 
 func NewJobOutputTool() fantasy.AgentTool {
@@ -59,26 +75,30 @@ func NewJobOutputTool() fantasy.AgentTool {
 				outputParts = append(outputParts, stderr)
 			}
 
+			output := strings.Join(outputParts, "\n")
+			window := windowJobOutput(output, params.Offset, params.Tail)
+			output = window.text
+
 			status := "running"
+			var exitCode int
 			if done {
 				status = "completed"
-				if err != nil {
-					exitCode := shell.ExitCode(err)
-					if exitCode != 0 {
-						outputParts = append(outputParts, fmt.Sprintf("Exit code %d", exitCode))
-					}
+				exitCode = shell.ExitCode(err)
+				if exitCode != 0 {
+					output = strings.TrimPrefix(output+fmt.Sprintf("\nExit code %d", exitCode), "\n")
 				}
 			}
 
-			output := strings.Join(outputParts, "\n")
-			output = TruncateOutput(output)
-
 			metadata := JobOutputResponseMetadata{
 				ShellID:          params.ShellID,
 				Command:          bgShell.Command,
 				Description:      bgShell.Description,
 				Done:             done,
 				WorkingDirectory: bgShell.WorkingDir,
+				ExitCode:         exitCode,
+				TotalBytes:       window.total,
+				ReturnedBytes:    window.returned,
+				NextOffset:       window.next,
 			}
 
 			if output == "" {
@@ -90,3 +110,76 @@ func NewJobOutputTool() fantasy.AgentTool {
 		},
 	)
 }
+
+// jobOutputWindowed is the part of a job's output one job_output call
+// returns.
+type jobOutputWindowed struct {
+	// total is the size of the whole output.
+	total int
+	// text is the returned output with notes on what was left out.
+	text string
+	// returned is the number of output bytes included in text.
+	returned int
+	// next is the offset of the first byte after the returned output, or
+	// 0 when it reaches the end.
+	next int
+}
+
+// windowJobOutput picks the part of output to return. A positive offset
+// returns up to [jobOutputWindow] bytes from there, otherwise a positive
+// tail returns the last tail bytes. With neither, output that does not fit
+// is cut to its first and last half windows. Cuts fall on rune boundaries
+// and are marked with how to read the rest.
+func windowJobOutput(output string, offset, tail int) jobOutputWindowed {
+	w := jobOutputWindow
+	total := len(output)
+	switch {
+	case offset > 0:
+		start := runeStartAfter(output, min(offset, total))
+		end := runeStartBefore(output, min(start+w, total))
+		text := output[start:end]
+		if start > 0 {
+			text = fmt.Sprintf("[... %d earlier bytes ...]\n", start) + text
+		}
+		win := jobOutputWindowed{total: total, text: text, returned: end - start}
+		if end < total {
+			win.text += fmt.Sprintf("\n[... %d more bytes; call job_output with offset=%d to continue ...]", total-end, end)
+			win.next = end
+		}
+		return win
+	case tail > 0:
+		start := runeStartAfter(output, total-min(tail, w, total))
+		text := output[start:]
+		if start > 0 {
+			text = fmt.Sprintf("[... %d earlier bytes; call job_output with an offset to read them ...]\n", start) + text
+		}
+		return jobOutputWindowed{total: total, text: text, returned: total - start}
+	case total <= w:
+		return jobOutputWindowed{total: total, text: output, returned: total}
+	}
+	headEnd := runeStartBefore(output, w/2)
+	tailStart := runeStartAfter(output, total-w/2)
+	return jobOutputWindowed{
+		total:    total,
+		text:     output[:headEnd] + fmt.Sprintf("\n\n[... %d bytes omitted; call job_output with offset=%d to read them ...]\n\n", tailStart-headEnd, headEnd) + output[tailStart:],
+		returned: headEnd + total - tailStart,
+		next:     headEnd,
+	}
+}
+
+// runeStartBefore moves i back to the start of the rune it falls in.
+func runeStartBefore(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// runeStartAfter moves i forward to the start of the next rune when it
+// falls inside one.
+func runeStartAfter(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return i
+}
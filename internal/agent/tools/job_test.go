@@ -2,10 +2,14 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/stretchr/testify/require"
 )
@@ -332,3 +336,73 @@ func TestBackgroundShell_AutoBackground(t *testing.T) {
 		require.Equal(t, bgShell.ID, retrieved.ID)
 	})
 }
+
+func TestWindowJobOutput(t *testing.T) {
+	t.Parallel()
+
+	small := windowJobOutput("hello", 0, 0)
+	require.Equal(t, jobOutputWindowed{total: 5, text: "hello", returned: 5}, small)
+
+	output := strings.Repeat("a", jobOutputWindow) + strings.Repeat("b", jobOutputWindow)
+	total := len(output)
+
+	head := windowJobOutput(output, 0, 0)
+	require.Equal(t, total, head.total)
+	require.Equal(t, jobOutputWindow, head.returned)
+	require.Equal(t, jobOutputWindow/2, head.next)
+	require.Contains(t, head.text, fmt.Sprintf("[... %d bytes omitted; call job_output with offset=%d to read them ...]", jobOutputWindow, jobOutputWindow/2))
+
+	page := windowJobOutput(output, head.next, 0)
+	require.Equal(t, jobOutputWindow, page.returned)
+	require.Equal(t, head.next+jobOutputWindow, page.next)
+	require.True(t, strings.HasPrefix(page.text, fmt.Sprintf("[... %d earlier bytes ...]\n", head.next)))
+	require.Contains(t, page.text, fmt.Sprintf("call job_output with offset=%d to continue", page.next))
+
+	last := windowJobOutput(output, page.next, 0)
+	require.Equal(t, total-page.next, last.returned)
+	require.Zero(t, last.next)
+	require.NotContains(t, last.text, "to continue")
+
+	tail := windowJobOutput(output, 0, 10)
+	require.Equal(t, 10, tail.returned)
+	require.True(t, strings.HasSuffix(tail.text, "\n"+strings.Repeat("b", 10)))
+
+	// Cuts never split a rune.
+	runes := windowJobOutput("ééé", 1, 0)
+	require.Equal(t, 4, runes.returned)
+	require.True(t, strings.HasSuffix(runes.text, "éé"))
+}
+
+func TestJobOutputTool_Windowed(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the command relies on head and tr")
+	}
+
+	bgManager := shell.GetBackgroundShellManager()
+	bgShell, err := bgManager.Start(t.Context(), t.TempDir(), nil, "head -c 40000 /dev/zero | tr '\\0' x", "")
+	require.NoError(t, err)
+	defer bgManager.Kill(bgShell.ID)
+
+	run := func(params JobOutputParams) (string, JobOutputResponseMetadata) {
+		params.ShellID = bgShell.ID
+		params.Wait = true
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := NewJobOutputTool().Run(t.Context(), fantasy.ToolCall{ID: "job", Name: JobOutputToolName, Input: string(input)})
+		require.NoError(t, err)
+		var meta JobOutputResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		return resp.Content, meta
+	}
+
+	_, meta := run(JobOutputParams{})
+	require.Equal(t, 40000, meta.TotalBytes)
+	require.Equal(t, jobOutputWindow, meta.ReturnedBytes)
+	require.Equal(t, jobOutputWindow/2, meta.NextOffset)
+
+	content, meta := run(JobOutputParams{Offset: meta.NextOffset})
+	require.Equal(t, 40000-jobOutputWindow/2, meta.ReturnedBytes)
+	require.Zero(t, meta.NextOffset)
+	require.Contains(t, content, "Status: completed")
+}
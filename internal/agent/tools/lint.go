@@ -0,0 +1,425 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+type LintParams struct {
+	Linter string   `json:"linter,omitempty" description:"The linter to run: golangci-lint, eslint or ruff. Defaults to the configured linter, or the one matching the project"`
+	Paths  []string `json:"paths,omitempty" description:"Files or directories to lint, relative to the working directory. Defaults to the whole project"`
+}
+
+type LintPermissionsParams struct {
+	Command string `json:"command"`
+}
+
+// LintResponseMetadata lists the findings of a call so the UI can group
+// them by file and severity.
+type LintResponseMetadata struct {
+	Linter   string        `json:"linter"`
+	Findings []LintFinding `json:"findings,omitempty"`
+	// Total is the number of findings; Findings holds at most
+	// maxLintFindings of them.
+	Total int `json:"total"`
+}
+
+// LintFinding is a single finding in [LintResponseMetadata].
+type LintFinding struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+	Rule   string `json:"rule,omitempty"`
+	// Severity is "error" or "warning".
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+const (
+	LintToolName = "lint"
+
+	maxLintFindings = 200
+)
+
+//go:embed lint.md.tpl
+var lintDescriptionTmpl []byte
+
+var lintDescriptionTpl = template.Must(
+	template.New("lintDescription").
+		Parse(string(lintDescriptionTmpl)),
+)
+
+type lintDescriptionData struct {
+	MaxFindings int
+}
+
+func lintDescription() string {
+	return renderTemplate(lintDescriptionTpl, lintDescriptionData{
+		MaxFindings: maxLintFindings,
+	})
+}
+
+// linter describes how to run a supported linter with JSON output and read
+// its findings.
+type linter struct {
+	name string
+	// markers are files whose presence in the working directory picks
+	// this linter when none is configured.
+	markers []string
+	// defaultPaths is linted when the call names no paths.
+	defaultPaths []string
+	// argSets are the arguments to try in order, for linter versions
+	// that take different flags; paths are appended to each.
+	argSets [][]string
+	parse   func(out []byte) ([]LintFinding, error)
+}
+
+var linters = []linter{
+	{
+		name:         "golangci-lint",
+		markers:      []string{"go.mod", ".golangci.yml", ".golangci.yaml"},
+		defaultPaths: []string{"./..."},
+		argSets: [][]string{
+			{"run", "--output.json.path=stdout", "--show-stats=false"},
+			{"run", "--out-format=json"},
+		},
+		parse: parseGolangciLint,
+	},
+	{
+		name:         "eslint",
+		markers:      []string{"eslint.config.js", "eslint.config.mjs", "eslint.config.cjs", "eslint.config.ts", ".eslintrc.js", ".eslintrc.json", ".eslintrc.yml", ".eslintrc", "package.json"},
+		defaultPaths: []string{"."},
+		argSets:      [][]string{{"--format", "json"}},
+		parse:        parseESLint,
+	},
+	{
+		name:         "ruff",
+		markers:      []string{"ruff.toml", ".ruff.toml", "pyproject.toml", "setup.py", "requirements.txt"},
+		defaultPaths: []string{"."},
+		argSets:      [][]string{{"check", "--output-format=json", "--no-fix"}},
+		parse:        parseRuff,
+	},
+}
+
+func NewLintTool(permissions permission.Service, workingDir string, opts config.ToolLint) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		LintToolName,
+		lintDescription(),
+		func(ctx context.Context, params LintParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			l, err := pickLinter(workingDir, cmp.Or(params.Linter, opts.Linter))
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			bin, err := linterPath(workingDir, l.name)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			paths := params.Paths
+			if len(paths) == 0 {
+				paths = l.defaultPaths
+			}
+			for _, p := range paths {
+				if p == "" || strings.HasPrefix(p, "-") {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid path %q", p)), nil
+				}
+			}
+
+			// Linters load the project's configuration, which for some
+			// is code, so running one needs the same permission as
+			// running it with bash.
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running a linter")
+			}
+			command := strings.Join(append(append([]string{l.name}, l.argSets[0]...), paths...), " ")
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        workingDir,
+					ToolCallID:  call.ID,
+					ToolName:    LintToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Run %s", command),
+					Params:      LintPermissionsParams{Command: command},
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return NewPermissionDeniedResponse(), nil
+			}
+
+			findings, err := runLinter(ctx, workingDir, bin, l, paths, opts.GetTimeout())
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			for i := range findings {
+				findings[i].Path = relativeLintPath(workingDir, findings[i].Path)
+			}
+
+			meta := LintResponseMetadata{Linter: l.name, Total: len(findings)}
+			meta.Findings = findings[:min(len(findings), maxLintFindings)]
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatLintFindings(meta)),
+				meta,
+			), nil
+		},
+	)
+}
+
+// pickLinter returns the linter named name or, when name is empty, the
+// first linter with a marker file in workingDir.
+func pickLinter(workingDir, name string) (linter, error) {
+	if name != "" {
+		for _, l := range linters {
+			if l.name == name {
+				return l, nil
+			}
+		}
+		return linter{}, fmt.Errorf("unsupported linter %q, expected golangci-lint, eslint or ruff", name)
+	}
+	for _, l := range linters {
+		for _, marker := range l.markers {
+			if _, err := os.Stat(filepath.Join(workingDir, marker)); err == nil {
+				return l, nil
+			}
+		}
+	}
+	return linter{}, errors.New("no supported linter matches this project; set linter to golangci-lint, eslint or ruff")
+}
+
+// linterPath finds the binary of the named linter, preferring one
+// installed in the project's node_modules.
+func linterPath(workingDir, name string) (string, error) {
+	local := filepath.Join(workingDir, "node_modules", ".bin", name)
+	if info, err := os.Stat(local); err == nil && !info.IsDir() {
+		return local, nil
+	}
+	bin, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s is not installed", name)
+	}
+	return bin, nil
+}
+
+// runLinter runs l on paths and parses its findings, trying each of its
+// argument sets until one gives output that parses. When none does, the
+// error of the first is returned.
+func runLinter(ctx context.Context, workingDir, bin string, l linter, paths []string, timeout time.Duration) ([]LintFinding, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var firstErr error
+	for _, args := range l.argSets {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, bin, append(slices.Clone(args), paths...)...)
+		cmd.Dir = workingDir
+		cmd.Env = append(os.Environ(), "NO_COLOR=1")
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		cmd.WaitDelay = time.Second
+		// Linters exit non-zero when they report findings, so the exit
+		// status only matters when the output can't be parsed.
+		runErr := cmd.Run()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%s timed out after %s", l.name, timeout)
+		}
+		findings, err := l.parse(stdout.Bytes())
+		if err == nil {
+			return findings, nil
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("%s failed: %s", l.name, cmp.Or(lintErrorOutput(stderr.String()), lintErrorOutput(stdout.String()), fmt.Sprint(runErr)))
+		}
+	}
+	return nil, firstErr
+}
+
+// lintErrorOutput returns the last lines of the output of a failed run.
+func lintErrorOutput(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(strings.Join(lines[max(len(lines)-20, 0):], "\n"))
+}
+
+// decodeLintJSON decodes the first JSON value in out into v, skipping any
+// text printed before it.
+func decodeLintJSON(out []byte, start byte, v any) error {
+	i := bytes.IndexByte(out, start)
+	if i < 0 {
+		return errors.New("no JSON output")
+	}
+	return json.NewDecoder(bytes.NewReader(out[i:])).Decode(v)
+}
+
+func parseGolangciLint(out []byte) ([]LintFinding, error) {
+	var report struct {
+		Issues []struct {
+			FromLinter string
+			Text       string
+			Severity   string
+			Pos        struct {
+				Filename string
+				Line     int
+				Column   int
+			}
+		}
+	}
+	if err := decodeLintJSON(out, '{', &report); err != nil {
+		return nil, err
+	}
+	findings := make([]LintFinding, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		findings = append(findings, LintFinding{
+			Path:     issue.Pos.Filename,
+			Line:     issue.Pos.Line,
+			Column:   issue.Pos.Column,
+			Rule:     issue.FromLinter,
+			Severity: lintSeverity(issue.Severity),
+			Message:  issue.Text,
+		})
+	}
+	return findings, nil
+}
+
+func parseESLint(out []byte) ([]LintFinding, error) {
+	var report []struct {
+		FilePath string `json:"filePath"`
+		Messages []struct {
+			RuleID   string `json:"ruleId"`
+			Severity int    `json:"severity"`
+			Message  string `json:"message"`
+			Line     int    `json:"line"`
+			Column   int    `json:"column"`
+		} `json:"messages"`
+	}
+	if err := decodeLintJSON(out, '[', &report); err != nil {
+		return nil, err
+	}
+	var findings []LintFinding
+	for _, file := range report {
+		for _, msg := range file.Messages {
+			severity := "warning"
+			if msg.Severity == 2 {
+				severity = "error"
+			}
+			findings = append(findings, LintFinding{
+				Path:     file.FilePath,
+				Line:     msg.Line,
+				Column:   msg.Column,
+				Rule:     msg.RuleID,
+				Severity: severity,
+				Message:  msg.Message,
+			})
+		}
+	}
+	return findings, nil
+}
+
+func parseRuff(out []byte) ([]LintFinding, error) {
+	var report []struct {
+		Code     string `json:"code"`
+		Message  string `json:"message"`
+		Filename string `json:"filename"`
+		Location struct {
+			Row    int `json:"row"`
+			Column int `json:"column"`
+		} `json:"location"`
+	}
+	if err := decodeLintJSON(out, '[', &report); err != nil {
+		return nil, err
+	}
+	findings := make([]LintFinding, 0, len(report))
+	for _, v := range report {
+		findings = append(findings, LintFinding{
+			Path:     v.Filename,
+			Line:     v.Location.Row,
+			Column:   v.Location.Column,
+			Rule:     v.Code,
+			Severity: "error",
+			Message:  v.Message,
+		})
+	}
+	return findings, nil
+}
+
+// lintSeverity maps a linter's severity name to "error" or "warning".
+// Linters that don't set one report errors.
+func lintSeverity(s string) string {
+	switch strings.ToLower(s) {
+	case "warning", "warn", "info", "hint", "low", "minor":
+		return "warning"
+	}
+	return "error"
+}
+
+// relativeLintPath returns path relative to workingDir when it is inside
+// it.
+func relativeLintPath(workingDir, path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	rel, err := filepath.Rel(workingDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// formatLintFindings lists the findings in meta by file, in the order the
+// linter reported them, one line per finding.
+func formatLintFindings(meta LintResponseMetadata) string {
+	if meta.Total == 0 {
+		return meta.Linter + ": no findings"
+	}
+
+	var errs int
+	var files []string
+	byFile := map[string][]LintFinding{}
+	for _, f := range meta.Findings {
+		if f.Severity == "error" {
+			errs++
+		}
+		if _, ok := byFile[f.Path]; !ok {
+			files = append(files, f.Path)
+		}
+		byFile[f.Path] = append(byFile[f.Path], f)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %d findings", meta.Linter, meta.Total)
+	if meta.Total == len(meta.Findings) {
+		fmt.Fprintf(&sb, " (errors: %d, warnings: %d)", errs, meta.Total-errs)
+	}
+	sb.WriteString("\n")
+	for _, file := range files {
+		fmt.Fprintf(&sb, "\n%s\n", file)
+		for _, f := range byFile[file] {
+			fmt.Fprintf(&sb, "  %d:%d %s", f.Line, f.Column, f.Severity)
+			if f.Rule != "" {
+				fmt.Fprintf(&sb, " %s", f.Rule)
+			}
+			fmt.Fprintf(&sb, ": %s\n", strings.ReplaceAll(f.Message, "\n", " "))
+		}
+	}
+	if more := meta.Total - len(meta.Findings); more > 0 {
+		fmt.Fprintf(&sb, "\n... and %d more; lint fewer paths to see them\n", more)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
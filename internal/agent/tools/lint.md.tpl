@@ -0,0 +1 @@
+Run the project's linter (golangci-lint, eslint or ruff) and get its findings as a compact list grouped by file, each with its line, column, severity, rule and message (max {{ .MaxFindings }} findings). The linter is picked from the project's files unless linter is set. Set paths to lint only some files or directories, for example to check the files you just changed. Use it to find and fix lint errors instead of running the linter with bash and reading its full output.
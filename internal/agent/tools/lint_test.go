@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinterOutput(t *testing.T) {
+	t.Parallel()
+
+	findings, err := parseGolangciLint([]byte(`{"Issues":[{"FromLinter":"errcheck","Text":"Error return value is not checked","Severity":"","Pos":{"Filename":"main.go","Line":12,"Column":5}}]}
+0 issues.`))
+	require.NoError(t, err)
+	require.Equal(t, []LintFinding{{Path: "main.go", Line: 12, Column: 5, Rule: "errcheck", Severity: "error", Message: "Error return value is not checked"}}, findings)
+
+	findings, err = parseESLint([]byte(`[{"filePath":"/p/a.js","messages":[{"ruleId":"no-unused-vars","severity":1,"message":"'x' is unused","line":1,"column":7},{"ruleId":null,"severity":2,"message":"Parsing error","line":3,"column":1}]}]`))
+	require.NoError(t, err)
+	require.Equal(t, []LintFinding{
+		{Path: "/p/a.js", Line: 1, Column: 7, Rule: "no-unused-vars", Severity: "warning", Message: "'x' is unused"},
+		{Path: "/p/a.js", Line: 3, Column: 1, Severity: "error", Message: "Parsing error"},
+	}, findings)
+
+	findings, err = parseRuff([]byte(`[{"code":"F401","message":"os imported but unused","filename":"/p/a.py","location":{"row":1,"column":8}}]`))
+	require.NoError(t, err)
+	require.Equal(t, []LintFinding{{Path: "/p/a.py", Line: 1, Column: 8, Rule: "F401", Severity: "error", Message: "os imported but unused"}}, findings)
+
+	_, err = parseRuff([]byte("error: unexpected argument"))
+	require.Error(t, err)
+}
+
+func TestPickLinter(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	_, err := pickLinter(dir, "")
+	require.Error(t, err)
+	_, err = pickLinter(dir, "pylint")
+	require.ErrorContains(t, err, "unsupported linter")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pyproject.toml"), nil, 0o644))
+	l, err := pickLinter(dir, "")
+	require.NoError(t, err)
+	require.Equal(t, "ruff", l.name)
+
+	l, err = pickLinter(dir, "eslint")
+	require.NoError(t, err)
+	require.Equal(t, "eslint", l.name)
+}
+
+func TestLintTool(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake linter is a shell script")
+	}
+
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "node_modules", ".bin")
+	require.NoError(t, os.MkdirAll(bin, 0o755))
+	report := `[{"filePath":"` + filepath.Join(dir, "src", "a.js") + `","messages":[{"ruleId":"no-undef","severity":2,"message":"'foo' is not defined","line":4,"column":2},{"ruleId":"semi","severity":1,"message":"Missing semicolon","line":9,"column":10}]}]`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "report.json"), []byte(report), 0o644))
+	script := "#!/bin/sh\ncat report.json\nexit 1\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "eslint"), []byte(script), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "package.json"), []byte("{}"), 0o644))
+
+	tool := NewLintTool(&mockBashPermissionService{}, dir, config.ToolLint{})
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "test-session")
+	run := func(params LintParams) fantasy.ToolResponse {
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: LintToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := run(LintParams{})
+	require.False(t, resp.IsError, resp.Content)
+	require.Equal(t, "eslint: 2 findings (errors: 1, warnings: 1)\n\n"+
+		"src/a.js\n"+
+		"  4:2 error no-undef: 'foo' is not defined\n"+
+		"  9:10 warning semi: Missing semicolon", resp.Content)
+
+	var meta LintResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Equal(t, "eslint", meta.Linter)
+	require.Equal(t, 2, meta.Total)
+	require.Equal(t, "src/a.js", meta.Findings[0].Path)
+
+	resp = run(LintParams{Paths: []string{"--fix"}})
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "invalid path")
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/history"
 	"github.com/charmbracelet/crush/internal/lsp"
@@ -48,6 +49,8 @@ func NewReplaceSymbolTool(
 	permissions permission.Service,
 	files history.Service,
 	filetracker filetracker.Service,
+	workingDir string,
+	allowOutsideWorkdir bool,
 ) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		ReplaceSymbolToolName,
@@ -60,6 +63,11 @@ func NewReplaceSymbolTool(
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
 
+			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, ok := checkWithinWorkingDir(workingDir, params.FilePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
+
 			action := params.Action
 			if action == "" {
 				action = "replace"
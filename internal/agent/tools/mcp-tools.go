@@ -1,9 +1,11 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
+	"strings"
 
 	"charm.land/fantasy"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
@@ -20,7 +22,8 @@ var whitelistDockerTools = []string{
 	"mcp_docker_code-mode",
 }
 
-// GetMCPTools gets all the currently available MCP tools.
+// GetMCPTools gets all the currently available MCP tools, sorted by server
+// and tool name.
 func GetMCPTools(permissions permission.Service, cfg *config.ConfigStore, wd string) []*Tool {
 	var result []*Tool
 	for mcpName, tools := range mcp.Tools() {
@@ -34,6 +37,14 @@ func GetMCPTools(permissions permission.Service, cfg *config.ConfigStore, wd str
 			})
 		}
 	}
+	// Sort by server, then tool, so that name clashes resolve the same way
+	// on every run.
+	slices.SortFunc(result, func(a, b *Tool) int {
+		return cmp.Or(
+			strings.Compare(a.mcpName, b.mcpName),
+			strings.Compare(a.tool.Name, b.tool.Name),
+		)
+	})
 	return result
 }
 
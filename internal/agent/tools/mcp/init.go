@@ -68,9 +68,12 @@ var (
 	sessions = csync.NewMap[string, *ClientSession]()
 	states   = csync.NewMap[string, ClientInfo]()
 	authURLs = csync.NewMap[string, *mcpoauth.Handler]()
-	broker   = pubsub.NewBroker[Event]()
-	initOnce sync.Once
-	initDone = make(chan struct{})
+	// startupFailed holds the servers whose start failed and that have
+	// not been retried yet.
+	startupFailed = csync.NewMap[string, struct{}]()
+	broker        = pubsub.NewBroker[Event]()
+	initOnce      sync.Once
+	initDone      = make(chan struct{})
 
 	// initStarted records whether Initialize has been armed. WaitForInit only
 	// blocks once initialization is expected; coordinators built outside app
@@ -250,22 +253,70 @@ func Close(ctx context.Context) error {
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
+// At most options.mcp_startup_concurrency servers start at once. Servers
+// that fail to start are retried once by [RetryFailedStartups].
 func Initialize(ctx context.Context, permissions permission.Service, cfg *config.ConfigStore) {
 	ArmInit()
 	slog.Info("Initializing MCP clients")
-	var wg sync.WaitGroup
-	// Initialize states for all configured MCPs
+	enabled := make(map[string]config.MCPConfig)
 	for name, m := range cfg.Config().MCP {
 		if m.Disabled {
 			updateState(name, StateDisabled, nil, nil, Counts{})
 			slog.Debug("Skipping disabled MCP", "name", name)
 			continue
 		}
+		enabled[name] = m
+	}
+	startClients(ctx, cfg, enabled)
+	initOnce.Do(func() { close(initDone) })
+}
 
-		// Set initial starting state
+// RetryFailedStartups makes a second attempt at starting the servers that
+// failed during [Initialize]. Each server is retried at most once, so only
+// the first call after startup does any work, and concurrent calls never
+// retry the same server twice. It blocks until the retried servers are up
+// or have failed again, so callers that must not wait should run it in a
+// goroutine; a server that was slow or briefly unavailable at launch then
+// still contributes its tools once it is up.
+func RetryFailedStartups(ctx context.Context, cfg *config.ConfigStore) {
+	retry := make(map[string]config.MCPConfig)
+	for name := range startupFailed.Seq2() {
+		if _, ok := startupFailed.Take(name); !ok {
+			continue
+		}
+		m, ok := cfg.Config().MCP[name]
+		if !ok || m.Disabled {
+			continue
+		}
+		if state, ok := states.Get(name); ok && state.State != StateError {
+			continue
+		}
+		retry[name] = m
+	}
+	if len(retry) == 0 {
+		return
+	}
+	slog.Info("Retrying MCP clients that failed to start", "count", len(retry))
+	startClients(ctx, cfg, retry)
+	// A second failure is final; the user can restart the server.
+	for name := range retry {
+		startupFailed.Del(name)
+	}
+}
+
+// startClients starts the given servers, at most
+// options.mcp_startup_concurrency at a time, and records the ones that
+// fail in startupFailed. Each connection attempt is bounded by the
+// server's own timeout.
+func startClients(ctx context.Context, cfg *config.ConfigStore, servers map[string]config.MCPConfig) {
+	sem := make(chan struct{}, cfg.Config().Options.GetMCPStartupConcurrency())
+	var wg sync.WaitGroup
+	for name, m := range servers {
 		wg.Add(1)
 		go func(name string, m config.MCPConfig) {
+			sem <- struct{}{}
 			defer func() {
+				<-sem
 				wg.Done()
 				if r := recover(); r != nil {
 					var err error
@@ -283,12 +334,12 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 			}()
 
 			if err := initClient(ctx, cfg, name, m, cfg.Resolver()); err != nil {
+				startupFailed.Set(name, struct{}{})
 				slog.Debug("Failed to initialize MCP client", "name", name, "error", err)
 			}
 		}(name, m)
 	}
 	wg.Wait()
-	initOnce.Do(func() { close(initDone) })
 }
 
 // WaitForInit blocks until MCP initialization is complete, i.e. until
@@ -433,7 +484,7 @@ func initClient(ctx context.Context, cfg *config.ConfigStore, name string, m con
 // Returns the session so callers can perform post-processing (e.g.
 // token persistence).
 func connectAndRegister(ctx context.Context, cfg *config.ConfigStore, name string, m config.MCPConfig, resolver config.VariableResolver, channelOptIn bool) (*ClientSession, error) {
-	session, err := createSession(ctx, cfg, name, m, resolver, channelOptIn)
+	session, err := newSession(ctx, cfg, name, m, resolver, channelOptIn)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -351,3 +352,87 @@ func TestGetOrRenewClient_RestoresPromptsAndResources(t *testing.T) {
 	require.Equal(t, Counts{Tools: 1, Prompts: 1, Resources: 1}, info.Counts,
 		"reported counts must match the restored registries")
 }
+
+// TestStartClients_BoundsConcurrencyAndRetriesOnce pins that startup runs
+// at most options.mcp_startup_concurrency servers at a time, that a failed
+// server does not stop the others, and that RetryFailedStartups gives it
+// exactly one more attempt.
+func TestStartClients_BoundsConcurrencyAndRetriesOnce(t *testing.T) {
+	names := []string{"test-start-a", "test-start-b", "test-start-c", "test-start-d", "test-start-flaky"}
+	const flaky = "test-start-flaky"
+
+	servers := config.MCPs{}
+	for _, name := range names {
+		servers[name] = config.MCPConfig{Type: config.MCPStdio}
+	}
+	cfg := config.NewTestStore(&config.Config{
+		MCP:     servers,
+		Options: &config.Options{MCPStartupConcurrency: 2},
+	})
+	t.Cleanup(func() {
+		for _, name := range names {
+			if s, ok := sessions.Take(name); ok {
+				_ = s.Close()
+			}
+			allTools.Del(name)
+			states.Del(name)
+			startupFailed.Del(name)
+		}
+	})
+
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+		attempts = map[string]int{}
+	)
+	origNewSession := newSession
+	newSession = func(_ context.Context, _ *config.ConfigStore, name string, _ config.MCPConfig, _ config.VariableResolver, _ bool) (*ClientSession, error) {
+		mu.Lock()
+		inFlight++
+		maxSeen = max(maxSeen, inFlight)
+		attempts[name]++
+		first := attempts[name] == 1
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		if name == flaky && first {
+			err := errors.New("not ready")
+			updateState(name, StateError, err, nil, Counts{})
+			return nil, err
+		}
+		s, _ := liveSession(t, "tool")
+		return s, nil
+	}
+	t.Cleanup(func() { newSession = origNewSession })
+
+	startClients(t.Context(), cfg, servers)
+
+	require.LessOrEqual(t, maxSeen, 2)
+	for _, name := range names {
+		state, ok := GetState(name)
+		require.True(t, ok)
+		if name == flaky {
+			require.Equal(t, StateError, state.State)
+		} else {
+			require.Equal(t, StateConnected, state.State, name)
+		}
+	}
+
+	RetryFailedStartups(t.Context(), cfg)
+	state, _ := GetState(flaky)
+	require.Equal(t, StateConnected, state.State)
+
+	RetryFailedStartups(t.Context(), cfg)
+	require.Equal(t, 2, attempts[flaky])
+	for _, name := range names {
+		if name != flaky {
+			require.Equal(t, 1, attempts[name], name)
+		}
+	}
+}
@@ -42,13 +42,35 @@ type FailedEdit struct {
 	Edit  MultiEditOperation `json:"edit"`
 }
 
+// EditConflict describes edits that cannot be applied together safely.
+// Edits holds the 1-based indices of the edits involved.
+type EditConflict struct {
+	Kind   EditConflictKind `json:"kind"`
+	Edits  []int            `json:"edits"`
+	Detail string           `json:"detail"`
+}
+
+type EditConflictKind string
+
+const (
+	// EditConflictDuplicate means two edits share the same old_string.
+	EditConflictDuplicate EditConflictKind = "duplicate"
+	// EditConflictOverlap means the text matched by two edits overlaps in
+	// the original content.
+	EditConflictOverlap EditConflictKind = "overlap"
+	// EditConflictAmbiguous means an edit without replace_all matches more
+	// than once in the original content.
+	EditConflictAmbiguous EditConflictKind = "ambiguous"
+)
+
 type MultiEditResponseMetadata struct {
-	Additions    int          `json:"additions"`
-	Removals     int          `json:"removals"`
-	OldContent   string       `json:"old_content,omitempty"`
-	NewContent   string       `json:"new_content,omitempty"`
-	EditsApplied int          `json:"edits_applied"`
-	EditsFailed  []FailedEdit `json:"edits_failed,omitempty"`
+	Additions    int            `json:"additions"`
+	Removals     int            `json:"removals"`
+	OldContent   string         `json:"old_content,omitempty"`
+	NewContent   string         `json:"new_content,omitempty"`
+	EditsApplied int            `json:"edits_applied"`
+	EditsFailed  []FailedEdit   `json:"edits_failed,omitempty"`
+	Conflicts    []EditConflict `json:"conflicts,omitempty"`
 }
 
 const MultiEditToolName = "multiedit"
@@ -62,6 +84,7 @@ func NewMultiEditTool(
 	files history.Service,
 	filetracker filetracker.Service,
 	workingDir string,
+	allowOutsideWorkdir bool,
 ) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		MultiEditToolName,
@@ -76,6 +99,9 @@ func NewMultiEditTool(
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, ok := checkWithinWorkingDir(workingDir, params.FilePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
 
 			// Validate all edits before applying any
 			if err := validateEdits(params.Edits); err != nil {
@@ -123,6 +149,88 @@ func validateEdits(edits []MultiEditOperation) error {
 	return nil
 }
 
+// findEditConflicts checks edits against the content they will be applied
+// to and reports those that would interfere with each other when applied in
+// sequence. Edits whose old_string does not occur in content are assumed to
+// target text introduced by an earlier edit and are left to sequential
+// application. startIndex is added to the reported edit indices.
+func findEditConflicts(content string, edits []MultiEditOperation, startIndex int) []EditConflict {
+	type span struct{ start, end int }
+
+	var conflicts []EditConflict
+	matches := make([][]span, len(edits))
+	firstSeen := make(map[string]int, len(edits))
+	for i, edit := range edits {
+		if edit.OldString == "" {
+			continue
+		}
+		if j, ok := firstSeen[edit.OldString]; ok {
+			conflicts = append(conflicts, EditConflict{
+				Kind:   EditConflictDuplicate,
+				Edits:  []int{startIndex + j + 1, startIndex + i + 1},
+				Detail: fmt.Sprintf("edits %d and %d have the same old_string", startIndex+j+1, startIndex+i+1),
+			})
+			continue
+		}
+		firstSeen[edit.OldString] = i
+
+		for offset := 0; ; {
+			idx := strings.Index(content[offset:], edit.OldString)
+			if idx == -1 {
+				break
+			}
+			start := offset + idx
+			matches[i] = append(matches[i], span{start, start + len(edit.OldString)})
+			offset = start + len(edit.OldString)
+		}
+		if len(matches[i]) > 1 && !edit.ReplaceAll {
+			conflicts = append(conflicts, EditConflict{
+				Kind:   EditConflictAmbiguous,
+				Edits:  []int{startIndex + i + 1},
+				Detail: fmt.Sprintf("edit %d: old_string matches %d times; add more context or set replace_all to true", startIndex+i+1, len(matches[i])),
+			})
+		}
+	}
+
+	for i := range edits {
+		for j := i + 1; j < len(edits); j++ {
+			overlaps := false
+			for _, a := range matches[i] {
+				for _, b := range matches[j] {
+					if a.start < b.end && b.start < a.end {
+						overlaps = true
+						break
+					}
+				}
+				if overlaps {
+					break
+				}
+			}
+			if overlaps {
+				conflicts = append(conflicts, EditConflict{
+					Kind:   EditConflictOverlap,
+					Edits:  []int{startIndex + i + 1, startIndex + j + 1},
+					Detail: fmt.Sprintf("edits %d and %d match overlapping text; merge them into a single edit", startIndex+i+1, startIndex+j+1),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// newConflictResponse rejects the whole operation, listing every conflict.
+func newConflictResponse(conflicts []EditConflict) fantasy.ToolResponse {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "no changes made - found %d conflicting edit(s):", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(&sb, "\n- %s: %s", c.Kind, c.Detail)
+	}
+	return fantasy.WithResponseMetadata(
+		fantasy.NewTextErrorResponse(sb.String()),
+		MultiEditResponseMetadata{Conflicts: conflicts},
+	)
+}
+
 func applyEditsToContent(currentContent string, edits []MultiEditOperation, startIndex int) (string, []FailedEdit) {
 	var failedEdits []FailedEdit
 	for i, edit := range edits {
@@ -147,6 +255,10 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.NewTextErrorResponse("first edit must have empty old_string for file creation"), nil
 	}
 
+	if conflicts := findEditConflicts(firstEdit.NewString, params.Edits[1:], 1); len(conflicts) > 0 {
+		return newConflictResponse(conflicts), nil
+	}
+
 	// Check if file already exists
 	if _, err := os.Stat(params.FilePath); err == nil {
 		return fantasy.NewTextErrorResponse(fmt.Sprintf("file already exists: %s", params.FilePath)), nil
@@ -255,6 +367,10 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		return resp, nil
 	}
 
+	if conflicts := findEditConflicts(oldContent, params.Edits, 0); len(conflicts) > 0 {
+		return newConflictResponse(conflicts), nil
+	}
+
 	currentContent, failedEdits := applyEditsToContent(oldContent, params.Edits, 0)
 
 	// Check if content actually changed
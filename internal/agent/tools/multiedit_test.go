@@ -31,6 +31,14 @@ func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest
 	return true
 }
 
+func (m *mockPermissionService) GrantTool(req permission.PermissionRequest) bool {
+	return true
+}
+
+func (m *mockPermissionService) ResetSession(sessionID string) int {
+	return 0
+}
+
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
@@ -320,3 +328,115 @@ func TestProcessMultiEditWithCreationPartialFailure(t *testing.T) {
 	require.Equal(t, "", meta.OldContent)
 	require.Equal(t, "one\nTWO\nthree\n", meta.NewContent)
 }
+
+func TestFindEditConflicts(t *testing.T) {
+	t.Parallel()
+
+	content := "alpha\nbeta\ngamma\nalpha\n"
+
+	tests := []struct {
+		name  string
+		edits []MultiEditOperation
+		want  []EditConflict
+	}{
+		{
+			name: "independent edits",
+			edits: []MultiEditOperation{
+				{OldString: "beta", NewString: "BETA"},
+				{OldString: "gamma", NewString: "GAMMA"},
+				{OldString: "alpha", NewString: "ALPHA", ReplaceAll: true},
+			},
+		},
+		{
+			name: "edit targets text from an earlier edit",
+			edits: []MultiEditOperation{
+				{OldString: "beta", NewString: "delta"},
+				{OldString: "delta", NewString: "epsilon"},
+			},
+		},
+		{
+			name: "duplicate old_string",
+			edits: []MultiEditOperation{
+				{OldString: "beta", NewString: "BETA"},
+				{OldString: "beta", NewString: "Beta"},
+			},
+			want: []EditConflict{{Kind: EditConflictDuplicate, Edits: []int{1, 2}}},
+		},
+		{
+			name: "overlapping ranges",
+			edits: []MultiEditOperation{
+				{OldString: "beta\ngamma", NewString: "x"},
+				{OldString: "gamma\nalpha", NewString: "y"},
+			},
+			want: []EditConflict{{Kind: EditConflictOverlap, Edits: []int{1, 2}}},
+		},
+		{
+			name: "ambiguous match",
+			edits: []MultiEditOperation{
+				{OldString: "alpha", NewString: "ALPHA"},
+			},
+			want: []EditConflict{{Kind: EditConflictAmbiguous, Edits: []int{1}}},
+		},
+		{
+			name: "replace_all overlaps a later edit",
+			edits: []MultiEditOperation{
+				{OldString: "a", NewString: "A", ReplaceAll: true},
+				{OldString: "beta", NewString: "BETA"},
+			},
+			want: []EditConflict{{Kind: EditConflictOverlap, Edits: []int{1, 2}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := findEditConflicts(content, tt.edits, 0)
+			require.Len(t, got, len(tt.want))
+			for i, c := range got {
+				require.Equal(t, tt.want[i].Kind, c.Kind)
+				require.Equal(t, tt.want[i].Edits, c.Edits)
+				require.NotEmpty(t, c.Detail)
+			}
+		})
+	}
+}
+
+func TestProcessMultiEditExistingFileConflicts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "test.txt")
+	original := "one\ntwo\nthree\n"
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0o644))
+
+	edit := editContext{
+		ctx:         context.WithValue(t.Context(), SessionIDContextKey, "session"),
+		permissions: &mockPermissionService{},
+		files:       &mockHistoryService{},
+		filetracker: &mockEditFileTracker{lastRead: time.Now().Add(time.Second)},
+		workingDir:  dir,
+	}
+	params := MultiEditParams{
+		FilePath: filePath,
+		Edits: []MultiEditOperation{
+			{OldString: "one\ntwo", NewString: "ONE TWO"},
+			{OldString: "two\nthree", NewString: "TWO THREE"},
+		},
+	}
+
+	resp, err := processMultiEditExistingFile(edit, params, fantasy.ToolCall{ID: "call"})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "conflicting edit")
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, original, string(content))
+
+	var meta MultiEditResponseMetadata
+	require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+	require.Len(t, meta.Conflicts, 1)
+	require.Equal(t, EditConflictOverlap, meta.Conflicts[0].Kind)
+	require.Equal(t, []int{1, 2}, meta.Conflicts[0].Edits)
+}
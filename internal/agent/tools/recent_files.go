@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	RecentFilesToolName = "recent_files"
+
+	defaultRecentFiles = 20
+	maxRecentFiles     = 100
+	// maxRecentFilesScanned bounds the walk so huge trees stay fast. Files
+	// beyond it are not considered.
+	maxRecentFilesScanned = 20000
+)
+
+//go:embed recent_files.md.tpl
+var recentFilesDescriptionTmpl []byte
+
+var recentFilesDescriptionTpl = template.Must(
+	template.New("recentFilesDescription").
+		Parse(string(recentFilesDescriptionTmpl)),
+)
+
+type recentFilesDescriptionData struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+func recentFilesDescription() string {
+	return renderTemplate(recentFilesDescriptionTpl, recentFilesDescriptionData{
+		DefaultLimit: defaultRecentFiles,
+		MaxLimit:     maxRecentFiles,
+	})
+}
+
+type RecentFilesParams struct {
+	Path  string `json:"path,omitempty" description:"Directory inside the project to look in. Defaults to the working directory."`
+	Limit int    `json:"limit,omitempty" description:"Maximum number of files to return"`
+}
+
+// RecentFile is a single entry of a recent_files result.
+type RecentFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type RecentFilesResponseMetadata struct {
+	Files []RecentFile `json:"files"`
+	// Truncated is set when the walk stopped at maxRecentFilesScanned
+	// entries, so older parts of the tree may be missing.
+	Truncated bool `json:"truncated"`
+}
+
+func NewRecentFilesTool(workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RecentFilesToolName,
+		recentFilesDescription(),
+		func(ctx context.Context, params RecentFilesParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			searchPath := filepathext.SmartJoin(workingDir, cmp.Or(params.Path, "."))
+			rel, err := filepath.Rel(workingDir, searchPath)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return fantasy.NewTextErrorResponse("path must be inside the working directory"), nil
+			}
+			if info, err := os.Stat(searchPath); err != nil || !info.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("not a directory: %s", params.Path)), nil
+			}
+
+			limit := params.Limit
+			if limit <= 0 {
+				limit = defaultRecentFiles
+			}
+			limit = min(limit, maxRecentFiles)
+
+			files, truncated, err := recentFiles(workingDir, searchPath, limit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error listing files: %v", err)), nil
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(formatRecentFiles(files, truncated, time.Now())),
+				RecentFilesResponseMetadata{
+					Files:     files,
+					Truncated: truncated,
+				},
+			), nil
+		},
+	)
+}
+
+// recentFiles returns up to limit files under searchPath, most recently
+// modified first, with paths relative to workingDir.
+func recentFiles(workingDir, searchPath string, limit int) ([]RecentFile, bool, error) {
+	paths, truncated, err := fsext.ListDirectory(searchPath, nil, 0, maxRecentFilesScanned)
+	if err != nil {
+		return nil, false, err
+	}
+
+	files := make([]RecentFile, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		rel, err := filepath.Rel(workingDir, path)
+		if err != nil {
+			rel = path
+		}
+		files = append(files, RecentFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	slices.SortFunc(files, func(a, b RecentFile) int {
+		if c := b.ModTime.Compare(a.ModTime); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	return files, truncated, nil
+}
+
+func formatRecentFiles(files []RecentFile, truncated bool, now time.Time) string {
+	if len(files) == 0 {
+		return "No files found"
+	}
+	var sb strings.Builder
+	for i, f := range files {
+		fmt.Fprintf(&sb, "%d. %s (%s, modified %s)\n", i+1, f.Path, humanize.Bytes(uint64(f.Size)), humanize.RelTime(f.ModTime, now, "ago", "from now"))
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "\n(Only the first %d entries of the tree were scanned. Pass a more specific path to cover the rest.)\n", maxRecentFilesScanned)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1 @@
+List the most recently modified files in the project, newest first, with sizes and modification times; respects .gitignore and .crushignore; default {{ .DefaultLimit }}, max {{ .MaxLimit }} results. Use at the start of a task to see what is being worked on.
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentFiles(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	base := time.Now().Add(-time.Hour)
+	mkfile := func(rel string, age time.Duration) {
+		full := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte("x"), 0o644))
+		mtime := base.Add(-age)
+		require.NoError(t, os.Chtimes(full, mtime, mtime))
+	}
+	mkfile("old.go", 5*time.Minute)
+	mkfile("pkg/newest.go", 0)
+	mkfile("pkg/middle.go", 2*time.Minute)
+	mkfile("build/ignored.go", -time.Minute)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".crushignore"), []byte("build/\n"), 0o644))
+	require.NoError(t, os.Chtimes(filepath.Join(root, ".crushignore"), base.Add(-time.Hour), base.Add(-time.Hour)))
+
+	paths := func(files []RecentFile) []string {
+		var out []string
+		for _, f := range files {
+			out = append(out, f.Path)
+		}
+		return out
+	}
+
+	t.Run("newest first without ignored files", func(t *testing.T) {
+		t.Parallel()
+
+		files, truncated, err := recentFiles(root, root, 10)
+		require.NoError(t, err)
+		require.False(t, truncated)
+		require.Equal(t, []string{"pkg/newest.go", "pkg/middle.go", "old.go", ".crushignore"}, paths(files))
+		require.EqualValues(t, 1, files[0].Size)
+	})
+
+	t.Run("caps the result count", func(t *testing.T) {
+		t.Parallel()
+
+		files, _, err := recentFiles(root, root, 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"pkg/newest.go", "pkg/middle.go"}, paths(files))
+	})
+
+	t.Run("scopes to a subdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		files, _, err := recentFiles(root, filepath.Join(root, "pkg"), 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"pkg/newest.go", "pkg/middle.go"}, paths(files))
+	})
+}
+
+func TestFormatRecentFiles(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	got := formatRecentFiles([]RecentFile{
+		{Path: "a.go", Size: 2048, ModTime: now.Add(-3 * time.Minute)},
+		{Path: "b.go", Size: 10, ModTime: now.Add(-2 * time.Hour)},
+	}, false, now)
+	require.Equal(t, "1. a.go (2.0 kB, modified 3 minutes ago)\n2. b.go (10 B, modified 2 hours ago)", got)
+	require.Equal(t, "No files found", formatRecentFiles(nil, false, now))
+}
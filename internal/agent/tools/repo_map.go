@@ -0,0 +1,388 @@
+package tools
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+const (
+	RepoMapToolName = "repo_map"
+
+	defaultRepoMapTokens = 2000
+	maxRepoMapTokens     = 8000
+	// maxRepoMapFilesScanned bounds the walk so huge trees stay fast.
+	maxRepoMapFilesScanned = 20000
+	// maxRepoMapSymbolsPerFile keeps one large file from eating the budget.
+	maxRepoMapSymbolsPerFile = 20
+	// maxRepoMapBytesRead is how much of a file the declaration scanner
+	// reads when no language server handles it.
+	maxRepoMapBytesRead = 256 * 1024
+)
+
+//go:embed repo_map.md.tpl
+var repoMapDescriptionTmpl []byte
+
+var repoMapDescriptionTpl = template.Must(
+	template.New("repoMapDescription").
+		Parse(string(repoMapDescriptionTmpl)),
+)
+
+type repoMapDescriptionData struct {
+	DefaultTokens int
+	MaxTokens     int
+}
+
+func repoMapDescription() string {
+	return renderTemplate(repoMapDescriptionTpl, repoMapDescriptionData{
+		DefaultTokens: defaultRepoMapTokens,
+		MaxTokens:     maxRepoMapTokens,
+	})
+}
+
+type RepoMapParams struct {
+	Path      string `json:"path,omitempty" description:"Directory inside the project to map. Defaults to the working directory."`
+	MaxTokens int    `json:"max_tokens,omitempty" description:"Approximate token budget for the map"`
+}
+
+type RepoMapResponseMetadata struct {
+	// Files is the number of files considered for the map.
+	Files int `json:"files"`
+	// Shown is the number of files that fit in the token budget.
+	Shown int `json:"shown"`
+	// Truncated is set when the walk stopped at maxRepoMapFilesScanned
+	// entries, so parts of the tree may be missing.
+	Truncated bool `json:"truncated"`
+}
+
+func NewRepoMapTool(lspManager *lsp.Manager, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RepoMapToolName,
+		repoMapDescription(),
+		func(ctx context.Context, params RepoMapParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			searchPath := filepathext.SmartJoin(workingDir, cmp.Or(params.Path, "."))
+			rel, err := filepath.Rel(workingDir, searchPath)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return fantasy.NewTextErrorResponse("path must be inside the working directory"), nil
+			}
+			if info, err := os.Stat(searchPath); err != nil || !info.IsDir() {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("not a directory: %s", params.Path)), nil
+			}
+
+			budget := params.MaxTokens
+			if budget <= 0 {
+				budget = defaultRepoMapTokens
+			}
+			budget = min(budget, maxRepoMapTokens)
+
+			paths, truncated, err := fsext.ListDirectory(searchPath, nil, 0, maxRepoMapFilesScanned)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("error listing files: %v", err)), nil
+			}
+			files := rankRepoFiles(workingDir, paths)
+
+			symbols := func(path string) []string {
+				if lspManager != nil {
+					if client := findLSPClient(lspManager, path); client != nil {
+						if result, err := client.DocumentSymbols(ctx, path); err == nil && len(result) > 0 {
+							return lspRepoMapSymbols(result)
+						}
+					}
+				}
+				return scanDeclarations(path)
+			}
+
+			text, shown := buildRepoMap(files, budget, symbols)
+			if truncated {
+				text += fmt.Sprintf("\n(Only the first %d entries of the tree were scanned. Pass a more specific path to cover the rest.)", maxRepoMapFilesScanned)
+			}
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(text),
+				RepoMapResponseMetadata{
+					Files:     len(files),
+					Shown:     shown,
+					Truncated: truncated,
+				},
+			), nil
+		},
+	)
+}
+
+// repoFile is a file considered for the repository map.
+type repoFile struct {
+	abs   string
+	rel   string
+	score float64
+}
+
+// rankRepoFiles returns the regular files among paths ordered by how
+// useful they are for getting oriented: manifests and entry points first,
+// then source files, shallow before deep, and tests, generated and
+// vendored files last.
+func rankRepoFiles(workingDir string, paths []string) []repoFile {
+	files := make([]repoFile, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		rel, err := filepath.Rel(workingDir, path)
+		if err != nil {
+			rel = path
+		}
+		rel = filepath.ToSlash(rel)
+		files = append(files, repoFile{abs: path, rel: rel, score: repoFileScore(rel, info.Size())})
+	}
+	slices.SortFunc(files, func(a, b repoFile) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		return strings.Compare(a.rel, b.rel)
+	})
+	return files
+}
+
+var repoKeyFiles = map[string]float64{
+	"readme.md":        10,
+	"go.mod":           9,
+	"package.json":     9,
+	"cargo.toml":       9,
+	"pyproject.toml":   9,
+	"setup.py":         8,
+	"pom.xml":          8,
+	"build.gradle":     8,
+	"build.gradle.kts": 8,
+	"gemfile":          8,
+	"composer.json":    8,
+	"makefile":         7,
+	"taskfile.yaml":    7,
+	"taskfile.yml":     7,
+	"dockerfile":       6,
+	"agents.md":        6,
+	"crush.md":         6,
+	"claude.md":        6,
+	"main.go":          6,
+	"main.py":          6,
+	"__main__.py":      6,
+	"main.rs":          6,
+	"lib.rs":           6,
+	"index.ts":         5,
+	"index.js":         5,
+	"app.py":           5,
+}
+
+func repoFileScore(rel string, size int64) float64 {
+	base := strings.ToLower(filepath.Base(rel))
+	ext := filepath.Ext(base)
+	depth := strings.Count(rel, "/")
+
+	score := 0.0
+	switch {
+	case repoKeyFiles[base] > 0:
+		score = repoKeyFiles[base]
+	case declarationPatterns[ext] != nil:
+		score = 4
+	case ext == ".md":
+		score = 2
+	default:
+		score = 1
+	}
+
+	// Prefer files near the root, and mildly prefer bigger source files,
+	// which tend to hold the core logic.
+	score -= 0.75 * float64(depth)
+	if size > 0 {
+		score += min(math.Log10(float64(size)), 5) * 0.3
+	}
+
+	lowerRel := strings.ToLower(rel)
+	switch {
+	case strings.Contains(lowerRel, "vendor/"), strings.Contains(lowerRel, "node_modules/"),
+		strings.Contains(lowerRel, "testdata/"), strings.Contains(lowerRel, "fixtures/"):
+		score -= 8
+	case strings.HasSuffix(base, "_test.go"), strings.HasPrefix(base, "test_"),
+		strings.Contains(base, ".test."), strings.Contains(base, ".spec."):
+		score -= 3
+	case strings.Contains(base, ".pb."), strings.Contains(base, "_gen."), strings.Contains(base, ".gen."),
+		strings.HasSuffix(base, ".min.js"), strings.HasSuffix(base, ".lock"), base == "go.sum",
+		base == "package-lock.json", base == "pnpm-lock.yaml", base == "yarn.lock":
+		score -= 6
+	}
+	return score
+}
+
+// buildRepoMap renders files in order, each followed by its top-level
+// symbols, until the token budget is spent. It returns the map and the
+// number of files in it.
+func buildRepoMap(files []repoFile, budget int, symbols func(path string) []string) (string, int) {
+	if len(files) == 0 {
+		return "No files found", 0
+	}
+
+	var sb strings.Builder
+	used, shown := 0, 0
+	for _, f := range files {
+		var entry strings.Builder
+		entry.WriteString(f.rel)
+		entry.WriteByte('\n')
+		for _, sym := range symbols(f.abs) {
+			entry.WriteString("  ")
+			entry.WriteString(sym)
+			entry.WriteByte('\n')
+		}
+		cost := estimateRepoMapTokens(entry.String())
+		if used+cost > budget {
+			// Fall back to the bare path so more of the layout fits.
+			entry.Reset()
+			entry.WriteString(f.rel)
+			entry.WriteByte('\n')
+			cost = estimateRepoMapTokens(entry.String())
+			if used+cost > budget {
+				break
+			}
+		}
+		sb.WriteString(entry.String())
+		used += cost
+		shown++
+	}
+	if rest := len(files) - shown; rest > 0 {
+		fmt.Fprintf(&sb, "\n(%d more files not shown. Raise max_tokens or pass a more specific path.)", rest)
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), shown
+}
+
+func estimateRepoMapTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// lspRepoMapSymbols returns the top-level declarations in symbols.
+func lspRepoMapSymbols(symbols []protocol.DocumentSymbolResult) []string {
+	var result []string
+	for _, sym := range symbols {
+		var kind protocol.SymbolKind
+		switch s := sym.(type) {
+		case *protocol.DocumentSymbol:
+			kind = s.Kind
+		case *protocol.SymbolInformation:
+			kind = s.Kind
+		}
+		switch kind {
+		case protocol.Class, protocol.Struct, protocol.Interface, protocol.Enum,
+			protocol.Function, protocol.Method, protocol.Constructor, protocol.Module,
+			protocol.Namespace, protocol.TypeParameter:
+		default:
+			continue
+		}
+		result = append(result, strings.ToLower(symbolKindString(sym))+" "+sym.GetName())
+		if len(result) == maxRepoMapSymbolsPerFile {
+			break
+		}
+	}
+	return result
+}
+
+// declarationPattern matches a top-level declaration. The first submatch
+// is the declared name.
+type declarationPattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+var (
+	goDeclarations = []declarationPattern{
+		{"func", regexp.MustCompile(`^func (?:\([^)]*\) )?(\w+)`)},
+		{"type", regexp.MustCompile(`^type (\w+)`)},
+	}
+	pythonDeclarations = []declarationPattern{
+		{"class", regexp.MustCompile(`^class (\w+)`)},
+		{"def", regexp.MustCompile(`^(?:async )?def (\w+)`)},
+	}
+	jsDeclarations = []declarationPattern{
+		{"class", regexp.MustCompile(`^(?:export )?(?:default )?(?:abstract )?class (\w+)`)},
+		{"function", regexp.MustCompile(`^(?:export )?(?:default )?(?:async )?function\*? ?(\w+)`)},
+		{"interface", regexp.MustCompile(`^(?:export )?interface (\w+)`)},
+		{"type", regexp.MustCompile(`^(?:export )?type (\w+)`)},
+		{"const", regexp.MustCompile(`^export const (\w+)`)},
+	}
+	rustDeclarations = []declarationPattern{
+		{"fn", regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?(?:async )?fn (\w+)`)},
+		{"struct", regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?struct (\w+)`)},
+		{"enum", regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?enum (\w+)`)},
+		{"trait", regexp.MustCompile(`^(?:pub(?:\([^)]*\))? )?trait (\w+)`)},
+		{"impl", regexp.MustCompile(`^impl(?:<[^>]*>)? (?:\w+ for )?(\w+)`)},
+	}
+	jvmDeclarations = []declarationPattern{
+		{"class", regexp.MustCompile(`^(?:public |internal |abstract |final |sealed |data |open )*class (\w+)`)},
+		{"interface", regexp.MustCompile(`^(?:public |internal |sealed )*interface (\w+)`)},
+		{"enum", regexp.MustCompile(`^(?:public )?enum (?:class )?(\w+)`)},
+		{"fun", regexp.MustCompile(`^(?:public |internal |private )?fun (?:<[^>]*> )?(\w+)`)},
+	}
+	rubyDeclarations = []declarationPattern{
+		{"class", regexp.MustCompile(`^class (\w+)`)},
+		{"module", regexp.MustCompile(`^module (\w+)`)},
+		{"def", regexp.MustCompile(`^def (?:self\.)?(\w+)`)},
+	}
+)
+
+// declarationPatterns maps file extensions to the patterns used when no
+// language server handles the file.
+var declarationPatterns = map[string][]declarationPattern{
+	".go":   goDeclarations,
+	".py":   pythonDeclarations,
+	".js":   jsDeclarations,
+	".jsx":  jsDeclarations,
+	".mjs":  jsDeclarations,
+	".ts":   jsDeclarations,
+	".tsx":  jsDeclarations,
+	".rs":   rustDeclarations,
+	".java": jvmDeclarations,
+	".kt":   jvmDeclarations,
+	".rb":   rubyDeclarations,
+}
+
+// scanDeclarations returns the top-level declarations in the file at path
+// found with [declarationPatterns].
+func scanDeclarations(path string) []string {
+	patterns := declarationPatterns[strings.ToLower(filepath.Ext(path))]
+	if patterns == nil {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var result []string
+	scanner := bufio.NewScanner(io.LimitReader(f, maxRepoMapBytesRead))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRepoMapBytesRead)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, p := range patterns {
+			if m := p.re.FindStringSubmatch(line); m != nil {
+				result = append(result, p.kind+" "+m[1])
+				break
+			}
+		}
+		if len(result) == maxRepoMapSymbolsPerFile {
+			break
+		}
+	}
+	return result
+}
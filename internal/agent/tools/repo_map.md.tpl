@@ -0,0 +1 @@
+Show a compact map of the repository: its most important files ranked by importance (manifests, entry points and source files near the root first; tests, generated and vendored files last), each with its top-level symbols from the language server or a declaration scan; respects .gitignore and .crushignore; fits in an approximate token budget, default {{ .DefaultTokens }}, max {{ .MaxTokens }}. Use it once to get oriented in an unfamiliar project instead of many ls, glob and grep calls.
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoMap(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	mkfile := func(rel, content string) {
+		full := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0o755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0o644))
+	}
+	mkfile("go.mod", "module example.com/app\n")
+	mkfile("main.go", "package main\n\nfunc main() {}\n")
+	mkfile("internal/server/server.go", "package server\n\ntype Server struct{}\n\nfunc (s *Server) Start() error { return nil }\n\nfunc helper() {}\n")
+	mkfile("internal/server/server_test.go", "package server\n\nfunc TestStart(t *testing.T) {}\n")
+	mkfile("web/app.ts", "export class App {}\nexport function render() {}\nconst local = 1\n")
+	mkfile("go.sum", "example.com/dep v1.0.0 h1:abc\n")
+
+	run := func(params RepoMapParams) (string, RepoMapResponseMetadata) {
+		tool := NewRepoMapTool(nil, root)
+		input, err := json.Marshal(params)
+		require.NoError(t, err)
+		resp, err := tool.Run(context.Background(), fantasy.ToolCall{ID: "call", Name: RepoMapToolName, Input: string(input)})
+		require.NoError(t, err)
+		require.False(t, resp.IsError, resp.Content)
+		var meta RepoMapResponseMetadata
+		require.NoError(t, json.Unmarshal([]byte(resp.Metadata), &meta))
+		return resp.Content, meta
+	}
+
+	t.Run("ranks files and lists their declarations", func(t *testing.T) {
+		t.Parallel()
+
+		got, meta := run(RepoMapParams{})
+		require.Equal(t, `go.mod
+main.go
+  func main
+web/app.ts
+  class App
+  function render
+internal/server/server.go
+  type Server
+  func Start
+  func helper
+internal/server/server_test.go
+  func TestStart
+go.sum`, got)
+		require.Equal(t, RepoMapResponseMetadata{Files: 6, Shown: 6}, meta)
+	})
+
+	t.Run("stays within the token budget", func(t *testing.T) {
+		t.Parallel()
+
+		got, meta := run(RepoMapParams{MaxTokens: 10})
+		require.Equal(t, "go.mod\nmain.go\n  func main\nweb/app.ts\n\n(3 more files not shown. Raise max_tokens or pass a more specific path.)", got)
+		require.Equal(t, 3, meta.Shown)
+	})
+
+	t.Run("scopes to a subdirectory", func(t *testing.T) {
+		t.Parallel()
+
+		got, _ := run(RepoMapParams{Path: "web"})
+		require.Equal(t, "web/app.ts\n  class App\n  function render", got)
+	})
+}
@@ -15,6 +15,7 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	agentDepthKey       string
 )
 
 const (
@@ -26,6 +27,9 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey is the key for the model name in the context.
 	ModelNameContextKey modelNameKey = "model_name"
+	// AgentDepthContextKey is the key for the sub-agent nesting depth in the
+	// context.
+	AgentDepthContextKey agentDepthKey = "agent_depth"
 )
 
 // getContextValue is a generic helper that retrieves a typed value from context.
@@ -61,6 +65,12 @@ func GetModelNameFromContext(ctx context.Context) string {
 	return getContextValue(ctx, ModelNameContextKey, "")
 }
 
+// GetAgentDepthFromContext retrieves the sub-agent nesting depth from the
+// context. The top-level agent runs at depth 0.
+func GetAgentDepthFromContext(ctx context.Context) int {
+	return getContextValue(ctx, AgentDepthContextKey, 0)
+}
+
 // NewPermissionDeniedResponse returns a tool response indicating the user
 // denied permission, with StopTurn set so the agent loop does not retry.
 func NewPermissionDeniedResponse() fantasy.ToolResponse {
@@ -304,6 +304,22 @@ func addLineNumbers(content string, startLine int) string {
 	return strings.Join(result, "\n")
 }
 
+// stripLineNumbers undoes [addLineNumbers]. It reports false, and returns
+// s unchanged, unless every line of s carries a line number prefix. Models
+// sometimes copy view output verbatim into edit arguments.
+func stripLineNumbers(s string) (string, bool) {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		num, rest, ok := strings.Cut(line, "|")
+		num = strings.TrimLeft(num, " ")
+		if !ok || num == "" || strings.TrimLeft(num, "0123456789") != "" {
+			return s, false
+		}
+		lines[i] = rest
+	}
+	return strings.Join(lines, "\n"), true
+}
+
 func readTextFile(filePath string, offset, limit, maxContentSize int) (string, bool, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
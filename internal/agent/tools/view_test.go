@@ -224,6 +224,14 @@ func (m *mockViewPermissionService) GrantPersistent(req permission.PermissionReq
 	return true
 }
 
+func (m *mockViewPermissionService) GrantTool(req permission.PermissionRequest) bool {
+	return true
+}
+
+func (m *mockViewPermissionService) ResetSession(sessionID string) int {
+	return 0
+}
+
 func (m *mockViewPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockViewPermissionService) SetSkipRequests(skip bool) {}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"charm.land/fantasy"
+)
+
+// checkWithinWorkingDir returns an error response when filePath, after
+// resolving symlinks, is outside workingDir. Tools that write files call
+// it before asking for permission so the model cannot write to places like
+// ~/.ssh unless options.allow_outside_workdir is set.
+func checkWithinWorkingDir(workingDir, filePath string, allowOutside bool) (fantasy.ToolResponse, bool) {
+	if allowOutside || workingDir == "" {
+		return fantasy.ToolResponse{}, true
+	}
+	if withinDir(resolvePath(workingDir), resolvePath(filePath)) {
+		return fantasy.ToolResponse{}, true
+	}
+	return fantasy.NewTextErrorResponse(fmt.Sprintf(
+		"Permission denied: %s is outside the working directory %s. Only files inside the working directory can be changed.",
+		filePath, workingDir,
+	)), false
+}
+
+// resolvePath returns the absolute path with symlinks resolved. Components
+// that do not exist yet, such as a file about to be created, are kept as
+// given and appended to their nearest existing ancestor.
+func resolvePath(path string) string {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		if !errors.Is(err, fs.ErrNotExist) || filepath.Dir(dir) == dir {
+			return path
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
+
+// withinDir reports whether path is dir or one of its descendants.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator)) && !filepath.IsAbs(rel)
+}
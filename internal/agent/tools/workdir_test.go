@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteToolRejectsPathsOutsideWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	workingDir := filepath.Join(root, "project")
+	outside := filepath.Join(root, "outside")
+	require.NoError(t, os.Mkdir(workingDir, 0o755))
+	require.NoError(t, os.Mkdir(outside, 0o755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(workingDir, "link")))
+
+	write := func(allowOutside bool, path string) fantasy.ToolResponse {
+		tool := NewWriteTool(nil, &mockPermissionService{}, &mockHistoryService{}, mockFileTrackerService{}, workingDir, allowOutside)
+		input, err := json.Marshal(WriteParams{FilePath: path, Content: "data"})
+		require.NoError(t, err)
+		ctx := context.WithValue(t.Context(), SessionIDContextKey, "test-session")
+		resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: WriteToolName, Input: string(input)})
+		require.NoError(t, err)
+		return resp
+	}
+
+	for _, path := range []string{
+		filepath.Join(outside, "abs.txt"),
+		"../outside/relative.txt",
+		"link/symlinked.txt",
+		"link/new/nested.txt",
+	} {
+		resp := write(false, path)
+		require.True(t, resp.IsError, path)
+		require.Contains(t, resp.Content, "Permission denied")
+		require.Contains(t, resp.Content, "outside the working directory")
+	}
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	require.False(t, write(false, "inside/new.txt").IsError)
+	require.False(t, write(true, "../outside/allowed.txt").IsError)
+	require.FileExists(t, filepath.Join(outside, "allowed.txt"))
+}
+
+func TestReplaceSymbolToolRejectsPathsOutsideWorkingDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	workingDir := filepath.Join(root, "project")
+	outside := filepath.Join(root, "outside")
+	require.NoError(t, os.Mkdir(workingDir, 0o755))
+	require.NoError(t, os.Mkdir(outside, 0o755))
+	target := filepath.Join(outside, "main.go")
+	require.NoError(t, os.WriteFile(target, []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	// The check runs before any LSP lookup, so no manager is needed.
+	tool := NewReplaceSymbolTool(nil, &mockPermissionService{}, &mockHistoryService{}, mockFileTrackerService{}, workingDir, false)
+	input, err := json.Marshal(ReplaceSymbolParams{Symbol: "main", FilePath: "../outside/main.go", Action: "delete"})
+	require.NoError(t, err)
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "test-session")
+	resp, err := tool.Run(ctx, fantasy.ToolCall{ID: "call", Name: ReplaceSymbolToolName, Input: string(input)})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "outside the working directory")
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(data))
+}
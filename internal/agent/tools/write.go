@@ -49,6 +49,7 @@ func NewWriteTool(
 	files history.Service,
 	filetracker filetracker.Service,
 	workingDir string,
+	allowOutsideWorkdir bool,
 ) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		WriteToolName,
@@ -64,6 +65,9 @@ func NewWriteTool(
 			}
 
 			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			if resp, ok := checkWithinWorkingDir(workingDir, filePath, allowOutsideWorkdir); !ok {
+				return resp, nil
+			}
 
 			fileInfo, err := os.Stat(filePath)
 			if err == nil {
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"charm.land/fantasy"
+)
+
+// trimToBudget drops the oldest turns from messages until their estimated
+// size fits in budget tokens. It returns the remaining messages and how
+// many were dropped.
+//
+// Leading system messages (the system prompt and context files) and the
+// pinned messages that follow them (the todo reminder and the summary of
+// earlier turns) are always kept. The rest is split into turns at user
+// messages; a turn is dropped as a whole so tool calls never lose their
+// results. The last turn, which holds the current prompt, is always kept,
+// so the result can still be over budget.
+func trimToBudget(messages []fantasy.Message, pinned int, budget int64) ([]fantasy.Message, int) {
+	if budget <= 0 || estimateMessageTokens(messages) <= budget {
+		return messages, 0
+	}
+	tokens := estimateMessageTokens(messages)
+	return dropOldestTurns(messages, pinned, func(dropped []fantasy.Message) bool {
+		tokens -= estimateMessageTokens(dropped)
+		return tokens <= budget
+	})
+}
+
+// trimToCount drops the oldest turns from messages until at most limit
+// messages follow the leading system and pinned messages. It returns the
+// remaining messages and how many were dropped. What is kept is the same
+// as for [trimToBudget].
+func trimToCount(messages []fantasy.Message, pinned, limit int) ([]fantasy.Message, int) {
+	if limit <= 0 {
+		return messages, 0
+	}
+	count := len(messages) - min(systemPrefixLen(messages)+pinned, len(messages))
+	if count <= limit {
+		return messages, 0
+	}
+	return dropOldestTurns(messages, pinned, func(dropped []fantasy.Message) bool {
+		count -= len(dropped)
+		return count <= limit
+	})
+}
+
+// systemPrefixLen returns the number of leading system messages.
+func systemPrefixLen(messages []fantasy.Message) int {
+	n := 0
+	for n < len(messages) && messages[n].Role == fantasy.MessageRoleSystem {
+		n++
+	}
+	return n
+}
+
+// dropOldestTurns drops whole turns after the system and pinned messages,
+// oldest first, handing each dropped turn to done until it reports that
+// enough was dropped. The last turn is never dropped.
+func dropOldestTurns(messages []fantasy.Message, pinned int, done func(dropped []fantasy.Message) bool) ([]fantasy.Message, int) {
+	start := min(systemPrefixLen(messages)+pinned, len(messages))
+
+	// Turn boundaries after the pinned prefix.
+	var turns []int
+	for i := start; i < len(messages); i++ {
+		if messages[i].Role == fantasy.MessageRoleUser {
+			turns = append(turns, i)
+		}
+	}
+	if len(turns) < 2 {
+		return messages, 0
+	}
+
+	// Only messages in [start, end) are candidates for dropping.
+	end := start
+	for _, next := range turns[1:] {
+		dropped := messages[end:next]
+		end = next
+		if done(dropped) {
+			break
+		}
+	}
+	if end == start {
+		return messages, 0
+	}
+
+	trimmed := make([]fantasy.Message, 0, len(messages)-(end-start))
+	trimmed = append(trimmed, messages[:start]...)
+	trimmed = append(trimmed, messages[end:]...)
+	return trimmed, end - start
+}
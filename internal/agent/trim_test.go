@@ -0,0 +1,105 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrimToBudget(t *testing.T) {
+	t.Parallel()
+
+	big := strings.Repeat("word ", 400)
+	toolCall := fantasy.Message{
+		Role:    fantasy.MessageRoleAssistant,
+		Content: []fantasy.MessagePart{fantasy.ToolCallPart{ToolCallID: "1", ToolName: "view", Input: "{}"}},
+	}
+	toolResult := fantasy.Message{
+		Role: fantasy.MessageRoleTool,
+		Content: []fantasy.MessagePart{fantasy.ToolResultPart{
+			ToolCallID: "1",
+			Output:     fantasy.ToolResultOutputContentText{Text: big},
+		}},
+	}
+	messages := []fantasy.Message{
+		fantasy.NewSystemMessage("system"),
+		fantasy.NewUserMessage("summary"),
+		fantasy.NewUserMessage("first " + big),
+		toolCall,
+		toolResult,
+		fantasy.NewUserMessage("second " + big),
+		fantasy.NewUserMessage("current"),
+	}
+	texts := func(msgs []fantasy.Message) []string {
+		var out []string
+		for _, m := range msgs {
+			for _, part := range m.Content {
+				switch p := part.(type) {
+				case fantasy.TextPart:
+					out = append(out, strings.Fields(p.Text)[0])
+				case fantasy.ToolCallPart:
+					out = append(out, "call")
+				case fantasy.ToolResultPart:
+					out = append(out, "result")
+				}
+			}
+		}
+		return out
+	}
+
+	t.Run("keeps messages that fit", func(t *testing.T) {
+		t.Parallel()
+
+		got, dropped := trimToBudget(messages, 1, estimateMessageTokens(messages))
+		require.Zero(t, dropped)
+		require.Equal(t, messages, got)
+	})
+
+	t.Run("drops whole turns oldest first", func(t *testing.T) {
+		t.Parallel()
+
+		budget := estimateMessageTokens(messages) - 10
+		got, dropped := trimToBudget(messages, 1, budget)
+		require.Equal(t, 3, dropped)
+		require.Equal(t, []string{"system", "summary", "second", "current"}, texts(got))
+		require.LessOrEqual(t, estimateMessageTokens(got), budget)
+	})
+
+	t.Run("keeps pinned messages and the current turn", func(t *testing.T) {
+		t.Parallel()
+
+		got, dropped := trimToBudget(messages, 1, 1)
+		require.Equal(t, 4, dropped)
+		require.Equal(t, []string{"system", "summary", "current"}, texts(got))
+	})
+
+	t.Run("disabled without a budget", func(t *testing.T) {
+		t.Parallel()
+
+		got, dropped := trimToBudget(messages, 1, 0)
+		require.Zero(t, dropped)
+		require.Equal(t, messages, got)
+	})
+
+	t.Run("drops turns beyond a message count", func(t *testing.T) {
+		t.Parallel()
+
+		got, dropped := trimToCount(messages, 1, 5)
+		require.Zero(t, dropped)
+		require.Equal(t, messages, got)
+
+		got, dropped = trimToCount(messages, 1, 3)
+		require.Equal(t, 3, dropped)
+		require.Equal(t, []string{"system", "summary", "second", "current"}, texts(got))
+
+		got, dropped = trimToCount(messages, 1, 1)
+		require.Equal(t, 4, dropped)
+		require.Equal(t, []string{"system", "summary", "current"}, texts(got))
+
+		got, dropped = trimToCount(messages, 1, 0)
+		require.Zero(t, dropped)
+		require.Equal(t, messages, got)
+	})
+}
@@ -99,9 +99,9 @@ type App struct {
 func New(ctx context.Context, conn *sql.DB, store *config.ConfigStore, skillsMgr *skills.Manager) (*App, error) {
 	q := db.New(conn)
 	sessions := session.NewService(q, conn)
-	messages := message.NewService(q)
-	files := history.NewService(q, conn)
 	cfg := store.Config()
+	messages := message.NewService(q, message.WithMaxPartBytes(cfg.Options.Storage.GetMaxMessageBytes()))
+	files := history.NewService(q, conn)
 	skipPermissionsRequests := store.Overrides().SkipPermissionRequests
 	var allowedTools []string
 	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
@@ -264,14 +264,24 @@ func (app *App) resolveSession(ctx context.Context, continueSessionID string, us
 	}
 }
 
+// SetupError reports that a non-interactive run failed before the prompt
+// was sent, for example because of an unknown model or session.
+type SetupError struct {
+	Err error
+}
+
+func (e *SetupError) Error() string { return e.Err.Error() }
+func (e *SetupError) Unwrap() error { return e.Err }
+
 // RunNonInteractive runs the application in non-interactive mode with the
-// given prompt, printing to stdout.
+// given prompt, printing to stdout. Errors that happen before the prompt is
+// sent are wrapped in a [SetupError].
 func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string, hideSpinner bool, continueSessionID string, useLast bool) error {
 	slog.Info("Running in non-interactive mode")
 
 	// Re-initialize the coder agent without interactive-only tools.
 	if err := app.InitCoderAgentNonInteractive(ctx); err != nil {
-		return fmt.Errorf("failed to reinitialize agent for non-interactive mode: %w", err)
+		return &SetupError{fmt.Errorf("failed to reinitialize agent for non-interactive mode: %w", err)}
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -279,7 +289,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 	if largeModel != "" || smallModel != "" {
 		if err := app.overrideModelsForNonInteractive(ctx, largeModel, smallModel); err != nil {
-			return fmt.Errorf("failed to override models: %w", err)
+			return &SetupError{fmt.Errorf("failed to override models: %w", err)}
 		}
 	}
 
@@ -321,6 +331,13 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		spinner.Start()
 	}
 
+	// Without a terminal to draw the spinner in, report progress as plain
+	// lines instead.
+	var progressLog *format.ProgressLog
+	if !hideSpinner && !stderrTTY {
+		progressLog = format.NewProgressLog(os.Stderr)
+	}
+
 	// Helper function to stop spinner once.
 	stopSpinner := func() {
 		if !hideSpinner && spinner != nil {
@@ -331,17 +348,24 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 	// Wait for MCP initialization to complete before reading MCP tools.
 	if err := mcp.WaitForInit(ctx); err != nil {
-		return fmt.Errorf("failed to wait for MCP initialization: %w", err)
+		return &SetupError{fmt.Errorf("failed to wait for MCP initialization: %w", err)}
 	}
 
 	// force update of agent models before running so mcp tools are loaded
 	app.AgentCoordinator.UpdateModels(ctx)
 
+	if maxTokens := app.config.Overrides().MaxTokens; maxTokens > 0 {
+		model := app.AgentCoordinator.Model()
+		if window := model.CatwalkCfg.ContextWindow; window > 0 && maxTokens > window {
+			return &SetupError{fmt.Errorf("max tokens %d is above the %d token context window of %s", maxTokens, window, model.ModelCfg.Model)}
+		}
+	}
+
 	defer stopSpinner()
 
 	sess, err := app.resolveSession(ctx, continueSessionID, useLast)
 	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+		return &SetupError{fmt.Errorf("failed to create session for non-interactive mode: %w", err)}
 	}
 
 	if continueSessionID != "" || useLast {
@@ -350,6 +374,8 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		slog.Info("Created session for non-interactive run", "session_id", sess.ID)
 	}
 
+	progressLog.RunStarted(sess.ID)
+
 	// Automatically approve all permission requests for this non-interactive
 	// session.
 	app.Permissions.AutoApproveSession(sess.ID)
@@ -377,6 +403,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}(ctx, sess.ID, prompt)
 
 	messageEvents := app.Messages.Subscribe(ctx)
+	notifications := app.agentNotifications.Subscribe(ctx)
 	messageReadBytes := make(map[string]int)
 	var printed bool
 
@@ -400,6 +427,7 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		select {
 		case result := <-done:
 			stopSpinner()
+			progressLog.RunFinished(result.err)
 			if result.err != nil {
 				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
 					slog.Debug("Non-interactive: agent processing cancelled", "session_id", sess.ID)
@@ -411,6 +439,9 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 		case event := <-messageEvents:
 			msg := event.Payload
+			if msg.SessionID == sess.ID {
+				reportToolProgress(progressLog, &msg)
+			}
 			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
@@ -436,6 +467,11 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 				messageReadBytes[msg.ID] = len(content)
 			}
 
+		case event := <-notifications:
+			if n := event.Payload; n.SessionID == sess.ID && n.Type == notify.TypeProviderRetry {
+				progressLog.ProviderRetrying(n.Message)
+			}
+
 		case <-ctx.Done():
 			stopSpinner()
 			return ctx.Err()
@@ -443,6 +479,18 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 }
 
+// reportToolProgress logs the tool calls and results carried by msg.
+func reportToolProgress(l *format.ProgressLog, msg *message.Message) {
+	for _, call := range msg.ToolCalls() {
+		if call.Finished {
+			l.ToolStarted(call.ID, call.Name)
+		}
+	}
+	for _, result := range msg.ToolResults() {
+		l.ToolFinished(result.ToolCallID, result.Name, result.IsError)
+	}
+}
+
 func (app *App) UpdateAgentModel(ctx context.Context) error {
 	if app.AgentCoordinator == nil {
 		return fmt.Errorf("agent configuration is missing")
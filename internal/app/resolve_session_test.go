@@ -68,6 +68,14 @@ func (m *mockSessionService) Rename(context.Context, string, string) error {
 	return nil
 }
 
+func (m *mockSessionService) AddCost(context.Context, string, float64) error {
+	return nil
+}
+
+func (m *mockSessionService) SetPinned(context.Context, string, bool) error {
+	return nil
+}
+
 func (m *mockSessionService) Delete(context.Context, string) error {
 	return nil
 }
@@ -31,6 +31,8 @@ func (b *Backend) GrantPermission(workspaceID string, req proto.PermissionGrant)
 		return ws.Permissions.Grant(perm), nil
 	case proto.PermissionAllowForSession:
 		return ws.Permissions.GrantPersistent(perm), nil
+	case proto.PermissionAllowToolForSession:
+		return ws.Permissions.GrantTool(perm), nil
 	case proto.PermissionDeny:
 		return ws.Permissions.Deny(perm), nil
 	default:
@@ -38,6 +40,17 @@ func (b *Backend) GrantPermission(workspaceID string, req proto.PermissionGrant)
 	}
 }
 
+// ResetSessionPermissions forgets the permissions granted for the rest
+// of a session and returns how many were forgotten.
+func (b *Backend) ResetSessionPermissions(workspaceID, sessionID string) (int, error) {
+	ws, err := b.GetWorkspace(workspaceID)
+	if err != nil {
+		return 0, err
+	}
+
+	return ws.Permissions.ResetSession(sessionID), nil
+}
+
 // SetPermissionsSkip sets whether permission prompts are skipped.
 func (b *Backend) SetPermissionsSkip(workspaceID string, skip bool) error {
 	ws, err := b.GetWorkspace(workspaceID)
@@ -671,6 +671,24 @@ func (c *Client) CancelQuestionBatch(ctx context.Context, id string) (bool, erro
 	return resp.Resolved, nil
 }
 
+// ResetSessionPermissions forgets the permissions granted for the rest
+// of a session and returns how many were forgotten.
+func (c *Client) ResetSessionPermissions(ctx context.Context, id, sessionID string) (int, error) {
+	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/sessions/%s/permissions/reset", id, sessionID), nil, nil, http.Header{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset session permissions: %w", err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to reset session permissions: status code %d", rsp.StatusCode)
+	}
+	var resp proto.PermissionResetResponse
+	if err := json.NewDecoder(rsp.Body).Decode(&resp); err != nil {
+		return 0, fmt.Errorf("failed to decode reset session permissions response: %w", err)
+	}
+	return resp.Cleared, nil
+}
+
 // SetPermissionsSkipRequests sets the skip-requests flag for a workspace.
 func (c *Client) SetPermissionsSkipRequests(ctx context.Context, id string, skip bool) error {
 	rsp, err := c.post(ctx, fmt.Sprintf("/workspaces/%s/permissions/skip", id), nil, jsonBody(proto.PermissionSkipRequest{Skip: skip}), http.Header{"Content-Type": []string{"application/json"}})
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// Exit codes of crush. Scripts can branch on them, so keep them stable and
+// in sync with the table in the help of `crush run`.
+const (
+	// exitCodeFailure is used for any failure not covered below, such as
+	// a provider rejecting the request or a tool error ending the run.
+	exitCodeFailure = 1
+	// exitCodeSetup means the run could not start: invalid flags,
+	// missing configuration, or an unknown model or session.
+	exitCodeSetup = 2
+	// exitCodeAuth means the provider rejected the credentials.
+	exitCodeAuth = 3
+	// exitCodeRateLimited means the provider kept rate limiting the
+	// request after all retries.
+	exitCodeRateLimited = 4
+	// exitCodeTimeout means the request to the provider timed out.
+	exitCodeTimeout = 5
+	// exitCodeInterrupted follows the shell convention of 128 + SIGINT.
+	exitCodeInterrupted = 130
+)
+
+// exitCodesHelp documents the exit codes for `crush run --help`.
+const exitCodesHelp = `Exit codes:
+  0    success
+  1    the run failed
+  2    invalid flags, missing configuration, or unknown model or session
+  3    the provider rejected the credentials
+  4    the provider was still rate limiting after all retries
+  5    the request to the provider timed out
+  130  interrupted`
+
+// setupError marks err as a failure to start the run.
+func setupError(err error) error {
+	return &app.SetupError{Err: err}
+}
+
+// notConfiguredError explains how to set up a provider when none is
+// configured.
+func notConfiguredError() error {
+	return setupError(fmt.Errorf(`%w. To set one up, either:
+  - run 'crush' to pick a provider interactively
+  - run 'crush login' to sign in to Charm Hyper or GitHub Copilot
+  - set a provider API key, such as ANTHROPIC_API_KEY or OPENAI_API_KEY`, config.ErrNotConfigured))
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, errInterrupted) {
+		return exitCodeInterrupted
+	}
+	if _, ok := errors.AsType[*app.SetupError](err); ok {
+		return exitCodeSetup
+	}
+
+	if providerErr, ok := errors.AsType[*fantasy.ProviderError](err); ok {
+		switch {
+		case providerErr.AuthError,
+			providerErr.StatusCode == http.StatusUnauthorized,
+			providerErr.StatusCode == http.StatusForbidden:
+			return exitCodeAuth
+		case providerErr.StatusCode == http.StatusTooManyRequests:
+			return exitCodeRateLimited
+		case providerErr.StatusCode == http.StatusRequestTimeout,
+			providerErr.StatusCode == http.StatusGatewayTimeout:
+			return exitCodeTimeout
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return exitCodeTimeout
+	}
+	if netErr, ok := errors.AsType[net.Error](err); ok && netErr.Timeout() {
+		return exitCodeTimeout
+	}
+	return exitCodeFailure
+}
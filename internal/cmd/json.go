@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// jsonOptions controls how commands encode their --json output.
+type jsonOptions struct {
+	// Pretty indents the output instead of writing a single line.
+	Pretty bool
+	// OmitEmpty leaves null values and empty strings, arrays and objects
+	// out of objects.
+	OmitEmpty bool
+}
+
+// addJSONFlags adds the flags that shape JSON output to cmd. Every command
+// with a --json flag calls it, so all of them format JSON the same way.
+func addJSONFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("json-pretty", false, "Indent JSON output")
+	cmd.Flags().Bool("json-omit-empty", false, "Leave null and empty fields out of JSON output")
+}
+
+// jsonOptionsFrom reads the flags added by addJSONFlags.
+func jsonOptionsFrom(cmd *cobra.Command) jsonOptions {
+	pretty, _ := cmd.Flags().GetBool("json-pretty")
+	omitEmpty, _ := cmd.Flags().GetBool("json-omit-empty")
+	return jsonOptions{Pretty: pretty, OmitEmpty: omitEmpty}
+}
+
+// writeJSON writes v to w as JSON followed by a newline. HTML characters
+// are not escaped.
+func writeJSON(w io.Writer, v any, opts jsonOptions) error {
+	if opts.OmitEmpty {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var generic any
+		if err := dec.Decode(&generic); err != nil {
+			return err
+		}
+		v = omitEmptyJSON(generic)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if opts.Pretty {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// omitEmptyJSON removes object fields that are null, empty strings, or
+// arrays or objects that are empty once their own empty fields are gone.
+// Zeros and false are kept since they carry meaning, and array elements
+// are kept so positions do not shift.
+func omitEmptyJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, field := range v {
+			field = omitEmptyJSON(field)
+			if isEmptyJSON(field) {
+				delete(v, key)
+				continue
+			}
+			v[key] = field
+		}
+		return v
+	case []any:
+		for i, elem := range v {
+			v[i] = omitEmptyJSON(elem)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isEmptyJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
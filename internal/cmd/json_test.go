@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+		Note  *string  `json:"note"`
+	}
+	v := struct {
+		Title string         `json:"title"`
+		Empty string         `json:"empty"`
+		Items []item         `json:"items"`
+		Meta  map[string]any `json:"meta"`
+		Big   int64          `json:"big"`
+	}{
+		Title: "<a&b>",
+		Items: []item{{Name: "x"}, {}},
+		Meta:  map[string]any{"nested": map[string]any{"gone": nil}},
+		Big:   9007199254740993,
+	}
+
+	write := func(opts jsonOptions) string {
+		var b bytes.Buffer
+		require.NoError(t, writeJSON(&b, v, opts))
+		return b.String()
+	}
+
+	require.Equal(t,
+		`{"title":"<a&b>","empty":"","items":[{"name":"x","count":0,"tags":null,"note":null},{"name":"","count":0,"tags":null,"note":null}],"meta":{"nested":{"gone":null}},"big":9007199254740993}`+"\n",
+		write(jsonOptions{}))
+	require.Equal(t,
+		`{"big":9007199254740993,"items":[{"count":0,"name":"x"},{"count":0}],"title":"<a&b>"}`+"\n",
+		write(jsonOptions{OmitEmpty: true}))
+	require.Equal(t,
+		"{\n  \"big\": 9007199254740993,\n  \"items\": [\n    {\n      \"count\": 0,\n      \"name\": \"x\"\n    },\n    {\n      \"count\": 0\n    }\n  ],\n  \"title\": \"<a&b>\"\n}\n",
+		write(jsonOptions{Pretty: true, OmitEmpty: true}))
+}
+
+func TestJSONOptionsFrom(t *testing.T) {
+	t.Parallel()
+
+	cmd := &cobra.Command{Use: "test"}
+	addJSONFlags(cmd)
+	require.Equal(t, jsonOptions{}, jsonOptionsFrom(cmd))
+	require.NoError(t, cmd.Flags().Set("json-pretty", "true"))
+	require.NoError(t, cmd.Flags().Set("json-omit-empty", "true"))
+	require.Equal(t, jsonOptions{Pretty: true, OmitEmpty: true}, jsonOptionsFrom(cmd))
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/mcpserver"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/charmbracelet/crush/internal/workspace"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+)
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "mcp-serve",
+	Short: "Serve Crush's tools over MCP on stdio",
+	Long: `Run Crush as a Model Context Protocol server on stdin and stdout, so
+other agents can use its bash, edit, glob, grep, ls, multiedit, view and
+write tools.
+
+Calls go through the permission layer: tools allowed in
+permissions.allowed_tools run, and every other permission request is
+denied, since there is nobody to ask. Use --yolo to allow everything.
+Tool calls are recorded in a session named "MCP server".`,
+	Example: `
+# Let another MCP client use Crush's tools in this project
+crush mcp-serve --cwd /path/to/project
+  `,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		ws, cleanup, err := setupLocalWorkspace(cmd)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		a := ws.(*workspace.AppWorkspace).App()
+
+		sess, err := a.Sessions.Create(ctx, "MCP server")
+		if err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+		go denyPermissionRequests(a, a.Permissions.Subscribe(ctx))
+
+		server := mcpserver.New(version.Version, sess.ID, mcpServeTools(a))
+		return server.Run(ctx, &mcp.StdioTransport{})
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+}
+
+// mcpServeTools builds the tools `crush mcp-serve` offers, leaving out the
+// ones disabled in options.disabled_tools.
+func mcpServeTools(a *app.App) []fantasy.AgentTool {
+	cfg := a.Config()
+	workingDir := a.Store().WorkingDir()
+	allowOutsideWorkdir := cfg.Options.AllowOutsideWorkdir
+	all := []fantasy.AgentTool{
+		tools.NewBashTool(a.Permissions, workingDir, cfg.Options.Attribution, ""),
+		tools.NewEditTool(a.LSPManager, a.Permissions, a.History, a.FileTracker, workingDir, allowOutsideWorkdir),
+		tools.NewGlobTool(workingDir, cfg.Tools.Glob),
+		tools.NewGrepTool(workingDir, cfg.Tools.Grep),
+		tools.NewLsTool(a.Permissions, workingDir, cfg.Tools.Ls),
+		tools.NewMultiEditTool(a.LSPManager, a.Permissions, a.History, a.FileTracker, workingDir, allowOutsideWorkdir),
+		tools.NewViewTool(a.LSPManager, a.Permissions, a.FileTracker, nil, workingDir, cfg.Options.SkillsPaths...),
+		tools.NewWriteTool(a.LSPManager, a.Permissions, a.History, a.FileTracker, workingDir, allowOutsideWorkdir),
+	}
+	return slices.DeleteFunc(all, func(t fantasy.AgentTool) bool {
+		return slices.Contains(cfg.Options.DisabledTools, t.Info().Name)
+	})
+}
+
+// denyPermissionRequests denies every permission request that reaches the
+// prompt stage. Requests approved by configuration or --yolo never do.
+func denyPermissionRequests(a *app.App, requests <-chan pubsub.Event[permission.PermissionRequest]) {
+	for ev := range requests {
+		req := ev.Payload
+		slog.Info("Denying permission request from MCP client", "tool", req.ToolName, "path", req.Path)
+		a.Permissions.Deny(req)
+	}
+}
@@ -21,7 +21,10 @@ var modelsCmd = &cobra.Command{
 crush models
 
 # Search models
-crush models gpt5`,
+crush models gpt5
+
+# Show which reasoning settings and inputs each model supports
+crush models --capabilities claude`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cwd, err := ResolveCwd(cmd)
@@ -38,6 +41,8 @@ crush models gpt5`,
 		}
 
 		term := strings.ToLower(strings.Join(args, " "))
+		showCapabilities, _ := cmd.Flags().GetBool("capabilities")
+		capabilities := make(map[string]config.ModelCapabilities)
 
 		type providerEntry struct {
 			name       string
@@ -70,6 +75,7 @@ crush models gpt5`,
 					}
 				}
 				entry.models = append(entry.models, model.ID)
+				capabilities[providerID+"/"+model.ID] = config.CapabilitiesOf(providerID, model)
 			}
 			if len(entry.models) > 0 {
 				slices.Sort(entry.models)
@@ -101,6 +107,7 @@ crush models gpt5`,
 					}
 				}
 				entry.models = append(entry.models, model.ID)
+				capabilities[providerID+"/"+model.ID] = config.CapabilitiesOf(providerID, model)
 			}
 			if len(entry.models) > 0 {
 				slices.Sort(entry.models)
@@ -125,7 +132,11 @@ crush models gpt5`,
 			for _, providerID := range providerIDs {
 				entry := entries[providerID]
 				for _, modelID := range entry.models {
-					fmt.Println(providerID + "/" + modelID)
+					line := providerID + "/" + modelID
+					if showCapabilities {
+						line += "\t" + formatCapabilities(capabilities[line])
+					}
+					fmt.Println(line)
 				}
 			}
 			return nil
@@ -140,7 +151,11 @@ crush models gpt5`,
 			}
 			providerNode := tree.Root(label)
 			for _, modelID := range entry.models {
-				providerNode.Child(modelID)
+				label := modelID
+				if showCapabilities {
+					label += " (" + formatCapabilities(capabilities[providerID+"/"+modelID]) + ")"
+				}
+				providerNode.Child(label)
 			}
 			t.Child(providerNode)
 		}
@@ -151,5 +166,29 @@ crush models gpt5`,
 }
 
 func init() {
+	modelsCmd.Flags().Bool("capabilities", false, "Show the reasoning effort levels, thinking and image support of each model")
 	rootCmd.AddCommand(modelsCmd)
 }
+
+// formatCapabilities describes c in one line, e.g. "effort: low, medium,
+// high (default medium); images".
+func formatCapabilities(c config.ModelCapabilities) string {
+	var parts []string
+	switch {
+	case len(c.EffortLevels) > 0:
+		effort := "effort: " + strings.Join(c.EffortLevels, ", ")
+		if c.DefaultEffort != "" {
+			effort += " (default " + c.DefaultEffort + ")"
+		}
+		parts = append(parts, effort)
+	case c.Thinking:
+		parts = append(parts, "thinking")
+	}
+	if c.Images {
+		parts = append(parts, "images")
+	}
+	if len(parts) == 0 {
+		return "text only"
+	}
+	return strings.Join(parts, "; ")
+}
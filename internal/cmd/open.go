@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var openLast bool
+
+var openCmd = &cobra.Command{
+	Use:   "open [id]",
+	Short: "Open a session in the interactive UI",
+	Long:  "Start the interactive UI with a previous session loaded. ID can be a UUID, full hash, or hash prefix. Use --last to open the most recently updated session.",
+	Example: `
+# Open a session by ID
+crush open 3f2a9c
+
+# Open the most recently updated session
+crush open --last
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if openLast == (len(args) == 1) {
+			return errors.New("pass either a session ID or --last")
+		}
+		return runTUI(cmd, func(ctx context.Context, ws workspace.Workspace) (string, error) {
+			if !openLast {
+				sess, err := resolveWorkspaceSessionID(ctx, ws, args[0])
+				if err != nil {
+					return "", err
+				}
+				return sess.ID, nil
+			}
+			sessions, err := ws.ListSessions(ctx)
+			if err != nil {
+				return "", err
+			}
+			sess, ok := lastUpdatedSession(sessions)
+			if !ok {
+				return "", errors.New("no sessions to open")
+			}
+			return sess.ID, nil
+		}, false)
+	},
+}
+
+func init() {
+	openCmd.Flags().BoolVar(&openLast, "last", false, "Open the most recently updated session")
+	openCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+}
+
+// lastUpdatedSession returns the most recently updated of sessions. Pinned
+// sessions are listed first, so the first session is not necessarily the
+// latest.
+func lastUpdatedSession(sessions []session.Session) (session.Session, bool) {
+	if len(sessions) == 0 {
+		return session.Session{}, false
+	}
+	return slices.MaxFunc(sessions, func(a, b session.Session) int {
+		return cmp.Compare(a.UpdatedAt, b.UpdatedAt)
+	}), true
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastUpdatedSession(t *testing.T) {
+	t.Parallel()
+
+	_, ok := lastUpdatedSession(nil)
+	require.False(t, ok)
+
+	sess, ok := lastUpdatedSession([]session.Session{
+		{ID: "pinned", Pinned: true, UpdatedAt: 100},
+		{ID: "latest", UpdatedAt: 300},
+		{ID: "older", UpdatedAt: 200},
+	})
+	require.True(t, ok)
+	require.Equal(t, "latest", sess.ID)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pinJSON   bool
+	unpinJSON bool
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <id>",
+	Short: "Pin a session",
+	Long:  "Pin a session so it is listed first and never pruned. Use --json for machine-readable output. ID can be a UUID, full hash, or hash prefix.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetPinned(cmd, args[0], true, pinJSON)
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <id>",
+	Short: "Unpin a session",
+	Long:  "Unpin a previously pinned session. Use --json for machine-readable output. ID can be a UUID, full hash, or hash prefix.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetPinned(cmd, args[0], false, unpinJSON)
+	},
+}
+
+func init() {
+	pinCmd.Flags().BoolVar(&pinJSON, "json", false, "output in JSON format")
+	unpinCmd.Flags().BoolVar(&unpinJSON, "json", false, "output in JSON format")
+	addJSONFlags(pinCmd)
+	addJSONFlags(unpinCmd)
+}
+
+type sessionPinResult struct {
+	ID     string `json:"id"`
+	UUID   string `json:"uuid"`
+	Title  string `json:"title"`
+	Pinned bool   `json:"pinned"`
+}
+
+func runSetPinned(cmd *cobra.Command, id string, pinned, jsonOutput bool) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	event.SessionPinned(jsonOutput, pinned)
+
+	sess, err := resolveSessionID(ctx, svc.sessions, id)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.sessions.SetPinned(ctx, sess.ID, pinned); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if jsonOutput {
+		return writeJSON(out, sessionPinResult{
+			ID:     session.HashID(sess.ID),
+			UUID:   sess.ID,
+			Title:  sess.Title,
+			Pinned: pinned,
+		}, jsonOptionsFrom(cmd))
+	}
+
+	verb := "Pinned"
+	if !pinned {
+		verb = "Unpinned"
+	}
+	fmt.Fprintf(out, "%s session %s\n", verb, session.HashID(sess.ID)[:12])
+	return nil
+}
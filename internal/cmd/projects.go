@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"os"
 
 	"charm.land/lipgloss/v2"
@@ -35,12 +34,7 @@ crush projects --json
 				Projects []projects.Project `json:"projects"`
 			}{Projects: projectList}
 
-			data, err := json.Marshal(output)
-			if err != nil {
-				return err
-			}
-			cmd.Println(string(data))
-			return nil
+			return writeJSON(cmd.OutOrStderr(), output, jsonOptionsFrom(cmd))
 		}
 
 		if len(projectList) == 0 {
@@ -74,4 +68,5 @@ crush projects --json
 
 func init() {
 	projectsCmd.Flags().Bool("json", false, "Output as JSON")
+	addJSONFlags(projectsCmd)
 }
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneDryRun      bool
+	pruneJSON        bool
+	pruneMaxSessions int
+	pruneMaxAgeDays  int
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old sessions",
+	Long: `Delete sessions that fall outside the retention policy and reclaim the
+space they used. The policy is read from options.retention in crush.json and
+can be overridden with flags.`,
+	Example: `
+# Preview what would be deleted
+crush prune --dry-run
+
+# Keep only the 100 most recent sessions
+crush prune --max-sessions 100
+
+# Delete sessions not updated in the last 30 days
+crush prune --max-age-days 30
+  `,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be deleted without deleting anything")
+	pruneCmd.Flags().BoolVar(&pruneJSON, "json", false, "output in JSON format")
+	addJSONFlags(pruneCmd)
+	pruneCmd.Flags().IntVar(&pruneMaxSessions, "max-sessions", 0, "Maximum number of sessions to keep (overrides options.retention.max_sessions)")
+	pruneCmd.Flags().IntVar(&pruneMaxAgeDays, "max-age-days", 0, "Delete sessions not updated in this many days (overrides options.retention.max_age_days)")
+}
+
+type pruneResult struct {
+	DryRun     bool          `json:"dry_run"`
+	Sessions   []sessionJSON `json:"sessions"`
+	Messages   int64         `json:"messages"`
+	FreedBytes int64         `json:"freed_bytes"`
+}
+
+func runPrune(cmd *cobra.Command, _ []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	policy, err := prunePolicy(cmd, svc)
+	if err != nil {
+		return err
+	}
+
+	list, err := svc.sessions.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+	candidates := session.PruneCandidates(list, policy, time.Now())
+	event.SessionsPruned(pruneJSON, pruneDryRun)
+
+	result := pruneResult{
+		DryRun:   pruneDryRun,
+		Sessions: make([]sessionJSON, len(candidates)),
+	}
+	for i, s := range candidates {
+		result.Sessions[i] = sessionJSON{
+			ID:       session.HashID(s.ID),
+			UUID:     s.ID,
+			Title:    s.Title,
+			Created:  time.Unix(s.CreatedAt, 0).Format(time.RFC3339),
+			Modified: time.Unix(s.UpdatedAt, 0).Format(time.RFC3339),
+		}
+		result.Messages += s.MessageCount
+	}
+
+	if !pruneDryRun && len(candidates) > 0 {
+		before, err := db.Size(ctx, svc.conn)
+		if err != nil {
+			return err
+		}
+		for _, s := range candidates {
+			if err := svc.sessions.Delete(ctx, s.ID); err != nil {
+				return fmt.Errorf("failed to delete session %s: %w", session.HashID(s.ID)[:12], err)
+			}
+		}
+		if err := db.Vacuum(ctx, svc.conn); err != nil {
+			return err
+		}
+		after, err := db.Size(ctx, svc.conn)
+		if err != nil {
+			return err
+		}
+		result.FreedBytes = max(before-after, 0)
+	}
+
+	out := cmd.OutOrStdout()
+	if pruneJSON {
+		return writeJSON(out, result, jsonOptionsFrom(cmd))
+	}
+
+	if len(candidates) == 0 {
+		fmt.Fprintln(out, "No sessions to prune")
+		return nil
+	}
+
+	verb := "Deleted"
+	if pruneDryRun {
+		verb = "Would delete"
+	}
+	for _, s := range result.Sessions {
+		fmt.Fprintf(out, "%s session %s %q\n", verb, s.ID[:12], s.Title)
+	}
+	fmt.Fprintf(out, "%s %d session(s) and %d message(s)", verb, len(candidates), result.Messages)
+	if !pruneDryRun {
+		fmt.Fprintf(out, ", freed %s", humanize.Bytes(uint64(result.FreedBytes)))
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// prunePolicy builds the retention policy from the config, letting any
+// flags the user passed take precedence.
+func prunePolicy(cmd *cobra.Command, svc *sessionServices) (session.PrunePolicy, error) {
+	var policy session.PrunePolicy
+	if r := svc.cfg.Config().Options.Retention; r != nil {
+		policy.MaxSessions = r.MaxSessions
+		policy.MaxAge = time.Duration(r.MaxAgeDays) * 24 * time.Hour
+	}
+	if cmd.Flags().Changed("max-sessions") {
+		policy.MaxSessions = pruneMaxSessions
+	}
+	if cmd.Flags().Changed("max-age-days") {
+		policy.MaxAge = time.Duration(pruneMaxAgeDays) * 24 * time.Hour
+	}
+
+	if policy.MaxSessions < 0 || policy.MaxAge < 0 {
+		return policy, fmt.Errorf("retention limits must not be negative")
+	}
+	if policy.IsZero() {
+		return policy, fmt.Errorf("no retention policy configured: set options.retention in crush.json or pass --max-sessions or --max-age-days")
+	}
+	return policy, nil
+}
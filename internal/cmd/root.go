@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	_ "embed"
 	"errors"
 	"fmt"
@@ -74,6 +75,11 @@ func init() {
 		loginCmd,
 		statsCmd,
 		sessionCmd,
+		pruneCmd,
+		pinCmd,
+		openCmd,
+		unpinCmd,
+		mcpServeCmd,
 	)
 }
 
@@ -109,45 +115,57 @@ crush --continue
 	RunE: func(cmd *cobra.Command, args []string) error {
 		sessionID, _ := cmd.Flags().GetString("session")
 		continueLast, _ := cmd.Flags().GetBool("continue")
-
-		ws, cleanup, err := setupWorkspaceWithProgressBar(cmd)
-		if err != nil {
-			return err
-		}
-		defer cleanup()
-
-		if sessionID != "" {
-			sess, err := resolveWorkspaceSessionID(cmd.Context(), ws, sessionID)
+		return runTUI(cmd, func(ctx context.Context, ws workspace.Workspace) (string, error) {
+			if sessionID == "" {
+				return "", nil
+			}
+			sess, err := resolveWorkspaceSessionID(ctx, ws, sessionID)
 			if err != nil {
-				return err
+				return "", err
 			}
-			sessionID = sess.ID
-		}
-
-		event.AppInitialized()
-
-		com := common.DefaultCommon(ws)
-		model := ui.New(com, sessionID, continueLast)
-
-		inputFilter := ui.NewFilter()
-		var env uv.Environ = os.Environ()
-		program := tea.NewProgram(
-			model,
-			tea.WithEnvironment(env),
-			tea.WithContext(cmd.Context()),
-			tea.WithFilter(inputFilter.Filter),
-		)
-		go ws.Subscribe(program)
-
-		if _, err := program.Run(); err != nil {
-			event.Error(err)
-			slog.Error("TUI run error", "error", err)
-			return errors.New("Crush crashed. If metrics are enabled, we were notified about it. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
-		}
-		return nil
+			return sess.ID, nil
+		}, continueLast)
 	},
 }
 
+// runTUI sets up the workspace and runs the interactive UI. resolve picks
+// the session to open, or "" for none, once the workspace is ready and
+// before the UI starts, so an unknown session fails without launching it.
+func runTUI(cmd *cobra.Command, resolve func(context.Context, workspace.Workspace) (string, error), continueLast bool) error {
+	ws, cleanup, err := setupWorkspaceWithProgressBar(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	sessionID, err := resolve(cmd.Context(), ws)
+	if err != nil {
+		return err
+	}
+
+	event.AppInitialized()
+
+	com := common.DefaultCommon(ws)
+	model := ui.New(com, sessionID, continueLast)
+
+	inputFilter := ui.NewFilter()
+	var env uv.Environ = os.Environ()
+	program := tea.NewProgram(
+		model,
+		tea.WithEnvironment(env),
+		tea.WithContext(cmd.Context()),
+		tea.WithFilter(inputFilter.Filter),
+	)
+	go ws.Subscribe(program)
+
+	if _, err := program.Run(); err != nil {
+		event.Error(err)
+		slog.Error("TUI run error", "error", err)
+		return errors.New("Crush crashed. If metrics are enabled, we were notified about it. If you'd like to report it, please copy the stacktrace above and open an issue at https://github.com/charmbracelet/crush/issues/new?template=bug.yml") //nolint:staticcheck
+	}
+	return nil
+}
+
 var heartbit = lipgloss.NewStyle().Foreground(charmtone.Dolly).SetString(`
     ▄▄▄▄▄▄▄▄    ▄▄▄▄▄▄▄▄
   ███████████  ███████████
@@ -196,7 +214,7 @@ func Execute() {
 		fang.WithVersion(version.Version),
 		fang.WithNotifySignal(os.Interrupt),
 	); err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -285,7 +303,15 @@ func setupLocalWorkspace(cmd *cobra.Command) (workspace.Workspace, func(), error
 		slog.Warn("Failed to register project", "error", err)
 	}
 
-	conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+	// With --no-persist (crush run only) sessions and messages live in an
+	// in-memory database that is thrown away on exit.
+	noPersist, _ := cmd.Flags().GetBool("no-persist")
+	var conn *sql.DB
+	if noPersist {
+		conn, err = db.ConnectMemory(ctx)
+	} else {
+		conn, err = db.Connect(ctx, cfg.Options.DataDirectory)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -318,7 +344,12 @@ func setupLocalWorkspace(cmd *cobra.Command) (workspace.Workspace, func(), error
 	}
 
 	ws := workspace.NewAppWorkspace(appInstance, store)
-	cleanup := func() { appInstance.Shutdown() }
+	cleanup := func() {
+		appInstance.Shutdown()
+		if noPersist {
+			_ = conn.Close()
+		}
+	}
 	return ws, cleanup, nil
 }
 
@@ -1,26 +1,32 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"charm.land/lipgloss/v2"
 	"charm.land/log/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/client"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/herdr"
+	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/anim"
+	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/styles"
 	"github.com/charmbracelet/crush/internal/workspace"
 	"github.com/charmbracelet/x/ansi"
@@ -28,6 +34,7 @@ import (
 	"github.com/charmbracelet/x/term"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+	"golang.org/x/text/language"
 )
 
 var runCmd = &cobra.Command{
@@ -35,7 +42,9 @@ var runCmd = &cobra.Command{
 	Use:     "run [prompt...]",
 	Short:   "Run a single non-interactive prompt",
 	Long: `Run a single prompt in non-interactive mode and exit.
-The prompt can be provided as arguments or piped from stdin.`,
+The prompt can be provided as arguments or piped from stdin.
+
+` + exitCodesHelp,
 	Example: `
 # Run a simple prompt
 crush run "Guess my 5 favorite Pokémon"
@@ -49,7 +58,7 @@ crush run "What is this code doing?" <<< prrr.go
 # Redirect output to a file
 crush run "Generate a hot README for this project" > MY_HOT_README.md
 
-# Run in quiet mode (hide the spinner)
+# Run in quiet mode (hide the spinner and progress lines)
 crush run --quiet "Generate a README for this project"
 
 # Run in verbose mode (show logs)
@@ -61,31 +70,152 @@ crush run --session {session-id} "Follow up on your last response"
 # Continue the most recent session
 crush run --continue "Follow up on your last response"
 
+# Render the answer as formatted markdown when writing to a terminal
+crush run --render "Explain how goroutines work"
+
+# Restrict the agent to a few read-only tools for this run
+crush run --tools view,grep,ls "Audit this package"
+
+# Ask a question without giving the agent any tools
+crush run --no-tools "What is the difference between a mutex and a semaphore?"
+
+# Run without saving the session
+crush run --no-persist "Summarize the changes in this diff" < changes.diff
+
+# Stop generating at a custom marker
+crush run --no-tools --stop "END" "List three colors, then write END"
+
+# Show where the time went once the run finishes
+crush run --profile-timings "Explain this stack trace" < panic.txt
+
+# Attribute the spend of a shared API key to a developer
+crush run --user jane@example.com "Write a changelog entry"
+
+# Save the messages and tools sent to the provider to attach to a bug report
+crush run --dump-messages request.json "Rename the config package"
+
+# Get a short, direct answer
+crush run --style concise "Which flag makes grep case insensitive?"
+
+# Get the answer in German
+crush run --language de "Explain what this repository does"
+
+# Review the output of a command
+crush run --context-cmd "git diff HEAD~1" "Review this change"
+
+# Read a long, multi-line prompt from a file
+crush run --prompt-file task.md
+
+# Cap the length of the answer
+crush run --max-tokens 256 "Summarize this log" < build.log
+
+# Print only the code of the answer, ready to pipe into a shell
+crush run --extract code "Write a bash one-liner that counts Go files" | sh
+
+# Replay the prompts of a session against another model
+crush run --replay {session-id} --model gpt-5
+
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var (
-			quiet, _      = cmd.Flags().GetBool("quiet")
-			verbose, _    = cmd.Flags().GetBool("verbose")
-			largeModel, _ = cmd.Flags().GetString("model")
-			smallModel, _ = cmd.Flags().GetString("small-model")
-			sessionID, _  = cmd.Flags().GetString("session")
-			useLast, _    = cmd.Flags().GetBool("continue")
+			quiet, _       = cmd.Flags().GetBool("quiet")
+			verbose, _     = cmd.Flags().GetBool("verbose")
+			largeModel, _  = cmd.Flags().GetString("model")
+			smallModel, _  = cmd.Flags().GetString("small-model")
+			sessionID, _   = cmd.Flags().GetString("session")
+			useLast, _     = cmd.Flags().GetBool("continue")
+			render, _      = cmd.Flags().GetBool("render")
+			toolNames, _   = cmd.Flags().GetStringSlice("tools")
+			noTools, _     = cmd.Flags().GetBool("no-tools")
+			noCache, _     = cmd.Flags().GetBool("no-cache")
+			stops, _       = cmd.Flags().GetStringArray("stop")
+			profile, _     = cmd.Flags().GetBool("profile-timings")
+			user, _        = cmd.Flags().GetString("user")
+			dumpPath, _    = cmd.Flags().GetString("dump-messages")
+			styleName, _   = cmd.Flags().GetString("style")
+			langCode, _    = cmd.Flags().GetString("language")
+			maxTokens, _   = cmd.Flags().GetInt64("max-tokens")
+			contextCmds, _ = cmd.Flags().GetStringArray("context-cmd")
+			extractMode, _ = cmd.Flags().GetString("extract")
+			replayID, _    = cmd.Flags().GetString("replay")
+			promptFile, _  = cmd.Flags().GetString("prompt-file")
 		)
 
-		// Cancel on SIGINT or SIGTERM.
-		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		allowedTools, err := runAllowedTools(cmd, toolNames, noTools)
+		if err != nil {
+			return setupError(err)
+		}
+		if err := config.ValidateRequestUser(user, ""); err != nil {
+			return setupError(fmt.Errorf("--user: %w", err))
+		}
+		var extract extractor
+		if extractMode != "" {
+			if render {
+				return setupError(fmt.Errorf("--extract cannot be combined with --render"))
+			}
+			if extract, err = parseExtract(extractMode); err != nil {
+				return setupError(err)
+			}
+		}
+		if cmd.Flags().Changed("max-tokens") && maxTokens <= 0 {
+			return setupError(fmt.Errorf("--max-tokens must be positive"))
+		}
+		var style config.ResponseStyle
+		if styleName != "" {
+			if style, err = config.ParseResponseStyle(styleName); err != nil {
+				return setupError(fmt.Errorf("--style: %w", err))
+			}
+		}
+		var lang language.Tag
+		if langCode != "" {
+			if lang, err = config.ParseLanguage(langCode); err != nil {
+				return setupError(fmt.Errorf("--language: %w", err))
+			}
+		}
+
+		// Cancel on SIGINT or SIGTERM. The deferred workspace cleanup then
+		// cancels the agent, flushes pending messages and kills background
+		// jobs before we exit with [errInterrupted].
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer cancel()
 
 		prompt := strings.Join(args, " ")
+		if promptFile != "" {
+			if prompt != "" {
+				return setupError(fmt.Errorf("--prompt-file cannot be combined with a prompt argument"))
+			}
+			if prompt, err = readPromptFile(promptFile); err != nil {
+				return setupError(err)
+			}
+		}
 
-		prompt, err := MaybePrependStdin(prompt)
-		if err != nil {
-			slog.Error("Failed to read from stdin", "error", err)
-			return err
+		if replayID != "" {
+			// The prompts come from the replayed session.
+			if prompt != "" {
+				return setupError(fmt.Errorf("--replay does not take a prompt"))
+			}
+		} else {
+			prompt, err = MaybePrependStdin(prompt)
+			if err != nil {
+				slog.Error("Failed to read from stdin", "error", err)
+				return err
+			}
+
+			if prompt == "" {
+				return setupError(fmt.Errorf("no prompt provided"))
+			}
 		}
 
-		if prompt == "" {
-			return fmt.Errorf("no prompt provided")
+		if len(contextCmds) > 0 {
+			cwd, err := ResolveCwd(cmd)
+			if err != nil {
+				return setupError(err)
+			}
+			output, err := runContextCommands(ctx, cwd, contextCmds)
+			if err != nil {
+				return interruptedOr(ctx, setupError(err))
+			}
+			prompt = output + prompt
 		}
 
 		event.SetNonInteractive(true)
@@ -98,27 +228,61 @@ crush run --continue "Follow up on your last response"
 		}
 
 		if useClientServer() {
+			if allowedTools != nil {
+				return setupError(fmt.Errorf("--tools and --no-tools are not supported in client/server mode"))
+			}
+			if len(stops) > 0 {
+				return setupError(fmt.Errorf("--stop is not supported in client/server mode"))
+			}
+			if noPersist, _ := cmd.Flags().GetBool("no-persist"); noPersist {
+				return setupError(fmt.Errorf("--no-persist is not supported in client/server mode"))
+			}
+			if profile {
+				return setupError(fmt.Errorf("--profile-timings is not supported in client/server mode"))
+			}
+			if user != "" {
+				return setupError(fmt.Errorf("--user is not supported in client/server mode"))
+			}
+			if dumpPath != "" {
+				return setupError(fmt.Errorf("--dump-messages is not supported in client/server mode"))
+			}
+			if style != "" {
+				return setupError(fmt.Errorf("--style is not supported in client/server mode"))
+			}
+			if lang != language.Und {
+				return setupError(fmt.Errorf("--language is not supported in client/server mode"))
+			}
+			if maxTokens > 0 {
+				return setupError(fmt.Errorf("--max-tokens is not supported in client/server mode"))
+			}
+			if noCache {
+				return setupError(fmt.Errorf("--no-cache is not supported in client/server mode"))
+			}
+			if replayID != "" {
+				return setupError(fmt.Errorf("--replay is not supported in client/server mode"))
+			}
+
 			c, ws, cleanup, err := connectToServer(cmd)
 			if err != nil {
-				return err
+				return setupError(err)
 			}
 			defer cleanup()
 
 			event.AppInitialized()
 
 			if !ws.Config.IsConfigured() {
-				return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+				return notConfiguredError()
 			}
 
 			clientWs := workspace.NewClientWorkspace(c, *ws)
 			if err := clientWs.InitCoderAgentNonInteractive(ctx); err != nil {
-				return fmt.Errorf("failed to initialize agent: %w", err)
+				return setupError(fmt.Errorf("failed to initialize agent: %w", err))
 			}
 
 			if sessionID != "" {
 				sess, err := resolveSessionByID(ctx, c, ws.ID, sessionID)
 				if err != nil {
-					return err
+					return setupError(err)
 				}
 				sessionID = sess.ID
 			}
@@ -127,38 +291,246 @@ crush run --continue "Follow up on your last response"
 				slog.SetDefault(slog.New(log.New(os.Stderr)))
 			}
 
-			return runNonInteractive(ctx, c, ws, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+			output, flush := runOutput(render, ws.Config.Models[config.SelectedModelTypeLarge].Provider)
+			defer flush()
+
+			answer, finish := extractOutput(output, extract)
+			err = runNonInteractive(ctx, c, ws, answer, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+			if err == nil {
+				err = finish()
+			}
+			return interruptedOr(ctx, err)
 		}
 
 		ws, cleanup, err := setupLocalWorkspace(cmd)
 		if err != nil {
-			return err
+			return setupError(err)
 		}
 		defer cleanup()
 
 		event.AppInitialized()
 
 		if !ws.Config().IsConfigured() {
-			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
+			return notConfiguredError()
 		}
 
 		if verbose {
 			slog.SetDefault(slog.New(log.New(os.Stderr)))
 		}
 
+		output, flush := runOutput(render, ws.Config().Models[config.SelectedModelTypeLarge].Provider)
+		defer flush()
+
 		appWs := ws.(*workspace.AppWorkspace)
-		return appWs.App().RunNonInteractive(ctx, os.Stdout, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+		if allowedTools != nil {
+			appWs.App().Store().Overrides().AllowedTools = allowedTools
+		}
+		appWs.App().Store().Overrides().DisableResponseCache = noCache
+		appWs.App().Store().Overrides().StopSequences = stops
+		appWs.App().Store().Overrides().RequestUser = user
+		appWs.App().Store().Overrides().ResponseStyle = style
+		appWs.App().Store().Overrides().Language = lang
+		appWs.App().Store().Overrides().MaxTokens = maxTokens
+
+		var timings *agent.Timings
+		if profile {
+			timings = &agent.Timings{}
+			ctx = agent.WithTimings(ctx, timings)
+		}
+		if dumpPath != "" {
+			dump := agent.NewMessageDump(dumpPath, configSecrets(appWs.App().Store())...)
+			ctx = agent.WithMessageDump(ctx, dump)
+		}
+		if replayID != "" {
+			err = runReplay(ctx, appWs.App(), output, cmd.ErrOrStderr(), replayID, largeModel, smallModel, quiet || verbose)
+			if timings != nil {
+				printTimings(cmd.ErrOrStderr(), timings.Report())
+			}
+			return interruptedOr(ctx, err)
+		}
+		answer, finish := extractOutput(output, extract)
+		err = appWs.App().RunNonInteractive(ctx, answer, prompt, largeModel, smallModel, quiet || verbose, sessionID, useLast)
+		if err == nil {
+			err = finish()
+		}
+		if timings != nil {
+			printTimings(cmd.ErrOrStderr(), timings.Report())
+		}
+		return interruptedOr(ctx, err)
 	},
 }
 
+// configSecrets returns the credentials in the configuration so they can be
+// redacted from debugging output: provider API keys, OAuth tokens and extra
+// headers, and MCP server environment variables and headers.
+func configSecrets(store *config.ConfigStore) []string {
+	var secrets []string
+	addToken := func(token *oauth.Token) {
+		if token != nil {
+			secrets = append(secrets, token.AccessToken, token.RefreshToken)
+		}
+	}
+	resolve := func(values map[string]string) {
+		for _, v := range values {
+			if resolved, err := store.Resolver().ResolveValue(v); err == nil {
+				secrets = append(secrets, resolved)
+			}
+		}
+	}
+
+	cfg := store.Config()
+	for _, p := range cfg.Providers.Seq2() {
+		secrets = append(secrets, p.APIKey)
+		addToken(p.OAuthToken)
+		for _, v := range p.ExtraHeaders {
+			secrets = append(secrets, v)
+		}
+	}
+	for _, m := range cfg.MCP {
+		addToken(m.OAuthToken)
+		resolve(m.Env)
+		resolve(m.Headers)
+	}
+	return secrets
+}
+
+// printTimings writes a breakdown of where the time of a run went.
+func printTimings(w io.Writer, r agent.TimingReport) {
+	round := func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	}
+	fmt.Fprintln(w, "Timings:")
+	fmt.Fprintf(w, "  %-20s %s\n", "time to first token", round(r.TimeToFirstToken))
+	fmt.Fprintf(w, "  %-20s %s\n", "waiting for model", round(r.Waiting))
+	fmt.Fprintf(w, "  %-20s %s\n", "streaming", round(r.Streaming))
+	fmt.Fprintf(w, "  %-20s %s\n", "tool execution", round(r.Tools))
+	fmt.Fprintf(w, "  %-20s %s\n", "total", round(r.Total))
+	fmt.Fprintf(w, "  %-20s %d\n", "model requests", r.Steps)
+}
+
+// errInterrupted is returned by `crush run` when a signal cancelled the run.
+// [Execute] maps it to [exitCodeInterrupted].
+var errInterrupted = errors.New("interrupted")
+
+// interruptedOr returns [errInterrupted] if ctx, the signal context of the
+// run, was cancelled, and err otherwise.
+func interruptedOr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return errInterrupted
+	}
+	return err
+}
+
 func init() {
-	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
+	runCmd.Flags().BoolP("quiet", "q", false, "Hide the spinner, or the progress lines when stderr is not a terminal")
 	runCmd.Flags().BoolP("verbose", "v", false, "Show logs")
 	runCmd.Flags().StringP("model", "m", "", "Model to use. Accepts 'model' or 'provider/model' to disambiguate models with the same name across providers")
 	runCmd.Flags().String("small-model", "", "Small model to use. If not provided, uses the default small model for the provider")
 	runCmd.Flags().StringP("session", "s", "", "Continue a previous session by ID")
 	runCmd.Flags().BoolP("continue", "C", false, "Continue the most recent session")
+	runCmd.Flags().Bool("render", false, "Render the response as markdown when stdout is a terminal")
+	runCmd.Flags().StringSlice("tools", nil, "Comma-separated list of built-in tools the agent may use for this run (MCP tools are disabled)")
+	runCmd.Flags().Bool("no-tools", false, "Disable all tools for this run")
+	runCmd.Flags().Bool("no-cache", false, "Bypass the response cache for this run")
+	runCmd.Flags().StringArray("stop", nil, "Stop generating when the model outputs this sequence. Can be repeated")
+	runCmd.Flags().Bool("no-persist", false, "Keep the session in memory only and don't write it to the database")
+	runCmd.Flags().Bool("profile-timings", false, "Print time to first token, streaming, tool and total time to stderr when done")
+	runCmd.Flags().String("dump-messages", "", "Write the messages and tool definitions sent to the provider to this file as JSON, with configured secrets redacted")
+	runCmd.Flags().String("user", "", "End-user identifier sent to OpenAI-compatible providers for spend attribution. Overrides options.request_user")
+	runCmd.Flags().Int64("max-tokens", 0, "Maximum number of tokens in each model response for this run. Overrides the model's max_tokens")
+	runCmd.Flags().StringArray("context-cmd", nil, "Run this shell command and add its output to the prompt. Can be repeated")
+	runCmd.Flags().String("extract", "", "Print only part of the answer: code, last-block or regex:<pattern>")
+	runCmd.Flags().String("prompt-file", "", "Read the prompt from this file. Piped input and --context-cmd output are still added to it")
+	runCmd.Flags().String("style", "", "Answer style for this run: concise, normal or detailed. Overrides options.response_style")
+	runCmd.Flags().String("language", "", "Language to answer and title the session in, as a locale code such as de or pt-BR. Overrides options.language")
+	runCmd.Flags().String("replay", "", "Re-run the user prompts of this session in a new session, e.g. with another --model, and compare usage")
 	runCmd.MarkFlagsMutuallyExclusive("session", "continue")
+	runCmd.MarkFlagsMutuallyExclusive("replay", "session")
+	runCmd.MarkFlagsMutuallyExclusive("replay", "continue")
+	runCmd.MarkFlagsMutuallyExclusive("replay", "no-persist")
+	runCmd.MarkFlagsMutuallyExclusive("replay", "context-cmd")
+	runCmd.MarkFlagsMutuallyExclusive("replay", "extract")
+	runCmd.MarkFlagsMutuallyExclusive("tools", "no-tools")
+	runCmd.MarkFlagsMutuallyExclusive("no-persist", "session")
+	runCmd.MarkFlagsMutuallyExclusive("no-persist", "continue")
+	runCmd.MarkFlagsMutuallyExclusive("prompt-file", "replay")
+}
+
+// readPromptFile returns the content of path as the prompt of `crush run`.
+func readPromptFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("--prompt-file: %w", err)
+	}
+	prompt := strings.TrimSpace(string(content))
+	if prompt == "" {
+		return "", fmt.Errorf("--prompt-file: %s is empty", path)
+	}
+	return prompt, nil
+}
+
+// runAllowedTools returns the tool restriction requested on the command
+// line, or nil when the agent should use its configured tools.
+func runAllowedTools(cmd *cobra.Command, names []string, noTools bool) ([]string, error) {
+	if noTools {
+		return []string{}, nil
+	}
+	if !cmd.Flags().Changed("tools") {
+		return nil, nil
+	}
+	allowed := make([]string, 0, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed = append(allowed, name)
+		}
+	}
+	if err := config.ValidateToolNames(allowed); err != nil {
+		return nil, err
+	}
+	return allowed, nil
+}
+
+// runOutput returns the writer `crush run` should stream the response to,
+// along with a flush func the caller must defer. When render is set and
+// stdout is a terminal, the response is buffered and rendered through the
+// TUI's markdown renderer on flush, since markdown can't be reliably
+// rendered in fragments. Otherwise output goes straight to stdout so piped
+// consumers get the raw text.
+func runOutput(render bool, providerID string) (io.Writer, func()) {
+	if !render || !term.IsTerminal(os.Stdout.Fd()) {
+		return os.Stdout, func() {}
+	}
+	var buf bytes.Buffer
+	return &buf, func() {
+		width := sessionOutputWidth
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width = w
+		}
+		sty := styles.ThemeForProvider(providerID)
+		if err := renderMarkdown(os.Stdout, buf.String(), &sty, min(width, sessionMaxContentWidth)); err != nil {
+			slog.Warn("Failed to render markdown output", "error", err)
+			_, _ = buf.WriteTo(os.Stdout)
+		}
+	}
+}
+
+// renderMarkdown renders content with the same glamour configuration used
+// by the chat view, so headings and code blocks (including syntax
+// highlighting) look the same as they do in the TUI.
+func renderMarkdown(w io.Writer, content string, sty *styles.Styles, width int) error {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	r := common.MarkdownRenderer(sty, width)
+	mu := common.LockMarkdownRenderer(r)
+	mu.Lock()
+	rendered, err := r.Render(content)
+	mu.Unlock()
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, strings.TrimRight(rendered, "\n")+"\n")
+	return err
 }
 
 // runNonInteractive executes the agent via the server and streams output
@@ -167,6 +539,7 @@ func runNonInteractive(
 	ctx context.Context,
 	c *client.Client,
 	ws *proto.Workspace,
+	output io.Writer,
 	prompt, largeModel, smallModel string,
 	hideSpinner bool,
 	continueSessionID string,
@@ -179,7 +552,7 @@ func runNonInteractive(
 
 	if largeModel != "" || smallModel != "" {
 		if err := overrideModels(ctx, c, ws, largeModel, smallModel); err != nil {
-			return fmt.Errorf("failed to override models: %w", err)
+			return setupError(fmt.Errorf("failed to override models: %w", err))
 		}
 	}
 
@@ -216,6 +589,13 @@ func runNonInteractive(
 		spinner.Start()
 	}
 
+	// Without a terminal to draw the spinner in, report progress as plain
+	// lines instead.
+	var progressLog *format.ProgressLog
+	if !hideSpinner && !stderrTTY {
+		progressLog = format.NewProgressLog(os.Stderr)
+	}
+
 	stopSpinner := func() {
 		if !hideSpinner && spinner != nil {
 			spinner.Stop()
@@ -226,7 +606,7 @@ func runNonInteractive(
 	// Wait for the agent to become ready (MCP init, etc).
 	if err := waitForAgent(ctx, c, ws.ID); err != nil {
 		stopSpinner()
-		return fmt.Errorf("agent not ready: %w", err)
+		return setupError(fmt.Errorf("agent not ready: %w", err))
 	}
 
 	// Force-update agent models so MCP tools are loaded.
@@ -238,7 +618,7 @@ func runNonInteractive(
 
 	sess, err := resolveSession(ctx, c, ws.ID, continueSessionID, useLast)
 	if err != nil {
-		return fmt.Errorf("failed to resolve session: %w", err)
+		return setupError(fmt.Errorf("failed to resolve session: %w", err))
 	}
 	if continueSessionID != "" || useLast {
 		slog.Info("Continuing session for non-interactive run", "session_id", sess.ID)
@@ -261,11 +641,13 @@ func runNonInteractive(
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
+	progressLog.RunStarted(sess.ID)
 	stream := &runStream{
 		sessionID: sess.ID,
 		runID:     runID,
-		out:       os.Stdout,
+		out:       output,
 		read:      make(map[string]int),
+		progress:  progressLog,
 	}
 
 	// Start herdr integration when running inside a herdr pane.
@@ -277,7 +659,7 @@ func runNonInteractive(
 		if progress && stderrTTY {
 			_, _ = fmt.Fprintf(os.Stderr, ansi.ResetProgressBar)
 		}
-		_, _ = fmt.Fprintln(os.Stdout)
+		_, _ = fmt.Fprintln(output)
 	}()
 
 	for {
@@ -307,6 +689,13 @@ func runNonInteractive(
 
 		case <-ctx.Done():
 			stopSpinner()
+			// The agent runs on the server, so it keeps going unless we
+			// cancel it explicitly.
+			cancelCtx, cancelTimeout := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			if err := c.CancelAgentSession(cancelCtx, ws.ID, sess.ID); err != nil {
+				slog.Warn("Failed to cancel agent session", "session_id", sess.ID, "error", err)
+			}
+			cancelTimeout()
 			return ctx.Err()
 		}
 	}
@@ -333,6 +722,9 @@ type runStream struct {
 	out       io.Writer
 	read      map[string]int
 	printed   bool
+	// progress, when set, is told about tool calls and the end of the
+	// run. It may be nil.
+	progress *format.ProgressLog
 }
 
 // handle processes one SSE event. Returns done=true when the run
@@ -350,6 +742,16 @@ func (s *runStream) handle(ev any, stopSpinner func()) (done bool, err error) {
 	switch e := ev.(type) {
 	case pubsub.Event[proto.Message]:
 		msg := e.Payload
+		if msg.SessionID == s.sessionID {
+			for _, call := range msg.ToolCalls() {
+				if call.Finished {
+					s.progress.ToolStarted(call.ID, call.Name)
+				}
+			}
+			for _, result := range msg.ToolResults() {
+				s.progress.ToolFinished(result.ToolCallID, result.Name, result.IsError)
+			}
+		}
 		if msg.SessionID != s.sessionID || msg.Role != proto.Assistant || len(msg.Parts) == 0 {
 			return false, nil
 		}
@@ -400,7 +802,14 @@ func (s *runStream) handle(ev any, stopSpinner func()) (done bool, err error) {
 		}
 		stop()
 		if e.Payload.Error != "" && !e.Payload.Cancelled {
-			return true, fmt.Errorf("agent run failed: %s", e.Payload.Error)
+			err := fmt.Errorf("agent run failed: %s", e.Payload.Error)
+			s.progress.RunFinished(err)
+			return true, err
+		}
+		if e.Payload.Cancelled {
+			s.progress.RunFinished(context.Canceled)
+		} else {
+			s.progress.RunFinished(nil)
 		}
 		// Reconcile stdout against the authoritative final
 		// assistant text carried in the event. The pubsub fan-in
@@ -445,7 +854,9 @@ func (s *runStream) handle(ev any, stopSpinner func()) (done bool, err error) {
 			return false, nil
 		}
 		stop()
-		return true, fmt.Errorf("agent error: %w", e.Payload.Error)
+		err := fmt.Errorf("agent error: %w", e.Payload.Error)
+		s.progress.RunFinished(err)
+		return true, err
 	}
 	return false, nil
 }
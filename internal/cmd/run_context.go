@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// contextCmdTimeout bounds how long each --context-cmd may run.
+const contextCmdTimeout = 2 * time.Minute
+
+// runContextCommands runs each command through the embedded shell in dir
+// and returns their stdout, each wrapped in a <command-output> tag naming
+// the command so the model can tell the output apart from the prompt. The
+// first command that fails or times out aborts with its stderr.
+func runContextCommands(ctx context.Context, dir string, commands []string) (string, error) {
+	var sb strings.Builder
+	for _, command := range commands {
+		stdout, err := runContextCommand(ctx, dir, command)
+		if err != nil {
+			return "", fmt.Errorf("--context-cmd %q: %w", command, err)
+		}
+		fmt.Fprintf(&sb, "<command-output command=%q>\n%s\n</command-output>\n\n", command, strings.TrimRight(stdout, "\n"))
+	}
+	return sb.String(), nil
+}
+
+func runContextCommand(ctx context.Context, dir, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, contextCmdTimeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{WorkingDir: dir})
+	stdout, stderr, err := sh.Exec(ctx, command)
+	switch {
+	case err == nil:
+		return stdout, nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("timed out after %s", contextCmdTimeout)
+	case shell.ExitCode(err) != 0:
+		err = fmt.Errorf("exited with status %d", shell.ExitCode(err))
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return "", fmt.Errorf("%w:\n%s", err, stderr)
+	}
+	return "", err
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunContextCommands(t *testing.T) {
+	t.Parallel()
+
+	t.Run("wraps stdout of each command", func(t *testing.T) {
+		t.Parallel()
+		out, err := runContextCommands(t.Context(), t.TempDir(), []string{"echo one", "echo two"})
+		require.NoError(t, err)
+		require.Equal(t, "<command-output command=\"echo one\">\none\n</command-output>\n\n"+
+			"<command-output command=\"echo two\">\ntwo\n</command-output>\n\n", out)
+	})
+
+	t.Run("failure shows stderr", func(t *testing.T) {
+		t.Parallel()
+		_, err := runContextCommands(t.Context(), t.TempDir(), []string{"echo ok", "echo broken >&2; exit 3"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `--context-cmd "echo broken >&2; exit 3": exited with status 3`)
+		require.Contains(t, err.Error(), "broken")
+	})
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// errNothingExtracted is returned when --extract finds nothing in the
+// response.
+var errNothingExtracted = errors.New("nothing to extract in the response")
+
+// extractor post-processes the response of `crush run` for --extract.
+type extractor func(response string) (string, error)
+
+// parseExtract returns the extractor for an --extract mode:
+//
+//   - code: the contents of every fenced code block, in order;
+//   - last-block: the last fenced code block, or the last paragraph if
+//     there is none;
+//   - regex:<pattern>: every match of pattern, one per line, or of its
+//     first capturing group if it has one.
+func parseExtract(mode string) (extractor, error) {
+	switch {
+	case mode == "code":
+		return func(response string) (string, error) {
+			blocks := fencedCodeBlocks(response)
+			if len(blocks) == 0 {
+				return "", errNothingExtracted
+			}
+			return strings.Join(blocks, ""), nil
+		}, nil
+	case mode == "last-block":
+		return func(response string) (string, error) {
+			if blocks := fencedCodeBlocks(response); len(blocks) > 0 {
+				return blocks[len(blocks)-1], nil
+			}
+			paragraphs := strings.Split(strings.TrimSpace(response), "\n\n")
+			last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+			if last == "" {
+				return "", errNothingExtracted
+			}
+			return last + "\n", nil
+		}, nil
+	case strings.HasPrefix(mode, "regex:"):
+		re, err := regexp.Compile(strings.TrimPrefix(mode, "regex:"))
+		if err != nil {
+			return nil, fmt.Errorf("--extract: %w", err)
+		}
+		return func(response string) (string, error) {
+			var sb strings.Builder
+			for _, m := range re.FindAllStringSubmatch(response, -1) {
+				match := m[0]
+				if len(m) > 1 {
+					match = m[1]
+				}
+				sb.WriteString(match)
+				sb.WriteString("\n")
+			}
+			if sb.Len() == 0 {
+				return "", errNothingExtracted
+			}
+			return sb.String(), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("--extract must be code, last-block or regex:<pattern>, got %q", mode)
+}
+
+// extractOutput returns the writer the response should be streamed to and
+// a func to call once the run succeeded. Without an extractor the response
+// goes straight to output; otherwise it is buffered and finish writes the
+// extracted part to output.
+func extractOutput(output io.Writer, extract extractor) (io.Writer, func() error) {
+	if extract == nil {
+		return output, func() error { return nil }
+	}
+	var buf bytes.Buffer
+	return &buf, func() error {
+		extracted, err := extract(buf.String())
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(output, extracted)
+		return err
+	}
+}
+
+// fencedCodeBlocks returns the contents of the fenced code blocks in
+// markdown, each ending in a newline. An unclosed block runs to the end.
+func fencedCodeBlocks(markdown string) []string {
+	var (
+		blocks []string
+		fence  string
+		block  strings.Builder
+	)
+	for line := range strings.Lines(markdown) {
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+				block.Reset()
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			blocks = append(blocks, block.String())
+			fence = ""
+			continue
+		}
+		block.WriteString(strings.TrimSuffix(line, "\n") + "\n")
+	}
+	if fence != "" && block.Len() > 0 {
+		blocks = append(blocks, block.String())
+	}
+	return blocks
+}
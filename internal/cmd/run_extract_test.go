@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExtract(t *testing.T) {
+	t.Parallel()
+
+	response := "Here you go:\n\n```bash\nfind . -name '*.go' | wc -l\n```\n\nOr in two steps:\n\n~~~\nls\n  pwd\n~~~\n\nThat counts 42 files. Answer: 42"
+
+	for _, tc := range []struct {
+		mode, want string
+	}{
+		{"code", "find . -name '*.go' | wc -l\nls\n  pwd\n"},
+		{"last-block", "ls\n  pwd\n"},
+		{`regex:Answer: (\d+)`, "42\n"},
+		{`regex:\d+`, "42\n42\n"},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Parallel()
+			extract, err := parseExtract(tc.mode)
+			require.NoError(t, err)
+			got, err := extract(response)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("last paragraph without code", func(t *testing.T) {
+		t.Parallel()
+		extract, err := parseExtract("last-block")
+		require.NoError(t, err)
+		got, err := extract("Let me think.\n\nThe answer is 42.\n")
+		require.NoError(t, err)
+		require.Equal(t, "The answer is 42.\n", got)
+	})
+
+	t.Run("nothing to extract", func(t *testing.T) {
+		t.Parallel()
+		extract, err := parseExtract("code")
+		require.NoError(t, err)
+		_, err = extract("no code here")
+		require.ErrorIs(t, err, errNothingExtracted)
+	})
+
+	t.Run("invalid modes", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseExtract("json")
+		require.ErrorContains(t, err, "code, last-block or regex:<pattern>")
+		_, err = parseExtract("regex:(")
+		require.ErrorContains(t, err, "--extract")
+	})
+}
+
+func TestExtractOutput(t *testing.T) {
+	t.Parallel()
+
+	var out strings.Builder
+	answer, finish := extractOutput(&out, nil)
+	require.Same(t, &out, answer)
+	require.NoError(t, finish())
+
+	extract, err := parseExtract("code")
+	require.NoError(t, err)
+	answer, finish = extractOutput(&out, extract)
+	_, _ = answer.Write([]byte("text\n```\necho hi\n```\n"))
+	require.Empty(t, out.String())
+	require.NoError(t, finish())
+	require.Equal(t, "echo hi\n", out.String())
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptedOr(t *testing.T) {
+	t.Parallel()
+
+	runErr := errors.New("agent processing failed")
+
+	require.ErrorIs(t, interruptedOr(t.Context(), runErr), runErr)
+	require.NoError(t, interruptedOr(t.Context(), nil))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	require.ErrorIs(t, interruptedOr(ctx, nil), errInterrupted)
+	require.ErrorIs(t, interruptedOr(ctx, context.Canceled), errInterrupted)
+}
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 1, exitCode(errors.New("boom")))
+	require.Equal(t, exitCodeInterrupted, exitCode(errInterrupted))
+	require.Equal(t, exitCodeInterrupted, exitCode(fmt.Errorf("run: %w", errInterrupted)))
+
+	providerErr := func(status int) error {
+		retried := &fantasy.RetryError{Errors: []error{&fantasy.ProviderError{StatusCode: status}}}
+		return fmt.Errorf("agent processing failed: %w", retried)
+	}
+	require.Equal(t, exitCodeSetup, exitCode(setupError(errors.New("no prompt provided"))))
+	require.Equal(t, exitCodeSetup, exitCode(fmt.Errorf("run: %w", &app.SetupError{Err: errors.New("session not found")})))
+	require.Equal(t, exitCodeAuth, exitCode(providerErr(http.StatusUnauthorized)))
+	require.Equal(t, exitCodeAuth, exitCode(&fantasy.ProviderError{AuthError: true}))
+	require.Equal(t, exitCodeRateLimited, exitCode(providerErr(http.StatusTooManyRequests)))
+	require.Equal(t, exitCodeTimeout, exitCode(providerErr(http.StatusGatewayTimeout)))
+	require.Equal(t, exitCodeTimeout, exitCode(fmt.Errorf("stream: %w", context.DeadlineExceeded)))
+	require.Equal(t, exitCodeFailure, exitCode(providerErr(http.StatusBadRequest)))
+}
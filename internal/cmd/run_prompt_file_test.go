@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPromptFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	task := filepath.Join(dir, "task.md")
+	require.NoError(t, os.WriteFile(task, []byte("\nFix the parser.\n\nKeep the tests passing.\n"), 0o644))
+	prompt, err := readPromptFile(task)
+	require.NoError(t, err)
+	require.Equal(t, "Fix the parser.\n\nKeep the tests passing.", prompt)
+
+	empty := filepath.Join(dir, "empty.md")
+	require.NoError(t, os.WriteFile(empty, []byte(" \n"), 0o644))
+	_, err = readPromptFile(empty)
+	require.ErrorContains(t, err, "empty.md is empty")
+
+	_, err = readPromptFile(filepath.Join(dir, "missing.md"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
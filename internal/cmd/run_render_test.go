@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.ThemeForProvider("")
+
+	t.Run("renders markdown", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		err := renderMarkdown(&buf, "# Title\n\nSome **bold** text.\n", &sty, 80)
+		require.NoError(t, err)
+
+		out := ansi.Strip(buf.String())
+		require.Contains(t, out, "Title")
+		require.Contains(t, out, "bold")
+		require.NotContains(t, out, "**bold**")
+	})
+
+	t.Run("skips empty content", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		err := renderMarkdown(&buf, " \n\t", &sty, 80)
+		require.NoError(t, err)
+		require.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// replayPrompts returns the text of the user messages in msgs, in order.
+// Summaries and messages without text, such as attachment-only turns, are
+// skipped.
+func replayPrompts(msgs []message.Message) []string {
+	var prompts []string
+	for _, msg := range msgs {
+		if msg.Role != message.User || msg.IsSummaryMessage {
+			continue
+		}
+		if text := strings.TrimSpace(msg.Content().Text); text != "" {
+			prompts = append(prompts, text)
+		}
+	}
+	return prompts
+}
+
+// replayModel returns "provider/model" of the last assistant message in
+// msgs, or "unknown" if there is none.
+func replayModel(msgs []message.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == message.Assistant && msgs[i].Model != "" {
+			return msgs[i].Provider + "/" + msgs[i].Model
+		}
+	}
+	return "unknown"
+}
+
+// runReplay re-runs the user prompts of the session sourceID, one after
+// the other, in a new session with the selected models. Answers are
+// written to output; a usage comparison of the two sessions is written to
+// stats.
+func runReplay(ctx context.Context, a *app.App, output, stats io.Writer, sourceID, largeModel, smallModel string, hideSpinner bool) error {
+	source, err := resolveSessionID(ctx, a.Sessions, sourceID)
+	if err != nil {
+		return setupError(err)
+	}
+	if source.ParentSessionID != "" {
+		return setupError(fmt.Errorf("cannot replay a child session: %s", sourceID))
+	}
+	sourceMsgs, err := a.Messages.List(ctx, source.ID)
+	if err != nil {
+		return setupError(fmt.Errorf("failed to list messages: %w", err))
+	}
+	prompts := replayPrompts(sourceMsgs)
+	if len(prompts) == 0 {
+		return setupError(fmt.Errorf("session %s has no user prompts to replay", sourceID))
+	}
+
+	replay, err := a.Sessions.Create(ctx, "Replay: "+source.Title)
+	if err != nil {
+		return setupError(fmt.Errorf("failed to create session: %w", err))
+	}
+	for i, prompt := range prompts {
+		if i > 0 {
+			fmt.Fprintln(output)
+		}
+		if err := a.RunNonInteractive(ctx, output, prompt, largeModel, smallModel, hideSpinner, replay.ID, false); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	// Reload both sessions for their final usage.
+	if source, err = a.Sessions.Get(ctx, source.ID); err != nil {
+		return err
+	}
+	if replay, err = a.Sessions.Get(ctx, replay.ID); err != nil {
+		return err
+	}
+	replayMsgs, err := a.Messages.List(ctx, replay.ID)
+	if err != nil {
+		return err
+	}
+	printReplayComparison(stats, len(prompts), source, replay, replayModel(sourceMsgs), replayModel(replayMsgs))
+	return nil
+}
+
+// printReplayComparison writes the usage of the original session next to
+// that of its replay.
+func printReplayComparison(w io.Writer, prompts int, source, replay session.Session, sourceModel, replayModel string) {
+	row := func(label, a, b string) {
+		fmt.Fprintf(w, "  %-18s %-28s %s\n", label, a, b)
+	}
+	cost := func(c float64) string {
+		return fmt.Sprintf("$%.4f", c)
+	}
+	fmt.Fprintf(w, "Replayed %d prompts:\n", prompts)
+	row("", "original", "replay")
+	row("session", session.HashID(source.ID)[:12], session.HashID(replay.ID)[:12])
+	row("model", sourceModel, replayModel)
+	row("prompt tokens", formatSessionTokens(source.PromptTokens), formatSessionTokens(replay.PromptTokens))
+	row("completion tokens", formatSessionTokens(source.CompletionTokens), formatSessionTokens(replay.CompletionTokens))
+	row("cost", cost(source.Cost), cost(replay.Cost))
+}
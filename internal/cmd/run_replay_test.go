@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayPrompts(t *testing.T) {
+	t.Parallel()
+
+	text := func(role message.MessageRole, s string) message.Message {
+		return message.Message{Role: role, Parts: []message.ContentPart{message.TextContent{Text: s}}}
+	}
+	summary := text(message.User, "summary of the above")
+	summary.IsSummaryMessage = true
+	msgs := []message.Message{
+		text(message.User, "first"),
+		text(message.Assistant, "answer"),
+		{Role: message.User},
+		summary,
+		text(message.User, "  second\n"),
+	}
+	require.Equal(t, []string{"first", "second"}, replayPrompts(msgs))
+
+	answer := text(message.Assistant, "answer")
+	answer.Provider, answer.Model = "openai", "gpt-5"
+	require.Equal(t, "openai/gpt-5", replayModel(append(msgs, answer)))
+	require.Equal(t, "unknown", replayModel(nil))
+}
+
+func TestPrintReplayComparison(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	source := session.Session{ID: "a", PromptTokens: 1500, CompletionTokens: 20, Cost: 0.01}
+	replay := session.Session{ID: "b", PromptTokens: 900, CompletionTokens: 2_000_000, Cost: 0.5}
+	printReplayComparison(&sb, 2, source, replay, "anthropic/claude", "openai/gpt-5")
+
+	out := sb.String()
+	require.Contains(t, out, "Replayed 2 prompts:")
+	require.Contains(t, out, "anthropic/claude")
+	require.Contains(t, out, "openai/gpt-5")
+	require.Contains(t, out, "1.5K")
+	require.Contains(t, out, "2M")
+	require.Contains(t, out, "$0.5000")
+}
@@ -3,9 +3,11 @@ package cmd
 import (
 	"bytes"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/charmbracelet/crush/internal/format"
 	"github.com/charmbracelet/crush/internal/proto"
 	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/stretchr/testify/require"
@@ -415,3 +417,36 @@ func TestRunStream_NoRunIDFallsBackToSessionID(t *testing.T) {
 	require.True(t, done)
 	require.Equal(t, "DONE", buf.String())
 }
+
+// TestRunStream_ReportsToolProgress verifies that tool calls and the
+// end of the run reach the progress log once each, with the
+// timestamped line format used in CI logs.
+func TestRunStream_ReportsToolProgress(t *testing.T) {
+	t.Parallel()
+
+	var progress bytes.Buffer
+	s := &runStream{sessionID: "S", runID: "R", out: &bytes.Buffer{}, read: map[string]int{}, progress: format.NewProgressLog(&progress)}
+
+	call := proto.Message{
+		ID: "m1", SessionID: "S", Role: proto.Assistant,
+		Parts: []proto.ContentPart{proto.ToolCall{ID: "c1", Name: "bash", Finished: true}},
+	}
+	for range 2 {
+		_, err := s.handle(pubsub.Event[proto.Message]{Payload: call}, nil)
+		require.NoError(t, err)
+	}
+	_, err := s.handle(pubsub.Event[proto.Message]{Payload: proto.Message{
+		ID: "m2", SessionID: "S", Role: proto.Tool,
+		Parts: []proto.ContentPart{proto.ToolResult{ToolCallID: "c1", Name: "bash", IsError: true}},
+	}}, nil)
+	require.NoError(t, err)
+	done, err := s.handle(pubsub.Event[proto.RunComplete]{Payload: proto.RunComplete{SessionID: "S", RunID: "R"}}, nil)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	lines := strings.Split(strings.TrimSpace(progress.String()), "\n")
+	require.Len(t, lines, 3)
+	for i, want := range []string{"tool bash started", "tool bash failed", "run completed"} {
+		require.Regexp(t, `^\d\d:\d\d:\d\d `+want+`$`, lines[i])
+	}
+}
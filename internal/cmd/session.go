@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -39,6 +40,8 @@ var sessionCmd = &cobra.Command{
 
 var (
 	sessionListJSON   bool
+	sessionListSort   string
+	sessionListLimit  int
 	sessionShowJSON   bool
 	sessionLastJSON   bool
 	sessionDeleteJSON bool
@@ -49,7 +52,7 @@ var sessionListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List all sessions",
-	Long:    "List all sessions. Use --json for machine-readable output.",
+	Long:    "List all sessions with their message count, token usage and cost. Use --sort to order by date, cost or tokens, --limit to show only the first sessions, and --json for machine-readable output.",
 	RunE:    runSessionList,
 }
 
@@ -87,10 +90,15 @@ var sessionRenameCmd = &cobra.Command{
 
 func init() {
 	sessionListCmd.Flags().BoolVar(&sessionListJSON, "json", false, "output in JSON format")
+	sessionListCmd.Flags().StringVar(&sessionListSort, "sort", sessionSortDate, "sort order: date, cost or tokens")
+	sessionListCmd.Flags().IntVar(&sessionListLimit, "limit", 0, "show at most this many sessions (0 for all)")
 	sessionShowCmd.Flags().BoolVar(&sessionShowJSON, "json", false, "output in JSON format")
 	sessionLastCmd.Flags().BoolVar(&sessionLastJSON, "json", false, "output in JSON format")
 	sessionDeleteCmd.Flags().BoolVar(&sessionDeleteJSON, "json", false, "output in JSON format")
 	sessionRenameCmd.Flags().BoolVar(&sessionRenameJSON, "json", false, "output in JSON format")
+	for _, c := range []*cobra.Command{sessionListCmd, sessionShowCmd, sessionLastCmd, sessionDeleteCmd, sessionRenameCmd} {
+		addJSONFlags(c)
+	}
 	sessionCmd.AddCommand(sessionListCmd)
 	sessionCmd.AddCommand(sessionShowCmd)
 	sessionCmd.AddCommand(sessionLastCmd)
@@ -102,6 +110,7 @@ type sessionServices struct {
 	sessions session.Service
 	messages message.Service
 	cfg      *config.ConfigStore
+	conn     *sql.DB
 }
 
 func sessionSetup(cmd *cobra.Command) (context.Context, *sessionServices, func(), error) {
@@ -127,8 +136,9 @@ func sessionSetup(cmd *cobra.Command) (context.Context, *sessionServices, func()
 	queries := db.New(conn)
 	svc := &sessionServices{
 		sessions: session.NewService(queries, conn),
-		messages: message.NewService(queries),
+		messages: message.NewService(queries, message.WithMaxPartBytes(cfg.Config().Options.Storage.GetMaxMessageBytes())),
 		cfg:      cfg,
+		conn:     conn,
 	}
 	return ctx, svc, func() { conn.Close() }, nil
 }
@@ -136,6 +146,10 @@ func sessionSetup(cmd *cobra.Command) (context.Context, *sessionServices, func()
 func runSessionList(cmd *cobra.Command, _ []string) error {
 	event.SetNonInteractive(true)
 
+	if sessionListLimit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+
 	ctx, svc, cleanup, err := sessionSetup(cmd)
 	if err != nil {
 		return err
@@ -148,6 +162,13 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to list sessions: %w", err)
 	}
+	list, err = sortSessions(list, sessionListSort)
+	if err != nil {
+		return err
+	}
+	if sessionListLimit > 0 && len(list) > sessionListLimit {
+		list = list[:sessionListLimit]
+	}
 
 	if sessionListJSON {
 		out := cmd.OutOrStdout()
@@ -159,11 +180,15 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 				Title:    s.Title,
 				Created:  time.Unix(s.CreatedAt, 0).Format(time.RFC3339),
 				Modified: time.Unix(s.UpdatedAt, 0).Format(time.RFC3339),
+				Pinned:   s.Pinned,
+
+				Messages:         s.MessageCount,
+				PromptTokens:     s.PromptTokens,
+				CompletionTokens: s.CompletionTokens,
+				Cost:             s.Cost,
 			}
 		}
-		enc := json.NewEncoder(out)
-		enc.SetEscapeHTML(false)
-		return enc.Encode(output)
+		return writeJSON(out, output, jsonOptionsFrom(cmd))
 	}
 
 	w, cleanup, usingPager := sessionWriter(ctx, len(list))
@@ -171,21 +196,28 @@ func runSessionList(cmd *cobra.Command, _ []string) error {
 
 	hashStyle := lipgloss.NewStyle().Foreground(charmtone.Malibu)
 	dateStyle := lipgloss.NewStyle().Foreground(charmtone.Damson)
+	pinStyle := lipgloss.NewStyle().Foreground(charmtone.Zest)
+	usageStyle := lipgloss.NewStyle().Foreground(charmtone.Squid)
 
 	width := sessionOutputWidth
 	if tw, _, err := term.GetSize(os.Stdout.Fd()); err == nil && tw > 0 {
 		width = tw
 	}
-	// 7 (hash) + 1 (space) + 25 (RFC3339 date) + 1 (space) = 34 chars prefix.
-	titleWidth := max(width-34, 10)
+	// 7 (hash) + 1 (space) + 25 (RFC3339 date) + 1 (space) + 23 (usage)
+	// + 1 (space) = 58 chars prefix.
+	titleWidth := max(width-58, 10)
 
 	var writeErr error
 	for _, s := range list {
 		hash := session.HashID(s.ID)[:7]
 		date := time.Unix(s.CreatedAt, 0).Format(time.RFC3339)
 		title := strings.ReplaceAll(s.Title, "\n", " ")
+		if s.Pinned {
+			title = pinStyle.Render(styles.PinnedIcon) + " " + title
+		}
 		title = ansi.Truncate(title, titleWidth, "…")
-		_, writeErr = fmt.Fprintln(w, hashStyle.Render(hash), dateStyle.Render(date), title)
+		usage := fmt.Sprintf("%4d msg %6s $%6.2f", s.MessageCount, formatSessionTokens(s.PromptTokens+s.CompletionTokens), s.Cost)
+		_, writeErr = fmt.Fprintln(w, hashStyle.Render(hash), dateStyle.Render(date), usageStyle.Render(usage), title)
 		if writeErr != nil {
 			break
 		}
@@ -202,6 +234,53 @@ type sessionJSON struct {
 	Title    string `json:"title"`
 	Created  string `json:"created"`
 	Modified string `json:"modified"`
+	Pinned   bool   `json:"pinned,omitempty"`
+
+	Messages         int64   `json:"messages"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Sort orders accepted by `crush session list --sort`.
+const (
+	sessionSortDate   = "date"
+	sessionSortCost   = "cost"
+	sessionSortTokens = "tokens"
+)
+
+// sortSessions orders sessions for listing. The date order is the one the
+// database returns: pinned sessions first, then the most recently updated.
+// The cost and tokens orders put the most expensive sessions first and keep
+// the date order between ties.
+func sortSessions(list []session.Session, by string) ([]session.Session, error) {
+	var key func(session.Session) float64
+	switch by {
+	case sessionSortDate, "":
+		return list, nil
+	case sessionSortCost:
+		key = func(s session.Session) float64 { return s.Cost }
+	case sessionSortTokens:
+		key = func(s session.Session) float64 { return float64(s.PromptTokens + s.CompletionTokens) }
+	default:
+		return nil, fmt.Errorf("invalid --sort %q: must be one of date, cost or tokens", by)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return key(list[i]) > key(list[j])
+	})
+	return list, nil
+}
+
+// formatSessionTokens formats a token count with K/M units.
+func formatSessionTokens(tokens int64) string {
+	switch {
+	case tokens >= 1_000_000:
+		return strings.Replace(fmt.Sprintf("%.1fM", float64(tokens)/1_000_000), ".0M", "M", 1)
+	case tokens >= 1_000:
+		return strings.Replace(fmt.Sprintf("%.1fK", float64(tokens)/1_000), ".0K", "K", 1)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
 }
 
 type sessionMutationResult struct {
@@ -280,7 +359,7 @@ func runSessionShow(cmd *cobra.Command, args []string) error {
 
 	msgPtrs := messagePtrs(msgs)
 	if sessionShowJSON {
-		return outputSessionJSON(cmd.OutOrStdout(), sess, msgPtrs)
+		return outputSessionJSON(cmd.OutOrStdout(), sess, msgPtrs, jsonOptionsFrom(cmd))
 	}
 	return outputSessionHuman(ctx, svc.cfg, sess, msgPtrs)
 }
@@ -307,14 +386,12 @@ func runSessionDelete(cmd *cobra.Command, args []string) error {
 
 	out := cmd.OutOrStdout()
 	if sessionDeleteJSON {
-		enc := json.NewEncoder(out)
-		enc.SetEscapeHTML(false)
-		return enc.Encode(sessionMutationResult{
+		return writeJSON(out, sessionMutationResult{
 			ID:      session.HashID(sess.ID),
 			UUID:    sess.ID,
 			Title:   sess.Title,
 			Deleted: true,
-		})
+		}, jsonOptionsFrom(cmd))
 	}
 
 	fmt.Fprintf(out, "Deleted session %s\n", session.HashID(sess.ID)[:12])
@@ -344,14 +421,12 @@ func runSessionRename(cmd *cobra.Command, args []string) error {
 
 	out := cmd.OutOrStdout()
 	if sessionRenameJSON {
-		enc := json.NewEncoder(out)
-		enc.SetEscapeHTML(false)
-		return enc.Encode(sessionMutationResult{
+		return writeJSON(out, sessionMutationResult{
 			ID:      session.HashID(sess.ID),
 			UUID:    sess.ID,
 			Title:   newTitle,
 			Renamed: true,
-		})
+		}, jsonOptionsFrom(cmd))
 	}
 
 	fmt.Fprintf(out, "Renamed session %s to %q\n", session.HashID(sess.ID)[:12], newTitle)
@@ -387,7 +462,7 @@ func runSessionLast(cmd *cobra.Command, _ []string) error {
 
 	msgPtrs := messagePtrs(msgs)
 	if sessionLastJSON {
-		return outputSessionJSON(cmd.OutOrStdout(), sess, msgPtrs)
+		return outputSessionJSON(cmd.OutOrStdout(), sess, msgPtrs, jsonOptionsFrom(cmd))
 	}
 	return outputSessionHuman(ctx, svc.cfg, sess, msgPtrs)
 }
@@ -405,7 +480,7 @@ func messagePtrs(msgs []message.Message) []*message.Message {
 	return ptrs
 }
 
-func outputSessionJSON(w io.Writer, sess session.Session, msgs []*message.Message) error {
+func outputSessionJSON(w io.Writer, sess session.Session, msgs []*message.Message, opts jsonOptions) error {
 	skills := extractSkillsFromMessages(msgs)
 	output := sessionShowOutput{
 		Meta: sessionShowMeta{
@@ -434,9 +509,7 @@ func outputSessionJSON(w io.Writer, sess session.Session, msgs []*message.Messag
 		}
 	}
 
-	enc := json.NewEncoder(w)
-	enc.SetEscapeHTML(false)
-	return enc.Encode(output)
+	return writeJSON(w, output, opts)
 }
 
 func outputSessionHuman(ctx context.Context, cfg *config.ConfigStore, sess session.Session, msgs []*message.Message) error {
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/spf13/cobra"
+)
+
+var sessionDiffJSON bool
+
+var sessionDiffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Compare the answers of two sessions",
+	Long:  "Compare two sessions turn by turn: the prompt, the tools used and the answer of each turn. Prints a unified diff, or the aligned turns with --json. IDs can be a UUID, full hash, or hash prefix.",
+	Example: `
+# See how a replay with another model answered differently
+crush session diff 3f2a9c 8b41d0
+  `,
+	Args: cobra.ExactArgs(2),
+	RunE: runSessionDiff,
+}
+
+func init() {
+	sessionDiffCmd.Flags().BoolVar(&sessionDiffJSON, "json", false, "output in JSON format")
+	addJSONFlags(sessionDiffCmd)
+	sessionCmd.AddCommand(sessionDiffCmd)
+}
+
+// sessionTurn is a user prompt and everything the agent did to answer it.
+type sessionTurn struct {
+	Prompt string   `json:"prompt"`
+	Tools  []string `json:"tools"`
+	Answer string   `json:"answer"`
+}
+
+type sessionDiffSide struct {
+	ID    string `json:"id"`
+	UUID  string `json:"uuid"`
+	Title string `json:"title"`
+}
+
+type sessionDiffTurn struct {
+	Turn int          `json:"turn"`
+	Same bool         `json:"same"`
+	A    *sessionTurn `json:"a"`
+	B    *sessionTurn `json:"b"`
+}
+
+type sessionDiffResult struct {
+	A     sessionDiffSide   `json:"a"`
+	B     sessionDiffSide   `json:"b"`
+	Turns []sessionDiffTurn `json:"turns"`
+}
+
+func runSessionDiff(cmd *cobra.Command, args []string) error {
+	event.SetNonInteractive(true)
+
+	ctx, svc, cleanup, err := sessionSetup(cmd)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var (
+		sessions [2]session.Session
+		turns    [2][]sessionTurn
+	)
+	for i, id := range args {
+		if sessions[i], err = resolveSessionID(ctx, svc.sessions, id); err != nil {
+			return err
+		}
+		msgs, err := svc.messages.List(ctx, sessions[i].ID)
+		if err != nil {
+			return fmt.Errorf("failed to list messages: %w", err)
+		}
+		turns[i] = sessionTurns(msgs)
+	}
+
+	out := cmd.OutOrStdout()
+	if sessionDiffJSON {
+		side := func(s session.Session) sessionDiffSide {
+			return sessionDiffSide{ID: session.HashID(s.ID), UUID: s.ID, Title: s.Title}
+		}
+		return writeJSON(out, sessionDiffResult{
+			A:     side(sessions[0]),
+			B:     side(sessions[1]),
+			Turns: alignTurns(turns[0], turns[1]),
+		}, jsonOptionsFrom(cmd))
+	}
+
+	label := func(prefix string, s session.Session) string {
+		return fmt.Sprintf("%s/%s %s", prefix, session.HashID(s.ID)[:12], strings.ReplaceAll(s.Title, "\n", " "))
+	}
+	return writeSessionDiff(out, label("a", sessions[0]), label("b", sessions[1]), turns[0], turns[1])
+}
+
+// sessionTurns splits msgs into turns, each starting at a user message.
+// Assistant text is joined per turn and tool calls are listed by name.
+// Messages before the first prompt, such as a summary, are skipped.
+func sessionTurns(msgs []message.Message) []sessionTurn {
+	var turns []sessionTurn
+	for _, msg := range msgs {
+		switch {
+		case msg.Role == message.User && !msg.IsSummaryMessage:
+			turns = append(turns, sessionTurn{Prompt: strings.TrimSpace(msg.Content().Text)})
+		case msg.Role == message.Assistant && len(turns) > 0:
+			turn := &turns[len(turns)-1]
+			for _, call := range msg.ToolCalls() {
+				turn.Tools = append(turn.Tools, call.Name)
+			}
+			if text := strings.TrimSpace(msg.Content().Text); text != "" {
+				if turn.Answer != "" {
+					turn.Answer += "\n\n"
+				}
+				turn.Answer += text
+			}
+		}
+	}
+	return turns
+}
+
+// alignTurns pairs the turns of two sessions by position. Turns only one
+// session has are paired with nil.
+func alignTurns(a, b []sessionTurn) []sessionDiffTurn {
+	result := make([]sessionDiffTurn, max(len(a), len(b)))
+	for i := range result {
+		result[i].Turn = i + 1
+		if i < len(a) {
+			result[i].A = &a[i]
+		}
+		if i < len(b) {
+			result[i].B = &b[i]
+		}
+		result[i].Same = result[i].A != nil && result[i].B != nil &&
+			formatTurn(i+1, *result[i].A) == formatTurn(i+1, *result[i].B)
+	}
+	return result
+}
+
+// formatTurn renders a turn as the text that is diffed. The turn header
+// keeps the diff hunks aligned with the turns.
+func formatTurn(n int, turn sessionTurn) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Turn %d\n", n)
+	for line := range strings.SplitSeq(turn.Prompt, "\n") {
+		fmt.Fprintf(&sb, "> %s\n", line)
+	}
+	if len(turn.Tools) > 0 {
+		fmt.Fprintf(&sb, "Tools: %s\n", strings.Join(turn.Tools, ", "))
+	}
+	sb.WriteString("\n")
+	if turn.Answer != "" {
+		sb.WriteString(turn.Answer)
+		sb.WriteString("\n\n")
+	}
+	return sb.String()
+}
+
+// writeSessionDiff writes a unified diff of the turns of two sessions, or
+// a note that they are the same.
+func writeSessionDiff(w io.Writer, labelA, labelB string, a, b []sessionTurn) error {
+	text := func(turns []sessionTurn) string {
+		var sb strings.Builder
+		for i, turn := range turns {
+			sb.WriteString(formatTurn(i+1, turn))
+		}
+		return sb.String()
+	}
+	unified := udiff.Unified(labelA, labelB, text(a), text(b))
+	if unified == "" {
+		_, err := fmt.Fprintln(w, "The sessions have the same prompts, tools and answers.")
+		return err
+	}
+	_, err := io.WriteString(w, unified)
+	return err
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionTurns(t *testing.T) {
+	t.Parallel()
+
+	msg := func(role message.MessageRole, text string, calls ...string) message.Message {
+		m := message.Message{Role: role, Parts: []message.ContentPart{message.TextContent{Text: text}}}
+		for _, name := range calls {
+			m.Parts = append(m.Parts, message.ToolCall{Name: name})
+		}
+		return m
+	}
+	turns := sessionTurns([]message.Message{
+		msg(message.User, "fix the bug"),
+		msg(message.Assistant, "", "view", "edit"),
+		msg(message.Assistant, "Fixed it."),
+		msg(message.User, "thanks"),
+		msg(message.Assistant, "You're welcome."),
+	})
+	require.Equal(t, []sessionTurn{
+		{Prompt: "fix the bug", Tools: []string{"view", "edit"}, Answer: "Fixed it."},
+		{Prompt: "thanks", Answer: "You're welcome."},
+	}, turns)
+}
+
+func TestSessionDiff(t *testing.T) {
+	t.Parallel()
+
+	a := []sessionTurn{
+		{Prompt: "fix the bug", Tools: []string{"view", "edit"}, Answer: "Fixed it."},
+		{Prompt: "thanks", Answer: "You're welcome."},
+	}
+	b := []sessionTurn{
+		{Prompt: "fix the bug", Tools: []string{"edit"}, Answer: "Fixed it."},
+		{Prompt: "thanks", Answer: "You're welcome."},
+		{Prompt: "anything else?", Answer: "No."},
+	}
+
+	t.Run("aligns turns", func(t *testing.T) {
+		t.Parallel()
+		turns := alignTurns(a, b)
+		require.Len(t, turns, 3)
+		require.False(t, turns[0].Same)
+		require.True(t, turns[1].Same)
+		require.Nil(t, turns[2].A)
+		require.Equal(t, "No.", turns[2].B.Answer)
+	})
+
+	t.Run("writes a unified diff", func(t *testing.T) {
+		t.Parallel()
+		var sb strings.Builder
+		require.NoError(t, writeSessionDiff(&sb, "a/one", "b/two", a, b))
+		out := sb.String()
+		require.Contains(t, out, "--- a/one\n+++ b/two\n")
+		require.Contains(t, out, "-Tools: view, edit\n+Tools: edit\n")
+		require.Contains(t, out, "+## Turn 3\n")
+	})
+
+	t.Run("reports identical sessions", func(t *testing.T) {
+		t.Parallel()
+		var sb strings.Builder
+		require.NoError(t, writeSessionDiff(&sb, "a", "b", a, a))
+		require.Equal(t, "The sessions have the same prompts, tools and answers.\n", sb.String())
+	})
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortSessions(t *testing.T) {
+	t.Parallel()
+
+	list := func() []session.Session {
+		return []session.Session{
+			{ID: "a", Cost: 0.10, PromptTokens: 5_000, CompletionTokens: 100},
+			{ID: "b", Cost: 2.50, PromptTokens: 1_000, CompletionTokens: 50},
+			{ID: "c", Cost: 0.10, PromptTokens: 90_000, CompletionTokens: 1_000},
+		}
+	}
+	ids := func(sessions []session.Session) []string {
+		out := make([]string, len(sessions))
+		for i, s := range sessions {
+			out[i] = s.ID
+		}
+		return out
+	}
+
+	sorted, err := sortSessions(list(), sessionSortDate)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, ids(sorted))
+
+	sorted, err = sortSessions(list(), sessionSortCost)
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "a", "c"}, ids(sorted))
+
+	sorted, err = sortSessions(list(), sessionSortTokens)
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "a", "b"}, ids(sorted))
+
+	_, err = sortSessions(list(), "size")
+	require.ErrorContains(t, err, "invalid --sort")
+}
+
+func TestFormatSessionTokens(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "999", formatSessionTokens(999))
+	require.Equal(t, "12K", formatSessionTokens(12_000))
+	require.Equal(t, "12.5K", formatSessionTokens(12_500))
+	require.Equal(t, "1.2M", formatSessionTokens(1_234_567))
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"database/sql"
 	_ "embed"
@@ -12,6 +13,7 @@ import (
 	"os"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -53,8 +55,16 @@ var statsCmd = &cobra.Command{
 func init() {
 	statsCmd.Flags().String("crawl-dir", "", "Crawl a directory recursively for all crush projects and aggregate stats")
 	statsCmd.Flags().Bool("all", false, "Aggregate stats from all known projects (from projects.json)")
+	statsCmd.Flags().String("sort", statsSortCount, "Order of the model and tool sections: count (most used first) or name")
+	statsCmd.Flags().Bool("group-by-provider", false, "List models grouped by provider, busiest provider first")
 }
 
+// Orders for the model and tool sections of the stats.
+const (
+	statsSortCount = "count"
+	statsSortName  = "name"
+)
+
 // Day names for day of week statistics.
 var dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
 
@@ -81,6 +91,9 @@ type TotalStats struct {
 	TotalMessages         int64   `json:"total_messages"`
 	AvgTokensPerSession   float64 `json:"avg_tokens_per_session"`
 	AvgMessagesPerSession float64 `json:"avg_messages_per_session"`
+	// LoopInterventions counts tool calls the agent answered with a note
+	// instead of running them, because the same call kept repeating.
+	LoopInterventions int64 `json:"loop_interventions"`
 }
 
 type DailyUsage struct {
@@ -140,6 +153,12 @@ func runStats(cmd *cobra.Command, _ []string) error {
 	dataDir, _ := cmd.Flags().GetString("data-dir")
 	crawlDir, _ := cmd.Flags().GetString("crawl-dir")
 	useAll, _ := cmd.Flags().GetBool("all")
+	sortBy, _ := cmd.Flags().GetString("sort")
+	groupByProvider, _ := cmd.Flags().GetBool("group-by-provider")
+
+	if sortBy != statsSortCount && sortBy != statsSortName {
+		return fmt.Errorf("invalid --sort %q: must be %s or %s", sortBy, statsSortCount, statsSortName)
+	}
 
 	var projectStats []ProjectStats
 	var err error
@@ -193,6 +212,7 @@ func runStats(cmd *cobra.Command, _ []string) error {
 	if mergedStats.Total.TotalSessions == 0 {
 		return fmt.Errorf("no data available: no sessions found in database")
 	}
+	sortStats(mergedStats, sortBy, groupByProvider)
 
 	currentUser, err := user.Current()
 	if err != nil {
@@ -412,6 +432,7 @@ func mergeStats(projectStats []ProjectStats) *Stats {
 		merged.Total.TotalTokens += s.Total.TotalTokens
 		merged.Total.TotalCost += s.Total.TotalCost
 		merged.Total.TotalMessages += s.Total.TotalMessages
+		merged.Total.LoopInterventions += s.Total.LoopInterventions
 
 		// Aggregate daily usage.
 		for _, d := range s.UsageByDay {
@@ -534,6 +555,35 @@ func mergeStats(projectStats []ProjectStats) *Stats {
 	return merged
 }
 
+// sortStats orders the model and tool usage of stats by call count or by
+// name. Ties are broken by name so the order is stable. With
+// groupByProvider, models of the same provider are kept together, and
+// providers are ordered the same way as the models within them.
+func sortStats(stats *Stats, by string, groupByProvider bool) {
+	providerCounts := make(map[string]int64)
+	for _, m := range stats.UsageByModel {
+		providerCounts[m.Provider] += m.MessageCount
+	}
+	compare := func(countA, countB int64, nameA, nameB string) int {
+		if by == statsSortCount && countA != countB {
+			return cmp.Compare(countB, countA)
+		}
+		return strings.Compare(nameA, nameB)
+	}
+	slices.SortFunc(stats.UsageByModel, func(a, b ModelUsage) int {
+		if groupByProvider && a.Provider != b.Provider {
+			return compare(providerCounts[a.Provider], providerCounts[b.Provider], a.Provider, b.Provider)
+		}
+		return cmp.Or(
+			compare(a.MessageCount, b.MessageCount, a.Model, b.Model),
+			strings.Compare(a.Provider, b.Provider),
+		)
+	})
+	slices.SortFunc(stats.ToolUsage, func(a, b ToolUsage) int {
+		return compare(a.CallCount, b.CallCount, a.ToolName, b.ToolName)
+	})
+}
+
 func gatherStats(ctx context.Context, conn *sql.DB) (*Stats, error) {
 	queries := db.New(conn)
 
@@ -557,6 +607,12 @@ func gatherStats(ctx context.Context, conn *sql.DB) (*Stats, error) {
 		AvgMessagesPerSession: toFloat64(total.AvgMessagesPerSession),
 	}
 
+	loops, err := queries.GetLoopInterventions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get loop interventions: %w", err)
+	}
+	stats.Total.LoopInterventions = loops
+
 	// Usage by day.
 	dailyUsage, err := queries.GetUsageByDay(ctx)
 	if err != nil {
@@ -94,6 +94,9 @@ document.getElementById("avg-tokens").innerHTML =
   formatCompact(stats.total.avg_tokens_per_session);
 document.getElementById("avg-response").innerHTML =
   '<span title="Average">x̅</span> ' + formatTime(stats.avg_response_time_ms);
+document.getElementById("loop-interventions").textContent = formatNumber(
+  stats.total.loop_interventions ?? 0,
+);
 
 // Chart defaults
 Chart.defaults.color = colors.squid;
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortStats(t *testing.T) {
+	t.Parallel()
+
+	newStats := func() *Stats {
+		return &Stats{
+			UsageByModel: []ModelUsage{
+				{Model: "gpt-5", Provider: "openai", MessageCount: 5},
+				{Model: "sonnet", Provider: "anthropic", MessageCount: 8},
+				{Model: "gpt-5-mini", Provider: "openai", MessageCount: 7},
+				{Model: "haiku", Provider: "anthropic", MessageCount: 1},
+			},
+			ToolUsage: []ToolUsage{
+				{ToolName: "view", CallCount: 3},
+				{ToolName: "bash", CallCount: 9},
+				{ToolName: "edit", CallCount: 3},
+			},
+		}
+	}
+	models := func(s *Stats) []string {
+		var names []string
+		for _, m := range s.UsageByModel {
+			names = append(names, m.Model)
+		}
+		return names
+	}
+	tools := func(s *Stats) []string {
+		var names []string
+		for _, tool := range s.ToolUsage {
+			names = append(names, tool.ToolName)
+		}
+		return names
+	}
+
+	t.Run("by count", func(t *testing.T) {
+		t.Parallel()
+		s := newStats()
+		sortStats(s, statsSortCount, false)
+		require.Equal(t, []string{"sonnet", "gpt-5-mini", "gpt-5", "haiku"}, models(s))
+		require.Equal(t, []string{"bash", "edit", "view"}, tools(s))
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		t.Parallel()
+		s := newStats()
+		sortStats(s, statsSortName, false)
+		require.Equal(t, []string{"gpt-5", "gpt-5-mini", "haiku", "sonnet"}, models(s))
+		require.Equal(t, []string{"bash", "edit", "view"}, tools(s))
+	})
+
+	t.Run("grouped by provider", func(t *testing.T) {
+		t.Parallel()
+		s := newStats()
+		sortStats(s, statsSortCount, true)
+		// openai has 12 messages and anthropic 9.
+		require.Equal(t, []string{"gpt-5-mini", "gpt-5", "sonnet", "haiku"}, models(s))
+
+		sortStats(s, statsSortName, true)
+		require.Equal(t, []string{"haiku", "sonnet", "gpt-5", "gpt-5-mini"}, models(s))
+	})
+}
+
+func TestGenerateHTMLLoadsChartJSFromCDN(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "index.html")
+	require.NoError(t, generateHTML(&Stats{}, nil, "project", "user", path))
+	page, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(page), `<script src="https://cdn.jsdelivr.net/npm/chart.js"></script>`)
+}
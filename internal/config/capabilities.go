@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/catwalk/pkg/catwalk"
+)
+
+// ModelCapabilities describes which request settings a model accepts.
+type ModelCapabilities struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Reasoning reports whether the model can reason at all.
+	Reasoning bool `json:"reasoning"`
+	// Thinking reports whether reasoning is switched on and off with
+	// think rather than set through an effort level.
+	Thinking bool `json:"thinking"`
+	Images   bool `json:"images"`
+	// EffortLevels lists the values reasoning_effort accepts, in the
+	// order the provider reports them.
+	EffortLevels  []string `json:"effort_levels,omitempty"`
+	DefaultEffort string   `json:"default_effort,omitempty"`
+}
+
+// CapabilitiesOf returns the capabilities of model m of the given provider.
+func CapabilitiesOf(providerID string, m catwalk.Model) ModelCapabilities {
+	return ModelCapabilities{
+		Provider:      providerID,
+		Model:         m.ID,
+		Reasoning:     m.CanReason,
+		Thinking:      m.CanReason && len(m.ReasoningLevels) == 0,
+		Images:        m.SupportsImages,
+		EffortLevels:  slices.Clone(m.ReasoningLevels),
+		DefaultEffort: m.DefaultReasoningEffort,
+	}
+}
+
+// ModelCapabilities returns the capabilities of a configured model. They
+// come from the catwalk metadata of the model with the provider's model
+// entries in the config applied on top.
+func (c *Config) ModelCapabilities(providerID, modelID string) (ModelCapabilities, bool) {
+	m := c.GetModel(providerID, modelID)
+	if m == nil {
+		return ModelCapabilities{}, false
+	}
+	return CapabilitiesOf(providerID, *m), true
+}
+
+// CheckSelection reports settings of sel the model does not support and
+// would be left out of its requests.
+func (mc ModelCapabilities) CheckSelection(sel SelectedModel) error {
+	name := mc.Provider + "/" + mc.Model
+	if effort := sel.ReasoningEffort; effort != "" && !slices.Contains(mc.EffortLevels, effort) {
+		if len(mc.EffortLevels) == 0 {
+			return fmt.Errorf("model %s does not take a reasoning effort, ignoring %q", name, effort)
+		}
+		return fmt.Errorf("model %s does not support reasoning effort %q, valid levels are %s", name, effort, strings.Join(mc.EffortLevels, ", "))
+	}
+	if sel.Think && !mc.Reasoning {
+		return fmt.Errorf("model %s does not support thinking, ignoring think", name)
+	}
+	return nil
+}
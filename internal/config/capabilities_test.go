@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelCapabilities(t *testing.T) {
+	t.Parallel()
+
+	effort := CapabilitiesOf("openai", catwalk.Model{
+		ID:                     "gpt-5",
+		CanReason:              true,
+		ReasoningLevels:        []string{"low", "medium", "high"},
+		DefaultReasoningEffort: "medium",
+		SupportsImages:         true,
+	})
+	require.Equal(t, ModelCapabilities{
+		Provider:      "openai",
+		Model:         "gpt-5",
+		Reasoning:     true,
+		Images:        true,
+		EffortLevels:  []string{"low", "medium", "high"},
+		DefaultEffort: "medium",
+	}, effort)
+	require.NoError(t, effort.CheckSelection(SelectedModel{ReasoningEffort: "high"}))
+	require.EqualError(t, effort.CheckSelection(SelectedModel{ReasoningEffort: "max"}),
+		`model openai/gpt-5 does not support reasoning effort "max", valid levels are low, medium, high`)
+
+	thinking := CapabilitiesOf("anthropic", catwalk.Model{ID: "claude", CanReason: true})
+	require.True(t, thinking.Thinking)
+	require.NoError(t, thinking.CheckSelection(SelectedModel{Think: true}))
+	require.EqualError(t, thinking.CheckSelection(SelectedModel{ReasoningEffort: "low"}),
+		`model anthropic/claude does not take a reasoning effort, ignoring "low"`)
+
+	plain := CapabilitiesOf("openai", catwalk.Model{ID: "gpt-4o-mini"})
+	require.EqualError(t, plain.CheckSelection(SelectedModel{Think: true}),
+		"model openai/gpt-4o-mini does not support thinking, ignoring think")
+
+	cfg := &Config{Providers: csync.NewMapFrom(map[string]ProviderConfig{
+		"custom": {ID: "custom", Models: []catwalk.Model{{ID: "m", CanReason: true, ReasoningLevels: []string{"low"}}}},
+	})}
+	mc, ok := cfg.ModelCapabilities("custom", "m")
+	require.True(t, ok)
+	require.Equal(t, []string{"low"}, mc.EffortLevels)
+	_, ok = cfg.ModelCapabilities("custom", "missing")
+	require.False(t, ok)
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/home"
+)
+
+// installHints maps well-known server binaries (and the launchers MCP
+// servers are commonly started with) to a short install suggestion.
+var installHints = map[string]string{
+	"gopls":                           "go install golang.org/x/tools/gopls@latest",
+	"typescript-language-server":      "npm install -g typescript-language-server typescript",
+	"vscode-json-language-server":     "npm install -g vscode-langservers-extracted",
+	"vscode-css-language-server":      "npm install -g vscode-langservers-extracted",
+	"vscode-html-language-server":     "npm install -g vscode-langservers-extracted",
+	"pyright-langserver":              "npm install -g pyright",
+	"basedpyright-langserver":         "pip install basedpyright",
+	"pylsp":                           "pip install python-lsp-server",
+	"ruff":                            "pip install ruff",
+	"rust-analyzer":                   "rustup component add rust-analyzer",
+	"clangd":                          "install clangd from your system package manager or LLVM",
+	"lua-language-server":             "see https://luals.github.io/#install",
+	"bash-language-server":            "npm install -g bash-language-server",
+	"yaml-language-server":            "npm install -g yaml-language-server",
+	"zls":                             "see https://github.com/zigtools/zls#installation",
+	"intelephense":                    "npm install -g intelephense",
+	"svelteserver":                    "npm install -g svelte-language-server",
+	"vue-language-server":             "npm install -g @vue/language-server",
+	"elixir-ls":                       "see https://github.com/elixir-lsp/elixir-ls#installation",
+	"terraform-ls":                    "see https://github.com/hashicorp/terraform-ls#installation",
+	"dockerfile-language-server":      "npm install -g dockerfile-language-server-nodejs",
+	"docker-langserver":               "npm install -g dockerfile-language-server-nodejs",
+	"tailwindcss-language-server":     "npm install -g @tailwindcss/language-server",
+	"graphql-lsp":                     "npm install -g graphql-language-service-cli",
+	"npx":                             "install Node.js from https://nodejs.org",
+	"node":                            "install Node.js from https://nodejs.org",
+	"bunx":                            "install Bun from https://bun.sh",
+	"uvx":                             "install uv from https://docs.astral.sh/uv",
+	"uv":                              "install uv from https://docs.astral.sh/uv",
+	"docker":                          "install Docker from https://docs.docker.com/get-docker",
+	"deno":                            "install Deno from https://deno.com",
+	"python":                          "install Python from https://www.python.org",
+	"python3":                         "install Python from https://www.python.org",
+	"kotlin-language-server":          "see https://github.com/fwcd/kotlin-language-server#installation",
+	"jdtls":                           "see https://github.com/eclipse-jdtls/eclipse.jdt.ls#installation",
+	"omnisharp":                       "see https://github.com/OmniSharp/omnisharp-roslyn#downloading-omnisharp",
+	"solargraph":                      "gem install solargraph",
+	"ruby-lsp":                        "gem install ruby-lsp",
+	"sourcekit-lsp":                   "install the Swift toolchain from https://swift.org",
+	"haskell-language-server-wrapper": "ghcup install hls",
+}
+
+// MissingCommand describes an enabled LSP or MCP server whose command
+// cannot be found.
+type MissingCommand struct {
+	// Kind is either "lsp" or "mcp".
+	Kind    string
+	Name    string
+	Command string
+	// Hint is a suggested install step, empty when the command is not
+	// a well-known one.
+	Hint string
+}
+
+func (m MissingCommand) String() string {
+	msg := fmt.Sprintf("%s %q: command %q not found in PATH", m.Kind, m.Name, m.Command)
+	if m.Hint != "" {
+		msg += " (to install: " + m.Hint + ")"
+	}
+	return msg
+}
+
+// MissingCommands reports every enabled LSP and stdio MCP server whose
+// command, after variable expansion, cannot be resolved with
+// exec.LookPath. Commands that fail to expand are skipped: ValidateMCPs
+// already reports those as errors. Results are sorted by kind and then
+// by name.
+func (c *Config) MissingCommands(resolver VariableResolver) []MissingCommand {
+	var missing []MissingCommand
+	check := func(kind, name, command string) {
+		resolved, err := resolver.ResolveValue(command)
+		if err != nil || strings.TrimSpace(resolved) == "" {
+			return
+		}
+		resolved = home.Long(resolved)
+		if _, err := exec.LookPath(resolved); err == nil {
+			return
+		}
+		missing = append(missing, MissingCommand{
+			Kind:    kind,
+			Name:    name,
+			Command: resolved,
+			Hint:    installHints[strings.TrimSuffix(filepath.Base(resolved), ".exe")],
+		})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.LSP)) {
+		l := c.LSP[name]
+		if l.Disabled || l.Command == "" {
+			continue
+		}
+		check("lsp", name, l.Command)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.MCP)) {
+		m := c.MCP[name]
+		if m.Disabled || m.Type != MCPStdio {
+			continue
+		}
+		check("mcp", name, m.Command)
+	}
+	return missing
+}
+
+// warnMissingCommands logs a warning for every configured server whose
+// command is not installed. A missing binary only affects that server,
+// so it does not fail loading the config.
+func (c *Config) warnMissingCommands(resolver VariableResolver) {
+	for _, m := range c.MissingCommands(resolver) {
+		slog.Warn("Configured server command not found", "kind", m.Kind, "name", m.Name, "command", m.Command, "hint", m.Hint)
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_MissingCommands(t *testing.T) {
+	t.Parallel()
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	missingDir := t.TempDir()
+
+	r := NewShellVariableResolver(env.NewFromMap(map[string]string{
+		"SELF":        self,
+		"MISSING_DIR": missingDir,
+	}))
+
+	cfg := &Config{
+		LSP: LSPs{
+			"go":        {Command: filepath.Join(missingDir, "gopls")},
+			"installed": {Command: "$SELF"},
+			"off":       {Command: filepath.Join(missingDir, "gopls"), Disabled: true},
+			"custom":    {Command: "${MISSING_DIR}/my-lsp"},
+		},
+		MCP: map[string]MCPConfig{
+			"fs":        {Type: MCPStdio, Command: filepath.Join(missingDir, "npx")},
+			"unset":     {Type: MCPStdio, Command: "${NOPE:?set NOPE}"},
+			"remote":    {Type: MCPHttp, URL: "https://mcp.example.com"},
+			"installed": {Type: MCPStdio, Command: self},
+		},
+	}
+
+	missing := cfg.MissingCommands(r)
+	require.Equal(t, []MissingCommand{
+		{Kind: "lsp", Name: "custom", Command: filepath.Join(missingDir, "my-lsp")},
+		{Kind: "lsp", Name: "go", Command: filepath.Join(missingDir, "gopls"), Hint: installHints["gopls"]},
+		{Kind: "mcp", Name: "fs", Command: filepath.Join(missingDir, "npx"), Hint: installHints["npx"]},
+	}, missing)
+
+	require.Contains(t, missing[1].String(), `lsp "go": command`)
+	require.Contains(t, missing[1].String(), "go install golang.org/x/tools/gopls@latest")
+	require.NotContains(t, missing[0].String(), "to install")
+}
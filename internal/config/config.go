@@ -8,15 +8,20 @@ import (
 	"maps"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"charm.land/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
+	"github.com/charmbracelet/crush/internal/secrets"
 	"github.com/invopop/jsonschema"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
 )
 
 const (
@@ -82,6 +87,7 @@ type SelectedModel struct {
 	TopK             *int64   `json:"top_k,omitempty" jsonschema:"description=Top-k sampling parameter"`
 	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty" jsonschema:"description=Frequency penalty to reduce repetition"`
 	PresencePenalty  *float64 `json:"presence_penalty,omitempty" jsonschema:"description=Presence penalty to increase topic diversity"`
+	StopSequences    []string `json:"stop_sequences,omitempty" jsonschema:"description=Sequences that make the model stop generating. Supported by OpenAI-compatible providers and by OpenAI models not served through the Responses API,example=END"`
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
@@ -140,8 +146,16 @@ type ProviderConfig struct {
 	// only explicitly listed models are used.
 	AutoDiscoverModels *bool `json:"discover_models,omitempty" jsonschema:"description=Auto-discover models from /v1/models endpoint. When true with existing models they are merged (yours win),default=true"`
 
+	// CircuitBreaker stops requests to the provider after repeated
+	// failures instead of retrying every one of them.
+	CircuitBreaker *CircuitBreaker `json:"circuit_breaker,omitempty" jsonschema:"description=Fail fast after repeated provider failures"`
+
 	// The provider models
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
+
+	// ContextWindows overrides the context window of models by ID, taking
+	// precedence over the catwalk and discovered metadata.
+	ContextWindows map[string]int64 `json:"context_windows,omitempty" jsonschema:"description=Context window in tokens to use for a model ID in place of the one reported by the provider metadata\\, e.g. for a local model served with a larger window"`
 }
 
 // ToProvider converts the [ProviderConfig] to a [catwalk.Provider].
@@ -187,9 +201,9 @@ const (
 )
 
 type MCPConfig struct {
-	Command       string            `json:"command,omitempty" jsonschema:"description=Command to execute for stdio MCP servers,example=npx"`
+	Command       string            `json:"command,omitempty" jsonschema:"description=Command to execute for stdio MCP servers; $VAR and ${VAR} are expanded,example=npx"`
 	Env           map[string]string `json:"env,omitempty" jsonschema:"description=Environment variables to set for the MCP server"`
-	Args          []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the MCP server command"`
+	Args          []string          `json:"args,omitempty" jsonschema:"description=Arguments to pass to the MCP server command; $VAR and ${VAR} are expanded in each argument"`
 	Type          MCPType           `json:"type" jsonschema:"required,description=Type of MCP connection,enum=stdio,enum=sse,enum=http,default=stdio"`
 	URL           string            `json:"url,omitempty" jsonschema:"description=URL for HTTP or SSE MCP servers,format=uri,example=http://localhost:3000/mcp"`
 	Disabled      bool              `json:"disabled,omitempty" jsonschema:"description=Whether this MCP server is disabled,default=false"`
@@ -255,6 +269,53 @@ type TUIOptions struct {
 	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
 	Transparent *bool       `json:"transparent,omitempty" jsonschema:"description=Enable transparent background for the TUI interface,default=false"`
 	Scrollbar   string      `json:"scrollbar,omitempty" jsonschema:"description=Chat scrollbar visibility,enum=default,enum=always,enum=never,default=default"`
+	// MaxNestedTools is how many tool calls of a sub-agent are shown
+	// under its agent tool call before older ones are collapsed.
+	MaxNestedTools *int `json:"max_nested_tools,omitempty" jsonschema:"description=How many tool calls of a sub-agent are shown under its agent tool call. Older calls are collapsed into a summary line until the tool call is expanded. 0 shows all,default=10,minimum=0,example=5"`
+	// UsageWarning sets when the header warns that the session is
+	// getting long or expensive.
+	UsageWarning *UsageWarning `json:"usage_warning,omitempty" jsonschema:"description=When the header warns that the session nears the context window or a cost limit"`
+}
+
+// UsageWarning sets the limits at which the header colors the context
+// usage and the cost of the session.
+type UsageWarning struct {
+	ContextPercent *int    `json:"context_percent,omitempty" jsonschema:"description=Percentage of the context window at which the usage in the header turns yellow. It turns red halfway between this and the full window. 0 disables the warning,default=70,minimum=0,maximum=100"`
+	Cost           float64 `json:"cost,omitempty" jsonschema:"description=Session cost in USD that is shown in the header. It turns yellow at 80% of this and red when it is reached. 0 hides the cost,minimum=0,example=5"`
+}
+
+// DefaultContextWarningPercent is the default of
+// [UsageWarning.ContextPercent].
+const DefaultContextWarningPercent = 70
+
+// ContextWarningPercent returns the percentage of the context window at
+// which the usage is shown as a warning; 0 means never.
+func (o *TUIOptions) ContextWarningPercent() int {
+	if o == nil || o.UsageWarning == nil {
+		return DefaultContextWarningPercent
+	}
+	return min(max(ptrValOr(o.UsageWarning.ContextPercent, DefaultContextWarningPercent), 0), 100)
+}
+
+// CostWarning returns the session cost in USD at which the cost is shown
+// as critical; 0 means the cost is not shown.
+func (o *TUIOptions) CostWarning() float64 {
+	if o == nil || o.UsageWarning == nil {
+		return 0
+	}
+	return max(o.UsageWarning.Cost, 0)
+}
+
+// DefaultMaxNestedTools is the default of [TUIOptions.MaxNestedTools].
+const DefaultMaxNestedTools = 10
+
+// NestedToolsLimit returns how many nested tool calls an agent tool call
+// shows when collapsed; 0 means all of them.
+func (o *TUIOptions) NestedToolsLimit() int {
+	if o == nil {
+		return DefaultMaxNestedTools
+	}
+	return max(ptrValOr(o.MaxNestedTools, DefaultMaxNestedTools), 0)
 }
 
 // Completions defines options for the completions UI.
@@ -313,18 +374,551 @@ type Options struct {
 	// the SQLite database and workspace overrides. Relative paths are
 	// resolved against the working directory; absolute paths are used
 	// verbatim. After defaulting the stored value is always absolute.
-	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data. Relative paths are resolved against the working directory; absolute paths are used as-is.,default=.crush,example=.crush"`
-	DisabledTools             []string     `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
-	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
-	DisableDefaultProviders   bool         `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled\\, providers must be fully specified in the config file with base_url\\, models\\, and api_key - no merging with defaults occurs,default=false"`
-	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
-	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
-	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
-	AutoLSP                   *bool        `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
-	Progress                  *bool        `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
-	DisableNotifications      bool         `json:"disable_notifications,omitempty" jsonschema:"description=Deprecated: Use notification_style instead. Disable desktop notifications,default=false"`
-	NotificationStyle         string       `json:"notification_style,omitempty" jsonschema:"description=Notification style to use. Options: auto (default), native, osc, bell, disabled. Auto selects based on environment: native for local sessions, osc for SSH (with automatic OSC 99/777 detection).,enum=auto,enum=native,enum=osc,enum=bell,enum=disabled,default=auto"`
-	DisabledSkills            []string     `json:"disabled_skills,omitempty" jsonschema:"description=List of skill names to disable and hide from the agent,example=crush-config"`
+	DataDirectory             string                 `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data. Relative paths are resolved against the working directory; absolute paths are used as-is.,default=.crush,example=.crush"`
+	DataDirectoryMode         DataDirectoryMode      `json:"data_directory_mode,omitempty" jsonschema:"description=Where application data is stored when data_directory is not set: project keeps a .crush directory in each project\\, global shares one directory in the user data directory across projects,enum=project,enum=global,default=project"`
+	DisabledTools             []string               `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
+	DisableProviderAutoUpdate bool                   `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
+	DisableDefaultProviders   bool                   `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled\\, providers must be fully specified in the config file with base_url\\, models\\, and api_key - no merging with defaults occurs,default=false"`
+	Attribution               *Attribution           `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
+	DisableMetrics            bool                   `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
+	InitializeAs              string                 `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	AutoLSP                   *bool                  `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers,default=true"`
+	Progress                  *bool                  `json:"progress,omitempty" jsonschema:"description=Show indeterminate progress updates during long operations,default=true"`
+	DisableNotifications      bool                   `json:"disable_notifications,omitempty" jsonschema:"description=Deprecated: Use notification_style instead. Disable desktop notifications,default=false"`
+	NotificationStyle         string                 `json:"notification_style,omitempty" jsonschema:"description=Notification style to use. Options: auto (default), native, osc, bell, disabled. Auto selects based on environment: native for local sessions, osc for SSH (with automatic OSC 99/777 detection).,enum=auto,enum=native,enum=osc,enum=bell,enum=disabled,default=auto"`
+	DisabledSkills            []string               `json:"disabled_skills,omitempty" jsonschema:"description=List of skill names to disable and hide from the agent,example=crush-config"`
+	Retention                 *Retention             `json:"retention,omitempty" jsonschema:"description=Limits used by crush prune to remove old sessions"`
+	ResponseCache             *ResponseCache         `json:"response_cache,omitempty" jsonschema:"description=On-disk cache of model responses for repeated identical requests"`
+	MaxAgentDepth             int                    `json:"max_agent_depth,omitempty" jsonschema:"description=Maximum nesting depth of sub-agents started by the agent tools,default=2,minimum=1,example=3"`
+	AuxiliaryRetries          *int                   `json:"auxiliary_retries,omitempty" jsonschema:"description=How many times title generation and summarization retry a failed model request,default=1,minimum=0,maximum=5"`
+	MCPStartupConcurrency     int                    `json:"mcp_startup_concurrency,omitempty" jsonschema:"description=Maximum number of MCP servers started in parallel,default=8,minimum=1,example=4"`
+	ContextTrimRatio          float64                `json:"context_trim_ratio,omitempty" jsonschema:"description=Drop the oldest turns from a request whose estimated size exceeds this fraction of the model's context window. 0 disables trimming,minimum=0,maximum=1,example=0.9"`
+	StoreThinking             *bool                  `json:"store_thinking,omitempty" jsonschema:"description=Save the model's reasoning with the session. When false reasoning is still shown while it streams but is left out of the stored transcript,default=true"`
+	RequestUser               string                 `json:"request_user,omitempty" jsonschema:"description=End-user identifier sent as the user field of requests to OpenAI\\, OpenRouter and OpenAI-compatible providers. Used for abuse monitoring and spend attribution,maxLength=256,example=jane@example.com"`
+	AllowOutsideWorkdir       bool                   `json:"allow_outside_workdir,omitempty" jsonschema:"description=Let the edit\\, multiedit\\, codemod\\, env_edit\\, lsp_replace_symbol\\, write and download tools change files outside the working directory. Such writes are rejected by default,default=false"`
+	ResponseStyle             ResponseStyle          `json:"response_style,omitempty" jsonschema:"description=How verbose the agent's answers should be,enum=concise,enum=normal,enum=detailed,default=normal"`
+	Context                   *ContextOptions        `json:"context,omitempty" jsonschema:"description=How context files are added to the system prompt"`
+	MaxIdenticalToolCalls     int                    `json:"max_identical_tool_calls,omitempty" jsonschema:"description=How many identical tool calls (same tool and input) the agent may make in a row before further repeats are answered with a note instead of running again,default=3,minimum=1,example=5"`
+	ModelQuirks               map[string]ModelQuirks `json:"model_quirks,omitempty" jsonschema:"description=Request adjustments for models that reject standard parameters\\, keyed by model ID or a pattern such as o3*. Entries replace the built-in defaults for matching models"`
+	Title                     *TitleOptions          `json:"title,omitempty" jsonschema:"description=When session titles are generated"`
+	SmallModelConcurrency     int                    `json:"small_model_concurrency,omitempty" jsonschema:"description=Maximum number of small-model requests\\, such as title generation\\, in flight at once across all sessions,default=2,minimum=1,example=1"`
+	Security                  *SecurityOptions       `json:"security,omitempty" jsonschema:"description=Protection against leaking credentials through tool output"`
+	Summarize                 *SummarizeOptions      `json:"summarize,omitempty" jsonschema:"description=How conversations are summarized"`
+	EmptyResponse             EmptyResponsePolicy    `json:"empty_response,omitempty" jsonschema:"description=What to do when the model answers with no text and no tool calls: finish ends the turn with an error\\, retry asks the model once more before doing so,enum=finish,enum=retry,default=finish"`
+	ContextFallbackModel      string                 `json:"context_fallback_model,omitempty" jsonschema:"description=A provider/model pair with a larger context window. When the provider rejects a turn as too long for the large model the turn is retried with this model. Without one the session is summarized and the turn continues,example=google/gemini-2.5-pro"`
+	TodosInContext            bool                   `json:"todos_in_context,omitempty" jsonschema:"description=Remind the model of its todo list before every request while some items are still open,default=false"`
+	MaxConcurrentRequests     int                    `json:"max_concurrent_requests,omitempty" jsonschema:"description=Maximum number of turns generating at once across all sessions of the process. 0 means no limit,minimum=0,example=4"`
+	ConcurrencyLimitMode      ConcurrencyLimitMode   `json:"concurrency_limit_mode,omitempty" jsonschema:"description=What a turn does when max_concurrent_requests turns are already generating: queue waits for one to finish\\, reject fails the turn right away,enum=queue,enum=reject,default=queue"`
+	ToolResultCache           *bool                  `json:"tool_result_cache,omitempty" jsonschema:"description=Let identical view\\, ls\\, grep and glob calls within one turn reuse the first result until another tool runs,default=true"`
+	Language                  string                 `json:"language,omitempty" jsonschema:"description=Language the agent answers in and writes session titles and summaries in\\, as a locale code,default=en,example=de,example=pt-BR,example=ja"`
+	MaxHistoryMessages        int                    `json:"max_history_messages,omitempty" jsonschema:"description=Send at most this many of a session's most recent messages with each request\\, dropping the oldest turns first. The session keeps every message. 0 sends them all,minimum=0,example=200"`
+	Storage                   *StorageOptions        `json:"storage,omitempty" jsonschema:"description=Limits on what is saved to the session database"`
+	StrictToolParams          bool                   `json:"strict_tool_params,omitempty" jsonschema:"description=Answer built-in tool calls that pass unknown parameters\\, leave out required ones or give a value of the wrong type with the problems instead of running them,default=false"`
+}
+
+// ConcurrencyLimitMode is what a turn does when
+// options.max_concurrent_requests turns are already generating.
+type ConcurrencyLimitMode string
+
+const (
+	// ConcurrencyLimitQueue waits until another turn finishes.
+	ConcurrencyLimitQueue ConcurrencyLimitMode = "queue"
+	// ConcurrencyLimitReject fails the turn right away.
+	ConcurrencyLimitReject ConcurrencyLimitMode = "reject"
+)
+
+// GetConcurrencyLimitMode returns the configured concurrency limit mode.
+// Unset or unknown values fall back to [ConcurrencyLimitQueue].
+func (o *Options) GetConcurrencyLimitMode() ConcurrencyLimitMode {
+	if o == nil || o.ConcurrencyLimitMode != ConcurrencyLimitReject {
+		return ConcurrencyLimitQueue
+	}
+	return ConcurrencyLimitReject
+}
+
+// DataDirectoryMode picks the data directory when none is configured.
+type DataDirectoryMode string
+
+const (
+	// DataDirectoryProject keeps a .crush directory in each project.
+	DataDirectoryProject DataDirectoryMode = "project"
+	// DataDirectoryGlobal shares [GlobalDataDirectory] across projects,
+	// so the sessions of all projects are kept together.
+	DataDirectoryGlobal DataDirectoryMode = "global"
+)
+
+// EmptyResponsePolicy is what the agent does when the model answers with no
+// text and no tool calls.
+type EmptyResponsePolicy string
+
+const (
+	EmptyResponseFinish EmptyResponsePolicy = "finish"
+	EmptyResponseRetry  EmptyResponsePolicy = "retry"
+)
+
+// GetEmptyResponse returns the configured empty response policy. Unset or
+// unknown values fall back to [EmptyResponseFinish].
+func (o *Options) GetEmptyResponse() EmptyResponsePolicy {
+	if o == nil || o.EmptyResponse != EmptyResponseRetry {
+		return EmptyResponseFinish
+	}
+	return EmptyResponseRetry
+}
+
+// SummarizeOptions configures the model and length of conversation
+// summaries.
+type SummarizeOptions struct {
+	Model     string `json:"model,omitempty" jsonschema:"description=The model that writes the summary: large\\, small\\, or a provider/model pair for a model of its own,default=large,example=small,example=anthropic/claude-sonnet-4-5"`
+	MaxTokens int64  `json:"max_tokens,omitempty" jsonschema:"description=Maximum number of tokens the summary may use. The summarize prompt also asks the model to stay within it. Unset means no limit,minimum=1,example=4000"`
+}
+
+// Validate reports an error if the model is neither a selected model type
+// nor a provider/model pair. Whether the pair names a configured model is
+// checked when the agent is built.
+func (s *SummarizeOptions) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch SelectedModelType(s.Model) {
+	case "", SelectedModelTypeLarge, SelectedModelTypeSmall:
+		return nil
+	}
+	if provider, model, ok := strings.Cut(s.Model, "/"); !ok || provider == "" || model == "" {
+		return fmt.Errorf("summarize model %q must be large, small or provider/model", s.Model)
+	}
+	return nil
+}
+
+// GetSummarizeModel returns the model type that writes conversation
+// summaries, and the model itself when options.summarize.model is a
+// provider/model pair. Unset or unknown values fall back to
+// [SelectedModelTypeLarge].
+func (o *Options) GetSummarizeModel() (SelectedModelType, *SelectedModel) {
+	if o == nil || o.Summarize == nil {
+		return SelectedModelTypeLarge, nil
+	}
+	switch model := SelectedModelType(o.Summarize.Model); model {
+	case SelectedModelTypeLarge, SelectedModelTypeSmall:
+		return model, nil
+	}
+	provider, model, ok := strings.Cut(o.Summarize.Model, "/")
+	if !ok || provider == "" || model == "" {
+		return SelectedModelTypeLarge, nil
+	}
+	return "", &SelectedModel{Provider: provider, Model: model}
+}
+
+// GetSummarizeMaxTokens returns the maximum number of tokens a summary may
+// use, or 0 for no limit.
+func (o *Options) GetSummarizeMaxTokens() int64 {
+	if o == nil || o.Summarize == nil || o.Summarize.MaxTokens <= 0 {
+		return 0
+	}
+	return o.Summarize.MaxTokens
+}
+
+// GetContextFallbackModel returns the model set in
+// options.context_fallback_model, or nil if none is.
+func (o *Options) GetContextFallbackModel() *SelectedModel {
+	if o == nil {
+		return nil
+	}
+	provider, model, ok := strings.Cut(o.ContextFallbackModel, "/")
+	if !ok || provider == "" || model == "" {
+		return nil
+	}
+	return &SelectedModel{Provider: provider, Model: model}
+}
+
+// SecurityOptions configures how tool output is checked for credentials.
+type SecurityOptions struct {
+	MaskSecrets    bool     `json:"mask_secrets,omitempty" jsonschema:"description=Mask what looks like API keys\\, tokens and private keys in tool output before it is stored or sent to the provider,default=false"`
+	SecretPatterns []string `json:"secret_patterns,omitempty" jsonschema:"description=Extra regular expressions for secrets to mask. If a pattern has a capturing group only the group is masked,example=internal-[a-f0-9]{32}"`
+}
+
+// Validate reports an error if one of the secret patterns is not a valid
+// regular expression.
+func (s *SecurityOptions) Validate() error {
+	if s == nil {
+		return nil
+	}
+	_, err := secrets.New(s.SecretPatterns)
+	return err
+}
+
+// TitleOptions configures when and how session titles are generated.
+type TitleOptions struct {
+	Trigger     TitleTrigger `json:"trigger,omitempty" jsonschema:"description=When the title request is sent: start sends it alongside the main request\\, first_chunk waits until the model starts answering and skips it if the request fails before that,enum=start,enum=first_chunk,default=start"`
+	MinMessages int          `json:"min_messages,omitempty" jsonschema:"description=Number of user messages a session needs before it is titled. The title is generated from all of them,default=1,minimum=1,example=2"`
+	Prompt      string       `json:"prompt,omitempty" jsonschema:"description=Instructions for the title generator in place of the built-in ones\\, e.g. to set a language or style. max_words and max_length still apply to the result,example=Write a title of three to five words in German."`
+	MaxWords    int          `json:"max_words,omitempty" jsonschema:"description=Maximum number of words in a title. Unset means no word limit,minimum=0,example=6"`
+	MaxLength   int          `json:"max_length,omitempty" jsonschema:"description=Maximum title length in characters. Longer titles are cut to fit the session list,default=50,minimum=10,maximum=100,example=40"`
+}
+
+const (
+	// DefaultTitleMaxLength is the title length limit when none is set.
+	DefaultTitleMaxLength = 50
+	// MinTitleMaxLength and MaxTitleMaxLength bound title.max_length so
+	// titles stay readable and fit the session list.
+	MinTitleMaxLength = 10
+	MaxTitleMaxLength = 100
+)
+
+// Validate reports an error if the word or length limit is out of range.
+func (t *TitleOptions) Validate() error {
+	if t == nil {
+		return nil
+	}
+	if t.MaxWords < 0 {
+		return fmt.Errorf("title max_words must not be negative, got %d", t.MaxWords)
+	}
+	if t.MaxLength != 0 && (t.MaxLength < MinTitleMaxLength || t.MaxLength > MaxTitleMaxLength) {
+		return fmt.Errorf("title max_length must be between %d and %d, got %d", MinTitleMaxLength, MaxTitleMaxLength, t.MaxLength)
+	}
+	return nil
+}
+
+// TitleTrigger is when the title request of a session is sent relative to
+// its main request.
+type TitleTrigger string
+
+const (
+	TitleTriggerStart      TitleTrigger = "start"
+	TitleTriggerFirstChunk TitleTrigger = "first_chunk"
+)
+
+// GetTitleTrigger returns the configured title trigger. Unset or unknown
+// values fall back to [TitleTriggerStart].
+func (o *Options) GetTitleTrigger() TitleTrigger {
+	if o == nil || o.Title == nil || o.Title.Trigger != TitleTriggerFirstChunk {
+		return TitleTriggerStart
+	}
+	return TitleTriggerFirstChunk
+}
+
+// GetTitleMinMessages returns the number of user messages a session needs
+// before it is titled, at least 1.
+func (o *Options) GetTitleMinMessages() int {
+	if o == nil || o.Title == nil || o.Title.MinMessages <= 0 {
+		return 1
+	}
+	return o.Title.MinMessages
+}
+
+// GetTitlePrompt returns the custom instructions for the title generator,
+// or "" to use the built-in ones.
+func (o *Options) GetTitlePrompt() string {
+	if o == nil || o.Title == nil {
+		return ""
+	}
+	return strings.TrimSpace(o.Title.Prompt)
+}
+
+// GetTitleMaxWords returns the maximum number of words in a title, or 0
+// for no limit.
+func (o *Options) GetTitleMaxWords() int {
+	if o == nil || o.Title == nil || o.Title.MaxWords <= 0 {
+		return 0
+	}
+	return o.Title.MaxWords
+}
+
+// GetTitleMaxLength returns the maximum title length in characters,
+// clamped to [MinTitleMaxLength, MaxTitleMaxLength], or
+// [DefaultTitleMaxLength] when unset.
+func (o *Options) GetTitleMaxLength() int {
+	if o == nil || o.Title == nil || o.Title.MaxLength <= 0 {
+		return DefaultTitleMaxLength
+	}
+	return min(max(o.Title.MaxLength, MinTitleMaxLength), MaxTitleMaxLength)
+}
+
+// ContextOptions configures how context files such as AGENTS.md are added
+// to the system prompt.
+type ContextOptions struct {
+	Placement ContextPlacement `json:"placement,omitempty" jsonschema:"description=Where context files go: suffix puts them after the base instructions\\, prefix before them\\, and separate sends them as their own system message after the instructions,enum=suffix,enum=prefix,enum=separate,default=suffix"`
+}
+
+// ContextPlacement is where context files are placed relative to the base
+// instructions of the system prompt.
+type ContextPlacement string
+
+const (
+	ContextPlacementSuffix   ContextPlacement = "suffix"
+	ContextPlacementPrefix   ContextPlacement = "prefix"
+	ContextPlacementSeparate ContextPlacement = "separate"
+)
+
+// GetContextPlacement returns the configured context placement. Unset or
+// unknown values fall back to [ContextPlacementSuffix].
+func (o *Options) GetContextPlacement() ContextPlacement {
+	if o == nil || o.Context == nil {
+		return ContextPlacementSuffix
+	}
+	switch o.Context.Placement {
+	case ContextPlacementPrefix, ContextPlacementSeparate:
+		return o.Context.Placement
+	}
+	return ContextPlacementSuffix
+}
+
+// ResponseStyle adjusts how verbose the coder agent's answers are.
+type ResponseStyle string
+
+const (
+	ResponseStyleConcise  ResponseStyle = "concise"
+	ResponseStyleNormal   ResponseStyle = "normal"
+	ResponseStyleDetailed ResponseStyle = "detailed"
+)
+
+// ParseResponseStyle returns the response style named s. An empty s is
+// [ResponseStyleNormal].
+func ParseResponseStyle(s string) (ResponseStyle, error) {
+	switch style := ResponseStyle(strings.ToLower(strings.TrimSpace(s))); style {
+	case "":
+		return ResponseStyleNormal, nil
+	case ResponseStyleConcise, ResponseStyleNormal, ResponseStyleDetailed:
+		return style, nil
+	}
+	return "", fmt.Errorf("unknown response style %q, expected concise, normal or detailed", s)
+}
+
+// GetResponseStyle returns the configured response style. Unknown values
+// fall back to [ResponseStyleNormal].
+func (o *Options) GetResponseStyle() ResponseStyle {
+	if o == nil {
+		return ResponseStyleNormal
+	}
+	style, err := ParseResponseStyle(string(o.ResponseStyle))
+	if err != nil {
+		return ResponseStyleNormal
+	}
+	return style
+}
+
+// ParseLanguage returns the language of the locale code s, such as "de" or
+// "pt-BR". An empty s is English; codes of unknown languages are an error.
+func ParseLanguage(s string) (language.Tag, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return language.English, nil
+	}
+	tag, err := language.Parse(s)
+	if _, confidence := tag.Base(); err != nil || confidence != language.Exact || display.English.Tags().Name(tag) == "" {
+		return language.Und, fmt.Errorf("unknown language %q, expected a locale code such as en, de or pt-BR", s)
+	}
+	return tag, nil
+}
+
+// GetLanguage returns the configured language. Unset or unknown values
+// fall back to English.
+func (o *Options) GetLanguage() language.Tag {
+	if o == nil {
+		return language.English
+	}
+	tag, err := ParseLanguage(o.Language)
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
+
+// MaxRequestUserLength is the longest options.request_user accepted by
+// any provider. It matches the limit OpenAI-compatible APIs commonly
+// enforce on the user field; longer values are rejected by the provider.
+const MaxRequestUserLength = 256
+
+// requestUserLimits holds the user field limit of provider types that
+// accept less than [MaxRequestUserLength].
+var requestUserLimits = map[catwalk.Type]int{
+	catwalk.TypeOpenRouter: 128,
+}
+
+// RequestUserLimit returns the longest user field accepted by providers
+// of type t. An empty t returns [MaxRequestUserLength].
+func RequestUserLimit(t catwalk.Type) int {
+	if limit, ok := requestUserLimits[t]; ok {
+		return limit
+	}
+	return MaxRequestUserLength
+}
+
+// ValidateRequestUser reports whether user can be sent as the user field
+// of a request to a provider of type t. Pass an empty t to check against
+// the limit shared by all providers.
+func ValidateRequestUser(user string, t catwalk.Type) error {
+	limit := RequestUserLimit(t)
+	if n := utf8.RuneCountInString(user); n > limit {
+		if t == "" {
+			return fmt.Errorf("request user is %d characters long, the maximum is %d", n, limit)
+		}
+		return fmt.Errorf("request user is %d characters long, the maximum for %s providers is %d", n, t, limit)
+	}
+	return nil
+}
+
+// DefaultAuxiliaryRetries is the number of retries for title generation and
+// summarization when options.auxiliary_retries is not set.
+const DefaultAuxiliaryRetries = 1
+
+// GetAuxiliaryRetries returns the configured number of retries for title
+// generation and summarization, or [DefaultAuxiliaryRetries].
+func (o *Options) GetAuxiliaryRetries() int {
+	if o == nil || o.AuxiliaryRetries == nil || *o.AuxiliaryRetries < 0 {
+		return DefaultAuxiliaryRetries
+	}
+	return *o.AuxiliaryRetries
+}
+
+// GetStoreThinking reports whether the model's reasoning is saved with
+// the session. It defaults to true.
+func (o *Options) GetStoreThinking() bool {
+	return o == nil || o.StoreThinking == nil || *o.StoreThinking
+}
+
+// GetToolResultCache reports whether identical read-only tool calls
+// within a turn share their result. It defaults to true.
+func (o *Options) GetToolResultCache() bool {
+	return o == nil || o.ToolResultCache == nil || *o.ToolResultCache
+}
+
+// DefaultMCPStartupConcurrency is the number of MCP servers started in
+// parallel when options.mcp_startup_concurrency is not set.
+const DefaultMCPStartupConcurrency = 8
+
+// GetMCPStartupConcurrency returns the configured number of MCP servers to
+// start in parallel, or [DefaultMCPStartupConcurrency].
+func (o *Options) GetMCPStartupConcurrency() int {
+	if o == nil || o.MCPStartupConcurrency <= 0 {
+		return DefaultMCPStartupConcurrency
+	}
+	return o.MCPStartupConcurrency
+}
+
+// DefaultMaxAgentDepth is the sub-agent nesting limit used when
+// options.max_agent_depth is not set.
+const DefaultMaxAgentDepth = 2
+
+// GetMaxAgentDepth returns the configured sub-agent nesting limit, or
+// [DefaultMaxAgentDepth].
+func (o *Options) GetMaxAgentDepth() int {
+	if o == nil || o.MaxAgentDepth <= 0 {
+		return DefaultMaxAgentDepth
+	}
+	return o.MaxAgentDepth
+}
+
+// DefaultMaxIdenticalToolCalls is the number of identical tool calls in a
+// row the agent may make when options.max_identical_tool_calls is not set.
+const DefaultMaxIdenticalToolCalls = 3
+
+// DefaultSmallModelConcurrency is the number of small-model requests that
+// may be in flight at once when options.small_model_concurrency is not set.
+const DefaultSmallModelConcurrency = 2
+
+// GetSmallModelConcurrency returns the configured number of small-model
+// requests that may be in flight at once, or [DefaultSmallModelConcurrency].
+func (o *Options) GetSmallModelConcurrency() int {
+	if o == nil || o.SmallModelConcurrency <= 0 {
+		return DefaultSmallModelConcurrency
+	}
+	return o.SmallModelConcurrency
+}
+
+// GetMaxIdenticalToolCalls returns the configured number of identical tool
+// calls in a row the agent may make, or [DefaultMaxIdenticalToolCalls].
+func (o *Options) GetMaxIdenticalToolCalls() int {
+	if o == nil || o.MaxIdenticalToolCalls <= 0 {
+		return DefaultMaxIdenticalToolCalls
+	}
+	return o.MaxIdenticalToolCalls
+}
+
+// ResponseCache configures the on-disk cache of model responses. Requests
+// are keyed by a hash of the provider, model, messages, tools and
+// parameters, so any change to those misses the cache.
+type ResponseCache struct {
+	Enabled  bool   `json:"enabled,omitempty" jsonschema:"description=Serve repeated identical model requests from the cache,default=false"`
+	Dir      string `json:"dir,omitempty" jsonschema:"description=Directory to store cached responses in. Defaults to cache/responses inside the data directory,example=/tmp/crush-cache"`
+	TTLHours int    `json:"ttl_hours,omitempty" jsonschema:"description=How long cached responses stay valid in hours,default=24,minimum=0,example=168"`
+}
+
+// GetDir returns the configured cache directory, or the default inside
+// dataDir.
+func (r ResponseCache) GetDir(dataDir string) string {
+	if r.Dir != "" {
+		return r.Dir
+	}
+	return filepath.Join(dataDir, "cache", "responses")
+}
+
+// GetTTL returns the configured time to live, or the default of one day.
+func (r ResponseCache) GetTTL() time.Duration {
+	if r.TTLHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(r.TTLHours) * time.Hour
+}
+
+// CircuitBreaker configures a provider's circuit breaker. After Failures
+// consecutive failures within the window, requests to the provider fail
+// immediately until the cooldown has passed; then a single probe request is
+// let through and its outcome closes or reopens the circuit. Only provider
+// failures count: server errors, rate limits and network errors, not
+// invalid requests or cancellations.
+type CircuitBreaker struct {
+	Enabled         bool `json:"enabled,omitempty" jsonschema:"description=Open the circuit after repeated provider failures,default=false"`
+	Failures        int  `json:"failures,omitempty" jsonschema:"description=Consecutive failures that open the circuit,default=5,minimum=0,example=3"`
+	WindowSeconds   int  `json:"window_seconds,omitempty" jsonschema:"description=Failures further apart than this many seconds start a new count,default=60,minimum=0,example=120"`
+	CooldownSeconds int  `json:"cooldown_seconds,omitempty" jsonschema:"description=Seconds to fail fast before probing the provider again,default=30,minimum=0,example=60"`
+}
+
+// GetFailures returns the configured failure threshold, or the default of
+// five.
+func (c CircuitBreaker) GetFailures() int {
+	if c.Failures <= 0 {
+		return 5
+	}
+	return c.Failures
+}
+
+// GetWindow returns the configured failure window, or the default of one
+// minute.
+func (c CircuitBreaker) GetWindow() time.Duration {
+	if c.WindowSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// GetCooldown returns the configured cooldown, or the default of thirty
+// seconds.
+func (c CircuitBreaker) GetCooldown() time.Duration {
+	if c.CooldownSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.CooldownSeconds) * time.Second
+}
+
+// Retention defines which sessions `crush prune` removes. A zero value
+// for either limit disables it.
+type Retention struct {
+	MaxSessions int `json:"max_sessions,omitempty" jsonschema:"description=Maximum number of sessions to keep. The least recently updated sessions beyond this count are pruned,minimum=0,example=200"`
+	MaxAgeDays  int `json:"max_age_days,omitempty" jsonschema:"description=Prune sessions that have not been updated in this many days,minimum=0,example=90"`
+}
+
+// StorageOptions limits what is written to the session database.
+type StorageOptions struct {
+	MaxMessageBytes int `json:"max_message_bytes,omitempty" jsonschema:"description=Cut the text\\, reasoning and tool output of each message part to this many bytes when saving it\\, ending it with a note of its full size. The model still sees the full content during the turn. 0 saves everything,minimum=0,example=262144"`
+}
+
+// GetMaxMessageBytes returns the per-part storage limit in bytes, or 0
+// when parts are stored in full.
+func (s *StorageOptions) GetMaxMessageBytes() int {
+	if s == nil || s.MaxMessageBytes < 0 {
+		return 0
+	}
+	return s.MaxMessageBytes
 }
 
 type MCPs map[string]MCPConfig
@@ -564,6 +1158,11 @@ type Tools struct {
 	Ls   ToolLs   `json:"ls,omitzero"`
 	Grep ToolGrep `json:"grep,omitzero"`
 	Glob ToolGlob `json:"glob,omitzero"`
+
+	Diagnostics ToolDiagnostics `json:"lsp_diagnostics,omitzero"`
+	Issue       ToolIssue       `json:"issue,omitzero"`
+	Lint        ToolLint        `json:"lint,omitzero"`
+	Download    ToolDownload    `json:"download,omitzero"`
 }
 
 type ToolLs struct {
@@ -594,6 +1193,71 @@ func (t ToolGlob) GetTimeout() time.Duration {
 	return ptrValOr(t.Timeout, 30*time.Second)
 }
 
+type ToolDiagnostics struct {
+	Concurrency *int           `json:"concurrency,omitempty" jsonschema:"description=Maximum number of LSP servers the diagnostics tool refreshes at once,default=4,example=8"`
+	Timeout     *time.Duration `json:"timeout,omitempty" jsonschema:"description=How long the diagnostics tool waits for each LSP server,default=5s,example=10s"`
+}
+
+// GetConcurrency returns the user-defined concurrency or the default. Values
+// below one are treated as one.
+func (t ToolDiagnostics) GetConcurrency() int {
+	return max(ptrValOr(t.Concurrency, 4), 1)
+}
+
+// GetTimeout returns the user-defined timeout or the default.
+func (t ToolDiagnostics) GetTimeout() time.Duration {
+	return ptrValOr(t.Timeout, 5*time.Second)
+}
+
+type ToolIssue struct {
+	GitHubToken string            `json:"github_token,omitempty" jsonschema:"description=Token for github.com; supports $VAR and $(command). Defaults to $GITHUB_TOKEN or $GH_TOKEN,example=$GITHUB_TOKEN"`
+	GitLabToken string            `json:"gitlab_token,omitempty" jsonschema:"description=Token for gitlab.com; supports $VAR and $(command). Defaults to $GITLAB_TOKEN,example=$GITLAB_TOKEN"`
+	Hosts       []string          `json:"hosts,omitempty" jsonschema:"description=Hosts the issue tool may fetch from. Defaults to github.com and gitlab.com,example=gitlab.example.com"`
+	Tokens      map[string]string `json:"tokens,omitempty" jsonschema:"description=Tokens for other hosts such as GitHub Enterprise or self-hosted GitLab by host name; supports $VAR and $(command). Tokens from the environment are only sent to github.com and gitlab.com"`
+}
+
+// GetHosts returns the user-defined hosts or the defaults.
+func (t ToolIssue) GetHosts() []string {
+	if len(t.Hosts) == 0 {
+		return []string{"github.com", "gitlab.com"}
+	}
+	return t.Hosts
+}
+
+type ToolLint struct {
+	Linter  string         `json:"linter,omitempty" jsonschema:"description=Linter the lint tool runs when the agent does not name one. Defaults to the one matching the project,enum=golangci-lint,enum=eslint,enum=ruff"`
+	Timeout *time.Duration `json:"timeout,omitempty" jsonschema:"description=How long the lint tool waits for the linter,default=2m,example=5m"`
+}
+
+// GetTimeout returns the user-defined timeout or the default.
+func (t ToolLint) GetTimeout() time.Duration {
+	return ptrValOr(t.Timeout, 2*time.Minute)
+}
+
+// DownloadIgnoredPolicy is what the download tool does when the target
+// path is ignored by .gitignore or .crushignore, such as a build directory.
+type DownloadIgnoredPolicy string
+
+const (
+	DownloadIgnoredAllow  DownloadIgnoredPolicy = "allow"
+	DownloadIgnoredWarn   DownloadIgnoredPolicy = "warn"
+	DownloadIgnoredRefuse DownloadIgnoredPolicy = "refuse"
+)
+
+type ToolDownload struct {
+	IgnoredPaths DownloadIgnoredPolicy `json:"ignored_paths,omitempty" jsonschema:"description=What the download tool does when the target path is ignored by .gitignore or .crushignore: allow saves the file\\, warn saves it and tells the agent\\, refuse fails the download,enum=allow,enum=warn,enum=refuse,default=warn"`
+}
+
+// GetIgnoredPaths returns the configured policy for ignored paths. Unset or
+// unknown values fall back to [DownloadIgnoredWarn].
+func (t ToolDownload) GetIgnoredPaths() DownloadIgnoredPolicy {
+	switch t.IgnoredPaths {
+	case DownloadIgnoredAllow, DownloadIgnoredRefuse:
+		return t.IgnoredPaths
+	}
+	return DownloadIgnoredWarn
+}
+
 // HookConfig defines a user-configured shell command that fires on a hook
 // event (e.g. PreToolUse). This is a pure-data struct: matcher compilation
 // is owned by hooks.Runner so a JSON round-trip, merge, or reload can't
@@ -704,6 +1368,10 @@ func (c *Config) EnabledProviders() []ProviderConfig {
 	return enabled
 }
 
+// ErrNotConfigured is returned when no provider is enabled. Check for it
+// up front with [Config.IsConfigured].
+var ErrNotConfigured = errors.New("no providers configured")
+
 // IsConfigured  return true if at least one provider is configured
 func (c *Config) IsConfigured() bool {
 	return len(c.EnabledProviders()) > 0
@@ -761,12 +1429,15 @@ func allToolNames() []string {
 	return []string{
 		"agent",
 		"bash",
+		"codemod",
 		"crush_info",
 		"crush_logs",
+		"docs",
 		"job_output",
 		"job_kill",
 		"download",
 		"edit",
+		"env_edit",
 		"multiedit",
 		"lsp_diagnostics",
 		"lsp_references",
@@ -778,10 +1449,14 @@ func allToolNames() []string {
 		"lsp_replace_symbol",
 		"fetch",
 		"agentic_fetch",
+		"issue",
+		"lint",
 		"glob",
 		"grep",
 		"ls",
 		"question",
+		"recent_files",
+		"repo_map",
 		"sourcegraph",
 		"todos",
 		"view",
@@ -791,6 +1466,22 @@ func allToolNames() []string {
 	}
 }
 
+// ValidateToolNames returns an error naming any entry in names that is not
+// a known built-in tool.
+func ValidateToolNames(names []string) error {
+	known := allToolNames()
+	var unknown []string
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown tool(s): %s (available: %s)", strings.Join(unknown, ", "), strings.Join(known, ", "))
+	}
+	return nil
+}
+
 func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 	if disabledTools == nil {
 		return allTools
@@ -800,7 +1491,7 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "lsp_call_hierarchy", "lsp_definition", "lsp_symbols", "sourcegraph", "view"}
+	readOnlyTools := []string{"glob", "grep", "ls", "lsp_call_hierarchy", "lsp_definition", "lsp_symbols", "recent_files", "repo_map", "sourcegraph", "view"}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"log/slog"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/discover"
+)
+
+// discoveredModels is the last successful model discovery of a custom
+// provider, kept on disk so the provider keeps its models, context windows
+// and capabilities when the endpoint cannot be reached at startup.
+type discoveredModels struct {
+	BaseURL string          `json:"base_url"`
+	Models  []catwalk.Model `json:"models"`
+}
+
+type discoveryResult struct {
+	cfg    discover.Config
+	models []catwalk.Model
+	err    error
+}
+
+// cacheDiscoveredModels saves the successful discoveries in results to
+// the cache at path, and replaces failed ones with the models cached for
+// the same provider and base URL, if any. Models from the configuration
+// still win over cached ones.
+func cacheDiscoveredModels(path string, results map[string]discoveryResult) {
+	c := newCache[map[string]discoveredModels](path)
+	cached, _, err := c.Get()
+	if err != nil || cached == nil {
+		cached = map[string]discoveredModels{}
+	}
+
+	changed := false
+	for id, result := range results {
+		entry, ok := cached[id]
+		switch {
+		case result.err == nil && len(result.models) > 0:
+			cached[id] = discoveredModels{BaseURL: result.cfg.BaseURL, Models: result.models}
+			changed = true
+		case result.err != nil && ok && entry.BaseURL == result.cfg.BaseURL:
+			slog.Warn("Model discovery failed, using cached models", "provider", id, "error", result.err)
+			results[id] = discoveryResult{cfg: result.cfg, models: mergeModels(result.cfg.ExistingModels, entry.Models)}
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := c.Store(cached); err != nil {
+		slog.Warn("Failed to cache discovered models", "error", err)
+	}
+}
+
+// mergeModels returns models followed by the models in extra whose IDs are
+// not in models.
+func mergeModels(models, extra []catwalk.Model) []catwalk.Model {
+	seen := make(map[string]struct{}, len(models))
+	for _, m := range models {
+		seen[m.ID] = struct{}{}
+	}
+	result := append([]catwalk.Model(nil), models...)
+	for _, m := range extra {
+		if _, ok := seen[m.ID]; !ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/discover"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheDiscoveredModels(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "discovered_models.json")
+	cfg := discover.Config{
+		ID:             "local",
+		BaseURL:        "http://localhost:8000/v1",
+		ExistingModels: []catwalk.Model{{ID: "pinned", ContextWindow: 4096}},
+	}
+	discovered := []catwalk.Model{
+		{ID: "pinned", ContextWindow: 4096},
+		{ID: "served", ContextWindow: 32768, SupportsImages: true},
+	}
+
+	cacheDiscoveredModels(path, map[string]discoveryResult{
+		"local": {cfg: cfg, models: discovered},
+	})
+
+	t.Run("failed discovery falls back to the cache", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := cfg
+		cfg.ExistingModels = []catwalk.Model{{ID: "pinned", ContextWindow: 8192}}
+		results := map[string]discoveryResult{
+			"local": {cfg: cfg, err: errors.New("connection refused")},
+		}
+		cacheDiscoveredModels(path, results)
+
+		require.NoError(t, results["local"].err)
+		require.Equal(t, []catwalk.Model{
+			{ID: "pinned", ContextWindow: 8192},
+			{ID: "served", ContextWindow: 32768, SupportsImages: true},
+		}, results["local"].models)
+	})
+
+	t.Run("cache is ignored when the base URL changed", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := cfg
+		cfg.BaseURL = "http://localhost:9000/v1"
+		results := map[string]discoveryResult{
+			"local": {cfg: cfg, err: errors.New("connection refused")},
+		}
+		cacheDiscoveredModels(path, results)
+
+		require.Error(t, results["local"].err)
+		require.Empty(t, results["local"].models)
+	})
+}
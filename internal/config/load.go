@@ -111,6 +111,20 @@ func Load(workingDir, dataDir string, debug bool) (*ConfigStore, error) {
 	valueResolver := NewShellVariableResolver(env)
 	store.resolver = valueResolver
 
+	if err := cfg.ValidateMCPs(valueResolver); err != nil {
+		return nil, fmt.Errorf("invalid mcp configuration: %w", err)
+	}
+	cfg.warnMissingCommands(valueResolver)
+	if err := cfg.Options.Security.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid security configuration: %w", err)
+	}
+	if err := cfg.Options.Summarize.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid summarize configuration: %w", err)
+	}
+	if err := cfg.Options.Title.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid title configuration: %w", err)
+	}
+
 	// Hold writeMu during initial load to prevent configureProviders
 	// from triggering auto-reload via RemoveConfigField.
 	store.writeMu.Lock()
@@ -281,8 +295,10 @@ func (c *Config) configureProviders(ctx context.Context, store *ConfigStore, env
 			SystemPromptPrefix: config.SystemPromptPrefix,
 			ExtraHeaders:       headers,
 			ExtraBody:          config.ExtraBody,
+			CircuitBreaker:     config.CircuitBreaker,
 			ExtraParams:        make(map[string]string),
 			Models:             p.Models,
+			ContextWindows:     config.ContextWindows,
 		}
 
 		switch {
@@ -365,11 +381,6 @@ func (c *Config) configureProviders(ctx context.Context, store *ConfigStore, env
 	// Discover models concurrently for custom providers that need it.
 	// A provider needs discovery when discover_models is explicitly true,
 	// or when the models list is empty (auto-trigger, unless opted out).
-	type discoveryResult struct {
-		models []catwalk.Model
-		err    error
-	}
-
 	discoveryResults := make(map[string]discoveryResult)
 	var mu sync.Mutex
 	var wg sync.WaitGroup
@@ -404,12 +415,15 @@ func (c *Config) configureProviders(ctx context.Context, store *ConfigStore, env
 				}
 			}
 			mu.Lock()
-			discoveryResults[id] = discoveryResult{models: models, err: err}
+			discoveryResults[id] = discoveryResult{cfg: cfg, models: models, err: err}
 			mu.Unlock()
 		})
 	}
 	wg.Wait()
 	discoverCancel()
+	if len(discoveryResults) > 0 {
+		cacheDiscoveredModels(cachePathFor("discovered_models"), discoveryResults)
+	}
 
 	// Validate the custom providers.
 	for id, providerConfig := range c.Providers.Seq2() {
@@ -497,6 +511,44 @@ func (c *Config) configureProviders(ctx context.Context, store *ConfigStore, env
 		return fmt.Errorf("default providers are disabled and there are no custom providers are configured")
 	}
 
+	for id, providerConfig := range c.Providers.Seq2() {
+		if len(providerConfig.ContextWindows) == 0 {
+			continue
+		}
+		if err := providerConfig.applyContextWindows(); err != nil {
+			return err
+		}
+		c.Providers.Set(id, providerConfig)
+	}
+
+	return nil
+}
+
+// applyContextWindows sets the context window of the models listed in
+// [ProviderConfig.ContextWindows]. It fails on a window that is not
+// positive and warns about overrides for unknown models or that differ
+// from the model's metadata.
+func (c *ProviderConfig) applyContextWindows() error {
+	if len(c.ContextWindows) == 0 {
+		return nil
+	}
+	// The models may be shared with the known providers.
+	c.Models = slices.Clone(c.Models)
+	for _, modelID := range slices.Sorted(maps.Keys(c.ContextWindows)) {
+		window := c.ContextWindows[modelID]
+		if window <= 0 {
+			return fmt.Errorf("provider %s: context window of %s must be positive, got %d", c.ID, modelID, window)
+		}
+		i := slices.IndexFunc(c.Models, func(m catwalk.Model) bool { return m.ID == modelID })
+		if i < 0 {
+			slog.Warn("Context window set for a model the provider does not have", "provider", c.ID, "model", modelID)
+			continue
+		}
+		if known := c.Models[i].ContextWindow; known > 0 && known != window {
+			slog.Warn("Context window override differs from the model metadata", "provider", c.ID, "model", modelID, "metadata", known, "override", window)
+		}
+		c.Models[i].ContextWindow = window
+	}
 	return nil
 }
 
@@ -519,6 +571,8 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 
 	if dataDir != "" {
 		c.Options.DataDirectory = dataDir
+	} else if c.Options.DataDirectory == "" && c.Options.DataDirectoryMode == DataDirectoryGlobal {
+		c.Options.DataDirectory = GlobalDataDirectory()
 	} else if c.Options.DataDirectory == "" {
 		if path, ok := fsext.LookupClosestBounded(workingDir, projectBoundary(workingDir), defaultDataDirectory); ok {
 			c.Options.DataDirectory = path
@@ -655,7 +709,7 @@ func (c *Config) applyLSPDefaults() {
 
 func (c *Config) defaultModelSelection(knownProviders []catwalk.Provider) (largeModel SelectedModel, smallModel SelectedModel, err error) {
 	if len(knownProviders) == 0 && c.Providers.Len() == 0 {
-		err = fmt.Errorf("no providers configured, please configure at least one provider")
+		err = fmt.Errorf("%w, please configure at least one provider", ErrNotConfigured)
 		return largeModel, smallModel, err
 	}
 
@@ -704,7 +758,7 @@ func (c *Config) defaultModelSelection(knownProviders []catwalk.Provider) (large
 	})
 
 	if len(enabledProviders) == 0 {
-		err = fmt.Errorf("no providers configured, please configure at least one provider")
+		err = fmt.Errorf("%w, please configure at least one provider", ErrNotConfigured)
 		return largeModel, smallModel, err
 	}
 
@@ -1098,6 +1152,14 @@ func GlobalWorkspaceDir() string {
 	return filepath.Dir(GlobalConfigData())
 }
 
+// GlobalDataDirectory returns the data directory shared by all projects
+// when options.data_directory_mode is global. It lives in the user data
+// directory: $XDG_DATA_HOME/crush/data, ~/.local/share/crush/data, or
+// %LOCALAPPDATA%\crush\data on Windows.
+func GlobalDataDirectory() string {
+	return filepath.Join(GlobalWorkspaceDir(), "data")
+}
+
 func assignIfNil[T any](ptr **T, val T) {
 	if *ptr == nil {
 		*ptr = &val
@@ -1250,6 +1312,31 @@ func normalizeHookEvent(name string) string {
 	}
 }
 
+// ValidateMCPs expands the command and args of every enabled stdio MCP
+// server through resolver, so $VAR, ${VAR} and ${VAR:?msg} references
+// that cannot be resolved are reported at load time, naming the server,
+// instead of when the server is first started. The config itself is not
+// mutated: the values are expanded again when the server is spawned.
+func (c *Config) ValidateMCPs(resolver VariableResolver) error {
+	for _, name := range slices.Sorted(maps.Keys(c.MCP)) {
+		m := c.MCP[name]
+		if m.Disabled || m.Type != MCPStdio {
+			continue
+		}
+		command, err := resolver.ResolveValue(m.Command)
+		if err != nil {
+			return fmt.Errorf("mcp %q: command: %w", name, err)
+		}
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("mcp %q: command %q resolved to an empty value", name, m.Command)
+		}
+		if _, err := m.ResolvedArgs(resolver); err != nil {
+			return fmt.Errorf("mcp %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // ValidateHooks normalizes event names and checks that every configured
 // hook has a command and a syntactically valid matcher regex. Matcher
 // compilation used for matching is owned by hooks.Runner; this function
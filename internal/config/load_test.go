@@ -23,7 +23,16 @@ import (
 func TestMain(m *testing.M) {
 	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
 
+	// Keep caches written by the code under test, such as discovered
+	// models, out of the real data directory.
+	dataHome, err := os.MkdirTemp("", "crush-config-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_DATA_HOME", dataHome)
+
 	exitVal := m.Run()
+	os.RemoveAll(dataHome)
 	os.Exit(exitVal)
 }
 
@@ -222,6 +231,25 @@ func TestConfig_setDefaults(t *testing.T) {
 		require.Equal(t, filepath.Join(workingDir, "state"), cfg.Options.DataDirectory)
 	})
 
+	t.Run("global mode uses the shared data directory", func(t *testing.T) {
+		globalDir := t.TempDir()
+		t.Setenv("CRUSH_GLOBAL_DATA", globalDir)
+		cfg := &Config{Options: &Options{DataDirectoryMode: DataDirectoryGlobal}}
+
+		cfg.setDefaults(t.TempDir(), "")
+
+		require.Equal(t, filepath.Join(globalDir, "data"), cfg.Options.DataDirectory)
+	})
+
+	t.Run("configured data directory wins over global mode", func(t *testing.T) {
+		cfg := &Config{Options: &Options{DataDirectory: "./state", DataDirectoryMode: DataDirectoryGlobal}}
+		workingDir := filepath.Join(t.TempDir(), "worktree")
+
+		cfg.setDefaults(workingDir, "")
+
+		require.Equal(t, filepath.Join(workingDir, "state"), cfg.Options.DataDirectory)
+	})
+
 	t.Run("preserves absolute configured data directory", func(t *testing.T) {
 		// Use a platform-appropriate absolute path so the test runs
 		// the same way on POSIX and Windows.
@@ -389,6 +417,45 @@ func TestConfig_configureProvidersWithOverride(t *testing.T) {
 	require.Equal(t, "Updated", pc.Models[0].Name)
 }
 
+func TestConfig_configureProvidersContextWindows(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:          "openai",
+			APIKey:      "$OPENAI_API_KEY",
+			APIEndpoint: "https://api.openai.com/v1",
+			Models: []catwalk.Model{
+				{ID: "test-model", ContextWindow: 32_000},
+				{ID: "other-model", ContextWindow: 64_000},
+			},
+		},
+	}
+	env := env.NewFromMap(map[string]string{
+		"OPENAI_API_KEY": "test-key",
+	})
+	resolver := NewShellVariableResolver(env)
+
+	cfg := &Config{Providers: csync.NewMap[string, ProviderConfig]()}
+	cfg.Providers.Set("openai", ProviderConfig{ContextWindows: map[string]int64{"test-model": 128_000}})
+	cfg.Providers.Set("local", ProviderConfig{
+		BaseURL:        "http://localhost:8080/v1",
+		Models:         []catwalk.Model{{ID: "qwen"}},
+		ContextWindows: map[string]int64{"qwen": 131_072},
+	})
+	cfg.setDefaults("/tmp", "")
+	require.NoError(t, cfg.configureProviders(context.Background(), testStore(cfg), env, resolver, knownProviders))
+
+	require.Equal(t, int64(128_000), cfg.GetModel("openai", "test-model").ContextWindow)
+	require.Equal(t, int64(64_000), cfg.GetModel("openai", "other-model").ContextWindow)
+	require.Equal(t, int64(131_072), cfg.GetModel("local", "qwen").ContextWindow)
+	require.Equal(t, int64(32_000), knownProviders[0].Models[0].ContextWindow, "the known provider must not change")
+
+	cfg = &Config{Providers: csync.NewMap[string, ProviderConfig]()}
+	cfg.Providers.Set("openai", ProviderConfig{ContextWindows: map[string]int64{"test-model": 0}})
+	cfg.setDefaults("/tmp", "")
+	err := cfg.configureProviders(context.Background(), testStore(cfg), env, resolver, knownProviders)
+	require.ErrorContains(t, err, "context window of test-model must be positive")
+}
+
 func TestConfig_configureProvidersWithNewProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -705,7 +772,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "glob", "grep", "ls", "recent_files", "repo_map", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -723,11 +790,11 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "crush_info", "crush_logs", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "lsp_rename", "lsp_replace_symbol", "fetch", "agentic_fetch", "glob", "ls", "question", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "codemod", "crush_info", "crush_logs", "docs", "job_output", "job_kill", "env_edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "lsp_rename", "lsp_replace_symbol", "fetch", "agentic_fetch", "issue", "lint", "glob", "ls", "question", "recent_files", "repo_map", "sourcegraph", "todos", "view", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"lsp_symbols", "lsp_definition", "lsp_call_hierarchy", "glob", "ls", "recent_files", "repo_map", "sourcegraph", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
@@ -740,6 +807,8 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 				"lsp_call_hierarchy",
 				"lsp_definition",
 				"lsp_symbols",
+				"recent_files",
+				"repo_map",
 				"sourcegraph",
 				"view",
 			},
@@ -749,13 +818,22 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "crush_info", "crush_logs", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "lsp_rename", "lsp_replace_symbol", "fetch", "agentic_fetch", "question", "todos", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "codemod", "crush_info", "crush_logs", "docs", "job_output", "job_kill", "download", "edit", "env_edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "lsp_rename", "lsp_replace_symbol", "fetch", "agentic_fetch", "issue", "lint", "question", "todos", "write", "list_mcp_resources", "read_mcp_resource"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Len(t, taskAgent.AllowedTools, 0)
 }
 
+func TestValidateToolNames(t *testing.T) {
+	require.NoError(t, ValidateToolNames(nil))
+	require.NoError(t, ValidateToolNames([]string{"view", "grep", "ls"}))
+
+	err := ValidateToolNames([]string{"view", "nope", "bogus"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "nope, bogus")
+}
+
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -1294,7 +1372,7 @@ func TestConfig_defaultModelSelection(t *testing.T) {
 		require.NoError(t, err)
 
 		_, _, err = cfg.defaultModelSelection(knownProviders)
-		require.Error(t, err)
+		require.ErrorIs(t, err, ErrNotConfigured)
 	})
 	t.Run("should not error if model is missing", func(t *testing.T) {
 		knownProviders := []catwalk.Provider{
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ValidateMCPs(t *testing.T) {
+	t.Parallel()
+
+	r := NewShellVariableResolver(env.NewFromMap(map[string]string{
+		"HOME":      "/home/user",
+		"MCP_TOKEN": "secret",
+	}))
+
+	t.Run("resolvable command and args pass without mutating the config", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{MCP: map[string]MCPConfig{
+			"files": {
+				Type:    MCPStdio,
+				Command: "${HOME}/bin/mcp-files",
+				Args:    []string{"--token", "$MCP_TOKEN"},
+			},
+		}}
+		require.NoError(t, cfg.ValidateMCPs(r))
+		require.Equal(t, "${HOME}/bin/mcp-files", cfg.MCP["files"].Command)
+		require.Equal(t, "$MCP_TOKEN", cfg.MCP["files"].Args[1])
+	})
+
+	t.Run("required arg names the server", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{MCP: map[string]MCPConfig{
+			"github": {
+				Type:    MCPStdio,
+				Command: "github-mcp",
+				Args:    []string{"--token", "${GITHUB_TOKEN:?set GITHUB_TOKEN}"},
+			},
+		}}
+		err := cfg.ValidateMCPs(r)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `mcp "github"`)
+		require.Contains(t, err.Error(), "arg 1")
+	})
+
+	t.Run("command that expands to nothing names the server", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{MCP: map[string]MCPConfig{
+			"tool": {Type: MCPStdio, Command: "$MCP_BIN"},
+		}}
+		err := cfg.ValidateMCPs(r)
+		require.ErrorContains(t, err, `mcp "tool": command "$MCP_BIN" resolved to an empty value`)
+	})
+
+	t.Run("disabled and remote servers are skipped", func(t *testing.T) {
+		t.Parallel()
+		cfg := &Config{MCP: map[string]MCPConfig{
+			"off":    {Type: MCPStdio, Command: "$MCP_BIN", Disabled: true},
+			"remote": {Type: MCPHttp, URL: "https://mcp.example.com"},
+		}}
+		require.NoError(t, cfg.ValidateMCPs(r))
+	})
+}
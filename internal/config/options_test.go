@@ -0,0 +1,147 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"charm.land/catwalk/pkg/catwalk"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestOptionsGetAuxiliaryRetries(t *testing.T) {
+	t.Parallel()
+
+	retries := func(n int) *int { return &n }
+
+	require.Equal(t, DefaultAuxiliaryRetries, (*Options)(nil).GetAuxiliaryRetries())
+	require.Equal(t, DefaultAuxiliaryRetries, (&Options{}).GetAuxiliaryRetries())
+	require.Equal(t, DefaultAuxiliaryRetries, (&Options{AuxiliaryRetries: retries(-1)}).GetAuxiliaryRetries())
+	require.Equal(t, 0, (&Options{AuxiliaryRetries: retries(0)}).GetAuxiliaryRetries())
+	require.Equal(t, 3, (&Options{AuxiliaryRetries: retries(3)}).GetAuxiliaryRetries())
+}
+
+func TestValidateRequestUser(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateRequestUser("", ""))
+	require.NoError(t, ValidateRequestUser(strings.Repeat("é", MaxRequestUserLength), ""))
+	require.Error(t, ValidateRequestUser(strings.Repeat("a", MaxRequestUserLength+1), ""))
+
+	// OpenRouter accepts less than the shared limit.
+	long := strings.Repeat("a", RequestUserLimit(catwalk.TypeOpenRouter)+1)
+	require.NoError(t, ValidateRequestUser(long, catwalk.TypeOpenAI))
+	require.ErrorContains(t, ValidateRequestUser(long, catwalk.TypeOpenRouter), "openrouter")
+}
+
+func TestOptionsGetResponseStyle(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ResponseStyleNormal, (*Options)(nil).GetResponseStyle())
+	require.Equal(t, ResponseStyleNormal, (&Options{}).GetResponseStyle())
+	require.Equal(t, ResponseStyleNormal, (&Options{ResponseStyle: "chatty"}).GetResponseStyle())
+	require.Equal(t, ResponseStyleConcise, (&Options{ResponseStyle: "Concise"}).GetResponseStyle())
+	require.Equal(t, ResponseStyleDetailed, (&Options{ResponseStyle: ResponseStyleDetailed}).GetResponseStyle())
+
+	_, err := ParseResponseStyle("chatty")
+	require.Error(t, err)
+}
+
+func TestOptionsGetLanguage(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, language.English, (*Options)(nil).GetLanguage())
+	require.Equal(t, language.English, (&Options{}).GetLanguage())
+	require.Equal(t, language.English, (&Options{Language: "klingon"}).GetLanguage())
+	require.Equal(t, language.German, (&Options{Language: "de"}).GetLanguage())
+	require.Equal(t, language.BrazilianPortuguese, (&Options{Language: " pt_BR "}).GetLanguage())
+
+	for _, code := range []string{"xx", "english", "und", "qaa"} {
+		_, err := ParseLanguage(code)
+		require.Error(t, err, code)
+	}
+}
+
+func TestOptionsGetSummarize(t *testing.T) {
+	t.Parallel()
+
+	summarizeModel := func(o *Options) any {
+		typ, model := o.GetSummarizeModel()
+		if model != nil {
+			return *model
+		}
+		return typ
+	}
+	require.Equal(t, SelectedModelTypeLarge, summarizeModel(nil))
+	require.Equal(t, SelectedModelTypeLarge, summarizeModel(&Options{Summarize: &SummarizeOptions{Model: "medium"}}))
+	require.Equal(t, SelectedModelTypeSmall, summarizeModel(&Options{Summarize: &SummarizeOptions{Model: "small"}}))
+	require.Equal(t, SelectedModelTypeLarge, summarizeModel(&Options{Summarize: &SummarizeOptions{Model: "openai/"}}))
+	require.Equal(t,
+		SelectedModel{Provider: "openrouter", Model: "anthropic/claude-sonnet-4"},
+		summarizeModel(&Options{Summarize: &SummarizeOptions{Model: "openrouter/anthropic/claude-sonnet-4"}}))
+
+	require.NoError(t, (*SummarizeOptions)(nil).Validate())
+	require.NoError(t, (&SummarizeOptions{Model: "small"}).Validate())
+	require.NoError(t, (&SummarizeOptions{Model: "openai/gpt-4o"}).Validate())
+	require.Error(t, (&SummarizeOptions{Model: "medium"}).Validate())
+	require.Error(t, (&SummarizeOptions{Model: "/gpt-4o"}).Validate())
+
+	require.Zero(t, (*Options)(nil).GetSummarizeMaxTokens())
+	require.Zero(t, (&Options{Summarize: &SummarizeOptions{MaxTokens: -5}}).GetSummarizeMaxTokens())
+	require.Equal(t, int64(4000), (&Options{Summarize: &SummarizeOptions{MaxTokens: 4000}}).GetSummarizeMaxTokens())
+}
+
+func TestOptionsGetEmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, EmptyResponseFinish, (*Options)(nil).GetEmptyResponse())
+	require.Equal(t, EmptyResponseFinish, (&Options{EmptyResponse: "ignore"}).GetEmptyResponse())
+	require.Equal(t, EmptyResponseRetry, (&Options{EmptyResponse: EmptyResponseRetry}).GetEmptyResponse())
+}
+
+func TestToolDiagnosticsDefaults(t *testing.T) {
+	t.Parallel()
+
+	n := func(v int) *int { return &v }
+	timeout := 10 * time.Second
+
+	require.Equal(t, 4, ToolDiagnostics{}.GetConcurrency())
+	require.Equal(t, 1, ToolDiagnostics{Concurrency: n(0)}.GetConcurrency())
+	require.Equal(t, 8, ToolDiagnostics{Concurrency: n(8)}.GetConcurrency())
+	require.Equal(t, 5*time.Second, ToolDiagnostics{}.GetTimeout())
+	require.Equal(t, timeout, ToolDiagnostics{Timeout: &timeout}.GetTimeout())
+}
+
+func TestCircuitBreakerDefaults(t *testing.T) {
+	t.Parallel()
+
+	var cb CircuitBreaker
+	require.Equal(t, 5, cb.GetFailures())
+	require.Equal(t, time.Minute, cb.GetWindow())
+	require.Equal(t, 30*time.Second, cb.GetCooldown())
+
+	cb = CircuitBreaker{Failures: 2, WindowSeconds: 10, CooldownSeconds: 90}
+	require.Equal(t, 2, cb.GetFailures())
+	require.Equal(t, 10*time.Second, cb.GetWindow())
+	require.Equal(t, 90*time.Second, cb.GetCooldown())
+}
+
+func TestOptionsGetTitleLimits(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, DefaultTitleMaxLength, (*Options)(nil).GetTitleMaxLength())
+	require.Equal(t, 0, (*Options)(nil).GetTitleMaxWords())
+	require.Empty(t, (*Options)(nil).GetTitlePrompt())
+
+	opts := &Options{Title: &TitleOptions{Prompt: "  In German.\n", MaxWords: 6, MaxLength: 500}}
+	require.Equal(t, "In German.", opts.GetTitlePrompt())
+	require.Equal(t, 6, opts.GetTitleMaxWords())
+	require.Equal(t, MaxTitleMaxLength, opts.GetTitleMaxLength())
+
+	require.NoError(t, (*TitleOptions)(nil).Validate())
+	require.NoError(t, (&TitleOptions{MaxLength: 40}).Validate())
+	require.Error(t, (&TitleOptions{MaxLength: 5}).Validate())
+	require.Error(t, (&TitleOptions{MaxLength: 101}).Validate())
+	require.Error(t, (&TitleOptions{MaxWords: -1}).Validate())
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// ModelQuirks describes request parameters a model does not accept, so the
+// agent can leave them out or send them differently.
+type ModelQuirks struct {
+	// NoTemperature drops the temperature from requests.
+	NoTemperature bool `json:"no_temperature,omitempty" jsonschema:"description=Do not send a temperature,default=false"`
+	// UseMaxCompletionTokens sends the output token limit as
+	// max_completion_tokens instead of max_tokens. It only affects OpenAI
+	// and OpenAI-compatible providers.
+	UseMaxCompletionTokens bool `json:"use_max_completion_tokens,omitempty" jsonschema:"description=Send the output token limit as max_completion_tokens instead of max_tokens (OpenAI and OpenAI-compatible providers only),default=false"`
+	// NoSystemRole folds system messages into the first user message.
+	NoSystemRole bool `json:"no_system_role,omitempty" jsonschema:"description=Send the system prompt as part of the first user message instead of as system messages,default=false"`
+}
+
+// defaultModelQuirks are the quirks of known models. Keys follow the same
+// rules as [Options.ModelQuirks].
+var defaultModelQuirks = map[string]ModelQuirks{
+	"o1*":         {NoTemperature: true, UseMaxCompletionTokens: true},
+	"o1-mini*":    {NoTemperature: true, UseMaxCompletionTokens: true, NoSystemRole: true},
+	"o1-preview*": {NoTemperature: true, UseMaxCompletionTokens: true, NoSystemRole: true},
+	"o3*":         {NoTemperature: true, UseMaxCompletionTokens: true},
+	"o4-mini*":    {NoTemperature: true, UseMaxCompletionTokens: true},
+	"gpt-5*":      {NoTemperature: true, UseMaxCompletionTokens: true},
+}
+
+// GetModelQuirks returns the quirks of the model with the given ID. Entries
+// in options.model_quirks take precedence over the built-in defaults; within
+// each, an exact ID wins over a pattern and a longer pattern over a shorter
+// one. IDs with a vendor prefix, such as openai/o3 on OpenRouter, also match
+// keys for the part after the last slash.
+func (o *Options) GetModelQuirks(modelID string) ModelQuirks {
+	if o != nil {
+		if q, ok := lookupModelQuirks(o.ModelQuirks, modelID); ok {
+			return q
+		}
+	}
+	q, _ := lookupModelQuirks(defaultModelQuirks, modelID)
+	return q
+}
+
+func lookupModelQuirks(quirks map[string]ModelQuirks, modelID string) (ModelQuirks, bool) {
+	ids := []string{modelID}
+	if i := strings.LastIndex(modelID, "/"); i >= 0 {
+		ids = append(ids, modelID[i+1:])
+	}
+	for _, id := range ids {
+		if q, ok := quirks[id]; ok {
+			return q, true
+		}
+	}
+
+	var best string
+	for pattern := range quirks {
+		if !strings.ContainsAny(pattern, "*?[") {
+			continue
+		}
+		if best != "" && (len(pattern) < len(best) || len(pattern) == len(best) && pattern > best) {
+			continue
+		}
+		for _, id := range ids {
+			if ok, _ := path.Match(pattern, id); ok {
+				best = pattern
+				break
+			}
+		}
+	}
+	if best == "" {
+		return ModelQuirks{}, false
+	}
+	return quirks[best], true
+}
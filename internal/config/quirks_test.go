@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetModelQuirks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+		var o *Options
+		require.Equal(t, ModelQuirks{NoTemperature: true, UseMaxCompletionTokens: true}, o.GetModelQuirks("o3-mini"))
+		require.True(t, o.GetModelQuirks("o1-mini-2024-09-12").NoSystemRole)
+		require.False(t, o.GetModelQuirks("o1-2024-12-17").NoSystemRole)
+		require.True(t, o.GetModelQuirks("openai/o1-preview").NoSystemRole)
+		require.Equal(t, ModelQuirks{}, o.GetModelQuirks("claude-sonnet-4"))
+	})
+
+	t.Run("user entries override defaults", func(t *testing.T) {
+		t.Parallel()
+		o := &Options{ModelQuirks: map[string]ModelQuirks{
+			"o3-mini":      {},
+			"my-reasoner*": {NoTemperature: true},
+			"my-reasoner-v2": {
+				NoTemperature: true,
+				NoSystemRole:  true,
+			},
+		}}
+		require.Equal(t, ModelQuirks{}, o.GetModelQuirks("o3-mini"))
+		require.Equal(t, ModelQuirks{NoTemperature: true, UseMaxCompletionTokens: true}, o.GetModelQuirks("o3"))
+		require.Equal(t, ModelQuirks{NoTemperature: true}, o.GetModelQuirks("my-reasoner-large"))
+		require.Equal(t, ModelQuirks{NoTemperature: true, NoSystemRole: true}, o.GetModelQuirks("vendor/my-reasoner-v2"))
+	})
+}
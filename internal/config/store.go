@@ -21,6 +21,7 @@ import (
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"golang.org/x/sync/singleflight"
+	"golang.org/x/text/language"
 )
 
 // configLockDeadline bounds how long lockConfig waits for the
@@ -55,6 +56,29 @@ type RuntimeOverrides struct {
 	// pushes channel events when it also appears here. Entries may be written
 	// as "server:<name>" or as a bare "<name>".
 	EnabledChannels []string
+	// AllowedTools, when non-nil, restricts every agent to the named
+	// built-in tools for this process (via the --tools and --no-tools
+	// flags of crush run). MCP tools are disabled whenever it is non-nil,
+	// and an empty, non-nil slice disables tools entirely.
+	AllowedTools []string
+	// DisableResponseCache bypasses options.response_cache for this
+	// process (via the --no-cache flag of crush run).
+	DisableResponseCache bool
+	// StopSequences are added to the large model's stop sequences for
+	// this process (via the --stop flag of crush run).
+	StopSequences []string
+	// RequestUser replaces options.request_user for this process (via the
+	// --user flag of crush run).
+	RequestUser string
+	// ResponseStyle replaces options.response_style for this process (via
+	// the --style flag of crush run).
+	ResponseStyle ResponseStyle
+	// Language, when set, replaces options.language for this process (via
+	// the --language flag of crush run).
+	Language language.Tag
+	// MaxTokens, when positive, replaces the large model's max_tokens for
+	// this process (via the --max-tokens flag of crush run).
+	MaxTokens int64
 }
 
 // ConfigStore is the single entry point for all config access. It owns the
@@ -1056,6 +1080,19 @@ func (s *ConfigStore) reloadFromDiskLocked(ctx context.Context) error {
 	// Reconfigure providers
 	env := env.New()
 	resolver := NewShellVariableResolver(env)
+	if err := cfg.ValidateMCPs(resolver); err != nil {
+		return fmt.Errorf("invalid mcp configuration on reload: %w", err)
+	}
+	cfg.warnMissingCommands(resolver)
+	if err := cfg.Options.Security.Validate(); err != nil {
+		return fmt.Errorf("invalid security configuration on reload: %w", err)
+	}
+	if err := cfg.Options.Summarize.Validate(); err != nil {
+		return fmt.Errorf("invalid summarize configuration on reload: %w", err)
+	}
+	if err := cfg.Options.Title.Validate(); err != nil {
+		return fmt.Errorf("invalid title configuration on reload: %w", err)
+	}
 	providers, err := Providers(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to load providers during reload: %w", err)
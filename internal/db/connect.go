@@ -216,6 +216,35 @@ func ResetPool() {
 	}
 }
 
+// ConnectMemory opens a private in-memory SQLite database and runs
+// migrations. Nothing is written to disk and the data is discarded when the
+// connection is closed. The connection is not pooled, so callers close it
+// directly instead of calling [Release].
+func ConnectMemory(ctx context.Context) (*sql.DB, error) {
+	conn, err := openDB(":memory:")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each SQLite connection to ":memory:" gets its own empty database,
+	// so the pool must never open a second one.
+	conn.SetMaxOpenConns(1)
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if err := initGoose(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize goose: %w", err)
+	}
+	if err := goose.Up(conn, "migrations"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return conn, nil
+}
+
 // ConnectReadOnly opens a read-only SQLite database connection without running
 // migrations. Used for aggregating stats across multiple project databases.
 func ConnectReadOnly(ctx context.Context, dbPath string) (*sql.DB, error) {
@@ -208,3 +208,28 @@ func TestConnect_ServerPathFailsWhenDataDirLocked(t *testing.T) {
 	require.Error(t, err, "server-path Connect must refuse to open a locked data dir")
 	require.ErrorIs(t, err, ErrDataDirLocked)
 }
+
+func TestConnectMemory(t *testing.T) {
+	conn1, err := ConnectMemory(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn1.Close() })
+
+	conn2, err := ConnectMemory(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn2.Close() })
+
+	require.NotSame(t, conn1, conn2)
+
+	q := New(conn1)
+	_, err = q.CreateSession(context.Background(), CreateSessionParams{ID: "s1", Title: "memory"})
+	require.NoError(t, err)
+
+	sessions, err := q.ListSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+
+	// Each in-memory database is private to its connection.
+	sessions, err = New(conn2).ListSessions(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
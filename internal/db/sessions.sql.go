@@ -10,6 +10,23 @@ import (
 	"database/sql"
 )
 
+const addSessionCost = `-- name: AddSessionCost :exec
+UPDATE sessions
+SET
+    cost = cost + ?
+WHERE id = ?
+`
+
+type AddSessionCostParams struct {
+	Cost float64 `json:"cost"`
+	ID   string  `json:"id"`
+}
+
+func (q *Queries) AddSessionCost(ctx context.Context, arg AddSessionCostParams) error {
+	_, err := q.exec(ctx, q.addSessionCostStmt, addSessionCost, arg.Cost, arg.ID)
+	return err
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -33,7 +50,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned
 `
 
 type CreateSessionParams struct {
@@ -69,10 +86,27 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Pinned,
 	)
 	return i, err
 }
 
+const deleteChildSessions = `-- name: DeleteChildSessions :exec
+WITH RECURSIVE descendants(id) AS (
+    SELECT id FROM sessions WHERE parent_session_id = ?
+    UNION ALL
+    SELECT s.id FROM sessions s
+    JOIN descendants d ON s.parent_session_id = d.id
+)
+DELETE FROM sessions
+WHERE id IN (SELECT id FROM descendants)
+`
+
+func (q *Queries) DeleteChildSessions(ctx context.Context, parentSessionID sql.NullString) error {
+	_, err := q.exec(ctx, q.deleteChildSessionsStmt, deleteChildSessions, parentSessionID)
+	return err
+}
+
 const deleteSession = `-- name: DeleteSession :exec
 DELETE FROM sessions
 WHERE id = ?
@@ -84,7 +118,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getLastSession = `-- name: GetLastSession :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned
 FROM sessions
 ORDER BY updated_at DESC
 LIMIT 1
@@ -105,12 +139,13 @@ func (q *Queries) GetLastSession(ctx context.Context) (Session, error) {
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Pinned,
 	)
 	return i, err
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -130,15 +165,16 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Pinned,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned
 FROM sessions
 WHERE parent_session_id is NULL
-ORDER BY updated_at DESC
+ORDER BY pinned DESC, updated_at DESC
 `
 
 func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
@@ -162,6 +198,7 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.Pinned,
 		); err != nil {
 			return nil, err
 		}
@@ -193,6 +230,23 @@ func (q *Queries) RenameSession(ctx context.Context, arg RenameSessionParams) er
 	return err
 }
 
+const setSessionPinned = `-- name: SetSessionPinned :exec
+UPDATE sessions
+SET
+    pinned = ?
+WHERE id = ?
+`
+
+type SetSessionPinnedParams struct {
+	Pinned int64  `json:"pinned"`
+	ID     string `json:"id"`
+}
+
+func (q *Queries) SetSessionPinned(ctx context.Context, arg SetSessionPinnedParams) error {
+	_, err := q.exec(ctx, q.setSessionPinnedStmt, setSessionPinned, arg.Pinned, arg.ID)
+	return err
+}
+
 const updateSession = `-- name: UpdateSession :one
 UPDATE sessions
 SET
@@ -203,7 +257,7 @@ SET
     cost = ?,
     todos = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, pinned
 `
 
 type UpdateSessionParams struct {
@@ -239,6 +293,7 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.Pinned,
 	)
 	return i, err
 }
@@ -38,7 +38,7 @@ LIMIT 1;
 SELECT *
 FROM sessions
 WHERE parent_session_id is NULL
-ORDER BY updated_at DESC;
+ORDER BY pinned DESC, updated_at DESC;
 
 -- name: UpdateSession :one
 UPDATE sessions
@@ -69,6 +69,28 @@ SET
     title = ?
 WHERE id = ?;
 
+-- name: SetSessionPinned :exec
+UPDATE sessions
+SET
+    pinned = ?
+WHERE id = ?;
+
 -- name: DeleteSession :exec
 DELETE FROM sessions
 WHERE id = ?;
+
+-- name: DeleteChildSessions :exec
+WITH RECURSIVE descendants(id) AS (
+    SELECT id FROM sessions WHERE parent_session_id = ?
+    UNION ALL
+    SELECT s.id FROM sessions s
+    JOIN descendants d ON s.parent_session_id = d.id
+)
+DELETE FROM sessions
+WHERE id IN (SELECT id FROM descendants);
+
+-- name: AddSessionCost :exec
+UPDATE sessions
+SET
+    cost = cost + ?
+WHERE id = ?;
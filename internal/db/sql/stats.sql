@@ -82,6 +82,13 @@ WHERE json_extract(value, '$.type') = 'tool_call'
 GROUP BY tool_name
 ORDER BY call_count DESC;
 
+-- name: GetLoopInterventions :one
+SELECT
+    COUNT(*) as loop_interventions
+FROM messages, json_each(parts)
+WHERE json_extract(value, '$.type') = 'tool_result'
+  AND json_extract(value, '$.data.metadata') LIKE '%"repeated_tool_call":true%';
+
 -- name: GetHourDayHeatmap :many
 SELECT
     CAST(strftime('%w', created_at, 'unixepoch') AS INTEGER) as day_of_week,
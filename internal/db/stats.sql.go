@@ -66,6 +66,21 @@ func (q *Queries) GetHourDayHeatmap(ctx context.Context) ([]GetHourDayHeatmapRow
 	return items, nil
 }
 
+const getLoopInterventions = `-- name: GetLoopInterventions :one
+SELECT
+    COUNT(*) as loop_interventions
+FROM messages, json_each(parts)
+WHERE json_extract(value, '$.type') = 'tool_result'
+  AND json_extract(value, '$.data.metadata') LIKE '%"repeated_tool_call":true%'
+`
+
+func (q *Queries) GetLoopInterventions(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.getLoopInterventionsStmt, getLoopInterventions)
+	var loop_interventions int64
+	err := row.Scan(&loop_interventions)
+	return loop_interventions, err
+}
+
 const getRecentActivity = `-- name: GetRecentActivity :many
 SELECT
     date(created_at, 'unixepoch') as day,
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Size returns the size in bytes of the main database file as reported by
+// SQLite (page count times page size). Pages sitting on the freelist are
+// included, which is what makes the value useful for measuring the effect
+// of [Vacuum].
+func Size(ctx context.Context, conn *sql.DB) (int64, error) {
+	var pageCount, pageSize int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	if err := conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page size: %w", err)
+	}
+	return pageCount * pageSize, nil
+}
+
+// Vacuum rebuilds the database file to reclaim the space left behind by
+// deleted rows, then truncates the write-ahead log so the freed space is
+// returned to the filesystem.
+func Vacuum(ctx context.Context, conn *sql.DB) error {
+	if _, err := conn.ExecContext(ctx, "VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint database: %w", err)
+	}
+	return nil
+}
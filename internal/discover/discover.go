@@ -2,10 +2,12 @@ package discover
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -91,12 +93,48 @@ type Resolver interface {
 }
 
 type modelsResponse struct {
-	Data []struct {
-		ID      string `json:"id"`
-		Object  string `json:"object"`
-		Created int64  `json:"created"`
-		OwnedBy string `json:"owned_by"`
-	} `json:"data"`
+	Data []modelEntry `json:"data"`
+}
+
+// modelEntry is one model in a /models listing. Beyond the standard
+// OpenAI fields, several OpenAI-compatible servers describe the model
+// too, each in its own way; the fields below cover the common ones.
+type modelEntry struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+
+	// Context window: OpenRouter, Together and Fireworks use
+	// context_length, vLLM max_model_len, Groq context_window and LM
+	// Studio max_context_length.
+	ContextLength    int64 `json:"context_length"`
+	MaxModelLen      int64 `json:"max_model_len"`
+	ContextWindow    int64 `json:"context_window"`
+	MaxContextLength int64 `json:"max_context_length"`
+
+	// OpenRouter capabilities.
+	Architecture struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+	SupportedParameters []string `json:"supported_parameters"`
+	TopProvider         struct {
+		MaxCompletionTokens int64 `json:"max_completion_tokens"`
+	} `json:"top_provider"`
+}
+
+// model converts e to a catwalk model, filling in whatever metadata the
+// endpoint reported. Fields it did not report are left zero so config
+// defaults and enrichers can fill them.
+func (e modelEntry) model() catwalk.Model {
+	return catwalk.Model{
+		ID:               e.ID,
+		Name:             e.ID,
+		ContextWindow:    cmp.Or(e.ContextLength, e.MaxModelLen, e.ContextWindow, e.MaxContextLength),
+		DefaultMaxTokens: e.TopProvider.MaxCompletionTokens,
+		CanReason:        slices.Contains(e.SupportedParameters, "reasoning"),
+		SupportsImages:   slices.Contains(e.Architecture.InputModalities, "image"),
+	}
 }
 
 // DiscoverModels fetches available models from the provider's /models endpoint.
@@ -135,10 +173,7 @@ func DiscoverModels(ctx context.Context, cfg Config, resolver Resolver) ([]catwa
 		if _, ok := existing[e.ID]; ok {
 			continue
 		}
-		result = append(result, catwalk.Model{
-			ID:   e.ID,
-			Name: e.ID,
-		})
+		result = append(result, e.model())
 	}
 
 	return result, nil
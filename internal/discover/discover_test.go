@@ -219,3 +219,40 @@ func TestStripV1Suffix(t *testing.T) {
 		require.Equal(t, tt.want, got, "stripV1Suffix(%q)", tt.input)
 	}
 }
+
+func TestDiscoverModels_Metadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"data": [
+				{"id": "vllm-model", "object": "model", "max_model_len": 32768},
+				{"id": "groq-model", "object": "model", "context_window": 131072},
+				{
+					"id": "router-model",
+					"context_length": 200000,
+					"architecture": {"input_modalities": ["text", "image"]},
+					"supported_parameters": ["tools", "reasoning"],
+					"top_provider": {"max_completion_tokens": 64000}
+				},
+				{"id": "bare-model", "object": "model"}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	models, err := DiscoverModels(context.Background(), Config{ID: "test", BaseURL: server.URL + "/v1"}, &mockResolver{})
+	require.NoError(t, err)
+	require.Equal(t, []catwalk.Model{
+		{ID: "vllm-model", Name: "vllm-model", ContextWindow: 32768},
+		{ID: "groq-model", Name: "groq-model", ContextWindow: 131072},
+		{
+			ID:               "router-model",
+			Name:             "router-model",
+			ContextWindow:    200000,
+			DefaultMaxTokens: 64000,
+			CanReason:        true,
+			SupportsImages:   true,
+		},
+		{ID: "bare-model", Name: "bare-model"},
+	}, models)
+}
@@ -58,6 +58,27 @@ func TokensUsed(props ...any) {
 	)
 }
 
+func SummarizeFailed(props ...any) {
+	send(
+		"summarize failed",
+		props...,
+	)
+}
+
+func ContextFallback(props ...any) {
+	send(
+		"context fallback",
+		props...,
+	)
+}
+
+func RequestQueued(props ...any) {
+	send(
+		"request queued",
+		props...,
+	)
+}
+
 func StatsViewed() {
 	send("stats viewed")
 }
@@ -81,3 +102,11 @@ func SessionDeletedCommand(json bool) {
 func SessionRenamed(json bool) {
 	send("session renamed", "json", json)
 }
+
+func SessionsPruned(json, dryRun bool) {
+	send("sessions pruned", "json", json, "dry run", dryRun)
+}
+
+func SessionPinned(json, pinned bool) {
+	send("session pinned", "json", json, "pinned", pinned)
+}
@@ -0,0 +1,95 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProgressLog reports the progress of a non-interactive run as one
+// timestamped line per event. It stands in for the [Spinner] when stderr
+// is not a terminal, such as in CI logs, where redrawing in place would
+// only leave control codes behind.
+//
+// Every method is a no-op on a nil receiver, and each tool call is
+// reported once however often its message is updated.
+type ProgressLog struct {
+	mu       sync.Mutex
+	w        io.Writer
+	now      func() time.Time
+	started  map[string]bool
+	finished map[string]bool
+}
+
+// NewProgressLog returns a progress log writing to w.
+func NewProgressLog(w io.Writer) *ProgressLog {
+	return &ProgressLog{
+		w:        w,
+		now:      time.Now,
+		started:  make(map[string]bool),
+		finished: make(map[string]bool),
+	}
+}
+
+// RunStarted reports that the run of a session started.
+func (l *ProgressLog) RunStarted(sessionID string) {
+	l.printf("run started (session %s)", sessionID)
+}
+
+// ToolStarted reports that the model called a tool.
+func (l *ProgressLog) ToolStarted(id, name string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	seen := l.started[id]
+	l.started[id] = true
+	l.mu.Unlock()
+	if !seen {
+		l.printf("tool %s started", name)
+	}
+}
+
+// ToolFinished reports the result of a tool call.
+func (l *ProgressLog) ToolFinished(id, name string, isError bool) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	seen := l.finished[id]
+	l.finished[id] = true
+	l.mu.Unlock()
+	if seen {
+		return
+	}
+	if isError {
+		l.printf("tool %s failed", name)
+		return
+	}
+	l.printf("tool %s finished", name)
+}
+
+// ProviderRetrying reports that a model request failed and is retried;
+// status describes the error and the delay.
+func (l *ProgressLog) ProviderRetrying(status string) {
+	l.printf("provider error: %s", status)
+}
+
+// RunFinished reports the outcome of the run.
+func (l *ProgressLog) RunFinished(err error) {
+	if err != nil {
+		l.printf("run failed: %v", err)
+		return
+	}
+	l.printf("run completed")
+}
+
+func (l *ProgressLog) printf(format string, args ...any) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = fmt.Fprintf(l.w, "%s %s\n", l.now().Format(time.TimeOnly), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,46 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressLog(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	l := NewProgressLog(&buf)
+	l.now = func() time.Time { return time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC) }
+
+	l.RunStarted("s1")
+	l.ToolStarted("call-1", "bash")
+	l.ToolStarted("call-1", "bash")
+	l.ToolFinished("call-1", "bash", false)
+	l.ToolFinished("call-1", "bash", false)
+	l.ToolStarted("call-2", "view")
+	l.ToolFinished("call-2", "view", true)
+	l.ProviderRetrying("429 Too Many Requests; retrying in 5s")
+	l.RunFinished(errors.New("boom"))
+
+	require.Equal(t, `15:04:05 run started (session s1)
+15:04:05 tool bash started
+15:04:05 tool bash finished
+15:04:05 tool view started
+15:04:05 tool view failed
+15:04:05 provider error: 429 Too Many Requests; retrying in 5s
+15:04:05 run failed: boom
+`, buf.String())
+
+	var nilLog *ProgressLog
+	require.NotPanics(t, func() {
+		nilLog.RunStarted("s1")
+		nilLog.ToolStarted("call-1", "bash")
+		nilLog.ToolFinished("call-1", "bash", false)
+		nilLog.ProviderRetrying("retrying in 5s")
+		nilLog.RunFinished(nil)
+	})
+}
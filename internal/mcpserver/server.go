@@ -0,0 +1,89 @@
+// Package mcpserver exposes Crush's built-in tools to other programs over
+// the Model Context Protocol.
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// New returns an MCP server offering the given tools. Calls run with
+// sessionID in their context, so file history and permission requests are
+// recorded against that session like they are for the agent.
+func New(version, sessionID string, agentTools []fantasy.AgentTool) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "crush", Version: version}, nil)
+	for _, tool := range agentTools {
+		info := tool.Info()
+		server.AddTool(&mcp.Tool{
+			Name:        info.Name,
+			Description: info.Description,
+			InputSchema: inputSchema(info),
+		}, handler(tool, sessionID))
+	}
+	return server
+}
+
+// inputSchema builds the JSON schema of a tool's arguments from the
+// parameters fantasy generated for it.
+func inputSchema(info fantasy.ToolInfo) map[string]any {
+	schema := map[string]any{
+		"type":       "object",
+		"properties": info.Parameters,
+	}
+	if len(info.Required) > 0 {
+		schema["required"] = info.Required
+	}
+	return schema
+}
+
+func handler(tool fantasy.AgentTool, sessionID string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		input := "{}"
+		if len(req.Params.Arguments) > 0 {
+			input = string(req.Params.Arguments)
+		}
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		resp, err := tool.Run(ctx, fantasy.ToolCall{
+			ID:    uuid.NewString(),
+			Name:  req.Params.Name,
+			Input: input,
+		})
+		if err != nil {
+			// Errors such as a denied permission are reported to the
+			// caller as a failed call rather than a protocol error.
+			return errorResult(err.Error()), nil
+		}
+		return toResult(resp), nil
+	}
+}
+
+// toResult converts a tool response to an MCP tool result.
+func toResult(resp fantasy.ToolResponse) *mcp.CallToolResult {
+	result := &mcp.CallToolResult{IsError: resp.IsError}
+	switch resp.Type {
+	case "image":
+		result.Content = append(result.Content, &mcp.ImageContent{Data: resp.Data, MIMEType: resp.MediaType})
+	case "media":
+		result.Content = append(result.Content, &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+			URI:      "crush://" + uuid.NewString(),
+			MIMEType: resp.MediaType,
+			Blob:     resp.Data,
+		}})
+	}
+	if resp.Content != "" || len(result.Content) == 0 {
+		result.Content = append(result.Content, &mcp.TextContent{Text: resp.Content})
+	}
+	return result
+}
+
+func errorResult(msg string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf("Error: %s", msg)}},
+	}
+}
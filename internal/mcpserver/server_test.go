@@ -0,0 +1,57 @@
+package mcpserver
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+type echoParams struct {
+	Text string `json:"text" description:"Text to echo"`
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	var gotSession string
+	echo := fantasy.NewAgentTool("echo", "Echo the text back",
+		func(ctx context.Context, params echoParams, _ fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			gotSession = tools.GetSessionFromContext(ctx)
+			if params.Text == "" {
+				return fantasy.NewTextErrorResponse("text is required"), nil
+			}
+			return fantasy.NewTextResponse(params.Text), nil
+		})
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := New("test", "session-1", []fantasy.AgentTool{echo}).Connect(t.Context(), serverTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { serverSession.Close() })
+	client, err := mcp.NewClient(&mcp.Implementation{Name: "client"}, nil).Connect(t.Context(), clientTransport, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	list, err := client.ListTools(t.Context(), nil)
+	require.NoError(t, err)
+	require.Len(t, list.Tools, 1)
+	require.Equal(t, "echo", list.Tools[0].Name)
+	require.Equal(t, "Echo the text back", list.Tools[0].Description)
+	schema, ok := list.Tools[0].InputSchema.(map[string]any)
+	require.True(t, ok)
+	require.Contains(t, schema["properties"], "text")
+
+	result, err := client.CallTool(t.Context(), &mcp.CallToolParams{Name: "echo", Arguments: map[string]any{"text": "hi"}})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, "hi", result.Content[0].(*mcp.TextContent).Text)
+	require.Equal(t, "session-1", gotSession)
+
+	result, err = client.CallTool(t.Context(), &mcp.CallToolParams{Name: "echo", Arguments: map[string]any{}})
+	require.NoError(t, err)
+	require.True(t, result.IsError)
+	require.Equal(t, "text is required", result.Content[0].(*mcp.TextContent).Text)
+}
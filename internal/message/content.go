@@ -171,6 +171,9 @@ type Message struct {
 	CreatedAt        int64
 	UpdatedAt        int64
 	IsSummaryMessage bool
+	// EphemeralThinking keeps reasoning in memory and in published
+	// updates but leaves it out of what is stored. It is not persisted.
+	EphemeralThinking bool
 }
 
 func (m *Message) Content() TextContent {
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/crush/internal/db"
 	"github.com/charmbracelet/crush/internal/pubsub"
@@ -26,6 +28,9 @@ type CreateMessageParams struct {
 	Model            string
 	Provider         string
 	IsSummaryMessage bool
+	// EphemeralThinking sets [Message.EphemeralThinking] on the created
+	// message.
+	EphemeralThinking bool
 }
 
 // Service is the public interface to the message store.
@@ -104,6 +109,9 @@ type service struct {
 	*pubsub.Broker[Message]
 	q        db.Querier
 	debounce time.Duration
+	// maxPartBytes caps the stored size of each part's content. 0 stores
+	// parts in full.
+	maxPartBytes int
 
 	mu      sync.Mutex
 	pending map[string]*pendingState
@@ -121,6 +129,17 @@ func WithDebounce(d time.Duration) ServiceOption {
 	}
 }
 
+// WithMaxPartBytes caps the stored size of the text, reasoning and tool
+// result content of each part at n bytes. Longer content is cut and ends
+// with a marker giving its full size; the message returned and published
+// by the service keeps the full content. Zero or a negative value stores
+// parts in full.
+func WithMaxPartBytes(n int) ServiceOption {
+	return func(s *service) {
+		s.maxPartBytes = n
+	}
+}
+
 func NewService(q db.Querier, opts ...ServiceOption) Service {
 	s := &service{
 		Broker:   pubsub.NewBroker[Message](),
@@ -165,7 +184,7 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 			Reason: "stop",
 		})
 	}
-	partsJSON, err := marshalParts(params.Parts)
+	partsJSON, err := marshalParts(truncateForStorage(params.Parts, s.maxPartBytes))
 	if err != nil {
 		return Message{}, err
 	}
@@ -189,6 +208,10 @@ func (s *service) Create(ctx context.Context, sessionID string, params CreateMes
 	if err != nil {
 		return Message{}, err
 	}
+	message.EphemeralThinking = params.EphemeralThinking
+	if s.maxPartBytes > 0 {
+		message.Parts = params.Parts
+	}
 	// Clone the message before publishing to avoid race conditions with
 	// concurrent modifications to the Parts slice.
 	s.Publish(pubsub.CreatedEvent, message.Clone())
@@ -391,7 +414,11 @@ func (s *service) flushOne(ctx context.Context, id string, syncCaller bool) erro
 // write performs the unguarded SQL write + UpdatedAt stamp. Caller
 // owns publishing.
 func (s *service) write(ctx context.Context, msg Message) error {
-	parts, err := marshalParts(msg.Parts)
+	stored := msg.Parts
+	if msg.EphemeralThinking {
+		stored = withoutReasoning(stored)
+	}
+	parts, err := marshalParts(truncateForStorage(stored, s.maxPartBytes))
 	if err != nil {
 		return err
 	}
@@ -410,6 +437,73 @@ func (s *service) write(ctx context.Context, msg Message) error {
 	return nil
 }
 
+// withoutReasoning returns parts without their reasoning content.
+func withoutReasoning(parts []ContentPart) []ContentPart {
+	kept := make([]ContentPart, 0, len(parts))
+	for _, part := range parts {
+		if _, ok := part.(ReasoningContent); !ok {
+			kept = append(kept, part)
+		}
+	}
+	return kept
+}
+
+// truncateForStorage returns parts with any text, reasoning or tool result
+// content longer than limit bytes cut to fit and ended with
+// [storageTruncatedMarker]. Tool call inputs and binary data are kept in
+// full since a cut copy could not be decoded. parts itself is left
+// untouched; a limit of 0 or less returns it as is.
+func truncateForStorage(parts []ContentPart, limit int) []ContentPart {
+	if limit <= 0 {
+		return parts
+	}
+	var out []ContentPart
+	for i, part := range parts {
+		var cut ContentPart
+		switch p := part.(type) {
+		case TextContent:
+			if len(p.Text) > limit {
+				p.Text = truncateContent(p.Text, limit)
+				cut = p
+			}
+		case ReasoningContent:
+			if len(p.Thinking) > limit {
+				p.Thinking = truncateContent(p.Thinking, limit)
+				cut = p
+			}
+		case ToolResult:
+			if len(p.Content) > limit {
+				p.Content = truncateContent(p.Content, limit)
+				cut = p
+			}
+		}
+		if cut == nil {
+			continue
+		}
+		if out == nil {
+			out = slices.Clone(parts)
+		}
+		out[i] = cut
+	}
+	if out == nil {
+		return parts
+	}
+	return out
+}
+
+// storageTruncatedMarker ends content that was cut to fit the storage
+// limit. The placeholder is the content's full size in bytes.
+const storageTruncatedMarker = "\n\n[truncated for storage: %d bytes in total]"
+
+// truncateContent cuts s to at most limit bytes on a rune boundary and
+// appends [storageTruncatedMarker].
+func truncateContent(s string, limit int) string {
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit] + fmt.Sprintf(storageTruncatedMarker, len(s))
+}
+
 // shouldFlushNow returns true when next represents a structural
 // change that must not be silently coalesced: the message just
 // finished, the tool-call set grew, a tool call transitioned to
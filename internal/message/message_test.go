@@ -2,6 +2,7 @@ package message
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -220,6 +221,70 @@ func TestUpdate_ReasoningEndFlushes(t *testing.T) {
 	require.NotZero(t, got.ReasoningContent().FinishedAt)
 }
 
+func TestUpdate_EphemeralThinkingIsPublishedButNotStored(t *testing.T) {
+	t.Parallel()
+
+	svc, sessionID := newTestService(t, WithDebounce(0))
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := collect(ctx, svc.Subscribe(ctx))
+
+	msg, err := svc.Create(t.Context(), sessionID, CreateMessageParams{Role: Assistant, EphemeralThinking: true})
+	require.NoError(t, err)
+	require.True(t, msg.EphemeralThinking)
+
+	msg.AppendReasoningContent("hmm")
+	msg.FinishThinking()
+	msg.AppendContent("answer")
+	msg.AddFinish(FinishReasonEndTurn, "", "")
+	require.NoError(t, svc.Update(t.Context(), msg))
+
+	got, err := svc.Get(t.Context(), msg.ID)
+	require.NoError(t, err)
+	require.Empty(t, got.ReasoningContent().Thinking)
+	require.Equal(t, "answer", got.Content().Text)
+
+	require.Eventually(t, func() bool {
+		for _, ev := range events.snapshot() {
+			if ev.Type == pubsub.UpdatedEvent && ev.Payload.ReasoningContent().Thinking == "hmm" {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMaxPartBytes_TruncatesStoredCopyOnly(t *testing.T) {
+	t.Parallel()
+
+	svc, sessionID := newTestService(t, WithDebounce(0), WithMaxPartBytes(8))
+
+	long := strings.Repeat("é", 10)
+	msg, err := svc.Create(t.Context(), sessionID, CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: long}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, long, msg.Content().Text)
+
+	got, err := svc.Get(t.Context(), msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "éééé\n\n[truncated for storage: 20 bytes in total]", got.Content().Text)
+
+	msg, err = svc.Create(t.Context(), sessionID, CreateMessageParams{Role: Tool})
+	require.NoError(t, err)
+	call := ToolCall{ID: "call", Name: "view", Input: `{"file_path":"main.go"}`, Finished: true}
+	msg.AddToolResult(ToolResult{ToolCallID: call.ID, Name: call.Name, Content: "0123456789"})
+	msg.AddToolCall(call)
+	require.NoError(t, svc.Update(t.Context(), msg))
+	require.Equal(t, "0123456789", msg.ToolResults()[0].Content)
+
+	got, err = svc.Get(t.Context(), msg.ID)
+	require.NoError(t, err)
+	require.Equal(t, "01234567\n\n[truncated for storage: 10 bytes in total]", got.ToolResults()[0].Content)
+	require.Equal(t, call.Input, got.ToolCalls()[0].Input)
+}
+
 func TestFlush_DrainsPendingDebouncedUpdates(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@ package permission
 
 import (
 	"context"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
@@ -69,6 +70,13 @@ type Service interface {
 	// pending request; false if the request had already been resolved
 	// (e.g., by another concurrent caller) or is unknown.
 	GrantPersistent(permission PermissionRequest) bool
+	// GrantTool grants a permission request and approves every later
+	// request of the same tool in the session, whatever its action or
+	// path. It returns true if this call resolved the pending request.
+	GrantTool(permission PermissionRequest) bool
+	// ResetSession forgets the grants made for the rest of a session by
+	// GrantPersistent and GrantTool. It returns how many were forgotten.
+	ResetSession(sessionID string) int
 	// Grant grants a permission request. It returns true if this call
 	// actually resolved the pending request; false if the request had
 	// already been resolved or is unknown.
@@ -95,9 +103,12 @@ type PermissionKey struct {
 type permissionService struct {
 	*pubsub.Broker[PermissionRequest]
 
-	notificationBroker    *pubsub.Broker[PermissionNotification]
-	workingDir            string
-	sessionPermissions    *csync.Map[PermissionKey, bool]
+	notificationBroker *pubsub.Broker[PermissionNotification]
+	workingDir         string
+	sessionPermissions *csync.Map[PermissionKey, bool]
+	// sessionTools holds the tools granted for a whole session, keyed
+	// by session ID and tool name only.
+	sessionTools          *csync.Map[PermissionKey, bool]
 	pendingRequests       *csync.Map[string, chan bool]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
@@ -170,6 +181,29 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) bool {
 	})
 }
 
+func (s *permissionService) GrantTool(permission PermissionRequest) bool {
+	return s.resolve(permission, true, false, func() {
+		s.sessionTools.Set(PermissionKey{
+			SessionID: permission.SessionID,
+			ToolName:  permission.ToolName,
+		}, true)
+		slog.Info("Tool allowed for the session", "session_id", permission.SessionID, "tool", permission.ToolName)
+	})
+}
+
+func (s *permissionService) ResetSession(sessionID string) int {
+	var n int
+	for _, grants := range []*csync.Map[PermissionKey, bool]{s.sessionPermissions, s.sessionTools} {
+		for key := range grants.Copy() {
+			if key.SessionID == sessionID {
+				grants.Del(key)
+				n++
+			}
+		}
+	}
+	return n
+}
+
 func (s *permissionService) Grant(permission PermissionRequest) bool {
 	return s.resolve(permission, true, false, nil)
 }
@@ -245,12 +279,16 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		Params:      opts.Params,
 	}
 
+	_, toolGranted := s.sessionTools.Get(PermissionKey{
+		SessionID: permission.SessionID,
+		ToolName:  permission.ToolName,
+	})
 	if _, ok := s.sessionPermissions.Get(PermissionKey{
 		SessionID: permission.SessionID,
 		ToolName:  permission.ToolName,
 		Action:    permission.Action,
 		Path:      permission.Path,
-	}); ok {
+	}); ok || toolGranted {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -301,6 +339,7 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
 		workingDir:          workingDir,
 		sessionPermissions:  csync.NewMap[PermissionKey, bool](),
+		sessionTools:        csync.NewMap[PermissionKey, bool](),
 		autoApproveSessions: make(map[string]bool),
 		allowedTools:        allowedTools,
 		pendingRequests:     csync.NewMap[string, chan bool](),
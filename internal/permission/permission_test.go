@@ -613,3 +613,57 @@ func TestPermissionService_ResolveIdempotency(t *testing.T) {
 		}
 	})
 }
+
+func TestPermissionService_GrantTool(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{})
+	events := service.Subscribe(t.Context())
+
+	// request runs req and resolves its prompt, if any, with resolve.
+	request := func(req CreatePermissionRequest, resolve func(PermissionRequest) bool) (granted, prompted bool) {
+		done := make(chan bool, 1)
+		go func() {
+			granted, _ := service.Request(t.Context(), req)
+			done <- granted
+		}()
+		select {
+		case granted := <-done:
+			return granted, false
+		case event := <-events:
+			resolve(event.Payload)
+			return <-done, true
+		}
+	}
+
+	granted, prompted := request(CreatePermissionRequest{
+		SessionID: "session1",
+		ToolName:  "bash",
+		Action:    "execute",
+		Path:      "/tmp",
+	}, service.GrantTool)
+	require.True(t, prompted)
+	require.True(t, granted)
+
+	// Another call of the tool in the session, with another action and
+	// path, is approved without a prompt.
+	granted, prompted = request(CreatePermissionRequest{
+		SessionID: "session1",
+		ToolName:  "bash",
+		Action:    "kill",
+		Path:      "/",
+	}, service.Deny)
+	require.False(t, prompted)
+	require.True(t, granted)
+
+	// Other tools and other sessions still ask.
+	_, prompted = request(CreatePermissionRequest{SessionID: "session1", ToolName: "edit", Action: "write", Path: "/tmp"}, service.Deny)
+	require.True(t, prompted)
+	granted, prompted = request(CreatePermissionRequest{SessionID: "session2", ToolName: "bash", Action: "execute", Path: "/tmp"}, service.Deny)
+	require.True(t, prompted)
+	require.False(t, granted)
+
+	require.Equal(t, 0, service.ResetSession("session2"))
+	require.Equal(t, 1, service.ResetSession("session1"))
+	granted, prompted = request(CreatePermissionRequest{SessionID: "session1", ToolName: "bash", Action: "execute", Path: "/tmp"}, service.Deny)
+	require.True(t, prompted)
+	require.False(t, granted)
+}
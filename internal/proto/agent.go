@@ -38,7 +38,8 @@ type AgentEvent struct {
 	// caller set one.
 	RunID string `json:"run_id,omitempty"`
 
-	// When summarizing.
+	// When summarizing. Progress also carries the status of a provider
+	// retry.
 	SessionID    string `json:"session_id,omitempty"`
 	SessionTitle string `json:"session_title,omitempty"`
 	Progress     string `json:"progress,omitempty"`
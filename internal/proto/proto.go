@@ -176,9 +176,10 @@ func (a AgentSession) IsZero() bool {
 type PermissionAction string
 
 const (
-	PermissionAllow           PermissionAction = "allow"
-	PermissionAllowForSession PermissionAction = "allow_session"
-	PermissionDeny            PermissionAction = "deny"
+	PermissionAllow               PermissionAction = "allow"
+	PermissionAllowForSession     PermissionAction = "allow_session"
+	PermissionAllowToolForSession PermissionAction = "allow_tool_session"
+	PermissionDeny                PermissionAction = "deny"
 )
 
 // MarshalText implements the [encoding.TextMarshaler] interface.
@@ -268,6 +269,12 @@ type PermissionSkipRequest struct {
 	Skip bool `json:"skip"`
 }
 
+// PermissionResetResponse reports how many session grants a reset
+// forgot.
+type PermissionResetResponse struct {
+	Cleared int `json:"cleared"`
+}
+
 // LSPEventType represents the type of LSP event.
 type LSPEventType string
 
@@ -45,6 +45,8 @@ type BashResponseMetadata struct {
 // DiagnosticsParams represents the parameters for the diagnostics tool.
 type DiagnosticsParams struct {
 	FilePath string `json:"file_path"`
+	Path     string `json:"path,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 }
 
 const DownloadToolName = "download"
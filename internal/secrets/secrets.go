@@ -0,0 +1,78 @@
+// Package secrets detects and masks credentials in text, such as tool
+// output that is about to be stored and sent to a model provider.
+package secrets
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// Mask replaces every secret found by a [Scrubber].
+const Mask = "[REDACTED]"
+
+// CustomKind is the kind reported for matches of user-supplied patterns.
+const CustomKind = "custom"
+
+type pattern struct {
+	kind string
+	re   *regexp.Regexp
+}
+
+// builtinPatterns match common credential formats. When a pattern has a
+// capturing group only the group is masked, so the surrounding label stays
+// readable.
+var builtinPatterns = []pattern{
+	{"private_key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"aws_secret_key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{"bearer_token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{16,}=*)`)},
+	{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"api_key", regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+}
+
+// Scrubber masks secrets in text.
+type Scrubber struct {
+	patterns []pattern
+}
+
+// New returns a Scrubber for the built-in patterns and the given extra
+// regular expressions. It returns an error if an extra pattern does not
+// compile.
+func New(extra []string) (*Scrubber, error) {
+	patterns := slices.Clone(builtinPatterns)
+	for _, expr := range extra {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("secret pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, pattern{CustomKind, re})
+	}
+	return &Scrubber{patterns: patterns}, nil
+}
+
+// Scrub returns text with every secret replaced by [Mask], along with the
+// sorted kinds of the secrets that were found.
+func (s *Scrubber) Scrub(text string) (string, []string) {
+	var kinds []string
+	for _, p := range s.patterns {
+		found := false
+		text = p.re.ReplaceAllStringFunc(text, func(match string) string {
+			found = true
+			if p.re.NumSubexp() == 0 {
+				return Mask
+			}
+			loc := p.re.FindStringSubmatchIndex(match)
+			if loc == nil || loc[2] < 0 {
+				return Mask
+			}
+			return match[:loc[2]] + Mask + match[loc[3]:]
+		})
+		if found && !slices.Contains(kinds, p.kind) {
+			kinds = append(kinds, p.kind)
+		}
+	}
+	slices.Sort(kinds)
+	return text, kinds
+}
@@ -121,9 +121,12 @@ func wrapEvent(ev any) *pubsub.Payload {
 			RunID:        e.Payload.RunID,
 			Type:         proto.AgentEventType(e.Payload.Type),
 		}
-		if e.Payload.Type == notify.TypeAgentError {
+		switch e.Payload.Type {
+		case notify.TypeAgentError:
 			payload.Type = proto.AgentEventTypeError
 			payload.Error = errors.New(e.Payload.Message)
+		case notify.TypeProviderRetry:
+			payload.Progress = e.Payload.Message
 		}
 		return envelope(pubsub.PayloadTypeAgentEvent, pubsub.Event[proto.AgentEvent]{
 			Type:    e.Type,
@@ -204,6 +207,7 @@ func sessionToProto(s session.Session) proto.Session {
 		CompletionTokens: s.CompletionTokens,
 		Cost:             s.Cost,
 		Todos:            todosToProto(s.Todos),
+		Pinned:           s.Pinned,
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
 	}
@@ -1113,6 +1113,29 @@ func (c *controllerV1) handlePostWorkspacePermissionsSkip(w http.ResponseWriter,
 	}
 }
 
+// handlePostWorkspaceSessionPermissionsReset forgets the permissions
+// granted for the rest of a session.
+//
+//	@Summary		Reset session permissions
+//	@Tags			permissions
+//	@Produce		json
+//	@Param			id	path		string	true	"Workspace ID"
+//	@Param			sid	path		string	true	"Session ID"
+//	@Success		200	{object}	proto.PermissionResetResponse
+//	@Failure		404	{object}	proto.Error
+//	@Failure		500	{object}	proto.Error
+//	@Router			/workspaces/{id}/sessions/{sid}/permissions/reset [post]
+func (c *controllerV1) handlePostWorkspaceSessionPermissionsReset(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sid := r.PathValue("sid")
+	cleared, err := c.backend.ResetSessionPermissions(id, sid)
+	if err != nil {
+		c.handleError(w, r, err)
+		return
+	}
+	jsonEncode(w, proto.PermissionResetResponse{Cleared: cleared})
+}
+
 // handleGetWorkspacePermissionsSkip returns whether permission prompts are skipped.
 //
 //	@Summary		Get skip permissions status
@@ -0,0 +1,56 @@
+package session
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// PrunePolicy describes which sessions are eligible for pruning. A zero
+// value for either limit disables it.
+type PrunePolicy struct {
+	// MaxSessions is the number of most recently updated sessions to keep.
+	MaxSessions int
+	// MaxAge prunes sessions that have not been updated within this
+	// duration.
+	MaxAge time.Duration
+}
+
+// IsZero reports whether the policy has no limits set, in which case it
+// would never prune anything.
+func (p PrunePolicy) IsZero() bool {
+	return p.MaxSessions <= 0 && p.MaxAge <= 0
+}
+
+// PruneCandidates returns the sessions in list that fall outside policy,
+// most recently updated first. Only top-level sessions are considered;
+// child sessions are removed together with their parent. Pinned sessions
+// are never pruned and do not count towards MaxSessions.
+func PruneCandidates(list []Session, policy PrunePolicy, now time.Time) []Session {
+	if policy.IsZero() {
+		return nil
+	}
+
+	sorted := slices.Clone(list)
+	slices.SortStableFunc(sorted, func(a, b Session) int {
+		return cmp.Compare(b.UpdatedAt, a.UpdatedAt)
+	})
+
+	var (
+		kept       int
+		candidates []Session
+	)
+	for _, s := range sorted {
+		if s.ParentSessionID != "" || s.Pinned {
+			continue
+		}
+		tooMany := policy.MaxSessions > 0 && kept >= policy.MaxSessions
+		tooOld := policy.MaxAge > 0 && now.Sub(time.Unix(s.UpdatedAt, 0)) > policy.MaxAge
+		if tooMany || tooOld {
+			candidates = append(candidates, s)
+			continue
+		}
+		kept++
+	}
+	return candidates
+}
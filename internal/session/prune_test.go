@@ -0,0 +1,61 @@
+package session
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_000_000, 0)
+	daysAgo := func(n int) int64 {
+		return now.Add(-time.Duration(n) * 24 * time.Hour).Unix()
+	}
+	list := []Session{
+		{ID: "old", UpdatedAt: daysAgo(40)},
+		{ID: "new", UpdatedAt: daysAgo(1)},
+		{ID: "mid", UpdatedAt: daysAgo(10)},
+		{ID: "child", ParentSessionID: "old", UpdatedAt: daysAgo(50)},
+	}
+	ids := func(list []Session) []string {
+		var out []string
+		for _, s := range list {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+
+	t.Run("zero policy prunes nothing", func(t *testing.T) {
+		t.Parallel()
+		require.Empty(t, PruneCandidates(list, PrunePolicy{}, now))
+	})
+
+	t.Run("max sessions keeps most recent", func(t *testing.T) {
+		t.Parallel()
+		got := PruneCandidates(list, PrunePolicy{MaxSessions: 1}, now)
+		require.Equal(t, []string{"mid", "old"}, ids(got))
+	})
+
+	t.Run("max age", func(t *testing.T) {
+		t.Parallel()
+		got := PruneCandidates(list, PrunePolicy{MaxAge: 30 * 24 * time.Hour}, now)
+		require.Equal(t, []string{"old"}, ids(got))
+	})
+
+	t.Run("pinned sessions are exempt", func(t *testing.T) {
+		t.Parallel()
+		pinned := append(slices.Clone(list), Session{ID: "pinned", Pinned: true, UpdatedAt: daysAgo(100)})
+		got := PruneCandidates(pinned, PrunePolicy{MaxSessions: 1, MaxAge: 30 * 24 * time.Hour}, now)
+		require.Equal(t, []string{"mid", "old"}, ids(got))
+	})
+
+	t.Run("both limits", func(t *testing.T) {
+		t.Parallel()
+		got := PruneCandidates(list, PrunePolicy{MaxSessions: 2, MaxAge: 5 * 24 * time.Hour}, now)
+		require.Equal(t, []string{"mid", "old"}, ids(got))
+	})
+}
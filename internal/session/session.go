@@ -58,6 +58,7 @@ type Session struct {
 	SummaryMessageID string
 	Cost             float64
 	Todos            []Todo
+	Pinned           bool
 	CreatedAt        int64
 	UpdatedAt        int64
 }
@@ -73,6 +74,8 @@ type Service interface {
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
 	Rename(ctx context.Context, id string, title string) error
+	SetPinned(ctx context.Context, id string, pinned bool) error
+	AddCost(ctx context.Context, id string, cost float64) error
 	Delete(ctx context.Context, id string) error
 
 	// Agent tool session management
@@ -154,6 +157,13 @@ func (s *service) Delete(ctx context.Context, id string) error {
 	if err = qtx.DeleteSessionFiles(ctx, dbSession.ID); err != nil {
 		return fmt.Errorf("deleting session files: %w", err)
 	}
+	// Child sessions (agent tool and title sessions) are useless without
+	// their parent. Nested agents create grandchildren, so every
+	// descendant goes. Their messages and files are removed by the
+	// foreign key cascade.
+	if err = qtx.DeleteChildSessions(ctx, sql.NullString{String: dbSession.ID, Valid: true}); err != nil {
+		return fmt.Errorf("deleting child sessions: %w", err)
+	}
 	if err = qtx.DeleteSession(ctx, dbSession.ID); err != nil {
 		return fmt.Errorf("deleting session: %w", err)
 	}
@@ -249,6 +259,36 @@ func (s *service) Rename(ctx context.Context, id string, title string) error {
 	return nil
 }
 
+// SetPinned pins or unpins a session. Pinned sessions are listed first and
+// are never pruned.
+func (s *service) SetPinned(ctx context.Context, id string, pinned bool) error {
+	var value int64
+	if pinned {
+		value = 1
+	}
+	if err := s.q.SetSessionPinned(ctx, db.SetSessionPinnedParams{
+		ID:     id,
+		Pinned: value,
+	}); err != nil {
+		return err
+	}
+	s.publishSessionUpdate(ctx, id)
+	return nil
+}
+
+// AddCost atomically adds cost to a session's running total. Use it instead
+// of Get and Save when other writers may update the session concurrently.
+func (s *service) AddCost(ctx context.Context, id string, cost float64) error {
+	if err := s.q.AddSessionCost(ctx, db.AddSessionCostParams{
+		ID:   id,
+		Cost: cost,
+	}); err != nil {
+		return err
+	}
+	s.publishSessionUpdate(ctx, id)
+	return nil
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -310,6 +350,7 @@ func (s *service) fromDBItem(item db.Session) Session {
 		SummaryMessageID: item.SummaryMessageID.String,
 		Cost:             item.Cost,
 		Todos:            todos,
+		Pinned:           item.Pinned != 0,
 		CreatedAt:        item.CreatedAt,
 		UpdatedAt:        item.UpdatedAt,
 	}
@@ -79,3 +79,68 @@ func TestEstimatedUsageStateCanBeClearedByExplicitSave(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, refetched.EstimatedUsage)
 }
+
+func TestDeleteRemovesChildSessions(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+
+	sessions := NewService(db.New(conn), conn)
+
+	parent, err := sessions.Create(t.Context(), "parent")
+	require.NoError(t, err)
+	child, err := sessions.CreateTaskSession(t.Context(), "tool-call", parent.ID, "child")
+	require.NoError(t, err)
+	// A nested agent's session hangs off the child.
+	grandchild, err := sessions.CreateTaskSession(t.Context(), "nested-tool-call", child.ID, "grandchild")
+	require.NoError(t, err)
+	other, err := sessions.Create(t.Context(), "other")
+	require.NoError(t, err)
+
+	require.NoError(t, sessions.Delete(t.Context(), parent.ID))
+
+	_, err = sessions.Get(t.Context(), child.ID)
+	require.Error(t, err)
+	_, err = sessions.Get(t.Context(), grandchild.ID)
+	require.Error(t, err)
+	_, err = sessions.Get(t.Context(), other.ID)
+	require.NoError(t, err)
+}
+
+func TestPinnedSessionsListedFirst(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Cleanup(func() {
+		require.NoError(t, db.Release(dataDir))
+		db.ResetPool()
+	})
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+
+	sessions := NewService(db.New(conn), conn)
+
+	first, err := sessions.Create(t.Context(), "first")
+	require.NoError(t, err)
+	_, err = sessions.Create(t.Context(), "second")
+	require.NoError(t, err)
+
+	require.NoError(t, sessions.SetPinned(t.Context(), first.ID, true))
+
+	list, err := sessions.List(t.Context())
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, first.ID, list[0].ID)
+	require.True(t, list[0].Pinned)
+	require.False(t, list[1].Pinned)
+
+	require.NoError(t, sessions.SetPinned(t.Context(), first.ID, false))
+
+	fetched, err := sessions.Get(t.Context(), first.ID)
+	require.NoError(t, err)
+	require.False(t, fetched.Pinned)
+}
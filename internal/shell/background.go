@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"slices"
 	"sync"
 	"sync/atomic"
@@ -87,6 +88,13 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 
 // Start creates and starts a new background shell with the given command.
 func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+	return m.StartWithEnv(ctx, workingDir, nil, blockFuncs, command, description)
+}
+
+// StartWithEnv is like [BackgroundShellManager.Start], but runs the command
+// with env, a list of "KEY=value" pairs, added to the process environment.
+// The variables only apply to this shell.
+func (m *BackgroundShellManager) StartWithEnv(ctx context.Context, workingDir string, env []string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
@@ -94,8 +102,13 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 
 	id := fmt.Sprintf("%03X", idCounter.Add(1))
 
+	var shellEnv []string
+	if len(env) > 0 {
+		shellEnv = append(os.Environ(), env...)
+	}
 	shell := NewShell(&Options{
 		WorkingDir: workingDir,
+		Env:        shellEnv,
 		BlockFuncs: blockFuncs,
 	})
 
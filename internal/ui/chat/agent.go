@@ -2,6 +2,8 @@ package chat
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
@@ -22,6 +24,9 @@ type NestedToolContainer interface {
 	NestedTools() []ToolMessageItem
 	SetNestedTools(tools []ToolMessageItem)
 	AddNestedTool(tool ToolMessageItem)
+	// SetNestedToolsLimit sets how many nested tools are shown while the
+	// item is collapsed; 0 shows all of them.
+	SetNestedToolsLimit(limit int)
 }
 
 // AgentToolMessageItem is a message item that represents an agent tool call.
@@ -29,6 +34,7 @@ type AgentToolMessageItem struct {
 	*baseToolMessageItem
 
 	nestedTools []ToolMessageItem
+	nestedLimit int
 }
 
 var (
@@ -118,6 +124,12 @@ func (a *AgentToolMessageItem) AddNestedTool(tool ToolMessageItem) {
 	a.Bump()
 }
 
+// SetNestedToolsLimit sets how many nested tools are shown while collapsed.
+func (a *AgentToolMessageItem) SetNestedToolsLimit(limit int) {
+	a.nestedLimit = limit
+	a.Bump()
+}
+
 // AgentToolRenderContext renders agent tool messages.
 type AgentToolRenderContext struct {
 	agent *AgentToolMessageItem
@@ -138,7 +150,13 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 		prompt = strings.ReplaceAll(prompt, "\n", " ")
 	}
 
-	header := toolHeader(sty, opts.Status, "Agent", cappedWidth, opts)
+	var toolParams []string
+	if usage := subAgentUsage(opts); usage != "" {
+		toolParams = append(toolParams, usage)
+	}
+	toolParams = withSubAgentDepth(toolParams, opts)
+
+	header := toolHeader(sty, opts.Status, "Agent", cappedWidth, opts, toolParams...)
 	if opts.Compact {
 		return header
 	}
@@ -166,11 +184,7 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 
 	// Build tree with nested tool calls.
 	childTools := tree.Root(header)
-
-	for _, nestedTool := range r.agent.nestedTools {
-		childView := nestedTool.Render(remainingWidth)
-		childTools.Child(childView)
-	}
+	addNestedTools(sty, childTools, r.agent.nestedTools, r.agent.nestedLimit, remainingWidth, opts.ExpandedContent)
 
 	// Build parts.
 	var parts []string
@@ -192,6 +206,78 @@ func (r *AgentToolRenderContext) RenderTool(sty *styles.Styles, width int, opts
 	return result
 }
 
+// addNestedTools adds the rendered nested tools to root. Unless expanded,
+// only the last limit tools are shown, after a line counting the others,
+// so a sub-agent that makes many calls doesn't flood the chat.
+func addNestedTools(sty *styles.Styles, root *tree.Tree, nested []ToolMessageItem, limit, width int, expanded bool) {
+	if hidden := len(nested) - limit; !expanded && limit > 0 && hidden > 0 {
+		noun := "calls"
+		if hidden == 1 {
+			noun = "call"
+		}
+		root.Child(sty.Tool.ContentTruncation.Render(fmt.Sprintf("+%d more nested %s", hidden, noun)))
+		nested = nested[hidden:]
+	}
+	for _, nestedTool := range nested {
+		root.Child(nestedTool.Render(width))
+	}
+}
+
+// subAgentMetadata returns the metadata of a finished sub-agent call.
+func subAgentMetadata(opts *ToolRenderOpts) (agent.SubAgentResponseMetadata, bool) {
+	var meta agent.SubAgentResponseMetadata
+	if !opts.HasResult() || opts.Result.Metadata == "" {
+		return meta, false
+	}
+	if json.Unmarshal([]byte(opts.Result.Metadata), &meta) != nil {
+		return meta, false
+	}
+	return meta, true
+}
+
+// withSubAgentDepth adds the nesting depth of a finished sub-agent call to
+// the header params. Direct children of the top-level agent (depth 1) are
+// the common case and are left unmarked.
+func withSubAgentDepth(params []string, opts *ToolRenderOpts) []string {
+	meta, ok := subAgentMetadata(opts)
+	if !ok || meta.Depth <= 1 {
+		return params
+	}
+	depth := strconv.Itoa(meta.Depth)
+	if len(params) == 0 {
+		return []string{"depth " + depth}
+	}
+	return append(params, "depth", depth)
+}
+
+// subAgentUsage summarizes what a finished sub-agent call cost, or returns
+// an empty string when the result carries no usage.
+func subAgentUsage(opts *ToolRenderOpts) string {
+	meta, ok := subAgentMetadata(opts)
+	if !ok {
+		return ""
+	}
+	tokens := meta.InputTokens + meta.OutputTokens
+	if tokens == 0 && meta.Cost == 0 {
+		return ""
+	}
+	return fmt.Sprintf("$%.2f, %s tokens", meta.Cost, formatTokenCount(tokens))
+}
+
+// formatTokenCount abbreviates a token count, e.g. 12300 as 12.3K.
+func formatTokenCount(tokens int64) string {
+	var s string
+	switch {
+	case tokens >= 1_000_000:
+		s = fmt.Sprintf("%.1fM", float64(tokens)/1_000_000)
+	case tokens >= 1_000:
+		s = fmt.Sprintf("%.1fK", float64(tokens)/1_000)
+	default:
+		return fmt.Sprintf("%d", tokens)
+	}
+	return strings.Replace(s, ".0", "", 1)
+}
+
 // -----------------------------------------------------------------------------
 // Agentic Fetch Tool
 // -----------------------------------------------------------------------------
@@ -201,6 +287,7 @@ type AgenticFetchToolMessageItem struct {
 	*baseToolMessageItem
 
 	nestedTools []ToolMessageItem
+	nestedLimit int
 }
 
 var (
@@ -275,6 +362,12 @@ func (a *AgenticFetchToolMessageItem) AddNestedTool(tool ToolMessageItem) {
 	a.Bump()
 }
 
+// SetNestedToolsLimit sets how many nested tools are shown while collapsed.
+func (a *AgenticFetchToolMessageItem) SetNestedToolsLimit(limit int) {
+	a.nestedLimit = limit
+	a.Bump()
+}
+
 // AgenticFetchToolRenderContext renders agentic fetch tool messages.
 type AgenticFetchToolRenderContext struct {
 	fetch *AgenticFetchToolMessageItem
@@ -301,11 +394,16 @@ func (r *AgenticFetchToolRenderContext) RenderTool(sty *styles.Styles, width int
 		prompt = strings.ReplaceAll(prompt, "\n", " ")
 	}
 
-	// Build header with optional URL param.
+	// Build header with optional URL and usage params.
 	var toolParams []string
-	if params.URL != "" {
-		toolParams = append(toolParams, params.URL)
+	usage := subAgentUsage(opts)
+	switch {
+	case params.URL != "":
+		toolParams = append(toolParams, params.URL, "usage", usage)
+	case usage != "":
+		toolParams = append(toolParams, usage)
 	}
+	toolParams = withSubAgentDepth(toolParams, opts)
 
 	header := toolHeader(sty, opts.Status, "Agentic Fetch", cappedWidth, opts, toolParams...)
 	if opts.Compact {
@@ -335,11 +433,7 @@ func (r *AgenticFetchToolRenderContext) RenderTool(sty *styles.Styles, width int
 
 	// Build tree with nested tool calls.
 	childTools := tree.Root(header)
-
-	for _, nestedTool := range r.fetch.nestedTools {
-		childView := nestedTool.Render(remainingWidth)
-		childTools.Child(childView)
-	}
+	addNestedTools(sty, childTools, r.fetch.nestedTools, r.fetch.nestedLimit, remainingWidth, opts.ExpandedContent)
 
 	// Build parts.
 	var parts []string
@@ -0,0 +1,77 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentToolRendersNestingDepth(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	render := func(depth int) string {
+		input, err := json.Marshal(agent.AgentParams{Prompt: "find the bug"})
+		require.NoError(t, err)
+		meta, err := json.Marshal(agent.SubAgentResponseMetadata{Depth: depth, Cost: 0.5, InputTokens: 1200})
+		require.NoError(t, err)
+		opts := &ToolRenderOpts{
+			ToolCall: message.ToolCall{Name: agent.AgentToolName, Input: string(input), Finished: true},
+			Result:   &message.ToolResult{Content: "done", Metadata: string(meta)},
+			Status:   ToolStatusSuccess,
+			Compact:  true,
+		}
+		r := &AgentToolRenderContext{agent: &AgentToolMessageItem{}}
+		return ansi.Strip(r.RenderTool(&sty, 120, opts))
+	}
+
+	require.Contains(t, render(2), "depth=2")
+	require.NotContains(t, render(1), "depth")
+}
+
+func TestAgentToolCollapsesNestedTools(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	item := NewAgentToolMessageItem(&sty, message.ToolCall{}, nil, false)
+	for i := range 5 {
+		nested := NewToolMessageItem(&sty, "msg", message.ToolCall{
+			ID:       fmt.Sprintf("call-%d", i),
+			Name:     tools.GlobToolName,
+			Input:    fmt.Sprintf(`{"pattern":"pattern-%d"}`, i),
+			Finished: true,
+		}, &message.ToolResult{Content: "none"}, false)
+		item.AddNestedTool(nested)
+	}
+	item.SetNestedToolsLimit(2)
+
+	input, err := json.Marshal(agent.AgentParams{Prompt: "find the bug"})
+	require.NoError(t, err)
+	render := func(expanded bool) string {
+		opts := &ToolRenderOpts{
+			ToolCall:        message.ToolCall{Name: agent.AgentToolName, Input: string(input), Finished: true},
+			Result:          &message.ToolResult{Content: "done"},
+			Status:          ToolStatusSuccess,
+			ExpandedContent: expanded,
+		}
+		r := &AgentToolRenderContext{agent: item}
+		return ansi.Strip(r.RenderTool(&sty, 120, opts))
+	}
+
+	collapsed := render(false)
+	require.Contains(t, collapsed, "+3 more nested calls")
+	require.NotContains(t, collapsed, "pattern-2")
+	require.Contains(t, collapsed, "pattern-3")
+	require.Contains(t, collapsed, "pattern-4")
+
+	expanded := render(true)
+	require.NotContains(t, expanded, "more nested")
+	require.Contains(t, expanded, "pattern-0")
+}
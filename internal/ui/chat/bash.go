@@ -58,7 +58,7 @@ func (b *BashToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 	if meta.Background {
 		description := cmp.Or(meta.Description, params.Command)
 		content := "Command: " + params.Command + "\n" + opts.Result.Content
-		return renderJobTool(sty, opts, cappedWidth, "Start", meta.ShellID, description, content)
+		return renderJobTool(sty, opts, cappedWidth, "Start", meta.ShellID, description, content, 0)
 	}
 
 	// Regular bash command.
@@ -68,11 +68,18 @@ func (b *BashToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *
 	}
 	cmd = strings.ReplaceAll(cmd, "\t", "    ")
 	toolParams := []string{cmd}
+	if params.WorkingDir != "" {
+		toolParams = append(toolParams, "cwd", params.WorkingDir)
+	}
+	if len(params.Env) > 0 {
+		toolParams = append(toolParams, "env", strings.Join(params.Env, " "))
+	}
 	if params.RunInBackground {
 		toolParams = append(toolParams, "background", "true")
 	}
 
-	header := toolHeader(sty, opts.Status, "Bash", cappedWidth, opts, toolParams...)
+	exit := exitCodeMark(sty, meta.ExitStatus())
+	header := toolHeader(sty, opts.Status, "Bash", cappedWidth-lipgloss.Width(exit), opts, toolParams...) + exit
 	if opts.Compact {
 		return header
 	}
@@ -135,10 +142,12 @@ func (j *JobOutputToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 	}
 
 	var description string
+	var exitCode int
 	if opts.HasResult() && opts.Result.Metadata != "" {
 		var meta tools.JobOutputResponseMetadata
 		if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err == nil {
 			description = cmp.Or(meta.Description, meta.Command)
+			exitCode = meta.ExitCode
 		}
 	}
 
@@ -146,7 +155,7 @@ func (j *JobOutputToolRenderContext) RenderTool(sty *styles.Styles, width int, o
 	if opts.HasResult() {
 		content = opts.Result.Content
 	}
-	return renderJobTool(sty, opts, cappedWidth, "Output", params.ShellID, description, content)
+	return renderJobTool(sty, opts, cappedWidth, "Output", params.ShellID, description, content, exitCode)
 }
 
 // -----------------------------------------------------------------------------
@@ -197,13 +206,15 @@ func (j *JobKillToolRenderContext) RenderTool(sty *styles.Styles, width int, opt
 	if opts.HasResult() {
 		content = opts.Result.Content
 	}
-	return renderJobTool(sty, opts, cappedWidth, "Kill", params.ShellID, description, content)
+	return renderJobTool(sty, opts, cappedWidth, "Kill", params.ShellID, description, content, 0)
 }
 
 // renderJobTool renders a job-related tool with the common pattern:
 // header → nested check → early state → body.
-func renderJobTool(sty *styles.Styles, opts *ToolRenderOpts, width int, action, shellID, description, content string) string {
-	header := jobHeader(sty, opts.Status, action, shellID, description, width)
+// A non-zero exitCode is shown after the header.
+func renderJobTool(sty *styles.Styles, opts *ToolRenderOpts, width int, action, shellID, description, content string, exitCode int) string {
+	exit := exitCodeMark(sty, exitCode)
+	header := jobHeader(sty, opts.Status, action, shellID, description, width-lipgloss.Width(exit)) + exit
 	if opts.Compact {
 		return header
 	}
@@ -221,6 +232,15 @@ func renderJobTool(sty *styles.Styles, opts *ToolRenderOpts, width int, action,
 	return joinToolParts(header, body)
 }
 
+// exitCodeMark renders a non-zero exit code for a tool header, matching the
+// marker of user shell commands. It is empty for a zero exit code.
+func exitCodeMark(sty *styles.Styles, exitCode int) string {
+	if exitCode == 0 {
+		return ""
+	}
+	return " " + sty.Messages.ShellExitCode.Render(fmt.Sprintf("(exit %d)", exitCode))
+}
+
 // jobHeader builds a header for job-related tools.
 // Format: "● Job (Action) PID shellID description..."
 func jobHeader(sty *styles.Styles, status ToolStatus, action, shellID, description string, width int) string {
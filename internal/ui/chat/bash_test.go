@@ -0,0 +1,33 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBashToolRendersExitCode(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	render := func(exitCode int) string {
+		input, err := json.Marshal(tools.BashParams{Command: "make test"})
+		require.NoError(t, err)
+		meta, err := json.Marshal(tools.BashResponseMetadata{Output: "FAIL", ExitCode: new(exitCode)})
+		require.NoError(t, err)
+		opts := &ToolRenderOpts{
+			ToolCall: message.ToolCall{Name: tools.BashToolName, Input: string(input), Finished: true},
+			Result:   &message.ToolResult{Content: "FAIL", Metadata: string(meta)},
+			Status:   ToolStatusSuccess,
+		}
+		return ansi.Strip((&BashToolRenderContext{}).RenderTool(&sty, 80, opts))
+	}
+
+	require.Contains(t, render(2), "(exit 2)")
+	require.NotContains(t, render(0), "(exit")
+}
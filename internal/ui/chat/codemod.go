@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// CodemodToolMessageItem is a message item that represents a codemod tool
+// call.
+type CodemodToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*CodemodToolMessageItem)(nil)
+
+// NewCodemodToolMessageItem creates a new [CodemodToolMessageItem].
+func NewCodemodToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &CodemodToolRenderContext{}, canceled)
+}
+
+// CodemodToolRenderContext renders codemod tool messages as a summary of
+// the affected files followed by their combined diff.
+type CodemodToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (c *CodemodToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Codemod", opts.Anim, opts.Compact)
+	}
+
+	var params tools.CodemodParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	toolParams := []string{params.Pattern, "files", params.Files}
+	if params.Path != "" {
+		toolParams = append(toolParams, "path", fsext.PrettyPath(params.Path))
+	}
+	header := toolHeader(sty, opts.Status, "Codemod", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if !opts.HasResult() {
+		if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+			return joinToolParts(header, earlyState)
+		}
+		return header
+	}
+
+	var meta tools.CodemodResponseMetadata
+	if err := json.Unmarshal([]byte(opts.Result.Metadata), &meta); err != nil || len(meta.Files) == 0 {
+		if opts.Result.IsError {
+			return joinToolParts(header, toolErrorContent(sty, opts.Result, cappedWidth))
+		}
+		bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+		return joinToolParts(header, sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent)))
+	}
+
+	parts := []string{header, ""}
+	if opts.Result.IsError {
+		// Not applied: it needed confirmation or permission was denied.
+		errResult := *opts.Result
+		errResult.Content, _, _ = strings.Cut(errResult.Content, "\n")
+		parts = append(parts, toolErrorContent(sty, &errResult, cappedWidth), "")
+	}
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	parts = append(parts,
+		sty.Tool.Body.Render(toolOutputPlainContent(sty, codemodSummary(meta), bodyWidth, opts.ExpandedContent)),
+		"",
+		toolOutputDiffContentFromUnified(sty, meta.Diff, cappedWidth, opts.ExpandedContent),
+	)
+	return strings.Join(parts, "\n")
+}
+
+// codemodSummary lists the files a codemod changed with their line counts.
+func codemodSummary(meta tools.CodemodResponseMetadata) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d file(s), +%d -%d\n", len(meta.Files), meta.Additions, meta.Removals)
+	for _, f := range meta.Files {
+		fmt.Fprintf(&b, "%s  +%d -%d\n", fsext.PrettyPath(f.Path), f.Additions, f.Removals)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
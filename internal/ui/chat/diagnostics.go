@@ -2,6 +2,8 @@ package chat
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/fsext"
@@ -48,8 +50,15 @@ func (d *DiagnosticsToolRenderContext) RenderTool(sty *styles.Styles, width int,
 	if params.FilePath != "" {
 		mainParam = fsext.PrettyPath(params.FilePath)
 	}
+	toolParams := []string{mainParam}
+	if params.Path != "" {
+		toolParams = append(toolParams, "path", fsext.PrettyPath(params.Path))
+	}
+	if params.Scope != "" {
+		toolParams = append(toolParams, "scope", params.Scope)
+	}
 
-	header := toolHeader(sty, opts.Status, "Diagnostics", cappedWidth, opts, mainParam)
+	header := toolHeader(sty, opts.Status, "Diagnostics", cappedWidth, opts, toolParams...)
 	if opts.Compact {
 		return header
 	}
@@ -62,7 +71,64 @@ func (d *DiagnosticsToolRenderContext) RenderTool(sty *styles.Styles, width int,
 		return header
 	}
 
+	content := opts.Result.Content
+	var meta tools.DiagnosticsResponseMetadata
+	if json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil && len(meta.Diagnostics) > 0 {
+		content = groupDiagnostics(meta)
+	}
+
 	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
-	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, content, bodyWidth, opts.ExpandedContent))
 	return joinToolParts(header, body)
 }
+
+// diagnosticSeverities lists the severity names of
+// [tools.DiagnosticEntry] in display order, with their group titles.
+var diagnosticSeverities = []struct{ name, title string }{
+	{"Error", "Errors"},
+	{"Warn", "Warnings"},
+	{"Info", "Info"},
+	{"Hint", "Hints"},
+}
+
+// groupDiagnostics lists the diagnostics in meta grouped by severity, then
+// by file, keeping the order the tool reported them in.
+func groupDiagnostics(meta tools.DiagnosticsResponseMetadata) string {
+	var lines []string
+	for _, severity := range diagnosticSeverities {
+		var files []string
+		byFile := map[string][]tools.DiagnosticEntry{}
+		for _, d := range meta.Diagnostics {
+			if d.Severity != severity.name {
+				continue
+			}
+			if _, ok := byFile[d.Path]; !ok {
+				files = append(files, d.Path)
+			}
+			byFile[d.Path] = append(byFile[d.Path], d)
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		count := 0
+		for _, entries := range byFile {
+			count += len(entries)
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d)", severity.title, count))
+		for _, file := range files {
+			lines = append(lines, "  "+fsext.PrettyPath(file))
+			for _, d := range byFile[file] {
+				line := fmt.Sprintf("    %d:%d %s", d.Line, d.Column, strings.ReplaceAll(d.Message, "\n", " "))
+				if d.Source != "" {
+					line += " [" + d.Source + "]"
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	if more := meta.Total - len(meta.Diagnostics); more > 0 {
+		lines = append(lines, fmt.Sprintf("… and %d more", more))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	meta := tools.DiagnosticsResponseMetadata{
+		Diagnostics: []tools.DiagnosticEntry{
+			{Path: "/x/a.go", Line: 3, Column: 1, Severity: "Error", Source: "gopls", Message: "undefined: foo"},
+			{Path: "/x/b.go", Line: 7, Column: 2, Severity: "Error", Message: "missing return"},
+			{Path: "/x/a.go", Line: 9, Column: 4, Severity: "Error", Message: "unused\nvariable"},
+			{Path: "/x/a.go", Line: 1, Column: 1, Severity: "Warn", Message: "shadowed"},
+		},
+		Total: 6,
+	}
+
+	require.Equal(t, "Errors (3)\n"+
+		"  /x/a.go\n"+
+		"    3:1 undefined: foo [gopls]\n"+
+		"    9:4 unused variable\n"+
+		"  /x/b.go\n"+
+		"    7:2 missing return\n"+
+		"Warnings (1)\n"+
+		"  /x/a.go\n"+
+		"    1:1 shadowed\n"+
+		"… and 2 more", groupDiagnostics(meta))
+}
+
+func TestDiagnosticsToolRendersGroups(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	input, err := json.Marshal(tools.DiagnosticsParams{Path: "/x", Scope: tools.DiagnosticsScopeChanged})
+	require.NoError(t, err)
+	meta, err := json.Marshal(tools.DiagnosticsResponseMetadata{
+		Diagnostics: []tools.DiagnosticEntry{{Path: "/x/a.go", Line: 3, Column: 1, Severity: "Error", Message: "undefined: foo"}},
+		Total:       1,
+	})
+	require.NoError(t, err)
+	opts := &ToolRenderOpts{
+		ToolCall: message.ToolCall{Name: tools.DiagnosticsToolName, Input: string(input), Finished: true},
+		Result:   &message.ToolResult{Content: "<project_diagnostics>\nError: /x/a.go:3:1 [gopls] undefined: foo\n</project_diagnostics>", Metadata: string(meta)},
+		Status:   ToolStatusSuccess,
+	}
+
+	out := ansi.Strip((&DiagnosticsToolRenderContext{}).RenderTool(&sty, 120, opts))
+	require.Contains(t, out, "scope=changed")
+	require.Contains(t, out, "Errors (1)")
+	require.Contains(t, out, "3:1 undefined: foo")
+	require.NotContains(t, out, "<project_diagnostics>")
+}
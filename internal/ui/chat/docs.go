@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"encoding/json"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// DocsToolMessageItem is a message item that represents a docs tool call.
+type DocsToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*DocsToolMessageItem)(nil)
+
+// NewDocsToolMessageItem creates a new [DocsToolMessageItem].
+func NewDocsToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &DocsToolRenderContext{}, canceled)
+}
+
+// DocsToolRenderContext renders docs tool messages with the fetched
+// documentation snippet as the body.
+type DocsToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *DocsToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Docs", opts.Anim, opts.Compact)
+	}
+
+	var params tools.DocsParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	toolParams := []string{params.Command, "query", params.Query}
+	if opts.HasResult() && opts.Result.Metadata != "" {
+		var meta tools.DocsResponseMetadata
+		if json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil {
+			toolParams = append(toolParams, "source", meta.Source)
+		}
+	}
+
+	header := toolHeader(sty, opts.Status, "Docs", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"encoding/json"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/secrets"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// EnvEditToolMessageItem is a message item that represents an env_edit
+// tool call.
+type EnvEditToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*EnvEditToolMessageItem)(nil)
+
+// NewEnvEditToolMessageItem creates a new [EnvEditToolMessageItem].
+func NewEnvEditToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &EnvEditToolRenderContext{}, canceled)
+}
+
+// EnvEditToolRenderContext renders env_edit tool messages. The value of a
+// set is never shown.
+type EnvEditToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (e *EnvEditToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Env", opts.Anim, opts.Compact)
+	}
+
+	var params tools.EnvEditParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	toolParams := []string{params.Operation}
+	if params.Key != "" {
+		toolParams = append(toolParams, "key", params.Key)
+	}
+	if params.Operation == tools.EnvEditSet {
+		toolParams = append(toolParams, "value", secrets.Mask)
+	}
+	if params.FilePath != "" {
+		toolParams = append(toolParams, "file_path", fsext.PrettyPath(params.FilePath))
+	}
+
+	header := toolHeader(sty, opts.Status, "Env", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
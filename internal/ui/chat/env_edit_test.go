@@ -0,0 +1,28 @@
+package chat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvEditToolHidesValue(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	input, err := json.Marshal(tools.EnvEditParams{Operation: tools.EnvEditSet, Key: "API_KEY", Value: "sk-very-secret"})
+	require.NoError(t, err)
+	opts := &ToolRenderOpts{
+		ToolCall: message.ToolCall{Name: tools.EnvEditToolName, Input: string(input), Finished: true},
+		Result:   &message.ToolResult{Content: "Set API_KEY in .env"},
+		Status:   ToolStatusSuccess,
+	}
+	out := ansi.Strip((&EnvEditToolRenderContext{}).RenderTool(&sty, 120, opts))
+	require.Contains(t, out, "API_KEY")
+	require.NotContains(t, out, "sk-very-secret")
+}
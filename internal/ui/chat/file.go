@@ -351,6 +351,16 @@ func (d *DownloadToolRenderContext) RenderTool(sty *styles.Styles, width int, op
 	if params.Timeout != 0 {
 		toolParams = append(toolParams, "timeout", formatTimeout(params.Timeout))
 	}
+	if opts.HasResult() && !opts.Result.IsError {
+		var meta tools.DownloadResponseMetadata
+		if json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil {
+			if meta.Overwritten {
+				toolParams = append(toolParams, "file", "overwritten")
+			} else {
+				toolParams = append(toolParams, "file", "new")
+			}
+		}
+	}
 
 	header := toolHeader(sty, opts.Status, "Download", cappedWidth, opts, toolParams...)
 	if opts.Compact {
@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// IssueToolMessageItem is a message item that represents an issue tool call.
+type IssueToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*IssueToolMessageItem)(nil)
+
+// NewIssueToolMessageItem creates a new [IssueToolMessageItem].
+func NewIssueToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &IssueToolRenderContext{}, canceled)
+}
+
+// IssueToolRenderContext renders issue tool messages with the metadata of
+// the fetched issue as the body.
+type IssueToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *IssueToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Issue", opts.Anim, opts.Compact)
+	}
+
+	var params tools.IssueParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	header := toolHeader(sty, opts.Status, "Issue", cappedWidth, opts, params.URL)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	content := opts.Result.Content
+	var meta tools.IssueResponseMetadata
+	if json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil && meta.Number > 0 {
+		content = issueSummary(meta)
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// issueSummary lists the metadata of a fetched issue: its title, then its
+// kind, state, author and comment count, then its labels.
+func issueSummary(meta tools.IssueResponseMetadata) string {
+	sep := "#"
+	if meta.Kind == "merge request" {
+		sep = "!"
+	}
+	lines := []string{
+		fmt.Sprintf("%s%s%d %s", meta.Repo, sep, meta.Number, meta.Title),
+		fmt.Sprintf("%s · %s · @%s", meta.Kind, meta.State, meta.Author),
+	}
+	switch meta.Comments {
+	case 0:
+	case 1:
+		lines[1] += " · 1 comment"
+	default:
+		lines[1] += fmt.Sprintf(" · %d comments", meta.Comments)
+	}
+	if len(meta.Labels) > 0 {
+		lines = append(lines, "Labels: "+strings.Join(meta.Labels, ", "))
+	}
+	if meta.Truncated {
+		lines = append(lines, "(truncated)")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// LintToolMessageItem is a message item that represents a lint tool call.
+type LintToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LintToolMessageItem)(nil)
+
+// NewLintToolMessageItem creates a new [LintToolMessageItem].
+func NewLintToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LintToolRenderContext{}, canceled)
+}
+
+// LintToolRenderContext renders lint tool messages with the findings
+// grouped by severity and file.
+type LintToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *LintToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Lint", opts.Anim, opts.Compact)
+	}
+
+	var params tools.LintParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+
+	mainParam := "project"
+	if len(params.Paths) > 0 {
+		mainParam = strings.Join(params.Paths, " ")
+	}
+	toolParams := []string{mainParam}
+	if params.Linter != "" {
+		toolParams = append(toolParams, "linter", params.Linter)
+	}
+
+	header := toolHeader(sty, opts.Status, "Lint", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	content := opts.Result.Content
+	var meta tools.LintResponseMetadata
+	if json.Unmarshal([]byte(opts.Result.Metadata), &meta) == nil && len(meta.Findings) > 0 {
+		content = groupLintFindings(meta)
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// lintSeverities lists the severities of [tools.LintFinding] in display
+// order, with their group titles.
+var lintSeverities = []struct{ name, title string }{
+	{"error", "Errors"},
+	{"warning", "Warnings"},
+}
+
+// groupLintFindings lists the findings in meta grouped by severity, then
+// by file, keeping the order the linter reported them in.
+func groupLintFindings(meta tools.LintResponseMetadata) string {
+	var lines []string
+	for _, severity := range lintSeverities {
+		var files []string
+		byFile := map[string][]tools.LintFinding{}
+		count := 0
+		for _, f := range meta.Findings {
+			if f.Severity != severity.name {
+				continue
+			}
+			if _, ok := byFile[f.Path]; !ok {
+				files = append(files, f.Path)
+			}
+			byFile[f.Path] = append(byFile[f.Path], f)
+			count++
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s (%d)", severity.title, count))
+		for _, file := range files {
+			lines = append(lines, "  "+file)
+			for _, f := range byFile[file] {
+				line := fmt.Sprintf("    %d:%d %s", f.Line, f.Column, strings.ReplaceAll(f.Message, "\n", " "))
+				if f.Rule != "" {
+					line += " [" + f.Rule + "]"
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	if more := meta.Total - len(meta.Findings); more > 0 {
+		lines = append(lines, fmt.Sprintf("… and %d more", more))
+	}
+	return strings.Join(lines, "\n")
+}
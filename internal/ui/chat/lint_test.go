@@ -0,0 +1,31 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupLintFindings(t *testing.T) {
+	t.Parallel()
+
+	meta := tools.LintResponseMetadata{
+		Linter: "golangci-lint",
+		Findings: []tools.LintFinding{
+			{Path: "a.go", Line: 3, Column: 1, Rule: "errcheck", Severity: "error", Message: "unchecked error"},
+			{Path: "b.go", Line: 7, Column: 2, Severity: "warning", Message: "line too\nlong"},
+			{Path: "a.go", Line: 9, Column: 4, Rule: "unused", Severity: "error", Message: "unused variable"},
+		},
+		Total: 5,
+	}
+
+	require.Equal(t, "Errors (2)\n"+
+		"  a.go\n"+
+		"    3:1 unchecked error [errcheck]\n"+
+		"    9:4 unused variable [unused]\n"+
+		"Warnings (1)\n"+
+		"  b.go\n"+
+		"    7:2 line too long\n"+
+		"… and 2 more", groupLintFindings(meta))
+}
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"encoding/json"
+	"strconv"
 
 	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/fsext"
@@ -193,6 +194,136 @@ func (l *LSToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *To
 	return joinToolParts(header, body)
 }
 
+// -----------------------------------------------------------------------------
+// Recent Files Tool
+// -----------------------------------------------------------------------------
+
+// RecentFilesToolMessageItem is a message item that represents a
+// recent_files tool call.
+type RecentFilesToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RecentFilesToolMessageItem)(nil)
+
+// NewRecentFilesToolMessageItem creates a new [RecentFilesToolMessageItem].
+func NewRecentFilesToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RecentFilesToolRenderContext{}, canceled)
+}
+
+// RecentFilesToolRenderContext renders recent_files tool messages as the
+// ranked list returned to the model.
+type RecentFilesToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *RecentFilesToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Recent Files", opts.Anim, opts.Compact)
+	}
+
+	var params tools.RecentFilesParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	path := params.Path
+	if path == "" {
+		path = "."
+	}
+	toolParams := []string{fsext.PrettyPath(path)}
+	if params.Limit > 0 {
+		toolParams = append(toolParams, "limit", strconv.Itoa(params.Limit))
+	}
+
+	header := toolHeader(sty, opts.Status, "Recent Files", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// -----------------------------------------------------------------------------
+// Repo Map Tool
+// -----------------------------------------------------------------------------
+
+// RepoMapToolMessageItem is a message item that represents a repo_map tool
+// call.
+type RepoMapToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*RepoMapToolMessageItem)(nil)
+
+// NewRepoMapToolMessageItem creates a new [RepoMapToolMessageItem].
+func NewRepoMapToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &RepoMapToolRenderContext{}, canceled)
+}
+
+// RepoMapToolRenderContext renders repo_map tool messages as the map
+// returned to the model.
+type RepoMapToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (r *RepoMapToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	if opts.IsPending() {
+		return pendingTool(sty, "Repo Map", opts.Anim, opts.Compact)
+	}
+
+	var params tools.RepoMapParams
+	if err := json.Unmarshal([]byte(opts.ToolCall.Input), &params); err != nil {
+		return toolErrorContent(sty, &message.ToolResult{Content: "Invalid parameters"}, cappedWidth)
+	}
+
+	path := params.Path
+	if path == "" {
+		path = "."
+	}
+	toolParams := []string{fsext.PrettyPath(path)}
+	if params.MaxTokens > 0 {
+		toolParams = append(toolParams, "max tokens", strconv.Itoa(params.MaxTokens))
+	}
+
+	header := toolHeader(sty, opts.Status, "Repo Map", cappedWidth, opts, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
 // -----------------------------------------------------------------------------
 // Sourcegraph Tool
 // -----------------------------------------------------------------------------
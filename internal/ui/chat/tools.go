@@ -231,18 +231,32 @@ func NewToolMessageItem(
 		item = NewEditToolMessageItem(sty, toolCall, result, canceled)
 	case tools.MultiEditToolName:
 		item = NewMultiEditToolMessageItem(sty, toolCall, result, canceled)
+	case tools.CodemodToolName:
+		item = NewCodemodToolMessageItem(sty, toolCall, result, canceled)
+	case tools.EnvEditToolName:
+		item = NewEnvEditToolMessageItem(sty, toolCall, result, canceled)
 	case tools.GlobToolName:
 		item = NewGlobToolMessageItem(sty, toolCall, result, canceled)
 	case tools.GrepToolName:
 		item = NewGrepToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSToolName:
 		item = NewLSToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RecentFilesToolName:
+		item = NewRecentFilesToolMessageItem(sty, toolCall, result, canceled)
+	case tools.RepoMapToolName:
+		item = NewRepoMapToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DownloadToolName:
 		item = NewDownloadToolMessageItem(sty, toolCall, result, canceled)
 	case tools.FetchToolName:
 		item = NewFetchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.SourcegraphToolName:
 		item = NewSourcegraphToolMessageItem(sty, toolCall, result, canceled)
+	case tools.IssueToolName:
+		item = NewIssueToolMessageItem(sty, toolCall, result, canceled)
+	case tools.LintToolName:
+		item = NewLintToolMessageItem(sty, toolCall, result, canceled)
+	case tools.DocsToolName:
+		item = NewDocsToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DiagnosticsToolName:
 		item = NewDiagnosticsToolMessageItem(sty, toolCall, result, canceled)
 	case agent.AgentToolName:
@@ -1200,6 +1214,27 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return strings.Join(parts, "\n")
 		}
+	case tools.IssueToolName:
+		var params tools.IssueParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			parts := []string{fmt.Sprintf("**URL:** %s", params.URL)}
+			if params.MaxComments > 0 {
+				parts = append(parts, fmt.Sprintf("**Max Comments:** %d", params.MaxComments))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.LintToolName:
+		var params tools.LintParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			var parts []string
+			if params.Linter != "" {
+				parts = append(parts, fmt.Sprintf("**Linter:** %s", params.Linter))
+			}
+			if len(params.Paths) > 0 {
+				parts = append(parts, fmt.Sprintf("**Paths:** %s", strings.Join(params.Paths, ", ")))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.AgenticFetchToolName:
 		var params tools.AgenticFetchParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -1252,6 +1287,32 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return fmt.Sprintf("**Path:** %s", fsext.PrettyPath(path))
 		}
+	case tools.RecentFilesToolName:
+		var params tools.RecentFilesParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			path := params.Path
+			if path == "" {
+				path = "."
+			}
+			parts := []string{fmt.Sprintf("**Path:** %s", fsext.PrettyPath(path))}
+			if params.Limit > 0 {
+				parts = append(parts, fmt.Sprintf("**Limit:** %d", params.Limit))
+			}
+			return strings.Join(parts, "\n")
+		}
+	case tools.RepoMapToolName:
+		var params tools.RepoMapParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			path := params.Path
+			if path == "" {
+				path = "."
+			}
+			parts := []string{fmt.Sprintf("**Path:** %s", fsext.PrettyPath(path))}
+			if params.MaxTokens > 0 {
+				parts = append(parts, fmt.Sprintf("**Max Tokens:** %d", params.MaxTokens))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.DownloadToolName:
 		var params tools.DownloadParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -1276,6 +1337,15 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return strings.Join(parts, "\n")
 		}
+	case tools.DocsToolName:
+		var params tools.DocsParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			parts := []string{fmt.Sprintf("**Command:** %s", params.Command)}
+			if params.Query != "" {
+				parts = append(parts, fmt.Sprintf("**Query:** %s", params.Query))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.DiagnosticsToolName:
 		return "**Project:** diagnostics"
 	case agent.AgentToolName:
@@ -1333,7 +1403,7 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return t.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.RecentFilesToolName, tools.RepoMapToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName, tools.DocsToolName:
 		return fmt.Sprintf("```\n%s\n```", t.result.Content)
 	default:
 		return t.result.Content
@@ -1677,8 +1747,18 @@ func prettifyToolName(name string) string {
 		return "Grep"
 	case tools.LSToolName:
 		return "List"
+	case tools.RecentFilesToolName:
+		return "Recent Files"
+	case tools.RepoMapToolName:
+		return "Repo Map"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.IssueToolName:
+		return "Issue"
+	case tools.LintToolName:
+		return "Lint"
+	case tools.DocsToolName:
+		return "Docs"
 	case tools.TodosToolName:
 		return "To-Do"
 	case tools.ViewToolName:
@@ -62,6 +62,11 @@ type (
 	ActionSummarize                   struct {
 		SessionID string
 	}
+	// ActionResetPermissions forgets the permissions granted for the
+	// rest of a session.
+	ActionResetPermissions struct {
+		SessionID string
+	}
 	// ActionSelectReasoningEffort is a message indicating a reasoning effort
 	// has been selected.
 	ActionSelectReasoningEffort struct {
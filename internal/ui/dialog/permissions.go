@@ -28,7 +28,10 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
-	PermissionDeny            PermissionAction = "deny"
+	// PermissionAllowToolForSession allows every later call of the tool
+	// in the session, whatever its action or path.
+	PermissionAllowToolForSession PermissionAction = "allow_tool_session"
+	PermissionDeny                PermissionAction = "deny"
 )
 
 // Permissions dialog sizing constants.
@@ -51,6 +54,8 @@ const (
 	minWindowWidth = 77
 	// minWindowHeight is the minimum window height before forcing fullscreen.
 	minWindowHeight = 20
+	// permissionOptions is the number of buttons the dialog offers.
+	permissionOptions = 4
 )
 
 // Permissions represents a dialog for permission requests.
@@ -61,7 +66,7 @@ type Permissions struct {
 	fullscreen   bool // true when dialog is fullscreen
 
 	permission     permission.PermissionRequest
-	selectedOption int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption int // 0: Allow, 1: Allow for session, 2: Allow tool for session, 3: Deny
 
 	viewport      viewport.Model
 	viewportDirty bool // true when viewport content needs to be re-rendered
@@ -85,6 +90,7 @@ type permissionsKeyMap struct {
 	Select           key.Binding
 	Allow            key.Binding
 	AllowSession     key.Binding
+	AllowTool        key.Binding
 	Deny             key.Binding
 	Close            key.Binding
 	ToggleDiffMode   key.Binding
@@ -123,6 +129,10 @@ func defaultPermissionsKeyMap() permissionsKeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "allow session"),
 		),
+		AllowTool: key.NewBinding(
+			key.WithKeys("o", "O"),
+			key.WithHelp("o", "allow tool for session"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D"),
 			key.WithHelp("d", "deny"),
@@ -239,16 +249,19 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 			// Escape denies the permission request.
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.Right), key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % permissionOptions
 		case key.Matches(msg, p.keyMap.Left):
-			// Add 2 instead of subtracting 1 to avoid negative modulo.
-			p.selectedOption = (p.selectedOption + 2) % 3
+			// Add permissionOptions-1 instead of subtracting 1 to avoid
+			// negative modulo.
+			p.selectedOption = (p.selectedOption + permissionOptions - 1) % permissionOptions
 		case key.Matches(msg, p.keyMap.Select):
 			return p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
 			return p.respond(PermissionAllow)
 		case key.Matches(msg, p.keyMap.AllowSession):
 			return p.respond(PermissionAllowForSession)
+		case key.Matches(msg, p.keyMap.AllowTool):
+			return p.respond(PermissionAllowToolForSession)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
@@ -307,6 +320,8 @@ func (p *Permissions) selectCurrentOption() tea.Msg {
 		return p.respond(PermissionAllow)
 	case 1:
 		return p.respond(PermissionAllowForSession)
+	case 2:
+		return p.respond(PermissionAllowToolForSession)
 	default:
 		return p.respond(PermissionDeny)
 	}
@@ -475,7 +490,11 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 	case tools.DownloadToolName:
 		if params, ok := p.permission.Params.(tools.DownloadPermissionsParams); ok {
 			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
-			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.FilePath), contentWidth))
+			file := fsext.PrettyPath(params.FilePath)
+			if params.Overwrite {
+				file += " (overwrite)"
+			}
+			lines = append(lines, p.renderKeyValue("File", file, contentWidth))
 		}
 	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName, tools.ReplaceSymbolToolName:
 		var filePath string
@@ -555,6 +574,10 @@ func (p *Permissions) renderContent(width int) string {
 		return p.renderFetchContent(width)
 	case tools.AgenticFetchToolName:
 		return p.renderAgenticFetchContent(width)
+	case tools.CodemodToolName:
+		return p.renderCodemodContent(width)
+	case tools.EnvEditToolName:
+		return p.renderEnvEditContent(width)
 	case tools.ViewToolName:
 		return p.renderViewContent(width)
 	case tools.LSToolName:
@@ -570,7 +593,13 @@ func (p *Permissions) renderBashContent(width int) string {
 		return ""
 	}
 
-	return p.renderContentPanel(params.Command, width)
+	// Show per-command variables the way a shell would take them, so
+	// they are reviewed together with the command.
+	content := params.Command
+	if len(params.Env) > 0 {
+		content = strings.Join(params.Env, " ") + " " + content
+	}
+	return p.renderContentPanel(content, width)
 }
 
 func (p *Permissions) renderEditContent(contentWidth int) string {
@@ -641,6 +670,9 @@ func (p *Permissions) renderDownloadContent(width int) string {
 	}
 
 	content := fmt.Sprintf("URL: %s\nFile: %s", params.URL, fsext.PrettyPath(params.FilePath))
+	if params.Overwrite {
+		content += "\nOverwrites the existing file"
+	}
 	if params.Timeout > 0 {
 		content += fmt.Sprintf("\nTimeout: %ds", params.Timeout)
 	}
@@ -657,6 +689,32 @@ func (p *Permissions) renderFetchContent(width int) string {
 	return p.renderContentPanel(params.URL, width)
 }
 
+func (p *Permissions) renderCodemodContent(width int) string {
+	params, ok := p.permission.Params.(tools.CodemodPermissionsParams)
+	if !ok {
+		return p.renderDefaultContent(width)
+	}
+
+	content := fmt.Sprintf("Pattern: %s\nRewrite: %s\n\n%s", params.Pattern, params.Rewrite, strings.TrimRight(params.Diff, "\n"))
+	if highlighted, err := common.SyntaxHighlight(p.com.Styles, content, "codemod.diff", p.com.Styles.Dialog.Permissions.ParamsBg); err == nil {
+		content = highlighted
+	}
+	return p.renderContentPanel(content, width)
+}
+
+func (p *Permissions) renderEnvEditContent(width int) string {
+	params, ok := p.permission.Params.(tools.EnvEditPermissionsParams)
+	if !ok {
+		return p.renderDefaultContent(width)
+	}
+
+	content := fmt.Sprintf("File: %s\nKey: %s", fsext.PrettyPath(params.FilePath), params.Key)
+	if params.Operation == tools.EnvEditSet {
+		content += fmt.Sprintf("\nValue: hidden, %d characters", params.ValueLength)
+	}
+	return p.renderContentPanel(content, width)
+}
+
 func (p *Permissions) renderAgenticFetchContent(width int) string {
 	params, ok := p.permission.Params.(tools.AgenticFetchPermissionsParams)
 	if !ok {
@@ -759,7 +817,8 @@ func (p *Permissions) renderButtons(contentWidth int, fullscreen bool) string {
 	buttons := []common.ButtonOpts{
 		{Text: "Allow", UnderlineIndex: 0, Selected: p.selectedOption == 0},
 		{Text: "Allow for Session", UnderlineIndex: 10, Selected: p.selectedOption == 1},
-		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 2},
+		{Text: "Allow Tool for Session", UnderlineIndex: 7, Selected: p.selectedOption == 2},
+		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 3},
 	}
 
 	content := common.ButtonGroup(p.com.Styles, buttons, "  ")
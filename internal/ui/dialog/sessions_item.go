@@ -106,8 +106,13 @@ func (s *SessionItem) Cursor() *tea.Cursor {
 }
 
 // InfoText returns the secondary text shown on the right of the item.
+// Pinned sessions are marked with [styles.PinnedIcon].
 func (s *SessionItem) InfoText() string {
-	return humanize.Time(time.Unix(s.UpdatedAt, 0))
+	info := humanize.Time(time.Unix(s.UpdatedAt, 0))
+	if s.Pinned {
+		info = styles.PinnedIcon + " " + info
+	}
+	return info
 }
 
 // SetHideInfo controls whether the timestamp info column is shown. The
@@ -127,6 +127,19 @@ func (h *header) drawHeader(
 	view.Draw(scr, area)
 }
 
+// usageStyle returns the style of a usage readout: the warning style from
+// warn and the critical style from critical.
+func usageStyle(t *styles.Styles, value, warn, critical float64) lipgloss.Style {
+	switch {
+	case value >= critical:
+		return t.Header.UsageCritical
+	case value >= warn:
+		return t.Header.UsageWarning
+	default:
+		return t.Header.Percentage
+	}
+}
+
 // renderHeaderDetails renders the details section of the header.
 func renderHeaderDetails(
 	com *common.Common,
@@ -144,16 +157,26 @@ func renderHeaderDetails(
 		parts = append(parts, t.LSP.ErrorDiagnostic.Render(fmt.Sprintf("%s%d", styles.LSPErrorIcon, lspErrorCount)))
 	}
 
-	agentCfg := com.Config().Agents[config.AgentCoder]
-	model := com.Config().GetModelByType(agentCfg.Model)
+	cfg := com.Config()
+	tuiOpts := cfg.Options.TUI
+	agentCfg := cfg.Agents[config.AgentCoder]
+	model := cfg.GetModelByType(agentCfg.Model)
 	if model != nil && model.ContextWindow > 0 {
 		percentage := (float64(session.CompletionTokens+session.PromptTokens) / float64(model.ContextWindow)) * 100
 		percentageText := fmt.Sprintf("%d%%", int(percentage))
 		if session.EstimatedUsage {
 			percentageText = "~" + percentageText
 		}
-		formattedPercentage := t.Header.Percentage.Render(percentageText)
-		parts = append(parts, formattedPercentage)
+		style := t.Header.Percentage
+		if warn := float64(tuiOpts.ContextWarningPercent()); warn > 0 {
+			style = usageStyle(t, percentage, warn, (warn+100)/2)
+		}
+		parts = append(parts, style.Render(percentageText))
+	}
+
+	if limit := tuiOpts.CostWarning(); limit > 0 {
+		style := usageStyle(t, session.Cost, limit*0.8, limit)
+		parts = append(parts, style.Render(fmt.Sprintf("$%.2f", session.Cost)))
 	}
 
 	if com.IsHyper() && hyperCredits != nil {
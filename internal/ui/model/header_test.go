@@ -0,0 +1,24 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageStyle(t *testing.T) {
+	t.Parallel()
+
+	sty := styles.CharmtonePantera()
+	opts := &config.TUIOptions{}
+	warn := float64(opts.ContextWarningPercent())
+	critical := (warn + 100) / 2
+	require.Equal(t, 70.0, warn)
+	require.Zero(t, opts.CostWarning())
+
+	require.Equal(t, sty.Header.Percentage, usageStyle(&sty, 42, warn, critical))
+	require.Equal(t, sty.Header.UsageWarning, usageStyle(&sty, 70, warn, critical))
+	require.Equal(t, sty.Header.UsageCritical, usageStyle(&sty, 85, warn, critical))
+}
@@ -1445,6 +1445,7 @@ func (m *UI) loadNestedToolCalls(items []chat.MessageItem) {
 		m.loadNestedToolCalls(nestedMessageItems)
 
 		// Set nested tools on the parent.
+		nestedContainer.SetNestedToolsLimit(m.com.Config().Options.TUI.NestedToolsLimit())
 		nestedContainer.SetNestedTools(nestedTools)
 	}
 }
@@ -1713,6 +1714,7 @@ func (m *UI) handleChildSessionMessage(event pubsub.Event[message.Message]) tea.
 	}
 
 	// Update the agent item with the new nested tools.
+	agentItem.SetNestedToolsLimit(m.com.Config().Options.TUI.NestedToolsLimit())
 	agentItem.SetNestedTools(nestedTools)
 
 	// Update the chat so it updates the index map for animations to work as expected
@@ -1815,6 +1817,12 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			return nil
 		})
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionResetPermissions:
+		cmds = append(cmds, func() tea.Msg {
+			cleared := m.com.Workspace.PermissionResetSession(msg.SessionID)
+			return util.NewInfoMsg(fmt.Sprintf("Session permissions reset, %d forgotten", cleared))
+		})
+		m.dialog.CloseDialog(dialog.CommandsID)
 	case dialog.ActionToggleHelp:
 		m.status.ToggleHelp()
 		m.dialog.CloseDialog(dialog.CommandsID)
@@ -1940,6 +1948,8 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.Workspace.PermissionGrant(msg.Permission)
 		case dialog.PermissionAllowForSession:
 			m.com.Workspace.PermissionGrantPersistent(msg.Permission)
+		case dialog.PermissionAllowToolForSession:
+			m.com.Workspace.PermissionGrantTool(msg.Permission)
 		case dialog.PermissionDeny:
 			m.com.Workspace.PermissionDeny(msg.Permission)
 		}
@@ -4390,6 +4400,11 @@ func (m *UI) handleAgentNotification(n notify.Notification) tea.Cmd {
 		// busy/queue refresh below.
 	case notify.TypeReAuthenticate:
 		return m.handleReAuthenticate(n.ProviderID)
+	case notify.TypeProviderRetry:
+		if m.session == nil || m.session.ID != n.SessionID {
+			return nil
+		}
+		return util.ReportWarn("Provider error: " + n.Message)
 	default:
 		return nil
 	}
@@ -602,6 +602,8 @@ func quickStyle(o quickStyleOpts) Styles {
 	s.Header.Charm = base.Foreground(o.secondary)
 	s.Header.Diagonals = base.Foreground(o.primary)
 	s.Header.Percentage = muted
+	s.Header.UsageWarning = base.Foreground(o.warning)
+	s.Header.UsageCritical = base.Foreground(o.error)
 	s.Header.HypercreditIcon = base.Foreground(o.secondary)
 	s.Header.Keystroke = muted
 	s.Header.KeystrokeTip = subtle
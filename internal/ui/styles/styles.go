@@ -46,6 +46,7 @@ const (
 	TextIcon   string = "≡"
 	SkillIcon  string = "▲"
 	RemoveIcon string = "✕"
+	PinnedIcon string = "✦"
 
 	ScrollbarThumb string = "┃"
 	ScrollbarTrack string = "│"
@@ -77,6 +78,8 @@ type Styles struct {
 		Charm             lipgloss.Style // Style for "Charm™" label
 		Diagonals         lipgloss.Style // Style for diagonal separators (╱)
 		Percentage        lipgloss.Style // Style for context percentage
+		UsageWarning      lipgloss.Style // Context percentage or cost nearing its limit
+		UsageCritical     lipgloss.Style // Context percentage or cost at its limit
 		HypercreditIcon   lipgloss.Style // Style for Hypercredit count (◆ N)
 		Keystroke         lipgloss.Style // Style for keystroke hints (e.g., "ctrl+d")
 		KeystrokeTip      lipgloss.Style // Style for keystroke action text (e.g., "open", "close")
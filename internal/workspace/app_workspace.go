@@ -252,10 +252,18 @@ func (w *AppWorkspace) PermissionGrantPersistent(perm permission.PermissionReque
 	return w.app.Permissions.GrantPersistent(perm)
 }
 
+func (w *AppWorkspace) PermissionGrantTool(perm permission.PermissionRequest) bool {
+	return w.app.Permissions.GrantTool(perm)
+}
+
 func (w *AppWorkspace) PermissionDeny(perm permission.PermissionRequest) bool {
 	return w.app.Permissions.Deny(perm)
 }
 
+func (w *AppWorkspace) PermissionResetSession(sessionID string) int {
+	return w.app.Permissions.ResetSession(sessionID)
+}
+
 func (w *AppWorkspace) PermissionSkipRequests() bool {
 	return w.app.Permissions.SkipRequests()
 }
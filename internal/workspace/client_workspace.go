@@ -345,6 +345,23 @@ func (w *ClientWorkspace) PermissionGrantPersistent(perm permission.PermissionRe
 	return resolved
 }
 
+func (w *ClientWorkspace) PermissionGrantTool(perm permission.PermissionRequest) bool {
+	resolved, _ := w.client.GrantPermission(context.Background(), w.workspaceID(), proto.PermissionGrant{
+		Permission: proto.PermissionRequest{
+			ID:          perm.ID,
+			SessionID:   perm.SessionID,
+			ToolCallID:  perm.ToolCallID,
+			ToolName:    perm.ToolName,
+			Description: perm.Description,
+			Action:      perm.Action,
+			Path:        perm.Path,
+			Params:      perm.Params,
+		},
+		Action: proto.PermissionAllowToolForSession,
+	})
+	return resolved
+}
+
 func (w *ClientWorkspace) PermissionDeny(perm permission.PermissionRequest) bool {
 	resolved, _ := w.client.GrantPermission(context.Background(), w.workspaceID(), proto.PermissionGrant{
 		Permission: proto.PermissionRequest{
@@ -362,6 +379,15 @@ func (w *ClientWorkspace) PermissionDeny(perm permission.PermissionRequest) bool
 	return resolved
 }
 
+func (w *ClientWorkspace) PermissionResetSession(sessionID string) int {
+	cleared, err := w.client.ResetSessionPermissions(context.Background(), w.workspaceID(), sessionID)
+	if err != nil {
+		slog.Error("Failed to reset session permissions", "error", err)
+		return 0
+	}
+	return cleared
+}
+
 func (w *ClientWorkspace) PermissionSkipRequests() bool {
 	skip, err := w.client.GetPermissionsSkipRequests(context.Background(), w.workspaceID())
 	if err != nil {
@@ -919,6 +945,9 @@ func (w *ClientWorkspace) translateEvent(ev any) tea.Msg {
 		if e.Payload.Error != nil {
 			n.Message = e.Payload.Error.Error()
 		}
+		if n.Type == notify.TypeProviderRetry {
+			n.Message = e.Payload.Progress
+		}
 		return pubsub.Event[notify.Notification]{
 			Type:    e.Type,
 			Payload: n,
@@ -995,6 +1024,7 @@ func protoToSession(s proto.Session) session.Session {
 		CompletionTokens: s.CompletionTokens,
 		Cost:             s.Cost,
 		Todos:            protoToTodos(s.Todos),
+		Pinned:           s.Pinned,
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
 	}
@@ -1116,6 +1146,7 @@ func sessionToProto(s session.Session) proto.Session {
 		CompletionTokens: s.CompletionTokens,
 		Cost:             s.Cost,
 		Todos:            todosToProto(s.Todos),
+		Pinned:           s.Pinned,
 		CreatedAt:        s.CreatedAt,
 		UpdatedAt:        s.UpdatedAt,
 	}
@@ -88,6 +88,13 @@ func TestClientWorkspace_PermissionGrantMapping(t *testing.T) {
 			},
 			want: proto.PermissionAllowForSession,
 		},
+		{
+			name: "GrantTool -> PermissionAllowToolForSession",
+			call: func(w *ClientWorkspace, p permission.PermissionRequest) {
+				w.PermissionGrantTool(p)
+			},
+			want: proto.PermissionAllowToolForSession,
+		},
 		{
 			name: "Deny -> PermissionDeny",
 			call: func(w *ClientWorkspace, p permission.PermissionRequest) {
@@ -123,8 +123,8 @@ type Workspace interface {
 
 	// Permissions
 	//
-	// PermissionGrant, PermissionGrantPersistent, and PermissionDeny
-	// return true if the call resolved the pending request and false if
+	// PermissionGrant, PermissionGrantPersistent, PermissionGrantTool,
+	// and PermissionDeny return true if the call resolved the pending request and false if
 	// it had already been resolved by another subscriber (or is no
 	// longer pending). A false return is not an error; the modal can
 	// still close locally because the resolution will arrive via the
@@ -132,7 +132,11 @@ type Workspace interface {
 	// won the race.
 	PermissionGrant(perm permission.PermissionRequest) bool
 	PermissionGrantPersistent(perm permission.PermissionRequest) bool
+	PermissionGrantTool(perm permission.PermissionRequest) bool
 	PermissionDeny(perm permission.PermissionRequest) bool
+	// PermissionResetSession forgets the permissions granted for the
+	// rest of a session and returns how many were forgotten.
+	PermissionResetSession(sessionID string) int
 	PermissionSkipRequests() bool
 	PermissionSetSkipRequests(skip bool)
 